@@ -0,0 +1,229 @@
+package rod
+
+import (
+	"context"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/input"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// BrowserAPI is the main methods of [Browser]. Downstream code can accept it instead of the [Browser]
+// so that it can be replaced with a mock in unit tests. Use [Browser.API] to get it from a [Browser].
+// The methods that return a page return the [PageAPI], so the whole chain can be mocked.
+type BrowserAPI interface {
+	Connect() error
+	Close() error
+	Page(opts proto.TargetCreateTarget) (PageAPI, error)
+	Pages() ([]PageAPI, error)
+	Version() (*proto.BrowserGetVersionResult, error)
+	GetCookies() ([]*proto.NetworkCookie, error)
+	SetCookies(cookies []*proto.NetworkCookieParam) error
+	EachEvent(callbacks ...interface{}) (wait func())
+	WaitEvent(e proto.Event) (wait func())
+	Call(ctx context.Context, sessionID, methodName string, params interface{}) (res []byte, err error)
+	GetContext() context.Context
+}
+
+// PageAPI is the main methods of [Page]. Downstream code can accept it instead of the [Page]
+// so that it can be replaced with a mock in unit tests. Use [Page.API] to get it from a [Page].
+// The methods that return an element return the [ElementAPI].
+type PageAPI interface {
+	Info() (*proto.TargetTargetInfo, error)
+	HTML() (string, error)
+	Navigate(url string) error
+	NavigateBack() error
+	NavigateForward() error
+	Reload() error
+	Close() error
+	Cookies(urls []string) ([]*proto.NetworkCookie, error)
+	SetCookies(cookies []*proto.NetworkCookieParam) error
+	SetViewport(params *proto.EmulationSetDeviceMetricsOverride) error
+	WaitLoad() error
+	WaitStable(d time.Duration) error
+	WaitIdle(timeout time.Duration) error
+	Screenshot(fullPage bool, req *proto.PageCaptureScreenshot) ([]byte, error)
+	PDF(req *proto.PagePrintToPDF) (*StreamReader, error)
+	Eval(js string, args ...interface{}) (*proto.RuntimeRemoteObject, error)
+	Evaluate(opts *EvalOptions) (*proto.RuntimeRemoteObject, error)
+	Has(selector string) (bool, ElementAPI, error)
+	Element(selector string) (ElementAPI, error)
+	ElementR(selector, jsRegex string) (ElementAPI, error)
+	ElementX(xPath string) (ElementAPI, error)
+	Elements(selector string) ([]ElementAPI, error)
+	ElementsX(xpath string) ([]ElementAPI, error)
+	EachEvent(callbacks ...interface{}) (wait func())
+	WaitEvent(e proto.Event) (wait func())
+	Call(ctx context.Context, sessionID, methodName string, params interface{}) (res []byte, err error)
+	GetContext() context.Context
+}
+
+// ElementAPI is the main methods of [Element]. Downstream code can accept it instead of the [Element]
+// so that it can be replaced with a mock in unit tests. Use [Element.API] to get it from an [Element].
+// The methods that return a page or an element return the [PageAPI] or the [ElementAPI].
+type ElementAPI interface {
+	Click(button proto.InputMouseButton, clickCount int) error
+	Tap() error
+	Hover() error
+	Focus() error
+	Blur() error
+	ScrollIntoView() error
+	Type(keys ...input.Key) error
+	Input(text string) error
+	SelectAllText() error
+	Select(selectors []string, selected bool, t SelectorType) error
+	SetFiles(paths []string) error
+	Text() (string, error)
+	HTML() (string, error)
+	Attribute(name string) (*string, error)
+	Property(name string) (gson.JSON, error)
+	Visible() (bool, error)
+	Disabled() (bool, error)
+	Matches(selector string) (bool, error)
+	WaitVisible() error
+	WaitInvisible() error
+	WaitStable(d time.Duration) error
+	Screenshot(format proto.PageCaptureScreenshotFormat, quality int) ([]byte, error)
+	Eval(js string, params ...interface{}) (*proto.RuntimeRemoteObject, error)
+	Has(selector string) (bool, ElementAPI, error)
+	Element(selector string) (ElementAPI, error)
+	ElementX(xPath string) (ElementAPI, error)
+	Elements(selector string) ([]ElementAPI, error)
+	Parent() (ElementAPI, error)
+	Remove() error
+	Page() PageAPI
+	GetContext() context.Context
+}
+
+var (
+	_ BrowserAPI = browserAPI{}
+	_ PageAPI    = pageAPI{}
+	_ ElementAPI = elementAPI{}
+)
+
+// API returns the [BrowserAPI] of the browser.
+func (b *Browser) API() BrowserAPI {
+	return browserAPI{b}
+}
+
+// API returns the [PageAPI] of the page.
+func (p *Page) API() PageAPI {
+	return pageAPI{p}
+}
+
+// API returns the [ElementAPI] of the element.
+func (el *Element) API() ElementAPI {
+	return elementAPI{el}
+}
+
+// browserAPI wraps the methods of the [Browser] that return the concrete types.
+type browserAPI struct{ *Browser }
+
+func (b browserAPI) Page(opts proto.TargetCreateTarget) (PageAPI, error) {
+	p, err := b.Browser.Page(opts)
+	if err != nil {
+		return nil, err
+	}
+	return p.API(), nil
+}
+
+func (b browserAPI) Pages() ([]PageAPI, error) {
+	list, err := b.Browser.Pages()
+	if err != nil {
+		return nil, err
+	}
+	return pagesAPI(list), nil
+}
+
+// pageAPI wraps the methods of the [Page] that return the concrete types.
+type pageAPI struct{ *Page }
+
+func (p pageAPI) Has(selector string) (bool, ElementAPI, error) {
+	has, el, err := p.Page.Has(selector)
+	if !has || err != nil {
+		return has, nil, err
+	}
+	return has, el.API(), nil
+}
+
+func (p pageAPI) Element(selector string) (ElementAPI, error) {
+	return elementOrErr(p.Page.Element(selector))
+}
+
+func (p pageAPI) ElementR(selector, jsRegex string) (ElementAPI, error) {
+	return elementOrErr(p.Page.ElementR(selector, jsRegex))
+}
+
+func (p pageAPI) ElementX(xPath string) (ElementAPI, error) {
+	return elementOrErr(p.Page.ElementX(xPath))
+}
+
+func (p pageAPI) Elements(selector string) ([]ElementAPI, error) {
+	return elementsOrErr(p.Page.Elements(selector))
+}
+
+func (p pageAPI) ElementsX(xpath string) ([]ElementAPI, error) {
+	return elementsOrErr(p.Page.ElementsX(xpath))
+}
+
+// elementAPI wraps the methods of the [Element] that return the concrete types.
+// The alias is embedded because the field name "Element" would conflict with the method.
+type elementAPI struct{ *apiElement }
+
+type apiElement = Element
+
+func (el elementAPI) Has(selector string) (bool, ElementAPI, error) {
+	has, e, err := el.apiElement.Has(selector)
+	if !has || err != nil {
+		return has, nil, err
+	}
+	return has, e.API(), nil
+}
+
+func (el elementAPI) Element(selector string) (ElementAPI, error) {
+	return elementOrErr(el.apiElement.Element(selector))
+}
+
+func (el elementAPI) ElementX(xPath string) (ElementAPI, error) {
+	return elementOrErr(el.apiElement.ElementX(xPath))
+}
+
+func (el elementAPI) Elements(selector string) ([]ElementAPI, error) {
+	return elementsOrErr(el.apiElement.Elements(selector))
+}
+
+func (el elementAPI) Parent() (ElementAPI, error) {
+	return elementOrErr(el.apiElement.Parent())
+}
+
+func (el elementAPI) Page() PageAPI {
+	return el.apiElement.Page().API()
+}
+
+func pagesAPI(list Pages) []PageAPI {
+	res := make([]PageAPI, 0, len(list))
+	for _, p := range list {
+		res = append(res, p.API())
+	}
+	return res
+}
+
+// elementOrErr avoids returning a non-nil [ElementAPI] that holds a nil [Element].
+func elementOrErr(el *Element, err error) (ElementAPI, error) {
+	if err != nil {
+		return nil, err
+	}
+	return el.API(), nil
+}
+
+func elementsOrErr(list Elements, err error) ([]ElementAPI, error) {
+	if err != nil {
+		return nil, err
+	}
+	res := make([]ElementAPI, 0, len(list))
+	for _, el := range list {
+		res = append(res, el.API())
+	}
+	return res, nil
+}
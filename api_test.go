@@ -0,0 +1,89 @@
+package rod_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestAPI(t *testing.T) {
+	g := setup(t)
+
+	var b rod.BrowserAPI = g.browser.API()
+
+	p, err := b.Page(proto.TargetCreateTarget{URL: g.html(`<div><p>a</p><p>b</p></div>`)})
+	g.E(err)
+	defer func() { _ = p.Close() }()
+	g.E(p.WaitLoad())
+
+	pages, err := b.Pages()
+	g.E(err)
+	g.Gt(len(pages), 0)
+
+	el, err := p.Element("p")
+	g.E(err)
+	g.Eq(el.Page().GetContext(), p.GetContext())
+
+	parent, err := el.Parent()
+	g.E(err)
+	list, err := parent.Elements("p")
+	g.E(err)
+	g.Len(list, 2)
+
+	text, err := list[1].Text()
+	g.E(err)
+	g.Eq(text, "b")
+
+	has, el, err := p.Has("span")
+	g.E(err)
+	g.False(has)
+	g.Nil(el)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	el, err = p.Element("p")
+	g.Err(err)
+	g.Nil(el)
+}
+
+// mockElement replaces the Text of the element for the unit tests of the downstream code.
+type mockElement struct {
+	rod.ElementAPI
+	text string
+}
+
+func (m *mockElement) Text() (string, error) {
+	if m.text == "" {
+		return "", errors.New("no text")
+	}
+	return m.text, nil
+}
+
+type mockPage struct {
+	rod.PageAPI
+	el rod.ElementAPI
+}
+
+func (m *mockPage) Element(string) (rod.ElementAPI, error) {
+	return m.el, nil
+}
+
+func TestAPIMock(t *testing.T) {
+	g := setup(t)
+
+	title := func(p rod.PageAPI) (string, error) {
+		el, err := p.Element("h1")
+		if err != nil {
+			return "", err
+		}
+		return el.Text()
+	}
+
+	text, err := title(&mockPage{el: &mockElement{text: "ok"}})
+	g.E(err)
+	g.Eq(text, "ok")
+
+	_, err = title(&mockPage{el: &mockElement{}})
+	g.Err(err)
+}
@@ -0,0 +1,293 @@
+// This file contains the helpers for the apps that use the browser as the native window, check launcher.NewAppMode .
+
+package rod
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/launcher"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// openLinksExternallyJS reports the links that would open a new window or leave the origin of the app,
+// and the window.open calls, to the binding instead of navigating.
+const openLinksExternallyJS = `(bind) => {
+	const report = (u) => window[bind](String(u))
+	const external = (u, target) => {
+		if (!/^https?:$/.test(u.protocol)) return false
+		return u.origin !== location.origin || (target && target !== '_self' && target !== '_top' && target !== '_parent')
+	}
+
+	document.addEventListener('click', (e) => {
+		const a = e.target && e.target.closest && e.target.closest('a[href]')
+		if (a && external(new URL(a.href), a.target)) {
+			e.preventDefault()
+			report(a.href)
+		}
+	}, true)
+
+	window.open = function (u) {
+		report(new URL(u || 'about:blank', location.href))
+		return null
+	}
+}`
+
+// OpenLinksExternally routes the links of the page that have target="_blank" or lead to another origin,
+// and the window.open calls, to the handler instead of opening them in the app window.
+// It's useful for the apps launched by launcher.NewAppMode, like the setWindowOpenHandler of Electron.
+// If the handler is nil, the urls will be opened in the default browser of the os via [OpenExternal].
+// The handler is called sequentially in a background goroutine. Call stop to turn it off.
+// Beware that the js of the page can report any url, the handler should validate it before opening it.
+func (p *Page) OpenLinksExternally(handler func(u string)) (stop func() error, err error) {
+	if handler == nil {
+		handler = func(u string) { _ = OpenExternal(u) }
+	}
+
+	bind := "_" + utils.RandString(8)
+
+	err = proto.RuntimeAddBinding{Name: bind}.Call(p)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			_ = proto.RuntimeRemoveBinding{Name: bind}.Call(p)
+		}
+	}()
+
+	_, err = p.Evaluate(Eval(openLinksExternallyJS, bind))
+	if err != nil {
+		return
+	}
+
+	remove, err := p.EvalOnNewDocument(fmt.Sprintf(`(%s)("%s")`, openLinksExternallyJS, bind))
+	if err != nil {
+		return
+	}
+
+	page, cancel := p.WithCancel()
+	go page.EachEvent(func(e *proto.RuntimeBindingCalled) {
+		if e.Name == bind {
+			handler(e.Payload)
+		}
+	})()
+
+	stop = func() error {
+		cancel()
+		err := remove()
+		if err != nil {
+			return err
+		}
+		return proto.RuntimeRemoveBinding{Name: bind}.Call(p)
+	}
+
+	return
+}
+
+// OpenExternal opens the url in the default browser of the os via launcher.OpenExternal .
+// Only the http and https urls are allowed, the other urls, such as the file urls or the custom protocols
+// that may launch the local programs, are refused with the [UnsafeExternalURLError].
+func OpenExternal(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return &UnsafeExternalURLError{u}
+	}
+	return launcher.OpenExternal(parsed.String())
+}
+
+// AppWindowOptions for [Page.AppWindow], the zero fields are ignored.
+type AppWindowOptions struct {
+	// Title of the window, it overrides the titles of the documents.
+	Title string
+
+	// Icon is the url of the window icon, it overrides the favicons of the documents.
+	Icon string
+
+	// DisableContextMenu disables the right-click menu of the documents.
+	DisableContextMenu bool
+
+	// BoundsFile to persist the window bounds. The bounds in it are restored when [Page.AppWindow] is called,
+	// and the bounds are saved to it when they change, so that the window reopens where the user left it.
+	BoundsFile string
+
+	// AlwaysOnTop keeps the window above the other windows, check launcher.AlwaysOnTop for the platforms.
+	// It's a no-op on the platforms launcher.AlwaysOnTop doesn't support, such as macOS,
+	// or if the wmctrl isn't installed on Linux.
+	AlwaysOnTop bool
+}
+
+// appWindowJS locks the title and the icon of the documents, and disables the context menu.
+const appWindowJS = `(title, icon, noMenu) => {
+	const apply = () => {
+		if (title && document.title !== title) document.title = title
+		if (icon && document.head) {
+			let link = document.head.querySelector('link[data-rod-icon]')
+			if (!link) {
+				document.head.querySelectorAll('link[rel~=icon]').forEach((l) => l.remove())
+				link = document.createElement('link')
+				link.rel = 'icon'
+				link.dataset.rodIcon = ''
+				document.head.appendChild(link)
+			}
+			if (link.href !== icon) link.href = icon
+		}
+	}
+	if (title || icon) {
+		apply()
+		new MutationObserver(apply).observe(document, { subtree: true, childList: true, characterData: true })
+	}
+
+	if (noMenu) document.addEventListener('contextmenu', (e) => e.preventDefault(), true)
+}`
+
+// AppWindow customizes the window of the page to make it look like a native app,
+// such as the app launched by launcher.NewAppMode. The infobars are controlled by the flags of the browser,
+// use the launcher.Launcher.NoInfobars to remove them, launcher.NewAppMode removes them by default.
+// Call stop to turn it off, the current document keeps the title and icon until it's reloaded.
+func (p *Page) AppWindow(opts AppWindowOptions) (stop func() error, err error) {
+	stops := []func() error{}
+	stop = func() error {
+		errs := []error{}
+		for i := len(stops) - 1; i >= 0; i-- {
+			errs = append(errs, stops[i]())
+		}
+		return errors.Join(errs...)
+	}
+	defer func() {
+		if err != nil {
+			_ = stop()
+		}
+	}()
+
+	if opts.Title != "" || opts.Icon != "" || opts.DisableContextMenu {
+		_, err = p.Evaluate(Eval(appWindowJS, opts.Title, opts.Icon, opts.DisableContextMenu))
+		if err != nil {
+			return
+		}
+
+		var remove func() error
+		remove, err = p.EvalOnNewDocument(fmt.Sprintf(`(%s)(%s, %s, %t)`,
+			appWindowJS, utils.MustToJSON(opts.Title), utils.MustToJSON(opts.Icon), opts.DisableContextMenu))
+		if err != nil {
+			return
+		}
+		stops = append(stops, remove)
+	}
+
+	if opts.BoundsFile != "" {
+		var persist func() error
+		persist, err = p.persistWindowBounds(opts.BoundsFile)
+		if err != nil {
+			return
+		}
+		stops = append(stops, persist)
+	}
+
+	if opts.AlwaysOnTop {
+		title := opts.Title
+		if title == "" {
+			var info *proto.TargetTargetInfo
+			info, err = p.Info()
+			if err != nil {
+				return
+			}
+			title = info.Title
+		}
+
+		// the window title may not be updated yet
+		ctx, cancel := context.WithTimeout(p.ctx, 3*time.Second)
+		defer cancel()
+		err = utils.Retry(ctx, utils.BackoffSleeper(100*time.Millisecond, time.Second, nil), func() (bool, error) {
+			err := launcher.AlwaysOnTop(title, true)
+			return err == nil || errors.Is(err, launcher.ErrAlwaysOnTopNotSupported), err
+		})
+		if errors.Is(err, launcher.ErrAlwaysOnTopNotSupported) {
+			return stop, nil
+		}
+		if err != nil {
+			return
+		}
+		stops = append(stops, func() error { return launcher.AlwaysOnTop(title, false) })
+	}
+
+	return
+}
+
+// persistWindowBounds restores the window bounds from the path, then saves them to the path when they change.
+func (p *Page) persistWindowBounds(path string) (stop func() error, err error) {
+	if b, err := os.ReadFile(path); err == nil {
+		var bounds proto.BrowserBounds
+		if json.Unmarshal(b, &bounds) == nil {
+			err = p.restoreWindowBounds(&bounds)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	last, err := p.GetWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	save := func() error {
+		bounds, err := p.GetWindow()
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(bounds, last) {
+			return nil
+		}
+		last = bounds
+		return utils.OutputFile(path, bounds)
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(500 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				_ = save()
+			}
+		}
+	}()
+
+	return func() error {
+		cancel()
+		<-done
+
+		// the window may be closed already, the last change has been saved by the loop
+		_ = save()
+		return nil
+	}, nil
+}
+
+// restoreWindowBounds sets the bounds, the state and the size can't be set at the same time.
+func (p *Page) restoreWindowBounds(bounds *proto.BrowserBounds) error {
+	state := bounds.WindowState
+	if state != "" && state != proto.BrowserWindowStateNormal {
+		return p.SetWindow(&proto.BrowserBounds{WindowState: state})
+	}
+
+	return p.SetWindow(&proto.BrowserBounds{
+		Left:   bounds.Left,
+		Top:    bounds.Top,
+		Width:  bounds.Width,
+		Height: bounds.Height,
+	})
+}
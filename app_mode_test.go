@@ -0,0 +1,107 @@
+package rod_test
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestOpenLinksExternally(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>
+		<a id="blank" href="/doc" target="_blank">blank</a>
+		<a id="other" href="https://example.com/">other</a>
+		<a id="self" href="/next">self</a>
+	</body></html>`)
+	s.Route("/next", ".html", `<html><body>next</body></html>`)
+
+	p := g.newPage(s.URL())
+	p.MustWaitLoad()
+
+	urls := make(chan string, 3)
+	stop, err := p.OpenLinksExternally(func(u string) { urls <- u })
+	g.E(err)
+
+	p.MustElement("#blank").MustClick()
+	g.Eq(<-urls, s.URL("/doc"))
+
+	p.MustElement("#other").MustClick()
+	g.Eq(<-urls, "https://example.com/")
+
+	g.Nil(p.MustEval(`() => window.open('/popup')`).Val())
+	g.Eq(<-urls, s.URL("/popup"))
+
+	wait := p.WaitNavigation(proto.PageLifecycleEventNameLoad)
+	p.MustElement("#self").MustClick()
+	wait()
+	g.Eq(p.MustElement("body").MustText(), "next")
+	g.Len(urls, 0)
+
+	g.E(stop())
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	_, err = p.OpenLinksExternally(nil)
+	g.Err(err)
+}
+
+func TestOpenExternalUnsafeURL(t *testing.T) {
+	g := setup(t)
+
+	for _, u := range []string{
+		"file:///etc/passwd",
+		"FILE:///C:/Windows/System32/calc.exe",
+		"javascript:alert(1)",
+		"ms-settings:",
+		"https:///no-host",
+		"/relative",
+	} {
+		err := rod.OpenExternal(u)
+		g.Is(err, &rod.UnsafeExternalURLError{})
+		g.Has(err.Error(), u)
+	}
+}
+
+func TestAppWindow(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.blank())
+	boundsFile := filepath.Join(t.TempDir(), "bounds.json")
+
+	stop := p.MustAppWindow(rod.AppWindowOptions{
+		Title:              "My App",
+		Icon:               "data:image/png;base64,AA==",
+		DisableContextMenu: true,
+		BoundsFile:         boundsFile,
+	})
+
+	p.MustNavigate(g.srcFile("fixtures/click.html")).MustWaitLoad()
+	g.Eq(p.MustEval(`() => document.title`).Str(), "My App")
+	g.Eq(p.MustEval(`() => document.querySelectorAll('link[rel~=icon]').length`).Int(), 1)
+	g.False(p.MustEval(`() => document.dispatchEvent(new MouseEvent('contextmenu', { cancelable: true }))`).Bool())
+
+	p.MustEval(`() => document.title = 'changed'`)
+	p.MustWait(`() => document.title === 'My App'`)
+
+	p.MustSetWindow(10, 20, 600, 500)
+	stop()
+
+	var bounds proto.BrowserBounds
+	g.E(json.Unmarshal(g.Read(boundsFile).Bytes(), &bounds))
+	g.Eq(*bounds.Width, 600)
+
+	// the bounds should be restored
+	p.MustSetWindow(0, 0, 800, 600)
+	p.MustAppWindow(rod.AppWindowOptions{BoundsFile: boundsFile})()
+	g.Eq(*p.MustGetWindow().Width, 600)
+
+	// it's a no-op if the platform isn't supported
+	if _, err := exec.LookPath("wmctrl"); err != nil {
+		p.MustAppWindow(rod.AppWindowOptions{AlwaysOnTop: true})()
+	}
+}
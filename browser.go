@@ -9,9 +9,14 @@ package rod
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xyjwsj/grod/lib/cdp"
@@ -21,6 +26,7 @@ import (
 	"github.com/xyjwsj/grod/lib/proto"
 	"github.com/xyjwsj/grod/lib/utils"
 	"github.com/ysmood/goob"
+	"github.com/ysmood/gson"
 )
 
 // Browser implements these interfaces.
@@ -45,6 +51,14 @@ type Browser struct {
 
 	logger utils.Logger
 
+	ocr OCR
+
+	mailbox MailboxProvider
+
+	bus *Bus
+
+	authPatterns *authPatterns
+
 	slowMotion time.Duration // see defaults.slow
 	trace      bool          // see defaults.Trace
 	monitor    string
@@ -52,6 +66,10 @@ type Browser struct {
 	defaultDevice devices.Device
 
 	controlURL  string
+	launcher    *launcher.Launcher
+	disconnect  *disconnection
+	latency     *int64 // the latency of the last cdp call in nanoseconds
+	ops         *pendingOps
 	client      CDPClient
 	event       *goob.Observable // all the browser events from cdp client
 	targetsLock *sync.Mutex
@@ -78,6 +96,11 @@ func New() *Browser {
 		defaultDevice: devices.LaptopWithMDPIScreen.Landscape(),
 		targetsLock:   &sync.Mutex{},
 		states:        &sync.Map{},
+		disconnect:    &disconnection{},
+		latency:       new(int64),
+		ops:           newPendingOps(),
+		bus:           newBus(),
+		authPatterns:  &authPatterns{},
 	}).WithPanic(utils.Panic)
 }
 
@@ -100,6 +123,14 @@ func (b *Browser) ControlURL(url string) *Browser {
 	return b
 }
 
+// Launcher sets the launcher that launched the browser, so that the [Browser.DisconnectErr] can
+// have the [launcher.CrashReport] when the browser crashes.
+// If the [Browser.ControlURL] is empty, the [Browser.Connect] will set it automatically.
+func (b *Browser) Launcher(l *launcher.Launcher) *Browser {
+	b.launcher = l
+	return b
+}
+
 // SlowMotion set the delay for each control action, such as the simulation of the human inputs.
 func (b *Browser) SlowMotion(delay time.Duration) *Browser {
 	b.slowMotion = delay
@@ -150,13 +181,14 @@ func (b *Browser) Connect() error {
 		u := b.controlURL
 		if u == "" {
 			var err error
-			u, err = launcher.New().Context(b.ctx).Launch()
+			b.launcher = launcher.New().Context(b.ctx)
+			u, err = b.launcher.Launch()
 			if err != nil {
 				return err
 			}
 		}
 
-		c, err := cdp.StartWithURL(b.ctx, u, nil)
+		c, err := b.dial(u)
 		if err != nil {
 			return err
 		}
@@ -174,6 +206,14 @@ func (b *Browser) Connect() error {
 	return proto.TargetSetDiscoverTargets{Discover: true}.Call(b)
 }
 
+// dial the control url of the browser.
+func (b *Browser) dial(u string) (*cdp.Client, error) {
+	if launcher.IsPipeURL(u) {
+		return launcher.PipeClient(u)
+	}
+	return cdp.StartWithURL(b.ctx, u, nil)
+}
+
 // Close the browser.
 func (b *Browser) Close() error {
 	if b.BrowserContextID == "" {
@@ -236,9 +276,220 @@ func (b *Browser) Pages() (Pages, error) {
 	return pageList, nil
 }
 
+// StartPages returns the pages of the [launcher.Launcher.StartURL] in the same order as the urls, such as the tabs
+// of a dashboard. The browser must have a launcher, such as the one set by [Browser.Launcher], otherwise
+// [ErrNoLauncher] is returned. It waits until each url has a page, a page matches a url if they have the same host,
+// path, and query, the scheme is ignored so the redirects to https are supported. If not all the urls match that way,
+// the pages that have the same host are used for the rest of the urls.
+// If the context of the browser has no deadline, it gives up after 30 seconds.
+func (b *Browser) StartPages() (Pages, error) {
+	if b.launcher == nil {
+		return nil, ErrNoLauncher
+	}
+
+	urls := b.launcher.StartURLs()
+
+	ctx := b.ctx
+	if _, has := ctx.Deadline(); !has {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, startPagesTimeout)
+		defer cancel()
+	}
+
+	var targets []*proto.TargetTargetInfo
+	err := utils.Retry(ctx, b.sleeper(), func() (bool, error) {
+		list, err := proto.TargetGetTargets{}.Call(b.Context(ctx))
+		if err != nil {
+			return true, err
+		}
+
+		targets = matchStartURLs(urls, list.TargetInfos)
+		return targets != nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pages := Pages{}
+	for _, t := range targets {
+		page, err := b.PageFromTarget(t.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// startPagesTimeout is the default timeout of the [Browser.StartPages].
+var startPagesTimeout = 30 * time.Second
+
+// matchStartURLs returns the page target for each of the urls in order, it returns nil if any url has no target.
+func matchStartURLs(urls []string, targets []*proto.TargetTargetInfo) []*proto.TargetTargetInfo {
+	matched := make([]*proto.TargetTargetInfo, len(urls))
+	used := map[proto.TargetTargetID]bool{}
+
+	match := func(same func(a, b *url.URL) bool) {
+		for i, raw := range urls {
+			if matched[i] != nil {
+				continue
+			}
+
+			u := startURL(raw)
+			for _, t := range targets {
+				if t.Type != proto.TargetTargetInfoTypePage || used[t.TargetID] {
+					continue
+				}
+
+				tu, err := url.Parse(t.URL)
+				if err == nil && u != nil && same(u, tu) {
+					matched[i] = t
+					used[t.TargetID] = true
+					break
+				}
+			}
+		}
+	}
+
+	sameHost := func(a, b *url.URL) bool {
+		return strings.EqualFold(strings.TrimPrefix(a.Host, "www."), strings.TrimPrefix(b.Host, "www.")) &&
+			(a.Host != "" || a.Opaque == b.Opaque)
+	}
+
+	match(func(a, b *url.URL) bool {
+		return sameHost(a, b) && strings.TrimSuffix(a.Path, "/") == strings.TrimSuffix(b.Path, "/") &&
+			a.RawQuery == b.RawQuery
+	})
+	match(sameHost)
+
+	for _, t := range matched {
+		if t == nil {
+			return nil
+		}
+	}
+	return matched
+}
+
+var regURLScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:[^0-9]`)
+
+// startURL parses the url of the command line like the browser, the url without a scheme is http.
+func startURL(raw string) *url.URL {
+	if !regURLScheme.MatchString(raw) {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// TileWindows positions all the open windows of the browser in a grid of the rows and cols that fills the display,
+// such as for the demo walls and the multi-account monitoring. The display is the area of the screen in pixels,
+// if it's nil the available area of the screen of the first page is used. The windows are placed in the order of
+// their creation, from left to right and top to bottom, each window takes a cell no matter how many tabs it has.
+// The minimized, maximized, and fullscreen windows are restored first. It only works for the headful browsers,
+// the headless ones have no real screen. An error is returned if there are more windows than the cells.
+func (b *Browser) TileWindows(rows, cols int, display *proto.BrowserBounds) error {
+	if rows < 1 || cols < 1 {
+		return fmt.Errorf("invalid grid: %dx%d", rows, cols)
+	}
+
+	pages, err := b.Pages()
+	if err != nil {
+		return err
+	}
+
+	windows := map[proto.BrowserWindowID]*Page{}
+	ids := []proto.BrowserWindowID{}
+	for _, p := range pages {
+		id, err := p.getWindowID()
+		if err != nil {
+			return err
+		}
+		if _, has := windows[id]; !has {
+			windows[id] = p
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if len(ids) > rows*cols {
+		return fmt.Errorf("%d windows don't fit in the %dx%d grid", len(ids), rows, cols)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	area, err := displayArea(windows[ids[0]], display)
+	if err != nil {
+		return err
+	}
+	width, height := *area.Width/cols, *area.Height/rows
+
+	for i, id := range ids {
+		err = proto.BrowserSetWindowBounds{
+			WindowID: id,
+			Bounds:   &proto.BrowserBounds{WindowState: proto.BrowserWindowStateNormal},
+		}.Call(b)
+		if err != nil {
+			return err
+		}
+
+		err = proto.BrowserSetWindowBounds{
+			WindowID: id,
+			Bounds: &proto.BrowserBounds{
+				Left:   gson.Int(*area.Left + i%cols*width),
+				Top:    gson.Int(*area.Top + i/cols*height),
+				Width:  gson.Int(width),
+				Height: gson.Int(height),
+			},
+		}.Call(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// displayArea returns a copy of the display, the fields that are not set are filled with the available area of the
+// screen of the page.
+func displayArea(p *Page, display *proto.BrowserBounds) (*proto.BrowserBounds, error) {
+	area := &proto.BrowserBounds{}
+	if display != nil {
+		*area = *display
+	}
+	if area.Left != nil && area.Top != nil && area.Width != nil && area.Height != nil {
+		return area, nil
+	}
+
+	res, err := p.Eval(`() => [screen.availLeft || 0, screen.availTop || 0, screen.availWidth, screen.availHeight]`)
+	if err != nil {
+		return nil, err
+	}
+
+	screen := res.Value.Arr()
+	for i, f := range []**int{&area.Left, &area.Top, &area.Width, &area.Height} {
+		if *f == nil {
+			*f = gson.Int(screen[i].Int())
+		}
+	}
+	return area, nil
+}
+
 // Call implements the [proto.Client] to call raw cdp interface directly.
 func (b *Browser) Call(ctx context.Context, sessionID, methodName string, params interface{}) (res []byte, err error) {
+	err = b.ops.begin(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer b.ops.end(sessionID)
+
+	start := time.Now()
 	res, err = b.client.Call(ctx, sessionID, methodName, params)
+	atomic.StoreInt64(b.latency, int64(time.Since(start)))
 	if err != nil {
 		return nil, err
 	}
@@ -257,6 +508,10 @@ func (b *Browser) PageFromSession(sessionID proto.TargetSessionID) *Page {
 		sleeper:       b.sleeper,
 		browser:       b,
 		SessionID:     sessionID,
+		queries:       newQueryCache(sessionCtx),
+		requests:      &requestTracker{},
+		bodies:        &bodyCache{},
+		hooks:         newPageHooks(sessionCtx),
 	}
 }
 
@@ -292,6 +547,10 @@ func (b *Browser) PageFromTarget(targetID proto.TargetTargetID) (*Page, error) {
 		jsCtxLock:     &sync.Mutex{},
 		jsCtxID:       new(proto.RuntimeRemoteObjectID),
 		helpersLock:   &sync.Mutex{},
+		queries:       newQueryCache(sessionCtx),
+		requests:      &requestTracker{},
+		bodies:        &bodyCache{},
+		hooks:         newPageHooks(sessionCtx),
 	}
 
 	page.root = page
@@ -315,6 +574,81 @@ func (b *Browser) PageFromTarget(targetID proto.TargetTargetID) (*Page, error) {
 	return page, nil
 }
 
+// hasFrameTarget tells if the frame is an out-of-process iframe, such frame has its own target.
+func (b *Browser) hasFrameTarget(id proto.PageFrameID) (bool, error) {
+	list, err := proto.TargetGetTargets{}.Call(b)
+	if err != nil {
+		return false, err
+	}
+
+	for _, target := range list.TargetInfos {
+		if target.Type == "iframe" && target.TargetID == proto.TargetTargetID(id) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// attachOOPIF attaches to the target of the out-of-process iframe that el represents.
+// The input devices are shared with the parent page, because the browser routes
+// the input events dispatched to the top-level page to the OOPIF.
+func (b *Browser) attachOOPIF(el *Element, id proto.PageFrameID) (*Page, error) {
+	b.targetsLock.Lock()
+	defer b.targetsLock.Unlock()
+
+	targetID := proto.TargetTargetID(id)
+
+	frame := b.loadCachedPage(targetID)
+	if frame == nil {
+		session, err := proto.TargetAttachToTarget{
+			TargetID: targetID,
+			Flatten:  true,
+		}.Call(b)
+		if err != nil {
+			return nil, err
+		}
+
+		sessionCtx, cancel := context.WithCancel(b.ctx)
+
+		frame = &Page{
+			e:             b.e,
+			ctx:           sessionCtx,
+			sessionCancel: cancel,
+			sleeper:       b.sleeper,
+			browser:       b,
+			TargetID:      targetID,
+			SessionID:     session.SessionID,
+			FrameID:       id,
+			root:          el.page.root,
+			Mouse:         el.page.Mouse,
+			Keyboard:      el.page.Keyboard,
+			Touch:         el.page.Touch,
+			jsCtxLock:     &sync.Mutex{},
+			jsCtxID:       new(proto.RuntimeRemoteObjectID),
+			helpersLock:   &sync.Mutex{},
+			queries:       newQueryCache(sessionCtx),
+			requests:      &requestTracker{},
+			bodies:        &bodyCache{},
+			hooks:         newPageHooks(sessionCtx),
+		}
+
+		b.cachePage(frame)
+
+		frame.initEvents()
+
+		frame.EnableDomain(&proto.PageEnable{})
+	}
+
+	frame.helpersLock.Lock()
+	clone := *frame
+	frame.helpersLock.Unlock()
+	clone.element = el
+	clone.sleeper = el.sleeper
+
+	return &clone, nil
+}
+
 // EachEvent is similar to [Page.EachEvent], but catches events of the entire browser.
 func (b *Browser) EachEvent(callbacks ...interface{}) (wait func()) {
 	return b.eachEvent("", callbacks...)
@@ -441,8 +775,8 @@ func (b *Browser) initEvents() {
 	event := b.client.Event()
 
 	go func() {
-		defer cancel()
 		for e := range event {
+			b.ops.track(e)
 			b.event.Publish(&Message{
 				SessionID: proto.TargetSessionID(e.SessionID),
 				Method:    e.Method,
@@ -450,9 +784,48 @@ func (b *Browser) initEvents() {
 				data:      e.Params,
 			})
 		}
+		cancel()
+		b.disconnected()
 	}()
 }
 
+// disconnection of the cdp client.
+type disconnection struct {
+	lock sync.Mutex
+	err  error
+}
+
+// crashReportTimeout is how long to wait for the browser process to exit after the disconnection.
+var crashReportTimeout = 3 * time.Second
+
+func (b *Browser) disconnected() {
+	b.setDisconnectErr(&BrowserDisconnectedError{})
+
+	if b.launcher == nil {
+		return
+	}
+
+	select {
+	case <-b.launcher.Exit():
+		b.setDisconnectErr(&BrowserDisconnectedError{Crash: b.launcher.CrashReport()})
+	case <-time.After(crashReportTimeout):
+	}
+}
+
+func (b *Browser) setDisconnectErr(err error) {
+	b.disconnect.lock.Lock()
+	defer b.disconnect.lock.Unlock()
+	b.disconnect.err = err
+}
+
+// DisconnectErr returns nil if the browser is still connected, otherwise returns a [*BrowserDisconnectedError].
+// If the browser crashed, the error will have the [launcher.CrashReport], check [Browser.Launcher].
+func (b *Browser) DisconnectErr() error {
+	b.disconnect.lock.Lock()
+	defer b.disconnect.lock.Unlock()
+	return b.disconnect.err
+}
+
 func (b *Browser) pageInfo(id proto.TargetTargetID) (*proto.TargetTargetInfo, error) {
 	res, err := proto.TargetGetTargetInfo{TargetID: id}.Call(b)
 	if err != nil {
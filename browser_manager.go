@@ -0,0 +1,315 @@
+// This file contains the manager of the warm browsers for high-throughput services.
+
+package rod
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// ErrBrowserManagerClosed is returned by [BrowserManager.Checkout] after the manager is closed.
+var ErrBrowserManagerClosed = errors.New("browser manager is closed")
+
+// BrowserManager maintains a fixed number of warm browsers, hands them out with checkout/checkin semantics,
+// health-checks them, and recycles the crashed ones with new browsers.
+// Unlike the [Pool], the browsers are launched ahead of time, so the checkout doesn't wait for the launch.
+//
+//	m := rod.NewBrowserManager(4, func() (*rod.Browser, error) {
+//		b := rod.New()
+//		return b, b.Connect()
+//	})
+//	defer m.Close()
+//
+//	b, _ := m.Checkout(ctx)
+//	defer m.Checkin(b)
+type BrowserManager struct {
+	size   int
+	launch func() (*Browser, error)
+
+	check       func(b *Browser) error
+	reset       func(b *Browser) error
+	checkTicker *time.Ticker
+
+	idle   chan *Browser
+	closed chan struct{}
+
+	// lock guards the isClosed and the wg.Add, so no goroutine is added after the Close starts to wait
+	lock     sync.Mutex
+	isClosed bool
+	wg       sync.WaitGroup
+
+	inUse    int64
+	recycled int64
+
+	errLock sync.Mutex
+	lastErr error
+}
+
+// BrowserManagerStats is the snapshot of the states of a [BrowserManager].
+type BrowserManagerStats struct {
+	// Size is the number of the browsers the manager maintains
+	Size int
+
+	// Idle browsers that are ready to checkout
+	Idle int
+
+	// InUse browsers that are checked out
+	InUse int
+
+	// Recycled is the total number of the unhealthy browsers that have been replaced
+	Recycled int
+
+	// LastError of the launch or the health check, it's nil if nothing failed
+	LastError error
+}
+
+// NewBrowserManager launches the size number of browsers via the launch function in the background.
+// If a launch fails it will be retried with backoff until the manager is closed.
+func NewBrowserManager(size int, launch func() (*Browser, error)) *BrowserManager {
+	m := &BrowserManager{
+		size:   size,
+		launch: launch,
+		check: func(b *Browser) error {
+			_, err := b.Timeout(10 * time.Second).Version()
+			return err
+		},
+		idle:   make(chan *Browser, size),
+		closed: make(chan struct{}),
+	}
+
+	for i := 0; i < size; i++ {
+		m.spawn()
+	}
+
+	return m
+}
+
+// Check sets the function to health-check a browser, the browser is treated as crashed if it returns an error.
+// The default one calls [Browser.Version] with a 10s timeout.
+func (m *BrowserManager) Check(fn func(b *Browser) error) *BrowserManager {
+	m.check = fn
+	return m
+}
+
+// Reset sets the function to clean up a browser when it's checked in, such as to close all its pages.
+// If it returns an error the browser will be recycled.
+func (m *BrowserManager) Reset(fn func(b *Browser) error) *BrowserManager {
+	m.reset = fn
+	return m
+}
+
+// HealthCheck checks the idle browsers every interval in the background, it's disabled by default.
+// The checked out browsers are always checked when they are checked in.
+func (m *BrowserManager) HealthCheck(interval time.Duration) *BrowserManager {
+	if m.checkTicker != nil {
+		m.checkTicker.Reset(interval)
+		return m
+	}
+
+	m.checkTicker = time.NewTicker(interval)
+	m.goroutine(func() {
+		for {
+			select {
+			case <-m.closed:
+				m.checkTicker.Stop()
+				return
+			case <-m.checkTicker.C:
+				m.checkIdle()
+			}
+		}
+	})
+	return m
+}
+
+// Checkout a warm browser, it waits until one is idle or the ctx is done.
+// The browser is health-checked before it's handed out, if it fails it will be recycled in the background
+// and the checkout waits for the next idle one. Use [BrowserManager.Checkin] to return it.
+func (m *BrowserManager) Checkout(ctx context.Context) (*Browser, error) {
+	for {
+		select {
+		case <-m.closed:
+			return nil, ErrBrowserManagerClosed
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-m.closed:
+			return nil, ErrBrowserManagerClosed
+		case b := <-m.idle:
+			if err := m.healthy(b, false); err != nil {
+				if !m.goroutine(func() { m.recycle(b, err) }) {
+					_ = b.Close()
+				}
+				continue
+			}
+			atomic.AddInt64(&m.inUse, 1)
+			return b, nil
+		}
+	}
+}
+
+// Checkin returns the browser to the manager. The browser will be reset and health-checked in the background,
+// if it fails it will be recycled. If the manager is closed, the browser will be closed.
+func (m *BrowserManager) Checkin(b *Browser) {
+	atomic.AddInt64(&m.inUse, -1)
+
+	ok := m.goroutine(func() {
+		err := m.healthy(b, true)
+		if err != nil {
+			m.recycle(b, err)
+			return
+		}
+		m.put(b)
+	})
+	if !ok {
+		_ = b.Close()
+	}
+}
+
+// Stats returns the states of the manager.
+func (m *BrowserManager) Stats() BrowserManagerStats {
+	s := BrowserManagerStats{
+		Size:     m.size,
+		Idle:     len(m.idle),
+		InUse:    int(atomic.LoadInt64(&m.inUse)),
+		Recycled: int(atomic.LoadInt64(&m.recycled)),
+	}
+	m.errLock.Lock()
+	s.LastError = m.lastErr
+	m.errLock.Unlock()
+	return s
+}
+
+// Close the manager and all the idle browsers, the checked out browsers will be closed when they are checked in.
+func (m *BrowserManager) Close() error {
+	m.lock.Lock()
+	if !m.isClosed {
+		m.isClosed = true
+		close(m.closed)
+	}
+	m.lock.Unlock()
+
+	m.wg.Wait()
+
+	errs := []error{}
+	for {
+		select {
+		case b := <-m.idle:
+			if err := b.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// goroutine runs the fn in the background and the [BrowserManager.Close] will wait for it.
+// It returns false without running the fn if the manager is closed.
+func (m *BrowserManager) goroutine(fn func()) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.isClosed {
+		return false
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		fn()
+	}()
+	return true
+}
+
+func (m *BrowserManager) setErr(err error) {
+	m.errLock.Lock()
+	m.lastErr = err
+	m.errLock.Unlock()
+}
+
+func (m *BrowserManager) healthy(b *Browser, reset bool) error {
+	if reset && m.reset != nil {
+		if err := m.reset(b); err != nil {
+			return err
+		}
+	}
+	return m.check(b)
+}
+
+// put the browser back to the idle list, or close it if the manager is closed.
+func (m *BrowserManager) put(b *Browser) {
+	select {
+	case <-m.closed:
+		_ = b.Close()
+	default:
+		m.idle <- b
+	}
+}
+
+// checkIdle checks the browsers that are idle now, the ones that are checked out meanwhile are skipped.
+func (m *BrowserManager) checkIdle() {
+	for i := len(m.idle); i > 0; i-- {
+		var b *Browser
+		select {
+		case b = <-m.idle:
+		default:
+			return
+		}
+
+		err := m.healthy(b, false)
+		if err != nil {
+			m.recycle(b, err)
+			continue
+		}
+		m.put(b)
+	}
+}
+
+// recycle closes the unhealthy browser and spawns a new one to replace it.
+func (m *BrowserManager) recycle(b *Browser, err error) {
+	m.setErr(err)
+	atomic.AddInt64(&m.recycled, 1)
+
+	if b.Timeout(10*time.Second).Close() != nil && b.BrowserContextID == "" && b.launcher != nil {
+		b.launcher.Kill()
+	}
+
+	m.spawn()
+}
+
+// spawn launches a new browser in the background until it succeeds or the manager is closed.
+func (m *BrowserManager) spawn() {
+	m.goroutine(func() {
+		sleeper := utils.BackoffSleeper(100*time.Millisecond, 10*time.Second, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-m.closed:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		for {
+			b, err := m.launch()
+			if err == nil {
+				m.put(b)
+				return
+			}
+			m.setErr(err)
+
+			if sleeper(ctx) != nil {
+				return
+			}
+		}
+	})
+}
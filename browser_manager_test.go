@@ -0,0 +1,109 @@
+package rod_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestBrowserManager(t *testing.T) {
+	g := setup(t)
+
+	var launched, failed int32
+	m := rod.NewBrowserManager(2, func() (*rod.Browser, error) {
+		// the first launch fails, it should be retried
+		if atomic.AddInt32(&failed, 1) == 1 {
+			return nil, errors.New("launch failed")
+		}
+		atomic.AddInt32(&launched, 1)
+		return g.browser.Incognito()
+	})
+	defer func() { g.E(m.Close()) }()
+
+	var crashed, dead int32
+	m.Check(func(b *rod.Browser) error {
+		if atomic.LoadInt32(&crashed) == 1 {
+			return errors.New("crashed")
+		}
+		if atomic.CompareAndSwapInt32(&dead, 1, 0) {
+			return errors.New("dead")
+		}
+		_, err := b.Version()
+		return err
+	})
+
+	resets := int32(0)
+	m.Reset(func(b *rod.Browser) error {
+		atomic.AddInt32(&resets, 1)
+		return nil
+	})
+
+	a := m.MustCheckout(g.Context())
+	b := m.MustCheckout(g.Context())
+	g.Neq(a.BrowserContextID, b.BrowserContextID)
+	g.Eq(m.Stats().InUse, 2)
+	g.Eq(m.Stats().LastError.Error(), "launch failed")
+
+	// all the browsers are checked out
+	ctx, cancel := context.WithTimeout(g.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err := m.Checkout(ctx)
+	g.Is(err, context.DeadlineExceeded)
+
+	a.MustPage(g.blank()).MustElement("body")
+	m.Checkin(a)
+	c := m.MustCheckout(g.Context())
+	g.Eq(c.BrowserContextID, a.BrowserContextID)
+	g.Eq(atomic.LoadInt32(&resets), 1)
+
+	// the crashed one is replaced with a new one
+	atomic.StoreInt32(&crashed, 1)
+	m.Checkin(c)
+	for m.Stats().Recycled == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	atomic.StoreInt32(&crashed, 0)
+
+	d := m.MustCheckout(g.Context())
+	g.Neq(d.BrowserContextID, a.BrowserContextID)
+	g.Eq(atomic.LoadInt32(&launched), 3)
+	g.Eq(m.Stats().LastError.Error(), "crashed")
+	m.Checkin(d)
+	m.Checkin(b)
+
+	// the dead one is replaced on checkout
+	for m.Stats().Idle < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	atomic.StoreInt32(&dead, 1)
+	e := m.MustCheckout(g.Context())
+	g.Eq(atomic.LoadInt32(&dead), 0)
+	g.Eq(m.Stats().Recycled, 2)
+	m.Checkin(e)
+
+	// the idle ones are checked in the background
+	m.HealthCheck(10 * time.Millisecond)
+	atomic.StoreInt32(&crashed, 1)
+	for m.Stats().Recycled < 3 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	atomic.StoreInt32(&crashed, 0)
+
+	f := m.MustCheckout(g.Context())
+	count := atomic.LoadInt32(&resets)
+
+	g.E(m.Close())
+	_, err = m.Checkout(g.Context())
+	g.Is(err, rod.ErrBrowserManagerClosed)
+
+	// the browser checked in after the close is closed right away
+	m.Checkin(f)
+	g.Eq(atomic.LoadInt32(&resets), count)
+	_, err = f.Page(proto.TargetCreateTarget{})
+	g.Err(err)
+}
@@ -1,12 +1,16 @@
 package rod_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -440,6 +444,39 @@ func TestBrowserConnectFailure(t *testing.T) {
 	}
 }
 
+func TestSleeperPolicy(t *testing.T) {
+	g := got.T(t)
+
+	measure := func(s utils.Sleeper, n int) time.Duration {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			g.E(s(g.Context()))
+		}
+		return time.Since(start)
+	}
+
+	fixed := rod.SleeperPolicy{Init: 10 * time.Millisecond, Factor: 1}.Sleeper()
+	d := measure(fixed(), 5)
+	g.Gte(d, 50*time.Millisecond)
+	g.Lt(d, 200*time.Millisecond)
+
+	grow := rod.SleeperPolicy{Init: 10 * time.Millisecond, Max: 40 * time.Millisecond, Factor: 2}.Sleeper()
+	d = measure(grow(), 3) // 20ms + 40ms + 40ms
+	g.Gte(d, 100*time.Millisecond)
+	g.Lt(d, 300*time.Millisecond)
+
+	// the negative scales of the wide jitter are clamped, so the delay never flips to a long one
+	wide := rod.SleeperPolicy{Init: 10 * time.Millisecond, Max: 40 * time.Millisecond, Factor: 2, Jitter: 100}.Sleeper()
+	g.Lt(measure(wide(), 5), time.Second)
+
+	ctx := g.Context()
+	ctx.Cancel()
+	g.Is(rod.SleeperPolicy{}.Sleeper()()(ctx), context.Canceled)
+
+	b := rod.New().SleeperPolicy(rod.SleeperPolicy{Init: time.Millisecond})
+	g.NotNil(b)
+}
+
 func TestBrowserPool(t *testing.T) {
 	g := got.T(t)
 
@@ -487,9 +524,220 @@ func TestBrowserLostConnection(t *testing.T) {
 	g.Err(err)
 }
 
+func TestBrowserCrashReport(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().Leakless(false)
+	b := rod.New().ControlURL(l.MustLaunch()).Launcher(l).MustConnect()
+	g.Nil(b.DisconnectErr())
+
+	_ = proto.BrowserCrash{}.Call(b)
+
+	<-l.Exit()
+	utils.Sleep(0.5)
+
+	err := b.DisconnectErr()
+	g.Is(err, &rod.BrowserDisconnectedError{})
+
+	var crash *launcher.CrashReport
+	g.True(errors.As(err, &crash))
+	g.NotNil(crash)
+}
+
+func TestBrowserSupervise(t *testing.T) {
+	g := setup(t)
+
+	_, err := rod.New().Supervise(nil)
+	g.Eq(err, rod.ErrNoLauncher)
+
+	l := launcher.New().Leakless(false)
+	b := rod.New().ControlURL(l.MustLaunch()).Launcher(l).MustConnect()
+
+	recovered := make(chan *rod.BrowserRecovery, 1)
+	stop := b.MustSupervise(func(r *rod.BrowserRecovery) { recovered <- r })
+	defer stop()
+
+	// the calls in flight during the recovery fail safely, run it with -race to check the data races
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_, _ = b.Pages()
+				_ = b.DisconnectErr()
+			}
+		}
+	}()
+
+	_ = proto.BrowserCrash{}.Call(b)
+
+	r := <-recovered
+	g.E(r.Err)
+	g.NotNil(r.Crash)
+	g.Is(b.DisconnectErr(), &rod.BrowserDisconnectedError{})
+	g.Nil(r.Browser.DisconnectErr())
+
+	r.Browser.MustPage(g.blank()).MustWaitLoad()
+	r.Browser.MustClose()
+}
+
+func TestBrowserTileWindows(t *testing.T) {
+	g := setup(t)
+
+	b := rod.New().ControlURL(launcher.New().MustLaunch()).MustConnect()
+	defer b.MustClose()
+
+	for i := 0; i < 2; i++ {
+		_, err := b.Page(proto.TargetCreateTarget{URL: "about:blank", NewWindow: true})
+		g.E(err)
+	}
+
+	b.MustTileWindows(2, 2, &proto.BrowserBounds{Left: gson.Int(0), Top: gson.Int(0), Width: gson.Int(1000), Height: gson.Int(800)})
+
+	cells := map[string]bool{}
+	for _, p := range b.MustPages() {
+		w := p.MustGetWindow()
+		g.Eq(*w.Width, 500)
+		g.Eq(*w.Height, 400)
+		cells[fmt.Sprint(*w.Left, *w.Top)] = true
+	}
+	g.Gt(len(cells), 1)
+
+	g.Has(b.TileWindows(1, 1, nil).Error(), "windows don't fit in the 1x1 grid")
+	g.Eq(b.TileWindows(0, 1, nil).Error(), "invalid grid: 0x1")
+}
+
+func TestBrowserStartPages(t *testing.T) {
+	g := setup(t)
+
+	_, err := rod.New().StartPages()
+	g.Eq(err, rod.ErrNoLauncher)
+
+	s := g.Serve()
+	s.Route("/b", ".html", `<html>b</html>`)
+	s.Route("/a", ".html", `<html>a</html>`)
+
+	l := launcher.New().StartURL(s.URL("/b"), s.URL("/a"), "about:blank")
+	b := rod.New().ControlURL(l.MustLaunch()).Launcher(l).Context(g.Timeout(10 * time.Second)).MustConnect()
+	defer b.MustClose()
+
+	pages := b.MustStartPages()
+	g.Len(pages, 3)
+	g.Eq(pages[0].MustInfo().URL, s.URL("/b"))
+	g.Eq(pages[1].MustInfo().URL, s.URL("/a"))
+	g.Eq(pages[2].MustInfo().URL, "about:blank")
+
+	// the returned pages are not bound to the timeout of the waiting
+	g.Eq(pages[0].MustEval(`() => 1`).Int(), 1)
+
+	l.StartURL(s.URL("/none"))
+	_, err = b.Timeout(time.Second).StartPages()
+	g.Is(err, context.DeadlineExceeded)
+}
+
+func TestBrowserExtensionTarget(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().Extension(filepath.Join("fixtures", "chrome-extension-background"))
+	defer l.Kill()
+
+	b := rod.New().ControlURL(l.MustLaunch()).MustConnect().Timeout(time.Minute)
+
+	target := b.MustExtensionTarget("")
+	g.Eq(target.Type, proto.TargetTargetInfoTypeServiceWorker)
+	g.Has(target.URL, "/background.js")
+
+	id := strings.Split(strings.TrimPrefix(target.URL, "chrome-extension://"), "/")[0]
+	g.Eq(b.MustExtensionTarget(id).TargetID, target.TargetID)
+}
+
 func TestBrowserConnectConflict(t *testing.T) {
 	g := setup(t)
 	g.Panic(func() {
 		rod.New().Client(&cdp.Client{}).ControlURL("test").MustConnect()
 	})
 }
+
+func TestHealth(t *testing.T) {
+	g := setup(t)
+
+	g.newPage(g.blank())
+
+	s := rod.Health(g.browser)
+	g.True(s.Connected)
+	g.Eq(s.Error, "")
+	g.Gt(s.Pages, 0)
+	g.Gte(s.Targets, s.Pages)
+	g.Has(s.Version, "Chrome")
+	g.Gt(s.Memory.Used, 0)
+	g.Gt(g.browser.Latency(), 0)
+
+	res := httptest.NewRecorder()
+	rod.HealthHandler(g.browser).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	g.Eq(res.Code, http.StatusOK)
+	g.Has(res.Body.String(), `"connected":true`)
+
+	g.mc.stubErr(1, proto.BrowserGetVersion{})
+	res = httptest.NewRecorder()
+	rod.HealthHandler(g.browser).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	g.Eq(res.Code, http.StatusServiceUnavailable)
+	g.Has(res.Body.String(), `"connected":false`)
+}
+
+func TestBrowserCloseGracefully(t *testing.T) {
+	g := setup(t)
+
+	b := rod.New().MustConnect()
+
+	// the page stays idle for longer than the idle window before the close
+	idle := b.MustPage(g.blank())
+	utils.Sleep(1.2)
+
+	p := b.MustPage(g.blank())
+	done := make(chan *proto.RuntimeRemoteObject)
+	go func() {
+		res, err := p.Eval(`() => new Promise(r => setTimeout(() => r(1), 500))`)
+		g.E(err)
+		done <- res
+	}()
+
+	// a query that polls the page has gaps between its calls
+	q := b.MustPage(g.blank())
+	q.MustEval(`() => setTimeout(() => document.body.innerHTML = '<p id="late">late</p>', 600)`)
+	found := make(chan string)
+	go func() {
+		el, err := q.Element("#late")
+		g.E(err)
+		text, err := el.Text()
+		g.E(err)
+		found <- text
+	}()
+	utils.Sleep(0.2)
+
+	closed := make(chan error)
+	go func() { closed <- b.CloseGracefully(g.Context()) }()
+	utils.Sleep(0.1)
+
+	_, err := idle.Eval(`() => 1`)
+	g.Is(err, &rod.BrowserClosingError{})
+
+	g.Eq((<-done).Value.Int(), 1)
+	g.Eq(<-found, "late")
+	g.E(<-closed)
+
+	_, err = p.Eval(`() => 1`)
+	g.Is(err, &rod.BrowserClosingError{})
+}
+
+func TestBrowserCloseGracefullyErr(t *testing.T) {
+	g := setup(t)
+
+	g.mc.stubErr(1, proto.TargetGetTargets{})
+	g.Err(g.browser.CloseGracefully(g.Context()))
+
+	// the browser still accepts the calls after the failed close
+	g.browser.MustPages()
+}
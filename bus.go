@@ -0,0 +1,231 @@
+// This file contains the message bus to coordinate the pages of a browser.
+
+package rod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/js"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// BusMessage is a message of the [Bus].
+type BusMessage struct {
+	Topic string
+	Data  gson.JSON
+
+	// From is the id of the page that publishes the message, it's empty if the message is from the Go code.
+	From proto.TargetTargetID
+}
+
+// Bus is a publish/subscribe message bus shared by the Go code and the attached pages of a browser,
+// it makes the multi-tab flows, such as OAuth popups and payment redirects, easier to coordinate.
+// In an attached page the scripts can use:
+//
+//	rodBus.publish("paid", { orderID: 1 })
+//	const unsubscribe = rodBus.subscribe("paid", (data, topic) => {})
+//
+// The messages are delivered to each attached page in order without blocking the publisher,
+// a message is dropped for a page if the page is too slow to receive it.
+type Bus struct {
+	lock  sync.Mutex
+	subs  map[string][]*busSubscriber
+	pages map[proto.TargetSessionID]*busPage
+}
+
+type busSubscriber struct {
+	handler func(*BusMessage)
+}
+
+type busPage struct {
+	page  *Page
+	bind  string
+	queue chan *BusMessage
+}
+
+const (
+	// busQueueSize is the max number of the pending messages of a page
+	busQueueSize = 100
+
+	// busDeliverTimeout is the timeout to deliver a message to a page
+	busDeliverTimeout = 5 * time.Second
+)
+
+// deliver the queued messages to the page until the page is detached.
+func (bp *busPage) deliver() {
+	for {
+		select {
+		case <-bp.page.ctx.Done():
+			return
+		case msg := <-bp.queue:
+			// the page may be navigating or closed, the message is dropped for it
+			p := bp.page.Timeout(busDeliverTimeout)
+			_, _ = p.Evaluate(Eval(`(name, topic, data) => window[name] && window[name](topic, data)`,
+				bp.bind+"_deliver", msg.Topic, msg.Data))
+			p.CancelTimeout()
+		}
+	}
+}
+
+func newBus() *Bus {
+	return &Bus{
+		subs:  map[string][]*busSubscriber{},
+		pages: map[proto.TargetSessionID]*busPage{},
+	}
+}
+
+// Bus returns the message bus of the browser, the browser clones share the same bus.
+func (b *Browser) Bus() *Bus {
+	return b.bus
+}
+
+// Attach the page to the bus, the rodBus api will be available in the current and new documents of the page.
+// Messages published before the page is attached won't be delivered to it.
+// Call detach to remove the page from the bus.
+func (bus *Bus) Attach(p *Page) (detach func() error, err error) {
+	bind := "_" + utils.RandString(8)
+
+	err = proto.RuntimeAddBinding{Name: bind}.Call(p)
+	if err != nil {
+		return
+	}
+
+	page, cancel := p.WithCancel()
+	defer func() {
+		if err != nil {
+			cancel()
+			_ = proto.RuntimeRemoveBinding{Name: bind}.Call(p)
+		}
+	}()
+
+	go page.EachEvent(func(e *proto.RuntimeBindingCalled) {
+		if e.Name != bind {
+			return
+		}
+
+		var msg struct {
+			Topic string    `json:"topic"`
+			Data  gson.JSON `json:"data"`
+		}
+		if json.Unmarshal([]byte(e.Payload), &msg) != nil {
+			return
+		}
+
+		bus.publish(&BusMessage{Topic: msg.Topic, Data: msg.Data, From: page.TargetID}, page.SessionID)
+	})()
+
+	_, err = page.Evaluate(Eval(js.BusClient.Definition, bind))
+	if err != nil {
+		return
+	}
+
+	remove, err := page.EvalOnNewDocument(fmt.Sprintf(`(%s)("%s")`, js.BusClient.Definition, bind))
+	if err != nil {
+		return
+	}
+
+	bp := &busPage{page: page, bind: bind, queue: make(chan *BusMessage, busQueueSize)}
+	go bp.deliver()
+
+	bus.lock.Lock()
+	bus.pages[page.SessionID] = bp
+	bus.lock.Unlock()
+
+	detach = func() error {
+		defer cancel()
+
+		bus.lock.Lock()
+		delete(bus.pages, page.SessionID)
+		bus.lock.Unlock()
+
+		err := remove()
+		if err != nil {
+			return err
+		}
+		return proto.RuntimeRemoveBinding{Name: bind}.Call(page)
+	}
+
+	return
+}
+
+// Publish the data to the subscribers of the topic, both the Go handlers and the attached pages.
+// The data will be encoded as json.
+func (bus *Bus) Publish(topic string, data interface{}) {
+	bus.publish(&BusMessage{Topic: topic, Data: gson.New(data)}, "")
+}
+
+// Subscribe the topic with the handler, the handler is called synchronously by the publisher,
+// so it should not block for long.
+func (bus *Bus) Subscribe(topic string, handler func(*BusMessage)) (unsubscribe func()) {
+	sub := &busSubscriber{handler}
+
+	bus.lock.Lock()
+	bus.subs[topic] = append(bus.subs[topic], sub)
+	bus.lock.Unlock()
+
+	return func() {
+		bus.lock.Lock()
+		defer bus.lock.Unlock()
+
+		list := []*busSubscriber{}
+		for _, s := range bus.subs[topic] {
+			if s != sub {
+				list = append(list, s)
+			}
+		}
+		bus.subs[topic] = list
+	}
+}
+
+// WaitMessage waits for the next message of the topic. It subscribes the topic before the wait function is called,
+// so that the message published in between won't be missed.
+func (bus *Bus) WaitMessage(ctx context.Context, topic string) (wait func() (*BusMessage, error)) {
+	ch := make(chan *BusMessage, 1)
+	unsubscribe := bus.Subscribe(topic, func(msg *BusMessage) {
+		select {
+		case ch <- msg:
+		default:
+		}
+	})
+
+	return func() (*BusMessage, error) {
+		defer unsubscribe()
+
+		select {
+		case msg := <-ch:
+			return msg, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// publish the message from the session, the message isn't sent back to the session.
+func (bus *Bus) publish(msg *BusMessage, from proto.TargetSessionID) {
+	bus.lock.Lock()
+	subs := append([]*busSubscriber{}, bus.subs[msg.Topic]...)
+	pages := make([]*busPage, 0, len(bus.pages))
+	for id, p := range bus.pages {
+		if id != from {
+			pages = append(pages, p)
+		}
+	}
+	bus.lock.Unlock()
+
+	for _, s := range subs {
+		s.handler(msg)
+	}
+
+	for _, p := range pages {
+		select {
+		case p.queue <- msg:
+		default:
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package rod_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestBus(t *testing.T) {
+	g := setup(t)
+
+	bus := g.browser.Bus()
+	g.Eq(g.browser.Timeout(time.Second).Bus(), bus)
+
+	a := g.newPage(g.blank())
+	b := g.newPage(g.blank())
+
+	detachA := bus.MustAttach(a)
+	defer bus.MustAttach(b)()
+
+	b.MustEval(`() => {
+		window.received = []
+		rodBus.subscribe('paid', (data, topic) => window.received.push([topic, data]))
+	}`)
+
+	wait := bus.WaitMessage(g.Context(), "paid")
+	a.MustEval(`() => rodBus.publish('paid', { order: 1 })`)
+	msg, err := wait()
+	g.E(err)
+	g.Eq(msg.Topic, "paid")
+	g.Eq(msg.Data.Get("order").Int(), 1)
+	g.Eq(msg.From, a.TargetID)
+
+	g.Eq(b.MustWait(`() => window.received.length === 1`).MustEval(`() => window.received[0][1].order`).Int(), 1)
+
+	// the messages from go
+	got := make(chan *rod.BusMessage, 1)
+	unsubscribe := bus.Subscribe("go", func(m *rod.BusMessage) { got <- m })
+	b.MustEval(`() => rodBus.subscribe('go', (data) => { window.fromGo = data })`)
+	bus.Publish("go", "hi")
+	g.Eq((<-got).From, proto.TargetTargetID(""))
+	g.Eq(b.MustEval(`() => window.fromGo`).Str(), "hi")
+	unsubscribe()
+
+	// a slow page doesn't block the publisher
+	b.MustEval(`() => rodBus.subscribe('slow', () => { const t = Date.now(); while (Date.now() - t < 1000); })`)
+	start := time.Now()
+	bus.Publish("slow", nil)
+	g.Lt(time.Since(start), 500*time.Millisecond)
+
+	// the api survives reloads
+	a.MustReload().MustWaitLoad()
+	g.True(a.MustEval(`() => !!window.rodBus`).Bool())
+
+	detachA()
+	a.MustReload().MustWaitLoad()
+	g.False(a.MustEval(`() => !!window.rodBus`).Bool())
+
+	ctx, cancel := context.WithCancel(g.Context())
+	cancel()
+	_, err = bus.WaitMessage(ctx, "none")()
+	g.Eq(err, context.Canceled)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeAddBinding{})
+		bus.MustAttach(a)
+	})
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		bus.MustAttach(a)
+	})
+}
@@ -0,0 +1,62 @@
+// This file contains the helpers to inspect what is drawn on the canvases.
+
+package rod
+
+import (
+	"fmt"
+
+	"github.com/xyjwsj/grod/lib/js"
+	"github.com/ysmood/gson"
+)
+
+// CanvasCommand is a method call or a property assignment on the 2d context of a canvas.
+type CanvasCommand struct {
+	// Name of the method or the property, such as "fillRect" or "fillStyle"
+	Name string `json:"name"`
+
+	// Args of the method call, or the value of the property assignment.
+	// The values that can't be encoded as json, such as gradients and images, are converted to strings
+	// like "[object CanvasGradient]".
+	Args []gson.JSON `json:"args"`
+
+	// Setter is true if it's a property assignment
+	Setter bool `json:"setter"`
+}
+
+// RecordCanvas injects a proxy of the canvas 2d api into the current document and the documents loaded later,
+// so that [Element.CanvasCommandsLog] can retrieve the draw calls of the canvases.
+// The calls before the injection can't be recorded, so usually it should be called before the navigation.
+// Use remove to stop the injection for the new documents.
+func (p *Page) RecordCanvas() (remove func() error, err error) {
+	_, err = p.Evaluate(Eval(js.RecordCanvas.Definition))
+	if err != nil {
+		return
+	}
+
+	return p.EvalOnNewDocument(fmt.Sprintf(`(%s)()`, js.RecordCanvas.Definition))
+}
+
+// CanvasCommandsLog returns the draw calls recorded on the canvas since the [Page.RecordCanvas]
+// or the last [Element.ClearCanvasCommandsLog], so that we can assert what was drawn without pixel diffing.
+// The read-only methods, such as getImageData and measureText, are not recorded,
+// only the latest 10000 calls of each canvas are kept.
+func (el *Element) CanvasCommandsLog() ([]*CanvasCommand, error) {
+	return el.canvasCommandsLog(false)
+}
+
+// ClearCanvasCommandsLog clears the recorded draw calls of the canvas.
+func (el *Element) ClearCanvasCommandsLog() error {
+	_, err := el.canvasCommandsLog(true)
+	return err
+}
+
+func (el *Element) canvasCommandsLog(clear bool) ([]*CanvasCommand, error) {
+	res, err := el.Evaluate(evalHelper(js.CanvasCommandsLog, clear))
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*CanvasCommand{}
+	err = res.Value.Unmarshal(&list)
+	return list, err
+}
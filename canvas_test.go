@@ -0,0 +1,75 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestCanvasCommandsLog(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>
+		<canvas id="a"></canvas>
+		<canvas id="b"></canvas>
+		<script>
+			const ctx = document.querySelector('#a').getContext('2d')
+			ctx.fillStyle = 'red'
+			ctx.fillRect(1, 2, 30, 40)
+			ctx.setLineDash([4, 2])
+			ctx.fillStyle = ctx.createLinearGradient(0, 0, 10, 10)
+			ctx.fillText('sales', 5, 6)
+		</script>
+	</body></html>`)
+
+	p := g.newPage()
+	remove := p.MustRecordCanvas()
+	p.MustNavigate(s.URL()).MustWaitLoad()
+
+	el := p.MustElement("#a")
+	list := el.MustCanvasCommandsLog()
+	g.Len(list, 5)
+
+	g.Eq(list[0].Name, "fillStyle")
+	g.True(list[0].Setter)
+	g.Eq(list[0].Args[0].Str(), "red")
+
+	g.Eq(list[1].Name, "fillRect")
+	g.False(list[1].Setter)
+	g.Eq(list[1].Args[3].Int(), 40)
+
+	g.Eq(list[2].Args[0].Arr()[0].Int(), 4)
+	g.Eq(list[3].Args[0].Str(), "[object CanvasGradient]") // createLinearGradient is read-only
+	g.Eq(list[4].Args[0].Str(), "sales")
+
+	g.Len(p.MustElement("#b").MustCanvasCommandsLog(), 0)
+
+	// the log is capped
+	p.MustEval(`() => {
+		const ctx = document.querySelector('#b').getContext('2d')
+		for (let i = 0; i < 10010; i++) ctx.fillRect(i, 0, 1, 1)
+		ctx.getImageData(0, 0, 1, 1)
+	}`)
+	list = p.MustElement("#b").MustCanvasCommandsLog()
+	g.Len(list, 10000)
+	g.Eq(list[0].Args[0].Int(), 10)
+	g.Eq(list[9999].Name, "fillRect")
+
+	g.Len(el.MustClearCanvasCommandsLog().MustCanvasCommandsLog(), 0)
+	p.MustEval(`() => document.querySelector('#a').getContext('2d').clearRect(0, 0, 1, 1)`)
+	g.Eq(el.MustCanvasCommandsLog()[0].Name, "clearRect")
+
+	remove()
+	p.MustReload().MustWaitLoad()
+	g.Len(p.MustElement("#a").MustCanvasCommandsLog(), 0)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		el.MustCanvasCommandsLog()
+	})
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		g.newPage().MustRecordCanvas()
+	})
+}
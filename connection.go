@@ -0,0 +1,229 @@
+// This file contains the helpers to report the negotiated protocols and the connection reuse of the responses.
+
+package rod
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// NetworkProtocol is the normalized application protocol of a response.
+type NetworkProtocol string
+
+const (
+	// NetworkProtocolHTTP1 is the HTTP/1.0 or HTTP/1.1.
+	NetworkProtocolHTTP1 NetworkProtocol = "http/1.1"
+	// NetworkProtocolH2 is the HTTP/2.
+	NetworkProtocolH2 NetworkProtocol = "h2"
+	// NetworkProtocolH3 is the HTTP/3, including the drafts such as "h3-29".
+	NetworkProtocolH3 NetworkProtocol = "h3"
+	// NetworkProtocolQUIC is the legacy Google QUIC, such as "quic/1+spdy/3".
+	NetworkProtocolQUIC NetworkProtocol = "quic"
+	// NetworkProtocolOther is the other protocols, such as "data" or "blob".
+	NetworkProtocolOther NetworkProtocol = "other"
+)
+
+// CertKeyType is the key type of the server certificate.
+type CertKeyType string
+
+const (
+	// CertKeyTypeRSA type.
+	CertKeyTypeRSA CertKeyType = "RSA"
+	// CertKeyTypeECDSA type.
+	CertKeyTypeECDSA CertKeyType = "ECDSA"
+	// CertKeyTypeEd25519 type.
+	CertKeyTypeEd25519 CertKeyType = "Ed25519"
+)
+
+// ConnectionInfo is the typed transport info of a response, check [NewConnectionInfo].
+type ConnectionInfo struct {
+	// Protocol negotiated for the response
+	Protocol NetworkProtocol
+
+	// RawProtocol reported by the browser, such as "h3-29"
+	RawProtocol string
+
+	// ConnectionID of the underlying connection, the responses of the same connection have the same id
+	ConnectionID float64
+
+	// Reused is true if the response reused an existing connection
+	Reused bool
+
+	// RemoteAddress of the connection, such as "1.2.3.4:443"
+	RemoteAddress string
+
+	// AlternateProtocolUsage is why the browser uses or doesn't use the HTTP/3
+	AlternateProtocolUsage proto.NetworkAlternateProtocolUsage
+
+	// FromCache is true if the response is served from the disk cache, the prefetch cache, or the service worker
+	FromCache bool
+
+	// TLS version, such as "TLS 1.3" or "QUIC", empty for the plain text connection
+	TLS string
+
+	// Cipher of the TLS
+	Cipher string
+
+	// KeyExchangeGroup of the TLS, such as "X25519MLKEM768"
+	KeyExchangeGroup string
+
+	// CertKeyType is derived from the server signature algorithm, empty if it's unknown
+	CertKeyType CertKeyType
+}
+
+// QUIC returns true if the response is transported over the QUIC, such as the HTTP/3.
+func (c *ConnectionInfo) QUIC() bool {
+	return c.Protocol == NetworkProtocolH3 || c.Protocol == NetworkProtocolQUIC
+}
+
+// NewConnectionInfo returns the typed transport info of the res.
+func NewConnectionInfo(res *proto.NetworkResponse) *ConnectionInfo {
+	c := &ConnectionInfo{
+		Protocol:               normalizeProtocol(res.Protocol),
+		RawProtocol:            res.Protocol,
+		ConnectionID:           res.ConnectionID,
+		Reused:                 res.ConnectionReused,
+		AlternateProtocolUsage: res.AlternateProtocolUsage,
+		FromCache:              res.FromDiskCache || res.FromPrefetchCache || res.FromServiceWorker,
+	}
+
+	if res.RemoteIPAddress != "" {
+		c.RemoteAddress = res.RemoteIPAddress
+		if res.RemotePort != nil {
+			c.RemoteAddress = net.JoinHostPort(res.RemoteIPAddress, strconv.Itoa(*res.RemotePort))
+		}
+	}
+
+	if d := res.SecurityDetails; d != nil {
+		c.TLS = d.Protocol
+		c.Cipher = d.Cipher
+		c.KeyExchangeGroup = d.KeyExchangeGroup
+		if d.ServerSignatureAlgorithm != nil {
+			c.CertKeyType = certKeyType(tls.SignatureScheme(*d.ServerSignatureAlgorithm))
+		}
+	}
+
+	return c
+}
+
+// Connection returns the transport info of the response, nil if there's no response yet.
+func (r *NetworkRequest) Connection() *ConnectionInfo {
+	if r.Response == nil {
+		return nil
+	}
+	return NewConnectionInfo(r.Response)
+}
+
+func normalizeProtocol(p string) NetworkProtocol {
+	p = strings.ToLower(p)
+	switch {
+	case strings.HasPrefix(p, "http/1"):
+		return NetworkProtocolHTTP1
+	case p == "h2":
+		return NetworkProtocolH2
+	case strings.HasPrefix(p, "h3"):
+		return NetworkProtocolH3
+	case strings.HasPrefix(p, "quic"):
+		return NetworkProtocolQUIC
+	}
+	return NetworkProtocolOther
+}
+
+func certKeyType(s tls.SignatureScheme) CertKeyType {
+	switch s {
+	case tls.PKCS1WithSHA1, tls.PKCS1WithSHA256, tls.PKCS1WithSHA384, tls.PKCS1WithSHA512,
+		tls.PSSWithSHA256, tls.PSSWithSHA384, tls.PSSWithSHA512,
+		0x0809, 0x080a, 0x080b: // rsa_pss_pss_sha256, rsa_pss_pss_sha384, rsa_pss_pss_sha512
+		return CertKeyTypeRSA
+	case tls.ECDSAWithSHA1, tls.ECDSAWithP256AndSHA256, tls.ECDSAWithP384AndSHA384, tls.ECDSAWithP521AndSHA512:
+		return CertKeyTypeECDSA
+	case tls.Ed25519:
+		return CertKeyTypeEd25519
+	}
+	return ""
+}
+
+// HostConnectionStats is the connection reuse statistics of a host, check [Page.ConnectionStats].
+type HostConnectionStats struct {
+	// Host of the requests, such as "example.com:443"
+	Host string
+
+	// Requests is the number of the responses from the network, the cached ones are not counted
+	Requests int
+
+	// Connections is the number of the distinct connections
+	Connections int
+
+	// Reused is the number of the responses that reused a connection
+	Reused int
+
+	// Protocols is the number of the responses of each protocol
+	Protocols map[NetworkProtocol]int
+}
+
+// ConnectionStats returns the connection reuse statistics of each host of the requests recorded by
+// [Page.TrackRequests], sorted by the host. It's useful to verify protocol rollouts from the real browser traffic.
+func (p *Page) ConnectionStats() []*HostConnectionStats {
+	type key struct {
+		host string
+		id   float64
+	}
+
+	hosts := map[string]*HostConnectionStats{}
+	connections := map[key]bool{}
+
+	// the copies of the requests, the tracker may update them concurrently
+	for _, r := range p.requests.snapshot(0) {
+		if r.Response == nil {
+			continue
+		}
+
+		c := r.Connection()
+		if c.FromCache {
+			continue
+		}
+
+		u, err := url.Parse(r.Response.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		host := u.Host
+		if u.Port() == "" {
+			port := "80"
+			if u.Scheme == "https" || u.Scheme == "wss" {
+				port = "443"
+			}
+			host = net.JoinHostPort(u.Hostname(), port)
+		}
+
+		s, has := hosts[host]
+		if !has {
+			s = &HostConnectionStats{Host: host, Protocols: map[NetworkProtocol]int{}}
+			hosts[host] = s
+		}
+
+		s.Requests++
+		s.Protocols[c.Protocol]++
+		if c.Reused {
+			s.Reused++
+		}
+		if k := (key{host, c.ConnectionID}); !connections[k] {
+			connections[k] = true
+			s.Connections++
+		}
+	}
+
+	list := make([]*HostConnectionStats, 0, len(hosts))
+	for _, s := range hosts {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Host < list[j].Host })
+	return list
+}
@@ -53,6 +53,12 @@ func (b *Browser) Sleeper(sleeper func() utils.Sleeper) *Browser {
 	return &newObj
 }
 
+// SleeperPolicy returns a clone with the sleeper of the policy for chained sub-operations.
+// The pages created by the clone will inherit the policy.
+func (b *Browser) SleeperPolicy(policy SleeperPolicy) *Browser {
+	return b.Sleeper(policy.Sleeper())
+}
+
 // Context returns a clone with the specified ctx for chained sub-operations.
 func (p *Page) Context(ctx context.Context) *Page {
 	p.helpersLock.Lock()
@@ -93,6 +99,19 @@ func (p *Page) Sleeper(sleeper func() utils.Sleeper) *Page {
 	return &newObj
 }
 
+// SleeperPolicy returns a clone with the sleeper of the policy for chained sub-operations.
+func (p *Page) SleeperPolicy(policy SleeperPolicy) *Page {
+	return p.Sleeper(policy.Sleeper())
+}
+
+// WithWaitUpgrade returns a clone whose element queries wait for the custom elements they find to be upgraded,
+// so that the queries won't hit un-upgraded custom elements. Check [Element.WaitUpgrade] for details.
+func (p *Page) WithWaitUpgrade() *Page {
+	newObj := *p
+	newObj.waitUpgrade = true
+	return &newObj
+}
+
 // Context returns a clone with the specified ctx for chained sub-operations.
 func (el *Element) Context(ctx context.Context) *Element {
 	newObj := *el
@@ -0,0 +1,180 @@
+// This file contains the helpers of the partitioned cookies (CHIPS) and the third-party cookie blocking.
+
+package rod
+
+import (
+	"sync"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// PartitionedCookies returns the partitioned cookies of the browser whose partition key is under the topLevelSite,
+// such as "https://example.com". If the topLevelSite is empty, the partitioned cookies of all the partitions
+// are returned. Use [Browser.SetCookies] with the [proto.NetworkCookieParam.PartitionKey] to set them.
+func (b *Browser) PartitionedCookies(topLevelSite string) ([]*proto.NetworkCookie, error) {
+	cookies, err := b.GetCookies()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*proto.NetworkCookie{}
+	for _, c := range cookies {
+		if !c.Partitioned() {
+			continue
+		}
+		if topLevelSite != "" && (c.PartitionKey == nil || c.PartitionKey.TopLevelSite != topLevelSite) {
+			continue
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+// DeleteCookies deletes the cookies by their name, domain, and path. The partitioned cookies are only deleted
+// from their own partitions, the unpartitioned cookies with the same name are kept, and vice versa.
+func (p *Page) DeleteCookies(cookies []*proto.NetworkCookie) error {
+	for _, c := range cookies {
+		err := proto.NetworkDeleteCookies{
+			Name:         c.Name,
+			Domain:       c.Domain,
+			Path:         c.Path,
+			PartitionKey: c.PartitionKey,
+		}.Call(p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BlockedCookie is a cookie that the browser refuses to send with a request or to store from a response,
+// or a cookie that should have been blocked by the third-party cookie blocking but is exempted.
+type BlockedCookie struct {
+	// RequestID of the request
+	RequestID proto.NetworkRequestID
+
+	// URL of the request, it's empty if the request isn't observed
+	URL string
+
+	// Cookie is nil if the browser fails to parse the CookieLine
+	Cookie *proto.NetworkCookie
+
+	// CookieLine is the Set-Cookie header of the cookie, it's only set if the cookie is from a response
+	CookieLine string
+
+	// Response is true if the cookie is from a response, false if it's to be sent with a request
+	Response bool
+
+	// Reasons why the cookie is blocked, such as "ThirdPartyPhaseout", they are the values of
+	// [proto.NetworkCookieBlockedReason] or [proto.NetworkSetCookieBlockedReason].
+	Reasons []string
+
+	// Exemption is set if the cookie should have been blocked by the third-party cookie blocking but is exempted,
+	// the Reasons is empty in that case.
+	Exemption proto.NetworkCookieExemptionReason
+}
+
+// ThirdParty returns true if the cookie is blocked by the third-party cookie blocking, which includes
+// the "Block third-party cookies" setting of the user and the third-party cookie phaseout.
+func (c *BlockedCookie) ThirdParty() bool {
+	for _, r := range c.Reasons {
+		switch r {
+		case string(proto.NetworkCookieBlockedReasonUserPreferences),
+			string(proto.NetworkCookieBlockedReasonThirdPartyPhaseout),
+			string(proto.NetworkCookieBlockedReasonThirdPartyBlockedInFirstPartySet):
+			return true
+		}
+	}
+	return false
+}
+
+// TrackBlockedCookies records the cookies that are blocked or exempted by the browser for the requests of the page
+// until stop is called, such as:
+//
+//	list, stop := page.TrackBlockedCookies()
+//	defer stop()
+//	page.MustNavigate("https://example.com").MustWaitLoad()
+//	for _, c := range list() {
+//	    if c.ThirdParty() {
+//	        fmt.Println("third-party cookie blocked:", c.URL, c.Cookie.Name)
+//	    }
+//	}
+//
+// The list returns the recorded cookies in the order they are observed.
+func (p *Page) TrackBlockedCookies() (list func() []*BlockedCookie, stop func()) {
+	p, cancel := p.WithCancel()
+
+	lock := sync.Mutex{}
+	urls := map[proto.NetworkRequestID]string{}
+	blocked := []*BlockedCookie{}
+
+	add := func(c *BlockedCookie) {
+		lock.Lock()
+		defer lock.Unlock()
+		blocked = append(blocked, c)
+	}
+
+	wait := p.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		lock.Lock()
+		defer lock.Unlock()
+		urls[e.RequestID] = e.Request.URL
+	}, func(e *proto.NetworkRequestWillBeSentExtraInfo) {
+		for _, c := range e.AssociatedCookies {
+			if len(c.BlockedReasons) == 0 && c.ExemptionReason == "" {
+				continue
+			}
+
+			reasons := make([]string, 0, len(c.BlockedReasons))
+			for _, r := range c.BlockedReasons {
+				reasons = append(reasons, string(r))
+			}
+			add(&BlockedCookie{
+				RequestID: e.RequestID,
+				Cookie:    c.Cookie,
+				Reasons:   reasons,
+				Exemption: c.ExemptionReason,
+			})
+		}
+	}, func(e *proto.NetworkResponseReceivedExtraInfo) {
+		for _, c := range e.BlockedCookies {
+			reasons := make([]string, 0, len(c.BlockedReasons))
+			for _, r := range c.BlockedReasons {
+				reasons = append(reasons, string(r))
+			}
+			add(&BlockedCookie{
+				RequestID:  e.RequestID,
+				Cookie:     c.Cookie,
+				CookieLine: c.CookieLine,
+				Response:   true,
+				Reasons:    reasons,
+			})
+		}
+		for _, c := range e.ExemptedCookies {
+			add(&BlockedCookie{
+				RequestID:  e.RequestID,
+				Cookie:     c.Cookie,
+				CookieLine: c.CookieLine,
+				Response:   true,
+				Exemption:  c.ExemptionReason,
+			})
+		}
+	})
+
+	go wait()
+
+	list = func() []*BlockedCookie {
+		lock.Lock()
+		defer lock.Unlock()
+
+		// the extra info events may arrive before the request is sent
+		res := make([]*BlockedCookie, 0, len(blocked))
+		for _, c := range blocked {
+			cp := *c
+			cp.URL = urls[c.RequestID]
+			res = append(res, &cp)
+		}
+		return res
+	}
+
+	return list, cancel
+}
@@ -0,0 +1,91 @@
+package rod_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestPartitionedCookies(t *testing.T) {
+	g := setup(t)
+
+	b := g.browser.MustIncognito()
+	defer b.MustClose()
+
+	b.MustSetCookies(&proto.NetworkCookie{
+		Name:   "a",
+		Value:  "plain",
+		Domain: "test.com",
+		Path:   "/",
+		Secure: true,
+	}, &proto.NetworkCookie{
+		Name:         "a",
+		Value:        "partitioned",
+		Domain:       "test.com",
+		Path:         "/",
+		Secure:       true,
+		PartitionKey: &proto.NetworkCookiePartitionKey{TopLevelSite: "https://top.com", HasCrossSiteAncestor: true},
+	})
+
+	g.Len(b.MustGetCookies(), 2)
+
+	list := b.MustPartitionedCookies("https://top.com")
+	g.Len(list, 1)
+	g.Eq(list[0].Value, "partitioned")
+	g.Eq(list[0].PartitionKey.TopLevelSite, "https://top.com")
+
+	g.Len(b.MustPartitionedCookies(""), 1)
+	g.Len(b.MustPartitionedCookies("https://other.com"), 0)
+
+	// the partition key is kept when the cookies are copied
+	b.MustSetCookies()
+	b.MustSetCookies(list...)
+	g.Len(b.MustPartitionedCookies("https://top.com"), 1)
+
+	p := b.MustPage()
+	p.MustDeleteCookies(list...)
+	g.Len(b.MustPartitionedCookies(""), 0)
+
+	g.mc.stubErr(1, proto.StorageGetCookies{})
+	g.Err(b.PartitionedCookies(""))
+
+	g.mc.stubErr(1, proto.NetworkDeleteCookies{})
+	g.Err(p.DeleteCookies(list))
+}
+
+func TestTrackBlockedCookies(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/set", func(w http.ResponseWriter, _ *http.Request) {
+		// SameSite=None requires the Secure attribute
+		w.Header().Add("Set-Cookie", "a=1; SameSite=None")
+		w.Header().Add("Set-Cookie", "b=2")
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	p := g.newPage()
+
+	list, stop := p.TrackBlockedCookies()
+	defer stop()
+
+	wait := p.WaitNavigation(proto.PageLifecycleEventNameNetworkAlmostIdle)
+	p.MustNavigate(s.URL("/set"))
+	wait()
+
+	var blocked []string
+	for _, c := range list() {
+		g.False(c.ThirdParty())
+		if c.Response && c.Cookie != nil {
+			blocked = append(blocked, c.Cookie.Name)
+			g.Eq(c.URL, s.URL("/set"))
+			g.Has(c.CookieLine, "a=1")
+			g.Has(c.Reasons, string(proto.NetworkSetCookieBlockedReasonSameSiteNoneInsecure))
+		}
+	}
+	g.Eq(blocked, []string{"a"})
+
+	g.True((&rod.BlockedCookie{Reasons: []string{string(proto.NetworkCookieBlockedReasonThirdPartyPhaseout)}}).ThirdParty())
+}
@@ -131,6 +131,10 @@ func (p *Page) Overlay(left, top, width, height float64, msg string) (remove fun
 }
 
 func (p *Page) tryTrace(typ TraceType, msg ...interface{}) func() {
+	if typ == TraceTypeInput {
+		p.requests.breadcrumb(fmt.Sprint(msg...))
+	}
+
 	if !p.browser.trace {
 		return func() {}
 	}
@@ -211,6 +215,10 @@ func (el *Element) Overlay(msg string) (removeOverlay func()) {
 }
 
 func (el *Element) tryTrace(typ TraceType, msg ...interface{}) func() {
+	if typ == TraceTypeInput {
+		el.page.requests.breadcrumb(fmt.Sprint(msg...) + " " + el.String())
+	}
+
 	if !el.page.browser.trace {
 		return func() {}
 	}
@@ -0,0 +1,241 @@
+// This file contains the helpers to snapshot and diff the DOM tree.
+
+package rod
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// DOMSnapshot is a decoded [proto.DOMSnapshotCaptureSnapshotResult], it's easier to query and compare.
+type DOMSnapshot struct {
+	// URL of the root document
+	URL string
+
+	// Nodes in document order, the nodes of iframes follow their owner elements.
+	Nodes []*DOMSnapshotNode
+}
+
+// DOMSnapshotNode of a [DOMSnapshot].
+type DOMSnapshotNode struct {
+	// Path of the node, such as "/HTML[0]/BODY[0]/DIV[1]/#text[0]".
+	// The number is the index of the node among its siblings that have the same name.
+	// The nodes of an iframe document are prefixed with the path of the iframe element.
+	Path string
+
+	BackendNodeID proto.DOMBackendNodeID
+
+	// Type is the same as the nodeType in javascript
+	Type int
+
+	Name  string
+	Value string
+
+	Attributes map[string]string
+
+	// InputValue is the value of an input or textarea element
+	InputValue string
+
+	// Checked is true for checked radios, checkboxes, and selected options
+	Checked bool
+}
+
+// Get the node by its path, returns nil if not found.
+func (s *DOMSnapshot) Get(path string) *DOMSnapshotNode {
+	for _, n := range s.Nodes {
+		if n.Path == path {
+			return n
+		}
+	}
+	return nil
+}
+
+// DOMSnapshot captures a snapshot of the DOM tree of the page, including the iframes.
+// Use [DiffDOM] to compare two snapshots.
+func (p *Page) DOMSnapshot() (*DOMSnapshot, error) {
+	_ = proto.DOMSnapshotEnable{}.Call(p)
+
+	res, err := proto.DOMSnapshotCaptureSnapshot{
+		ComputedStyles: []string{},
+	}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &DOMSnapshot{}
+
+	if len(res.Documents) == 0 {
+		return s, nil
+	}
+
+	str := func(i proto.DOMSnapshotStringIndex) string {
+		if i < 0 || int(i) >= len(res.Strings) {
+			return ""
+		}
+		return res.Strings[i]
+	}
+
+	s.URL = str(res.Documents[0].DocumentURL)
+
+	var decode func(doc *proto.DOMSnapshotDocumentSnapshot, prefix string)
+	decode = func(doc *proto.DOMSnapshotDocumentSnapshot, prefix string) {
+		tree := doc.Nodes
+		if tree == nil {
+			return
+		}
+
+		inputValues := rareStrings(tree.InputValue, str)
+		textValues := rareStrings(tree.TextValue, str)
+		checked := rareBooleans(tree.InputChecked, tree.OptionSelected)
+		contentDocs := map[int]int{}
+		if tree.ContentDocumentIndex != nil {
+			for i, index := range tree.ContentDocumentIndex.Index {
+				contentDocs[index] = tree.ContentDocumentIndex.Value[i]
+			}
+		}
+
+		paths := make([]string, len(tree.NodeName))
+		counters := map[int]map[string]int{}
+
+		for i := range tree.NodeName {
+			parent := -1
+			if i < len(tree.ParentIndex) {
+				parent = tree.ParentIndex[i]
+			}
+
+			name := str(tree.NodeName[i])
+
+			base := prefix
+			if parent >= 0 {
+				base = paths[parent]
+			}
+			if counters[parent] == nil {
+				counters[parent] = map[string]int{}
+			}
+			paths[i] = fmt.Sprintf("%s/%s[%d]", base, name, counters[parent][name])
+			counters[parent][name]++
+
+			n := &DOMSnapshotNode{
+				Path:       paths[i],
+				Name:       name,
+				Attributes: map[string]string{},
+				Checked:    checked[i],
+			}
+			if i < len(tree.BackendNodeID) {
+				n.BackendNodeID = tree.BackendNodeID[i]
+			}
+			if i < len(tree.NodeType) {
+				n.Type = tree.NodeType[i]
+			}
+			if i < len(tree.NodeValue) {
+				n.Value = str(tree.NodeValue[i])
+			}
+			if i < len(tree.Attributes) {
+				attrs := tree.Attributes[i]
+				for j := 0; j+1 < len(attrs); j += 2 {
+					n.Attributes[str(attrs[j])] = str(attrs[j+1])
+				}
+			}
+			if v, has := inputValues[i]; has {
+				n.InputValue = v
+			} else if v, has := textValues[i]; has {
+				n.InputValue = v
+			}
+
+			s.Nodes = append(s.Nodes, n)
+
+			if index, has := contentDocs[i]; has && index < len(res.Documents) {
+				decode(res.Documents[index], paths[i])
+			}
+		}
+	}
+
+	decode(res.Documents[0], "")
+
+	return s, nil
+}
+
+func rareStrings(data *proto.DOMSnapshotRareStringData, str func(proto.DOMSnapshotStringIndex) string) map[int]string {
+	m := map[int]string{}
+	if data == nil {
+		return m
+	}
+	for i, index := range data.Index {
+		m[index] = str(data.Value[i])
+	}
+	return m
+}
+
+func rareBooleans(list ...*proto.DOMSnapshotRareBooleanData) map[int]bool {
+	m := map[int]bool{}
+	for _, data := range list {
+		if data == nil {
+			continue
+		}
+		for _, index := range data.Index {
+			m[index] = true
+		}
+	}
+	return m
+}
+
+// DOMDiff is the result of [DiffDOM].
+type DOMDiff struct {
+	// Added nodes that only exist in the new snapshot
+	Added []*DOMSnapshotNode
+
+	// Removed nodes that only exist in the old snapshot
+	Removed []*DOMSnapshotNode
+
+	// Changed nodes that exist in both snapshots but have different value, attributes, or states
+	Changed []*DOMNodeChange
+}
+
+// DOMNodeChange of a node.
+type DOMNodeChange struct {
+	Path   string
+	Before *DOMSnapshotNode
+	After  *DOMSnapshotNode
+}
+
+// Empty tells if there's no difference.
+func (d *DOMDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffDOM compares two snapshots, the nodes are matched by their [DOMSnapshotNode.Path].
+// So it works for snapshots of different page loads, such as detecting the changes of a website.
+func DiffDOM(a, b *DOMSnapshot) *DOMDiff {
+	d := &DOMDiff{}
+
+	before := map[string]*DOMSnapshotNode{}
+	for _, n := range a.Nodes {
+		before[n.Path] = n
+	}
+
+	after := map[string]*DOMSnapshotNode{}
+	for _, n := range b.Nodes {
+		after[n.Path] = n
+
+		old, has := before[n.Path]
+		if !has {
+			d.Added = append(d.Added, n)
+			continue
+		}
+
+		if old.Value != n.Value || old.InputValue != n.InputValue || old.Checked != n.Checked ||
+			!reflect.DeepEqual(old.Attributes, n.Attributes) {
+			d.Changed = append(d.Changed, &DOMNodeChange{Path: n.Path, Before: old, After: n})
+		}
+	}
+
+	for _, n := range a.Nodes {
+		if _, has := after[n.Path]; !has {
+			d.Removed = append(d.Removed, n)
+		}
+	}
+
+	return d
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"reflect"
 	"strings"
 	"time"
@@ -153,7 +155,9 @@ func (el *Element) Interactable() (pt *proto.Point, err error) {
 		return nil, &NoPointerEventsError{el}
 	}
 
-	shape, err := el.Shape()
+	// The hit test must use the coordinates of the session that owns the element,
+	// for an element inside an OOPIF they are relative to the OOPIF.
+	shape, err := el.localShape()
 	if err != nil {
 		return nil, err
 	}
@@ -164,7 +168,12 @@ func (el *Element) Interactable() (pt *proto.Point, err error) {
 		return
 	}
 
-	scroll, err := el.page.root.Context(el.ctx).Eval(`() => ({ x: window.scrollX, y: window.scrollY })`)
+	win := el.page.root
+	if oopif := el.page.outerOOPIF(); oopif != nil {
+		win = oopif
+	}
+
+	scroll, err := win.Context(el.ctx).Eval(`() => ({ x: window.scrollX, y: window.scrollY })`)
 	if err != nil {
 		return
 	}
@@ -187,7 +196,16 @@ func (el *Element) Interactable() (pt *proto.Point, err error) {
 
 	if !isParent {
 		err = &CoveredError{elAtPoint}
+		return
 	}
+
+	toRoot, err := el.page.Context(el.ctx).rootTransform()
+	if err != nil {
+		return
+	}
+
+	root := toRoot(*pt)
+	pt = &root
 	return
 }
 
@@ -198,7 +216,33 @@ func (el *Element) Interactable() (pt *proto.Point, err error) {
 //	  ____________          ____________
 //	 /        ___/    =    /___________/    +     _________
 //	/________/                                   /________/
+//
+// The coordinates are always relative to the viewport of the root page, even if the element is inside
+// a scrolled or transformed out-of-process iframe.
 func (el *Element) Shape() (*proto.DOMGetContentQuadsResult, error) {
+	shape, err := el.localShape()
+	if err != nil {
+		return nil, err
+	}
+
+	toRoot, err := el.page.Context(el.ctx).rootTransform()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range shape.Quads {
+		q.Each(func(pt proto.Point, i int) {
+			pt = toRoot(pt)
+			q[i*2] = pt.X
+			q[i*2+1] = pt.Y
+		})
+	}
+
+	return shape, nil
+}
+
+// localShape is the shape relative to the viewport of the session that owns the element.
+func (el *Element) localShape() (*proto.DOMGetContentQuadsResult, error) {
 	return proto.DOMGetContentQuads{ObjectID: el.id()}.Call(el)
 }
 
@@ -455,8 +499,12 @@ func (el *Element) ShadowRoot() (*Element, error) {
 }
 
 // Frame creates a page instance that represents the iframe.
+// Both same-process iframes and out-of-process iframes (OOPIF) are supported,
+// for an OOPIF the frame target will be attached and the returned page will use its own session.
+// It waits until the document of the iframe is ready before returning.
+// The target of the iframe is only looked up when its document isn't in the session of the element.
 func (el *Element) Frame() (*Page, error) {
-	node, err := el.Describe(1, false)
+	node, err := el.Describe(1, true)
 	if err != nil {
 		return nil, err
 	}
@@ -466,8 +514,34 @@ func (el *Element) Frame() (*Page, error) {
 	clone.jsCtxID = new(proto.RuntimeRemoteObjectID)
 	clone.element = el
 	clone.sleeper = el.sleeper
+	clone.queries = newQueryCache(el.page.queries.ctx)
+	clone.hooks = newPageHooks(el.page.hooks.ctx)
+
+	frame := &clone
+
+	if node.FrameID == "" {
+		return frame, nil
+	}
+
+	// the content document of an out-of-process iframe is only in the session of its own target
+	if node.ContentDocument == nil {
+		oopif, err := el.page.browser.Context(el.ctx).hasFrameTarget(node.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		if oopif {
+			frame, err = el.page.browser.attachOOPIF(el, node.FrameID)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	return &clone, nil
+	err = frame.Context(el.ctx).waitDocumentReady()
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
 }
 
 // ContainsElement check if the target is equal or inside the element.
@@ -513,9 +587,31 @@ func (el *Element) WaitLoad() error {
 	return err
 }
 
+// WaitAnimations waits until the running CSS animations and transitions of the element and its
+// descendants are finished or canceled. Infinite animations are ignored.
+func (el *Element) WaitAnimations() error {
+	defer el.tryTrace(TraceTypeWait, "animations")()
+	_, err := el.Evaluate(evalHelper(js.WaitAnimations).ByPromise())
+	return err
+}
+
+// WaitUpgrade waits until the element is upgraded if it's a custom element, such as a web component
+// whose customElements.define is called after the element is added to the document.
+// It returns immediately for the built-in elements and the defined custom elements.
+func (el *Element) WaitUpgrade() error {
+	defer el.tryTrace(TraceTypeWait, "upgrade")()
+	_, err := el.Evaluate(Eval(`() => {
+		const name = this.localName
+		if (!name.includes('-') || this.matches(':defined')) return
+		return customElements.whenDefined(name)
+	}`).ByPromise())
+	return err
+}
+
 // WaitStable waits until no shape or position change for d duration.
 // Be careful, d is not the max wait timeout, it's the least stable time.
 // If you want to set a timeout you can use the [Element.Timeout] function.
+// Sampling the shape may miss the slow easing of an animation, call [Element.WaitAnimations] before it if needed.
 func (el *Element) WaitStable(d time.Duration) error {
 	err := el.WaitVisible()
 	if err != nil {
@@ -683,7 +779,17 @@ func (el *Element) Screenshot(format proto.PageCaptureScreenshotFormat, quality
 		Format:  format,
 	}
 
-	bin, err := el.page.Context(el.ctx).Screenshot(false, opts)
+	// Go can't encode webp, so we let the browser crop it
+	if format == proto.PageCaptureScreenshotFormatWebp {
+		opts.Clip, err = el.screenshotClip(0)
+		if err != nil {
+			return nil, err
+		}
+		return el.page.root.Context(el.ctx).Screenshot(false, opts)
+	}
+
+	// use the root page, because the OOPIF can't capture the area outside of it
+	bin, err := el.page.root.Context(el.ctx).Screenshot(false, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -705,6 +811,213 @@ func (el *Element) Screenshot(format proto.PageCaptureScreenshotFormat, quality
 	)
 }
 
+// ElementScreenshotOptions for [Element.ScreenshotWithOptions].
+type ElementScreenshotOptions struct {
+	// Format (optional) Image compression format (defaults to png).
+	Format proto.PageCaptureScreenshotFormat
+
+	// Quality (optional) Compression quality from range [0..100], only for jpeg and webp.
+	Quality int
+
+	// Scale (optional) multiplies the device pixel ratio, such as 2 for crisp 2x assets (defaults to 1).
+	Scale float64
+
+	// OmitBackground makes the default white background of the page transparent, only for png and webp.
+	OmitBackground bool
+
+	// Padding (optional) in css pixels to include around the element.
+	Padding float64
+}
+
+// ScreenshotWithOptions captures the area of the element, unlike [Element.Screenshot] the image is clipped by the browser,
+// so that it can be scaled, transparent, and padded. The opts can be nil.
+func (el *Element) ScreenshotWithOptions(opts *ElementScreenshotOptions) ([]byte, error) {
+	o := ElementScreenshotOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Scale <= 0 {
+		o.Scale = 1
+	}
+
+	err := el.ScrollIntoView()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &proto.PageCaptureScreenshot{Format: o.Format}
+	if o.Quality > 0 {
+		req.Quality = gson.Int(o.Quality)
+	}
+
+	req.Clip, err = el.screenshotClip(o.Padding)
+	if err != nil {
+		return nil, err
+	}
+	req.Clip.Scale = o.Scale
+
+	// use the root page, because the OOPIF can't capture the area outside of it
+	page := el.page.root.Context(el.ctx)
+
+	if o.OmitBackground {
+		err = proto.EmulationSetDefaultBackgroundColorOverride{
+			Color: &proto.DOMRGBA{A: gson.Num(0)},
+		}.Call(page)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = proto.EmulationSetDefaultBackgroundColorOverride{}.Call(page) }()
+	}
+
+	return page.Screenshot(false, req)
+}
+
+// screenshotClip returns the clip of the element with the padding for the [proto.PageCaptureScreenshot].
+func (el *Element) screenshotClip(padding float64) (*proto.PageViewport, error) {
+	shape, err := el.Shape()
+	if err != nil {
+		return nil, err
+	}
+	box := shape.Box()
+
+	// the box is relative to the viewport, the clip is relative to the document
+	metrics, err := proto.PageGetLayoutMetrics{}.Call(el.page.root.Context(el.ctx))
+	if err != nil {
+		return nil, err
+	}
+	if metrics.CSSVisualViewport == nil {
+		return nil, errors.New("failed to get css visual viewport")
+	}
+
+	x := box.X + metrics.CSSVisualViewport.PageX - padding
+	y := box.Y + metrics.CSSVisualViewport.PageY - padding
+
+	// the part of the padding that is out of the document is cut off, so the far edges stay in place
+	return &proto.PageViewport{
+		X:      math.Max(x, 0),
+		Y:      math.Max(y, 0),
+		Width:  box.Width + padding*2 + math.Min(x, 0),
+		Height: box.Height + padding*2 + math.Min(y, 0),
+		Scale:  1,
+	}, nil
+}
+
+// ScreenshotScrolled captures the full scrollable content of an overflow element, such as a chat pane,
+// by scrolling it internally and stitching the captures vertically. The borders and scrollbars of the element
+// are not included. The opt.FixedTop and opt.FixedBottom are not used. The opt can be nil.
+func (el *Element) ScreenshotScrolled(opt *ScrollScreenshotOptions) ([]byte, error) {
+	if opt == nil {
+		opt = &ScrollScreenshotOptions{}
+	}
+	if opt.WaitPerScroll == 0 {
+		opt.WaitPerScroll = time.Millisecond * 300
+	}
+
+	err := el.ScrollIntoView()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := el.Eval(`() => ({ scrollTop: this.scrollTop, scrollHeight: this.scrollHeight })`)
+	if err != nil {
+		return nil, err
+	}
+	origin := res.Value.Get("scrollTop").Num()
+	contentHeight := res.Value.Get("scrollHeight").Num()
+
+	defer func() { _, _ = el.Eval(`(top) => { this.scrollTop = top }`, origin) }()
+
+	page := el.page.root.Context(el.ctx)
+
+	var images []utils.ImgWithBox
+
+	for y := 0.0; y < contentHeight; {
+		// the browser may clamp the scrollTop, so we read the actual position back
+		res, err := el.Eval(`(top) => {
+			this.scrollTop = top
+			const rect = this.getBoundingClientRect()
+			return {
+				scrollTop: this.scrollTop,
+				left: rect.left + this.clientLeft + window.scrollX,
+				top: rect.top + this.clientTop + window.scrollY,
+				width: this.clientWidth,
+				height: this.clientHeight,
+			}
+		}`, y)
+		if err != nil {
+			return nil, err
+		}
+		box := res.Value
+
+		if y > 0 {
+			err = page.WaitDOMStable(opt.WaitPerScroll, 0)
+			if err != nil {
+				return nil, fmt.Errorf("WaitDOMStable error: %w", err)
+			}
+		}
+
+		offset := y - box.Get("scrollTop").Num()
+		height := math.Min(box.Get("height").Num()-offset, contentHeight-y)
+		if height <= 0 {
+			break
+		}
+
+		shot, err := proto.PageCaptureScreenshot{
+			Format:  opt.Format,
+			Quality: opt.Quality,
+			Clip: &proto.PageViewport{
+				X:      box.Get("left").Num(),
+				Y:      box.Get("top").Num() + offset,
+				Width:  box.Get("width").Num(),
+				Height: height,
+				Scale:  1,
+			},
+		}.Call(page)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, utils.ImgWithBox{Img: shot.Data})
+		y += height
+	}
+
+	var imgOption *utils.ImgOption
+	if opt.Quality != nil {
+		imgOption = &utils.ImgOption{Quality: *opt.Quality}
+	}
+	return utils.SplicePngVertical(images, opt.Format, imgOption)
+}
+
+// ScreenshotTo is similar to [Element.Screenshot], but writes the image to w, check [Page.ScreenshotTo].
+// Only the webp is streamed, the other formats are cropped in Go, so the decoded image is buffered.
+func (el *Element) ScreenshotTo(w io.Writer, format proto.PageCaptureScreenshotFormat, quality int) error {
+	if format == proto.PageCaptureScreenshotFormatWebp {
+		err := el.ScrollIntoView()
+		if err != nil {
+			return err
+		}
+
+		clip, err := el.screenshotClip(0)
+		if err != nil {
+			return err
+		}
+
+		return el.page.root.Context(el.ctx).ScreenshotTo(w, false, &proto.PageCaptureScreenshot{
+			Quality: gson.Int(quality),
+			Format:  format,
+			Clip:    clip,
+		})
+	}
+
+	bin, err := el.Screenshot(format, quality)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bin)
+	return err
+}
+
 // Release is a shortcut for [Page.Release] current element.
 func (el *Element) Release() error {
 	return el.page.Context(el.ctx).Release(el.Object)
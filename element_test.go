@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
 	"image/color"
 	"image/png"
 	"net"
@@ -263,6 +264,11 @@ func TestIframes(t *testing.T) {
 
 	g.Eq(frame01.MustEval(`() => testIsolation()`).Str(), "ok")
 	g.True(frame02.MustHas("[a=ok]"))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	frame, err := p.MustElement("iframe").Frame()
+	g.Err(err)
+	g.Nil(frame)
 }
 
 func TestIframeCrossDomains(t *testing.T) {
@@ -296,6 +302,101 @@ func TestIframeCrossDomains(t *testing.T) {
 	g.Eq(page.MustElement("iframe").MustFrame().MustElement("#a").MustText(), "a")
 }
 
+func TestIframeOOPIF(t *testing.T) {
+	g := setup(t)
+
+	r1 := g.Serve()
+	r2 := g.Serve()
+
+	host1 := net.JoinHostPort("localhost", r1.HostURL.Port())
+	host2 := net.JoinHostPort("127.0.0.1", r2.HostURL.Port())
+
+	u1 := fmt.Sprintf("http://%s/iframe", host1)
+	u2 := fmt.Sprintf("http://%s/page", host2)
+
+	r1.Route("/iframe", ".html", `<html>
+		<div id="a">a</div>
+	</html>`)
+
+	r2.Route("/page", ".html", `<html>
+		<iframe src="`+u1+`"></iframe>
+	</html>`)
+
+	// force every cross-site iframe to be an OOPIF
+	u := launcher.New().Delete("disable-features").Delete("disable-site-isolation-trials").
+		Set("site-per-process").NoSandbox(true).MustLaunch()
+	browser := rod.New().ControlURL(u).NoDefaultDevice().MustConnect()
+	defer browser.MustClose()
+
+	page := browser.MustPage(u2).MustWaitLoad()
+
+	frame := page.MustElement("iframe").MustFrame()
+	g.True(frame.IsIframe())
+	g.Neq(frame.SessionID, page.SessionID)
+	g.Eq(frame.MustElement("#a").MustText(), "a")
+	g.Eq(frame.MustEval(`() => location.href`).Str(), u1)
+}
+
+func TestIframeOOPIFNestedClick(t *testing.T) {
+	g := setup(t)
+
+	r1 := g.Serve()
+	r2 := g.Serve()
+	r3 := g.Serve()
+
+	u1 := fmt.Sprintf("http://%s/inner", net.JoinHostPort("localhost", r1.HostURL.Port()))
+	u2 := fmt.Sprintf("http://%s/middle", net.JoinHostPort("127.0.0.1", r2.HostURL.Port()))
+	u3 := fmt.Sprintf("http://%s/page", net.JoinHostPort("[::1]", r3.HostURL.Port()))
+
+	r1.Route("/inner", ".html", `<html><body style="margin: 0; height: 2000px">
+		<button style="position: absolute; top: 1000px; left: 30px"
+			onclick="this.innerText = 'ok'">btn</button>
+	</body></html>`)
+
+	r2.Route("/middle", ".html", `<html><body style="margin: 30px">
+		<iframe src="`+u1+`" style="border: 5px solid; transform: translate(20px, 10px) scale(0.8)"
+			width="300" height="200"></iframe>
+	</body></html>`)
+
+	r3.Route("/page", ".html", `<html><body style="margin: 0; height: 2000px">
+		<iframe src="`+u2+`" style="position: absolute; top: 800px; padding: 10px"
+			width="500" height="400"></iframe>
+	</body></html>`)
+
+	u := launcher.New().Delete("disable-features").Delete("disable-site-isolation-trials").
+		Set("site-per-process").NoSandbox(true).MustLaunch()
+	browser := rod.New().ControlURL(u).NoDefaultDevice().MustConnect()
+	defer browser.MustClose()
+
+	page := browser.MustPage(u3).MustWaitLoad()
+
+	middle := page.MustElement("iframe").MustFrame()
+	inner := middle.MustElement("iframe").MustFrame()
+	g.Neq(inner.SessionID, middle.SessionID)
+
+	btn := inner.MustElement("button")
+	btn.MustClick()
+	g.Eq(btn.MustText(), "ok")
+
+	// the translated shape should be inside the box of the outer iframe
+	box := page.MustElement("iframe").MustShape().Box()
+	pt := btn.MustShape().OnePointInside()
+	g.Gt(pt.X, box.X)
+	g.Gt(pt.Y, box.Y)
+}
+
+func TestFrameNotIframe(t *testing.T) {
+	g := setup(t)
+
+	g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+
+	// it's the same page as before
+	frame, err := g.page.MustElement("button").Frame()
+	g.E(err)
+	g.Eq(frame.SessionID, g.page.SessionID)
+	g.Eq(frame.MustElement("button").MustText(), "click me")
+}
+
 func TestContains(t *testing.T) {
 	g := setup(t)
 
@@ -689,6 +790,51 @@ func TestWaitStable(t *testing.T) {
 	})
 }
 
+func TestWaitAnimations(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/wait-animations.html"))
+	el := p.MustElement("button")
+	el.MustWaitAnimations()
+	g.Eq(el.MustEval(`() => this.getAnimations().length`).Int(), 0)
+
+	p.MustReload()
+	el = p.MustElement("button")
+	el.MustWaitAnimations().MustWaitStable()
+	g.Eq(el.MustEval(`() => this.getAnimations().length`).Int(), 0)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		el.MustWaitAnimations()
+	})
+}
+
+func TestWaitUpgrade(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/custom-elements.html"))
+	g.False(p.MustElement("x-card").MustEval(`() => !!this.shadowRoot`).Bool())
+
+	el := p.MustElement("x-card").MustWaitUpgrade()
+	g.True(el.MustEval(`() => !!this.shadowRoot`).Bool())
+
+	p.MustElement("body").MustWaitUpgrade()
+
+	p.MustReload()
+	el = p.WithWaitUpgrade().MustElement("x-label")
+	g.True(el.MustEval(`() => !!this.shadowRoot`).Bool())
+
+	p.MustReload()
+	for _, el := range p.WithWaitUpgrade().MustElements("x-card, x-label") {
+		g.True(el.MustEval(`() => !!this.shadowRoot`).Bool())
+	}
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		el.MustWaitUpgrade()
+	})
+}
+
 func TestWaitStableRAP(t *testing.T) {
 	g := setup(t)
 
@@ -791,6 +937,139 @@ func TestElementScreenshot(t *testing.T) {
 	})
 }
 
+func TestElementScreenshotWithOptions(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+	el := p.MustElement("h4")
+
+	decode := func(data []byte, err error) image.Image {
+		g.E(err)
+		img, err := png.Decode(bytes.NewBuffer(data))
+		g.E(err)
+		return img
+	}
+
+	img := decode(el.ScreenshotWithOptions(nil))
+	g.Eq(img.Bounds().Dx(), 200)
+	g.Eq(img.Bounds().Dy(), 30)
+
+	// the options of the caller are not modified
+	opts := &rod.ElementScreenshotOptions{}
+	img = decode(el.ScreenshotWithOptions(opts))
+	g.Eq(img.Bounds().Dx(), 200)
+	g.Eq(opts.Scale, 0.0)
+
+	img = decode(el.ScreenshotWithOptions(&rod.ElementScreenshotOptions{Scale: 2}))
+	g.Eq(img.Bounds().Dx(), 400)
+	g.Eq(img.Bounds().Dy(), 60)
+
+	img = decode(el.ScreenshotWithOptions(&rod.ElementScreenshotOptions{Padding: 10, OmitBackground: true}))
+	g.Eq(img.Bounds().Dx(), 220)
+	g.Eq(img.Bounds().Dy(), 50)
+	_, _, _, a := img.At(1, 1).RGBA()
+	g.Eq(a, uint32(0))
+
+	// the background is restored
+	img = decode(el.ScreenshotWithOptions(&rod.ElementScreenshotOptions{Padding: 10}))
+	_, _, _, a = img.At(1, 1).RGBA()
+	g.Eq(a, uint32(0xffff))
+
+	g.mc.stubErr(1, proto.PageGetLayoutMetrics{})
+	_, err := el.ScreenshotWithOptions(nil)
+	g.Err(err)
+}
+
+func TestElementScreenshotPaddingAtOrigin(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body style="margin: 0; background: white">
+		<div id="box" style="width: 50px; height: 50px; background: rgb(0, 255, 0)"></div>
+	</body></html>`)
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+	el := p.MustElement("#box")
+
+	// the padding out of the document is cut off, the far edges keep their padding
+	data, err := el.ScreenshotWithOptions(&rod.ElementScreenshotOptions{Padding: 10})
+	g.E(err)
+	img, err := png.Decode(bytes.NewBuffer(data))
+	g.E(err)
+	g.Eq(img.Bounds().Dx(), 60)
+	g.Eq(img.Bounds().Dy(), 60)
+
+	r, gr, b, _ := img.At(45, 45).RGBA()
+	g.Eq([]uint32{r, gr, b}, []uint32{0, 0xffff, 0})
+	r, gr, b, _ = img.At(55, 55).RGBA()
+	g.Eq([]uint32{r, gr, b}, []uint32{0xffff, 0xffff, 0xffff})
+}
+
+func TestElementScreenshotScrolledPage(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body style="margin: 0">
+		<div style="height: 3000px; background: red"></div>
+		<div id="box" style="width: 50px; height: 50px; background: rgb(0, 255, 0)"></div>
+		<div style="height: 3000px; background: red"></div>
+	</body></html>`)
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+	el := p.MustElement("#box")
+
+	// the clip is relative to the document, not the viewport
+	data, err := el.ScreenshotWithOptions(nil)
+	g.E(err)
+	img, err := png.Decode(bytes.NewBuffer(data))
+	g.E(err)
+	g.Eq(img.Bounds().Dx(), 50)
+	r, gr, _, _ := img.At(25, 25).RGBA()
+	g.Eq(r, uint32(0))
+	g.Eq(gr, uint32(0xffff))
+
+	data, err = el.Screenshot(proto.PageCaptureScreenshotFormatWebp, 100)
+	g.E(err)
+	g.Eq(string(data[8:12]), "WEBP")
+}
+
+func TestElementScreenshotScrolled(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body style="margin: 0">
+		<div id="pane" style="width: 100px; height: 150px; overflow: auto; border: 5px solid red; scrollbar-width: none">
+			<div style="height: 200px; background: green"></div>
+			<div style="height: 200px; background: blue"></div>
+			<div style="height: 100px; background: black"></div>
+		</div>
+	</body></html>`)
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+	el := p.MustElement("#pane")
+	el.MustEval(`() => { this.scrollTop = 30 }`)
+
+	data, err := el.ScreenshotScrolled(&rod.ScrollScreenshotOptions{WaitPerScroll: time.Millisecond})
+	g.E(err)
+	img, err := png.Decode(bytes.NewBuffer(data))
+	g.E(err)
+	g.Eq(img.Bounds().Dx(), 100)
+	g.Eq(img.Bounds().Dy(), 500)
+
+	_, _, b, _ := img.At(50, 300).RGBA()
+	g.Gt(b, 0)
+
+	// the scroll position is restored
+	g.Eq(el.MustEval(`() => this.scrollTop`).Int(), 30)
+
+	el.MustScreenshotScrolled()
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.PageCaptureScreenshot{})
+		el.MustScreenshotScrolled()
+	})
+}
+
 func TestUseReleasedElement(t *testing.T) {
 	g := setup(t)
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/xyjwsj/grod/lib/launcher"
 	"github.com/xyjwsj/grod/lib/proto"
 	"github.com/xyjwsj/grod/lib/utils"
 )
@@ -191,3 +192,114 @@ func (e *NoShadowRootError) Error() string {
 
 // Is interface.
 func (e *NoShadowRootError) Is(err error) bool { _, ok := err.(*NoShadowRootError); return ok }
+
+// BrowserDisconnectedError error.
+type BrowserDisconnectedError struct {
+	// Crash report of the browser, it's nil if the browser isn't launched by the [Browser.Launcher],
+	// or the browser didn't crash.
+	Crash *launcher.CrashReport
+}
+
+func (e *BrowserDisconnectedError) Error() string {
+	if e.Crash == nil {
+		return "the browser is disconnected"
+	}
+	return "the browser is disconnected: " + e.Crash.Error()
+}
+
+// Is interface.
+func (e *BrowserDisconnectedError) Is(err error) bool {
+	_, ok := err.(*BrowserDisconnectedError)
+	return ok
+}
+
+// Unwrap stdlib interface.
+func (e *BrowserDisconnectedError) Unwrap() error {
+	if e.Crash == nil {
+		return nil
+	}
+	return e.Crash
+}
+
+// BrowserClosingError error.
+type BrowserClosingError struct{}
+
+func (e *BrowserClosingError) Error() string {
+	return "the browser is closing"
+}
+
+// Is interface.
+func (e *BrowserClosingError) Is(err error) bool { _, ok := err.(*BrowserClosingError); return ok }
+
+// OCRNotSetError error.
+type OCRNotSetError struct{}
+
+func (e *OCRNotSetError) Error() string {
+	return "the ocr engine is not set, use Browser.OCR to set it"
+}
+
+// Is interface.
+func (e *OCRNotSetError) Is(err error) bool { _, ok := err.(*OCRNotSetError); return ok }
+
+// NoFileInputError error.
+type NoFileInputError struct{}
+
+func (e *NoFileInputError) Error() string {
+	return "the file chooser isn't opened by a file input"
+}
+
+// Is interface.
+func (e *NoFileInputError) Is(err error) bool { _, ok := err.(*NoFileInputError); return ok }
+
+// MailboxNotSetError error.
+type MailboxNotSetError struct{}
+
+func (e *MailboxNotSetError) Error() string {
+	return "the mailbox is not set, use Browser.Mailbox to set it"
+}
+
+// Is interface.
+func (e *MailboxNotSetError) Is(err error) bool { _, ok := err.(*MailboxNotSetError); return ok }
+
+// ScenarioBrokenError error.
+type ScenarioBrokenError struct {
+	Barrier string
+}
+
+func (e *ScenarioBrokenError) Error() string {
+	return fmt.Sprintf("another actor of the scenario failed before the barrier: %s", e.Barrier)
+}
+
+// Is interface.
+func (e *ScenarioBrokenError) Is(err error) bool { _, ok := err.(*ScenarioBrokenError); return ok }
+
+// OAuthError is the error that the OAuth provider redirects back with.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return "oauth error: " + e.Code
+	}
+	return fmt.Sprintf("oauth error: %s: %s", e.Code, e.Description)
+}
+
+// Is interface.
+func (e *OAuthError) Is(err error) bool { _, ok := err.(*OAuthError); return ok }
+
+// UnsafeExternalURLError error.
+type UnsafeExternalURLError struct {
+	URL string
+}
+
+func (e *UnsafeExternalURLError) Error() string {
+	return fmt.Sprintf("only the http and https urls can be opened externally: %q", e.URL)
+}
+
+// Is interface.
+func (e *UnsafeExternalURLError) Is(err error) bool {
+	_, ok := err.(*UnsafeExternalURLError)
+	return ok
+}
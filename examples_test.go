@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
-	"path/filepath"
 	"sync"
 	"time"
 
@@ -654,15 +653,9 @@ func ExampleBrowser_pool() {
 }
 
 func Example_load_extension() {
-	extPath, _ := filepath.Abs("fixtures/chrome-extension")
-
 	u := launcher.New().
-		// Must use abs path for an extension
-		Set("load-extension", extPath).
-		// Headless mode doesn't support extension yet.
-		// Reason: https://bugs.chromium.org/p/chromium/issues/detail?id=706008#c5
-		// You can use XVFB to get rid of it: https://github.com/xyjwsj/grod/blob/main/lib/examples/launch-managed/main.go
-		Headless(false).
+		// It sets the flags to load the extension and switches the headless mode to the new one that supports it.
+		Extension("fixtures/chrome-extension").
 		MustLaunch()
 
 	page := rod.New().ControlURL(u).MustConnect().MustPage("http://mdn.dev")
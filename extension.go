@@ -0,0 +1,53 @@
+// This file contains the helpers for the extensions loaded by launcher.Launcher.Extension .
+
+package rod
+
+import (
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// ExtensionTarget waits for the background target of the extension of the id, it's the service worker of
+// the manifest v3 extensions or the background page of the manifest v2 ones. If the id is empty, the first
+// found extension will be returned. For a background page use [Browser.PageFromTarget] to control it, for
+// a service worker attach to it via [proto.TargetAttachToTarget] with the Flatten and use [Browser.PageFromSession]
+// to evaluate js in it. Use [Browser.Timeout] to limit the waiting.
+func (b *Browser) ExtensionTarget(id string) (*proto.TargetTargetInfo, error) {
+	match := func(t *proto.TargetTargetInfo) bool {
+		return (t.Type == proto.TargetTargetInfoTypeBackgroundPage || t.Type == proto.TargetTargetInfoTypeServiceWorker) &&
+			strings.HasPrefix(t.URL, "chrome-extension://"+id)
+	}
+
+	b, cancel := b.WithCancel()
+	defer cancel()
+
+	var found *proto.TargetTargetInfo
+
+	// subscribe before listing the targets, so that the targets created in between won't be missed
+	wait := b.EachEvent(func(e *proto.TargetTargetCreated) bool {
+		if match(e.TargetInfo) {
+			found = e.TargetInfo
+			return true
+		}
+		return false
+	})
+
+	list, err := proto.TargetGetTargets{}.Call(b)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range list.TargetInfos {
+		if match(t) {
+			return t, nil
+		}
+	}
+
+	wait()
+
+	if found == nil {
+		return nil, b.ctx.Err()
+	}
+	return found, nil
+}
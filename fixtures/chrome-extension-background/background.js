@@ -0,0 +1 @@
+self.rodTest = 'ok'
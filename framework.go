@@ -0,0 +1,103 @@
+// This file contains the waits that are aware of the frontend frameworks.
+
+package rod
+
+import (
+	"github.com/xyjwsj/grod/lib/js"
+)
+
+// Framework of the frontend.
+type Framework string
+
+const (
+	// FrameworkAuto detects the frameworks from the server-side rendered markup, such as the #__next of Next.js.
+	FrameworkAuto Framework = ""
+
+	// FrameworkReact type.
+	FrameworkReact Framework = "react"
+
+	// FrameworkVue type.
+	FrameworkVue Framework = "vue"
+
+	// FrameworkSvelte type.
+	FrameworkSvelte Framework = "svelte"
+
+	// FrameworkAngular type.
+	FrameworkAngular Framework = "angular"
+)
+
+// WaitHydrated waits until the server-side rendered page is hydrated by the framework, so that the clicks
+// won't land before the event listeners are attached and silently do nothing.
+// It's based on heuristics, such as the dehydrated state of the React roots, the markers Vue sets after
+// the app is mounted, and the delegated event listeners Svelte stores on the elements.
+// The roots of the apps are only looked up in the document, the body, and the direct children of the body.
+// With [FrameworkAuto], if no known framework is detected, it only waits for the window.onload event.
+// Use [Page.Timeout] to limit the waiting, because the markers may never show up for a page
+// that isn't rendered by the framework.
+func (p *Page) WaitHydrated(framework Framework) error {
+	defer p.tryTrace(TraceTypeWait, "hydrated")()
+	return p.Wait(evalHelper(js.Hydrated, framework))
+}
+
+// FrameworkIdleWaiter waits for the task queues of a framework to be idle.
+type FrameworkIdleWaiter struct {
+	Framework Framework
+
+	// JS function that returns a promise which resolves when the framework is idle.
+	// It should return undefined if the page doesn't use the framework.
+	JS string
+}
+
+// FrameworkIdleWaiters used by [Page.WaitFrameworkIdle]. Append to it to support more frameworks,
+// it should only be modified before the waits start.
+var FrameworkIdleWaiters = []*FrameworkIdleWaiter{
+	{
+		// the testability api protractor uses, angularjs is supported too
+		Framework: FrameworkAngular,
+		JS: `() => {
+			if (window.getAllAngularTestabilities) {
+				return Promise.all(getAllAngularTestabilities().map(t => new Promise(r => t.whenStable(r))))
+			}
+			const injector = window.angular && angular.element(document.body).injector()
+			if (injector) {
+				return new Promise(r => injector.get('$browser').notifyWhenNoOutstandingRequests(r))
+			}
+		}`,
+	},
+	{
+		// react has no public api for it, the scheduler runs the tasks via macrotasks,
+		// so we wait for a frame, a macrotask, then an idle period of the main thread
+		Framework: FrameworkReact,
+		JS: `() => {
+			const used = !!document.querySelector('[data-reactroot]') ||
+				Array.from(document.querySelectorAll('body > *')).some(el =>
+					Object.keys(el).some(k => k.startsWith('__reactContainer$') || k === '_reactRootContainer'))
+			if (!used) return
+			const idle = window.requestIdleCallback || setTimeout
+			return new Promise(r => requestAnimationFrame(() => setTimeout(() => idle(r), 0)))
+		}`,
+	},
+	{
+		Framework: FrameworkVue,
+		JS: `() => {
+			const el = document.querySelector('[data-v-app]')
+			const app = el && el.__vue_app__
+			if (app) return app.config.globalProperties.$nextTick()
+		}`,
+	},
+}
+
+// WaitFrameworkIdle waits until the frameworks the page uses are idle, such as the Testability.whenStable of Angular,
+// so that the waits align with the task queues of the frameworks. Check [FrameworkIdleWaiters] for the supported frameworks.
+// It returns immediately if the page doesn't use any of them.
+func (p *Page) WaitFrameworkIdle() error {
+	defer p.tryTrace(TraceTypeWait, "framework idle")()
+
+	for _, w := range FrameworkIdleWaiters {
+		_, err := p.Evaluate(Eval(w.JS).ByPromise())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
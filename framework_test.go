@@ -0,0 +1,98 @@
+package rod_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestPageWaitHydrated(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/react", ".html", `<html><body><div id="__next"><button>ok</button></div><script>
+		// the container is marked when hydrateRoot is called, before the hydration is done
+		const state = { isDehydrated: true }
+		document.querySelector('#__next')['__reactContainer$x1'] = { stateNode: { current: { memoizedState: state } } }
+		setTimeout(() => { state.isDehydrated = false; window.hydrated = true }, 300)
+	</script></body></html>`)
+	s.Route("/vue", ".html", `<html><body><div id="app" data-server-rendered="true"></div><script>
+		setTimeout(() => document.querySelector('#app').setAttribute('data-v-app', ''), 300)
+	</script></body></html>`)
+	s.Route("/svelte", ".html", `<html><body data-sveltekit-preload-data><button>ok</button><script>
+		setTimeout(() => { document.querySelector('button').__click = () => {} }, 300)
+	</script></body></html>`)
+	s.Route("/plain", ".html", `<html><body>ok</body></html>`)
+
+	p := g.newPage()
+
+	check := func(path string, framework rod.Framework, js string) {
+		g.Helper()
+		p.MustNavigate(s.URL(path)).MustWaitHydrated(framework)
+		g.True(p.MustEval(js).Bool())
+	}
+
+	check("/react", rod.FrameworkReact, `() => window.hydrated`)
+	check("/react", rod.FrameworkAuto, `() => window.hydrated`)
+	check("/vue", rod.FrameworkVue, `() => document.querySelector('#app').hasAttribute('data-v-app')`)
+	check("/vue", rod.FrameworkAuto, `() => document.querySelector('#app').hasAttribute('data-v-app')`)
+	check("/svelte", rod.FrameworkSvelte, `() => !!document.querySelector('button').__click`)
+	check("/plain", rod.FrameworkAuto, `() => document.readyState === 'complete'`)
+
+	err := p.MustNavigate(s.URL("/plain")).Timeout(time.Second).WaitHydrated(rod.FrameworkReact)
+	g.Is(err, context.DeadlineExceeded)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		p.MustWaitHydrated(rod.FrameworkAuto)
+	})
+}
+
+func TestPageWaitFrameworkIdle(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/angular", ".html", `<html><body><script>
+		window.stable = false
+		window.getAllAngularTestabilities = () => [{
+			whenStable: (cb) => setTimeout(() => { window.stable = true; cb() }, 300)
+		}]
+	</script></body></html>`)
+	s.Route("/vue", ".html", `<html><body><div data-v-app></div><script>
+		window.stable = false
+		document.querySelector('[data-v-app]').__vue_app__ = { config: { globalProperties: {
+			$nextTick: () => new Promise(r => setTimeout(() => { window.stable = true; r() }, 300))
+		} } }
+	</script></body></html>`)
+	s.Route("/react", ".html", `<html><body><div id="root"></div><script>
+		document.querySelector('#root')['__reactContainer$x'] = {}
+		window.stable = true
+	</script></body></html>`)
+	s.Route("/plain", ".html", `<html><body>ok</body></html>`)
+
+	p := g.newPage()
+
+	for _, path := range []string{"/angular", "/vue", "/react"} {
+		p.MustNavigate(s.URL(path)).MustWaitLoad().MustWaitFrameworkIdle()
+		g.True(p.MustEval(`() => window.stable`).Bool())
+	}
+
+	p.MustNavigate(s.URL("/plain")).MustWaitFrameworkIdle()
+
+	old := rod.FrameworkIdleWaiters
+	defer func() { rod.FrameworkIdleWaiters = old }()
+	rod.FrameworkIdleWaiters = append(old[:len(old):len(old)], &rod.FrameworkIdleWaiter{
+		Framework: "custom",
+		JS:        `() => { window.customIdle = true }`,
+	})
+	p.MustWaitFrameworkIdle()
+	g.True(p.MustEval(`() => window.customIdle`).Bool())
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		p.MustWaitFrameworkIdle()
+	})
+}
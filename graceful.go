@@ -0,0 +1,218 @@
+// This file contains the helpers to close the browser gracefully.
+
+package rod
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/cdp"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// gracefulIdle is how long the operations must stay idle before [Browser.CloseGracefully] closes the browser.
+// An operation, such as a query that polls the page, may have gaps between its calls, it's the max interval
+// of the [DefaultSleeperPolicy].
+var gracefulIdle = time.Second
+
+// pendingOps tracks the in-flight cdp calls and downloads of a browser.
+type pendingOps struct {
+	lock      sync.Mutex
+	closing   bool
+	calls     int
+	downloads map[string]bool
+
+	// sessions that have made calls, the ones that are active when the closing starts are allowed to keep
+	// calling, so that the operations that are already running can finish
+	sessions map[string]*sessionOps
+	allowed  map[string]bool
+
+	// changed is closed and replaced when the pending operations change
+	changed chan struct{}
+}
+
+type sessionOps struct {
+	calls int
+	last  time.Time
+}
+
+func newPendingOps() *pendingOps {
+	return &pendingOps{
+		downloads: map[string]bool{},
+		sessions:  map[string]*sessionOps{},
+		allowed:   map[string]bool{},
+		changed:   make(chan struct{}),
+	}
+}
+
+// gracefulKey marks the context of the calls of [Browser.CloseGracefully].
+type gracefulKey struct{}
+
+func (o *pendingOps) begin(ctx context.Context, sessionID string) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.closing && ctx.Value(gracefulKey{}) == nil && !o.allowed[sessionID] {
+		return &BrowserClosingError{}
+	}
+
+	s, has := o.sessions[sessionID]
+	if !has {
+		s = &sessionOps{}
+		o.sessions[sessionID] = s
+	}
+	s.calls++
+	s.last = time.Now()
+
+	o.calls++
+	o.notify()
+	return nil
+}
+
+func (o *pendingOps) end(sessionID string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if s, has := o.sessions[sessionID]; has {
+		s.calls--
+		s.last = time.Now()
+	}
+
+	o.calls--
+	o.notify()
+}
+
+// startClosing refuses the new calls except the ones of the sessions that are active now.
+func (o *pendingOps) startClosing() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.closing = true
+	o.allowed = map[string]bool{}
+	for id, s := range o.sessions {
+		if s.calls > 0 || time.Since(s.last) < gracefulIdle {
+			o.allowed[id] = true
+		}
+	}
+}
+
+// stopClosing accepts the new calls again, such as when the close fails.
+func (o *pendingOps) stopClosing() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.closing = false
+	o.allowed = map[string]bool{}
+}
+
+// closed refuses all the new calls after the browser is closed.
+func (o *pendingOps) closed() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.allowed = map[string]bool{}
+}
+
+// track the download events of the Browser domain and the Page domain.
+func (o *pendingOps) track(e *cdp.Event) {
+	var download struct {
+		GUID  string `json:"guid"`
+		State string `json:"state"`
+	}
+
+	switch e.Method {
+	case (&proto.TargetDetachedFromTarget{}).ProtoEvent():
+		var detached proto.TargetDetachedFromTarget
+		if json.Unmarshal(e.Params, &detached) == nil {
+			o.lock.Lock()
+			delete(o.sessions, string(detached.SessionID))
+			o.lock.Unlock()
+		}
+
+	case (&proto.BrowserDownloadWillBegin{}).ProtoEvent(), (&proto.PageDownloadWillBegin{}).ProtoEvent():
+		if json.Unmarshal(e.Params, &download) == nil {
+			o.lock.Lock()
+			o.downloads[download.GUID] = true
+			o.lock.Unlock()
+		}
+
+	case (&proto.BrowserDownloadProgress{}).ProtoEvent(), (&proto.PageDownloadProgress{}).ProtoEvent():
+		if json.Unmarshal(e.Params, &download) == nil && download.State != string(proto.BrowserDownloadProgressStateInProgress) {
+			o.lock.Lock()
+			delete(o.downloads, download.GUID)
+			o.notify()
+			o.lock.Unlock()
+		}
+	}
+}
+
+func (o *pendingOps) notify() {
+	close(o.changed)
+	o.changed = make(chan struct{})
+}
+
+// wait until there's no pending operation other than the current call. If there are the allowed sessions,
+// it also waits for them to be idle for the [gracefulIdle], because their operations may make more calls.
+func (o *pendingOps) wait(ctx context.Context) error {
+	for {
+		o.lock.Lock()
+		idle := o.calls == 0 && len(o.downloads) == 0
+		settled := len(o.allowed) == 0
+		changed := o.changed
+		o.lock.Unlock()
+
+		var timeout <-chan time.Time
+		if idle {
+			if settled {
+				return nil
+			}
+			timeout = time.After(gracefulIdle)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-timeout:
+			return nil
+		}
+	}
+}
+
+// CloseGracefully stops accepting new cdp calls, new calls will fail with [BrowserClosingError].
+// The sessions that are calling at the moment can keep calling, so the operations that are already running,
+// such as an [Page.Eval] or a query that polls the page, can finish.
+// Then it waits for the in-flight cdp calls and downloads to finish, the downloads are tracked via the download events,
+// such as the ones enabled by [Browser.WaitDownload] or the "eventsEnabled" of [proto.BrowserSetDownloadBehavior].
+// If there are such sessions, it also waits for them to stay idle for a second.
+// Then it closes all the pages and the browser.
+// If the ctx is done before the pending operations finish, the browser will still be closed.
+// If the browser fails to close, it accepts new cdp calls again, so the close can be retried.
+func (b *Browser) CloseGracefully(ctx context.Context) error {
+	b.ops.startClosing()
+
+	waitErr := b.ops.wait(ctx)
+
+	// use a fresh context, so the browser can still be closed after the ctx is done
+	closer := b.Context(context.WithValue(b.ctx, gracefulKey{}, true))
+
+	pages, err := closer.Pages()
+	if err != nil {
+		b.ops.stopClosing()
+		return err
+	}
+	for _, p := range pages {
+		_ = p.Context(closer.ctx).Close()
+	}
+
+	err = closer.Close()
+	if err != nil {
+		b.ops.stopClosing()
+		return err
+	}
+	b.ops.closed()
+
+	return waitErr
+}
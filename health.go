@@ -0,0 +1,112 @@
+// This file contains the helpers to check the health of a browser.
+
+package rod
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// HealthStatus of a browser, check [Health].
+type HealthStatus struct {
+	// Connected is false if the browser is disconnected or doesn't respond.
+	Connected bool `json:"connected"`
+
+	// Error is the reason why the status is unhealthy.
+	Error string `json:"error,omitempty"`
+
+	// Targets is the count of all the targets, such as pages, iframes, and workers.
+	Targets int `json:"targets"`
+
+	// Pages is the count of the page targets.
+	Pages int `json:"pages"`
+
+	// Latency of the cdp call of the check.
+	Latency time.Duration `json:"latency"`
+
+	// Version of the browser, such as "HeadlessChrome/120.0.6099.109".
+	Version string `json:"version"`
+
+	// Protocol version of the devtools protocol.
+	Protocol string `json:"protocol"`
+
+	// Memory is the js heap usage of all the pages.
+	Memory HealthMemory `json:"memory"`
+}
+
+// HealthMemory in bytes.
+type HealthMemory struct {
+	Used  float64 `json:"used"`
+	Total float64 `json:"total"`
+}
+
+// Health checks the browser, it never returns nil. Use [Browser.Context] to set the timeout of the check.
+// Such as:
+//
+//	http.Handle("/healthz", rod.HealthHandler(browser.Timeout(5 * time.Second)))
+func Health(b *Browser) *HealthStatus {
+	s := &HealthStatus{}
+
+	if err := b.DisconnectErr(); err != nil {
+		s.Error = err.Error()
+		return s
+	}
+
+	start := time.Now()
+	version, err := b.Version()
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Connected = true
+	s.Latency = time.Since(start)
+	s.Version = version.Product
+	s.Protocol = version.ProtocolVersion
+
+	targets, err := proto.TargetGetTargets{}.Call(b)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Targets = len(targets.TargetInfos)
+
+	pages, err := b.Pages()
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Pages = len(pages)
+
+	for _, p := range pages {
+		heap, err := proto.RuntimeGetHeapUsage{}.Call(p)
+		if err != nil {
+			continue
+		}
+		s.Memory.Used += heap.UsedSize
+		s.Memory.Total += heap.TotalSize
+	}
+
+	return s
+}
+
+// HealthHandler responds the [HealthStatus] as json, the status code is 503 if the browser is not connected.
+func HealthHandler(b *Browser) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := Health(b.Context(r.Context()))
+
+		w.Header().Set("Content-Type", "application/json")
+		if !s.Connected {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(s)
+	})
+}
+
+// Latency of the last cdp call, it includes the time that the browser takes to handle the call.
+func (b *Browser) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(b.latency))
+}
@@ -3,11 +3,15 @@ package rod
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/xyjwsj/grod/lib/proto"
 	"github.com/xyjwsj/grod/lib/utils"
@@ -27,10 +31,33 @@ func (b *Browser) HijackRequests() *HijackRouter {
 //	browser --req-> rod ---> server ---> rod --res-> browser
 //
 // The --req-> and --res-> are the parts that can be modified.
+// Once a handler is added, only the requests that match the patterns of the handlers are paused by the browser,
+// the others are approved by the browser side without a round trip to rod. The paused requests that match
+// the patterns of the handlers but are skipped by all of them are continued as they are, the other paused
+// requests are left to the other users of the Fetch domain, such as [Browser.HandleAuthFor].
+// Each paused request is handled in its own goroutine, so the calls to continue them are pipelined on
+// the connection instead of being batched, a slow handler or a slow round trip won't block the other requests.
 func (p *Page) HijackRequests() *HijackRouter {
 	return newHijackRouter(p.browser, p).initEvents()
 }
 
+// HijackMatchedRequests is the performance mode of [Browser.HijackRequests], check [Page.HijackMatchedRequests].
+func (b *Browser) HijackMatchedRequests() *HijackRouter {
+	r := newHijackRouter(b, b)
+	r.matchedOnly = true
+	return r.initEvents()
+}
+
+// HijackMatchedRequests is the performance mode of [Page.HijackRequests]. The default router intercepts all
+// the requests until the first handler is added, this one only enables the Fetch domain for the patterns of the
+// handlers at the request stage, and disables it when all the handlers are removed. Enabling the full interception
+// measurably slows down the page loads of the asset-heavy sites, so use it when only a few requests need hijacking.
+func (p *Page) HijackMatchedRequests() *HijackRouter {
+	r := newHijackRouter(p.browser, p)
+	r.matchedOnly = true
+	return r.initEvents()
+}
+
 // HijackRouter context.
 type HijackRouter struct {
 	run      func()
@@ -39,6 +66,9 @@ type HijackRouter struct {
 	enable   *proto.FetchEnable
 	client   proto.Client
 	browser  *Browser
+
+	// matchedOnly is true for the [Page.HijackMatchedRequests]
+	matchedOnly bool
 }
 
 func newHijackRouter(browser *Browser, client proto.Client) *HijackRouter {
@@ -64,16 +94,20 @@ func (r *HijackRouter) initEvents() *HijackRouter { //nolint: gocognit
 	eventCtx, cancel := context.WithCancel(ctx)
 	r.stop = cancel
 
-	_ = r.enable.Call(r.client)
+	if !r.matchedOnly {
+		_ = r.enable.Call(r.client)
+	}
 
 	r.run = r.browser.Context(eventCtx).eachEvent(sessionID, func(e *proto.FetchRequestPaused) bool {
 		go func() {
 			ctx := r.new(eventCtx, e)
+			matched, handled := false, false
 			for _, h := range r.handlers {
 				if !h.regexp.MatchString(e.Request.URL) {
 					continue
 				}
 
+				matched = true
 				h.handler(ctx)
 
 				if ctx.continueRequest != nil {
@@ -97,32 +131,48 @@ func (r *HijackRouter) initEvents() *HijackRouter { //nolint: gocognit
 					return
 				}
 
+				handled = true
 				err := ctx.Response.payload.Call(r.client)
 				if err != nil {
 					ctx.OnError(err)
 					return
 				}
 			}
+
+			// All the matched handlers skip the request. The requests that match no handler are not continued,
+			// because they may be paused for the other users of the Fetch domain of the same session.
+			if matched && !handled {
+				err := proto.FetchContinueRequest{RequestID: e.RequestID}.Call(r.client)
+				if err != nil {
+					ctx.OnError(err)
+				}
+			}
 		}()
 
 		return false
 	})
+
 	return r
 }
 
 // Add a hijack handler to router, the doc of the pattern is the same as "proto.FetchRequestPattern.URLPattern".
 func (r *HijackRouter) Add(pattern string, resourceType proto.NetworkResourceType, handler func(*Hijack)) error {
-	r.enable.Patterns = append(r.enable.Patterns, &proto.FetchRequestPattern{
+	p := &proto.FetchRequestPattern{
 		URLPattern:   pattern,
 		ResourceType: resourceType,
-	})
+	}
+	if r.matchedOnly {
+		p.RequestStage = proto.FetchRequestStageRequest
+	}
+	r.enable.Patterns = append(r.enable.Patterns, p)
 
 	reg := regexp.MustCompile(proto.PatternToReg(pattern))
 
 	r.handlers = append(r.handlers, &hijackHandler{
-		pattern: pattern,
-		regexp:  reg,
-		handler: handler,
+		pattern:        pattern,
+		requestPattern: p,
+		regexp:         reg,
+		handler:        handler,
 	})
 
 	return r.enable.Call(r.client)
@@ -134,13 +184,17 @@ func (r *HijackRouter) Remove(pattern string) error {
 	handlers := []*hijackHandler{}
 	for _, h := range r.handlers {
 		if h.pattern != pattern {
-			patterns = append(patterns, &proto.FetchRequestPattern{URLPattern: h.pattern})
+			patterns = append(patterns, h.requestPattern)
 			handlers = append(handlers, h)
 		}
 	}
 	r.enable.Patterns = patterns
 	r.handlers = handlers
 
+	if r.matchedOnly && len(patterns) == 0 {
+		return proto.FetchDisable{}.Call(r.client)
+	}
+
 	return r.enable.Call(r.client)
 }
 
@@ -193,9 +247,10 @@ func (r *HijackRouter) Stop() error {
 
 // hijackHandler to handle each request that match the regexp.
 type hijackHandler struct {
-	pattern string
-	regexp  *regexp.Regexp
-	handler func(*Hijack)
+	pattern        string
+	requestPattern *proto.FetchRequestPattern
+	regexp         *regexp.Regexp
+	handler        func(*Hijack)
 }
 
 // Hijack context.
@@ -453,3 +508,174 @@ func (b *Browser) HandleAuth(username, password string) func() error {
 		return
 	}
 }
+
+// HandleAuthFor is the performance mode of [Browser.HandleAuth], it keeps answering the auth challenges of the
+// requests that match the pattern with the credentials until stop is called. Only the matched requests are paused
+// by the Fetch domain and they are continued right away, so the other requests aren't slowed down.
+// The doc of the pattern is the same as "proto.FetchRequestPattern.URLPattern". If the credentials are rejected
+// by the server, the next challenge of the same request is canceled to avoid the endless retries.
+// The calls of it share the Fetch domain of the browser, stop only removes its own pattern, the domain is disabled
+// after the last one stops.
+func (b *Browser) HandleAuthFor(pattern, username, password string) (stop func() error, err error) {
+	p := &proto.FetchRequestPattern{
+		URLPattern:   pattern,
+		RequestStage: proto.FetchRequestStageRequest,
+	}
+
+	err = b.authPatterns.add(b, p)
+	if err != nil {
+		return nil, err
+	}
+
+	reg := regexp.MustCompile(proto.PatternToReg(pattern))
+
+	ctx, cancel := context.WithCancel(b.ctx)
+
+	// the ids of the requests that the credentials are sent to, the accepted ones are never reported back,
+	// so only the recent ones are kept
+	answered := map[proto.FetchRequestID]bool{}
+	order := []proto.FetchRequestID{}
+
+	wait := b.Context(ctx).eachEvent("", func(e *proto.FetchRequestPaused) {
+		if !reg.MatchString(e.Request.URL) {
+			return
+		}
+		go func() { _ = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(b) }()
+	}, func(e *proto.FetchAuthRequired) {
+		if !reg.MatchString(e.Request.URL) {
+			return
+		}
+
+		res := &proto.FetchAuthChallengeResponse{
+			Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+			Username: username,
+			Password: password,
+		}
+		if answered[e.RequestID] {
+			// the request ends after the cancel
+			delete(answered, e.RequestID)
+			res = &proto.FetchAuthChallengeResponse{Response: proto.FetchAuthChallengeResponseResponseCancelAuth}
+		} else {
+			answered[e.RequestID] = true
+			order = append(order, e.RequestID)
+			if len(order) > authAnsweredLimit {
+				delete(answered, order[0])
+				order = order[1:]
+			}
+		}
+
+		go func() { _ = proto.FetchContinueWithAuth{RequestID: e.RequestID, AuthChallengeResponse: res}.Call(b) }()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait()
+	}()
+
+	return func() error {
+		cancel()
+		<-done
+		return b.authPatterns.remove(b, p)
+	}, nil
+}
+
+// authAnsweredLimit is the max number of the recent requests a [Browser.HandleAuthFor] remembers.
+const authAnsweredLimit = 1000
+
+// authPatterns of the active [Browser.HandleAuthFor] calls of a browser.
+type authPatterns struct {
+	lock sync.Mutex
+	list []*proto.FetchRequestPattern
+}
+
+func (a *authPatterns) add(b *Browser, p *proto.FetchRequestPattern) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.list = append(a.list, p)
+
+	err := a.enable(b)
+	if err != nil {
+		a.list = a.list[:len(a.list)-1]
+	}
+	return err
+}
+
+func (a *authPatterns) remove(b *Browser, p *proto.FetchRequestPattern) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.list = slices.DeleteFunc(slices.Clone(a.list), func(item *proto.FetchRequestPattern) bool { return item == p })
+
+	// the empty patterns of the Fetch.enable means all the requests
+	if len(a.list) == 0 {
+		return proto.FetchDisable{}.Call(b)
+	}
+	return a.enable(b)
+}
+
+func (a *authPatterns) enable(b *Browser) error {
+	return proto.FetchEnable{Patterns: a.list, HandleAuthRequests: true}.Call(b)
+}
+
+// MapHosts resolves the hostnames to other addresses for the requests of the browser, such as:
+//
+//	stop, err := browser.MapHosts(map[string]string{"api.example.com": "127.0.0.1:8443"})
+//
+// If the address has no port, the port of the request will be used.
+// The requests to the hostnames are sent by Go with the original Host header and TLS server name,
+// the TLS verification is skipped because local fixtures usually don't have the certificates of the hostnames.
+// It's based on [Browser.HijackRequests], use the [launcher.Launcher.HostResolverRules] if you need a solution
+// in the network layer of the browser.
+func (b *Browser) MapHosts(hosts map[string]string) (stop func() error, err error) {
+	dialer := &net.Dialer{}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				host, port, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+
+				if to, has := hosts[host]; has {
+					addr = to
+					if _, _, err := net.SplitHostPort(to); err != nil {
+						addr = net.JoinHostPort(to, port)
+					}
+				}
+
+				return dialer.DialContext(ctx, network, addr)
+			},
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint: gosec
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	handler := func(h *Hijack) {
+		err := h.LoadResponse(client, true)
+		if err != nil {
+			h.OnError(err)
+			h.Response.Fail(proto.NetworkErrorReasonNameNotResolved)
+		}
+	}
+
+	router := b.HijackRequests()
+
+	for host := range hosts {
+		for _, pattern := range []string{"*://" + host + "/*", "*://" + host + ":*"} {
+			err = router.Add(pattern, "", handler)
+			if err != nil {
+				_ = router.Stop()
+				return nil, err
+			}
+		}
+	}
+
+	go router.Run()
+
+	return router.Stop, nil
+}
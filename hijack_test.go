@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/netfault"
 	"github.com/xyjwsj/grod/lib/proto"
 	"github.com/xyjwsj/grod/lib/utils"
 	"github.com/ysmood/gson"
@@ -214,6 +215,25 @@ func TestHijackSkip(t *testing.T) {
 	wg.Wait()
 }
 
+func TestHijackContinueUnhandled(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve().Route("/", ".html", `<body>ok</body>`)
+
+	router := g.page.HijackRequests()
+	defer router.MustStop()
+
+	router.MustAdd(s.URL("/*"), func(ctx *rod.Hijack) {
+		ctx.Skip = true
+	})
+
+	go router.Run()
+
+	g.page.MustNavigate(s.URL())
+
+	g.Eq("ok", g.page.MustElement("body").MustText())
+}
+
 func TestHijackOnErrorLog(t *testing.T) {
 	g := setup(t)
 
@@ -315,6 +335,33 @@ func TestHijackLoadResponseErr(t *testing.T) {
 	wg.Wait()
 }
 
+func TestHijackLoadResponseNetworkFaults(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/fault/reset", netfault.Reset())
+	s.Mux.HandleFunc("/fault/stall", netfault.Stall("text/html", "<html></html>", 2))
+	s.Mux.HandleFunc("/fault/chunked", netfault.MalformedChunked("text/html", "<html></html>"))
+
+	p := g.newPage().Context(g.Context())
+	router := p.HijackRequests()
+	defer router.MustStop()
+
+	errs := make(chan error, 1)
+
+	router.MustAdd(s.URL("/fault/*"), func(ctx *rod.Hijack) {
+		errs <- ctx.LoadResponse(&http.Client{Timeout: time.Second}, true)
+		ctx.Response.Fail(proto.NetworkErrorReasonConnectionFailed)
+	})
+
+	go router.Run()
+
+	for _, path := range []string{"/fault/reset", "/fault/stall", "/fault/chunked"} {
+		g.Is(p.Navigate(s.URL(path)), &rod.NavigationError{})
+		g.Err(<-errs)
+	}
+}
+
 func TestHijackResponseErr(t *testing.T) {
 	g := setup(t)
 
@@ -382,3 +429,90 @@ func TestHandleAuth(t *testing.T) {
 	wait2()
 	page2.MustClose()
 }
+
+func TestHijackMatchedRequests(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body></body><script src="/a.js"></script></html>`)
+	s.Route("/a.js", ".js", `document.body.innerText = 'origin'`)
+
+	p := g.newPage().Context(g.Context())
+	router := p.HijackMatchedRequests()
+	defer router.MustStop()
+
+	// nothing is intercepted before a handler is added
+	p.MustNavigate(s.URL()).MustElementR("body", "origin")
+
+	router.MustAdd(s.URL("/a.js"), func(ctx *rod.Hijack) {
+		ctx.Response.SetBody(`document.body.innerText = 'hijacked'`)
+	})
+	go router.Run()
+
+	p.MustNavigate(s.URL()).MustElementR("body", "hijacked")
+
+	router.MustRemove(s.URL("/a.js"))
+	p.MustNavigate(s.URL()).MustElementR("body", "origin")
+}
+
+func TestHandleAuthFor(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != "a" || p != "b" {
+			w.Header().Add("WWW-Authenticate", `Basic realm="web"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("denied"))
+			return
+		}
+		g.HandleHTTP(".html", `<p>ok</p>`)(w, r)
+	})
+
+	stop := g.browser.MustHandleAuthFor(s.URL("/a"), "a", "b")
+	other := g.browser.MustHandleAuthFor(s.URL("/other"), "a", "wrong")
+
+	page := g.newPage(s.URL("/a"))
+	page.MustElementR("p", "ok")
+
+	// stopping the other one won't affect it
+	other()
+
+	// it keeps working for the following requests
+	page.MustReload().MustElementR("p", "ok")
+
+	stop()
+
+	stop = g.browser.MustHandleAuthFor(s.URL("/a"), "a", "wrong")
+	defer stop()
+
+	page.MustNavigate(s.URL("/a")).MustElementR("body", "denied")
+}
+
+func TestMapHosts(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html>" + r.Host + "</html>"))
+	})
+
+	stop := g.browser.MustMapHosts(map[string]string{
+		"api.example.com":   s.HostURL.Host,
+		"other.example.com": s.HostURL.Hostname(),
+	})
+	defer stop()
+
+	p := g.newPage("http://api.example.com/").MustWaitLoad()
+	g.Eq(p.MustElement("html").MustText(), "api.example.com")
+
+	p.MustNavigate("http://other.example.com:" + s.HostURL.Port() + "/").MustWaitLoad()
+	g.Eq(p.MustElement("html").MustText(), "other.example.com:"+s.HostURL.Port())
+
+	g.Panic(func() {
+		g.mc.stubErr(2, proto.FetchEnable{})
+		g.browser.MustMapHosts(map[string]string{"a.com": "127.0.0.1"})
+	})
+}
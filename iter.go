@@ -0,0 +1,94 @@
+// This file contains the range-over-func iterators.
+
+package rod
+
+import (
+	"context"
+	"iter"
+	"reflect"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// ElementsSeq is the iterator version of [Page.Elements], it panics via the page's fail function
+// if the query fails. Break the loop to stop early.
+//
+//	for el := range page.ElementsSeq("a") {
+//		fmt.Println(el.MustText())
+//	}
+func (p *Page) ElementsSeq(selector string) iter.Seq[*Element] {
+	return func(yield func(*Element) bool) {
+		list, err := p.Elements(selector)
+		p.e(err)
+
+		for _, el := range list {
+			if !yield(el) {
+				return
+			}
+		}
+	}
+}
+
+// ElementsSeq is the iterator version of [Element.Elements], it panics via the element's fail function
+// if the query fails.
+func (el *Element) ElementsSeq(selector string) iter.Seq[*Element] {
+	return func(yield func(*Element) bool) {
+		list, err := el.Elements(selector)
+		el.e(err)
+
+		for _, item := range list {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// PagesSeq is the iterator version of [Browser.Pages], it panics via the browser's fail function
+// if the listing fails.
+func (b *Browser) PagesSeq() iter.Seq[*Page] {
+	return func(yield func(*Page) bool) {
+		list, err := b.Pages()
+		b.e(err)
+
+		for _, p := range list {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// EventsSeq iterates the events of type T of the page until the ctx or the page is done.
+// The domain of the event will be enabled during the iteration. Break the loop to stop.
+//
+//	for e := range rod.EventsSeq[proto.NetworkRequestWillBeSent](ctx, page) {
+//		fmt.Println(e.Request.URL)
+//	}
+func EventsSeq[T any, PT interface {
+	*T
+	proto.Event
+}](ctx context.Context, p *Page) iter.Seq[*T] {
+	return func(yield func(*T) bool) {
+		name := PT(new(T)).ProtoEvent()
+
+		domain, _ := proto.ParseMethodName(name)
+		if req := proto.GetType(domain + ".enable"); req != nil {
+			enable := reflect.New(req).Interface().(proto.Request) //nolint: forcetypeassert
+			defer p.EnableDomain(enable)()
+		}
+
+		p, cancel := p.Context(ctx).WithCancel()
+		defer cancel()
+
+		for msg := range p.Event() {
+			e := PT(new(T))
+			if !msg.Load(e) {
+				continue
+			}
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
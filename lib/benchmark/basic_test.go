@@ -4,11 +4,17 @@
 package main_test
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/cdp"
 	"github.com/xyjwsj/grod/lib/launcher"
+	"github.com/xyjwsj/grod/lib/proto"
 	"github.com/xyjwsj/grod/lib/utils"
 	"github.com/ysmood/got"
 )
@@ -30,3 +36,122 @@ func BenchmarkCleanup(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkHijack compares the page loads of a page with many assets without hijacking, with the router that
+// continues all the requests, and with the router that only pauses the matched request.
+func BenchmarkHijack(b *testing.B) {
+	const assets = 50
+
+	s := got.New(b).Serve()
+	html := "<html><body>"
+	for i := 0; i < assets; i++ {
+		html += fmt.Sprintf(`<img src="/img/%d.png">`, i)
+	}
+	s.Route("/", ".html", html+"</body></html>")
+	s.Mux.HandleFunc("/img/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+	})
+
+	browser := rod.New().MustConnect()
+	b.Cleanup(browser.MustClose)
+
+	load := func(b *testing.B, page *rod.Page) {
+		b.Helper()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			page.MustNavigate(s.URL()).MustWaitLoad()
+		}
+	}
+
+	b.Run("none", func(b *testing.B) {
+		page := browser.MustPage()
+		defer page.MustClose()
+
+		load(b, page)
+	})
+
+	b.Run("all", func(b *testing.B) {
+		page := browser.MustPage()
+		defer page.MustClose()
+
+		router := page.HijackRequests()
+		defer router.MustStop()
+		router.MustAdd("*", func(ctx *rod.Hijack) {
+			ctx.ContinueRequest(&proto.FetchContinueRequest{})
+		})
+		go router.Run()
+
+		load(b, page)
+	})
+
+	b.Run("matched", func(b *testing.B) {
+		page := browser.MustPage()
+		defer page.MustClose()
+
+		router := page.HijackMatchedRequests()
+		defer router.MustStop()
+		router.MustAdd(s.URL("/img/0.png"), func(ctx *rod.Hijack) {
+			ctx.ContinueRequest(&proto.FetchContinueRequest{})
+		})
+		go router.Run()
+
+		load(b, page)
+	})
+}
+
+// latencyClient is a fake cdp client that each Fetch.continueRequest call takes the latency to respond,
+// like the round trip to a remote browser.
+type latencyClient struct {
+	event     chan *cdp.Event
+	latency   time.Duration
+	continued chan struct{}
+}
+
+func (c *latencyClient) Event() <-chan *cdp.Event {
+	return c.event
+}
+
+func (c *latencyClient) Call(_ context.Context, _, method string, _ interface{}) ([]byte, error) {
+	if method == "Fetch.continueRequest" {
+		time.Sleep(c.latency)
+		c.continued <- struct{}{}
+	}
+	return []byte("{}"), nil
+}
+
+// BenchmarkHijackContinue measures how fast the router continues a burst of paused requests
+// when each call has a round trip latency.
+func BenchmarkHijackContinue(b *testing.B) {
+	const burst = 200
+
+	client := &latencyClient{
+		event:     make(chan *cdp.Event),
+		latency:   5 * time.Millisecond,
+		continued: make(chan struct{}),
+	}
+
+	browser := rod.New().Client(client).MustConnect()
+
+	router := browser.HijackRequests()
+	defer router.MustStop()
+	router.MustAdd("*", func(ctx *rod.Hijack) {
+		ctx.Skip = true
+	})
+	go router.Run()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		go func() {
+			for j := 0; j < burst; j++ {
+				client.event <- &cdp.Event{
+					Method: "Fetch.requestPaused",
+					Params: []byte(fmt.Sprintf(`{"requestId": "%d", "request": {"url": "http://test.com/%d"}}`, j, j)),
+				}
+			}
+		}()
+
+		for j := 0; j < burst; j++ {
+			<-client.continued
+		}
+	}
+}
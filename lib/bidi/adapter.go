@@ -0,0 +1,164 @@
+package bidi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/cdp"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// CDPPrefix is the module name of the CDP passthrough of the BiDi implementation of Chromium.
+const CDPPrefix = "goog:cdp"
+
+// CDPAdapter tunnels the CDP messages through a BiDi connection, it implements the rod.CDPClient,
+// so a rod.Browser can operate over either CDP or BiDi:
+//
+//	client := bidi.MustStartWithURL(ctx, chromedriverBiDiURL, nil)
+//	browser := rod.New().Client(bidi.MustNewCDPAdapter(ctx, client)).MustConnect()
+//
+// It requires the browser to support the CDP passthrough module, such as Chromium via chromedriver.
+// Browsers that only speak the standard BiDi, such as Firefox, will fail with [ErrCDPNotSupported],
+// use the [Client] directly for them.
+type CDPAdapter struct {
+	client *Client
+	prefix string
+	event  chan *cdp.Event
+
+	// stop is closed to stop consuming the events of the client if the adapter fails to start
+	stop chan struct{}
+}
+
+// MustNewCDPAdapter is similar to [NewCDPAdapter].
+func MustNewCDPAdapter(ctx context.Context, client *Client) *CDPAdapter {
+	a, err := NewCDPAdapter(ctx, client)
+	utils.E(err)
+	return a
+}
+
+// NewCDPAdapter subscribes the CDP events of the client and returns the adapter.
+// The adapter takes over the events of the client, don't consume [Client.Event] after it's created.
+func NewCDPAdapter(ctx context.Context, client *Client) (*CDPAdapter, error) {
+	a := &CDPAdapter{
+		client: client,
+		prefix: CDPPrefix,
+		event:  make(chan *cdp.Event),
+		stop:   make(chan struct{}),
+	}
+
+	// the events may arrive before the subscription returns, so the consuming starts first
+	go a.consumeEvents()
+
+	err := client.Subscribe(ctx, a.prefix)
+	if err != nil {
+		close(a.stop)
+
+		var e *Error
+		if errors.As(err, &e) {
+			return nil, fmt.Errorf("%w: %w", ErrCDPNotSupported, err)
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Call a CDP method via the "goog:cdp.sendCommand" command.
+func (a *CDPAdapter) Call(ctx context.Context, sessionID, method string, params interface{}) ([]byte, error) {
+	if params == nil {
+		params = struct{}{}
+	}
+
+	res, err := a.client.Call(ctx, a.prefix+".sendCommand", &sendCommand{
+		Method:  method,
+		Params:  params,
+		Session: sessionID,
+	})
+	if err != nil {
+		var e *Error
+		if errors.As(err, &e) {
+			return nil, toCDPError(e)
+		}
+		return nil, err
+	}
+
+	var r struct {
+		Result json.RawMessage `json:"result"`
+	}
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r.Result, nil
+}
+
+// Event returns a channel that will emit the CDP events. Must be consumed or will block producer.
+func (a *CDPAdapter) Event() <-chan *cdp.Event {
+	return a.event
+}
+
+type sendCommand struct {
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	Session string      `json:"session,omitempty"`
+}
+
+func (a *CDPAdapter) consumeEvents() {
+	defer close(a.event)
+
+	for {
+		var e *Event
+		var ok bool
+		select {
+		case <-a.stop:
+			return
+		case e, ok = <-a.client.Event():
+			if !ok {
+				return
+			}
+		}
+
+		if !strings.HasPrefix(e.Method, a.prefix+".") {
+			continue
+		}
+
+		var p struct {
+			Event   string          `json:"event"`
+			Params  json.RawMessage `json:"params"`
+			Session string          `json:"session"`
+		}
+		if json.Unmarshal(e.Params, &p) != nil {
+			continue
+		}
+
+		select {
+		case <-a.stop:
+			return
+		case a.event <- &cdp.Event{SessionID: p.Session, Method: p.Event, Params: p.Params}:
+		}
+	}
+}
+
+// cdpErrors are the errors that rod checks via errors.Is, the passthrough only keeps their messages.
+var cdpErrors = []*cdp.Error{
+	cdp.ErrCtxNotFound,
+	cdp.ErrSessionNotFound,
+	cdp.ErrSearchSessionNotFound,
+	cdp.ErrCtxDestroyed,
+	cdp.ErrObjNotFound,
+	cdp.ErrNodeNotFoundAtPos,
+	cdp.ErrNotAttachedToActivePage,
+}
+
+func toCDPError(e *Error) *cdp.Error {
+	for _, known := range cdpErrors {
+		if e.Message == known.Message {
+			err := *known
+			return &err
+		}
+	}
+	return &cdp.Error{Code: -32000, Message: e.Message}
+}
@@ -0,0 +1,203 @@
+// Package bidi for application layer communication with browser via the WebDriver BiDi protocol.
+// Check [CDPAdapter] to use it as the transport of rod.
+package bidi
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xyjwsj/grod/lib/cdp"
+	"github.com/xyjwsj/grod/lib/defaults"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// Command to send to browser.
+type Command struct {
+	ID     int         `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+// Message from browser, it's either a command result, a command error, or an event.
+type Message struct {
+	// Type is "success", "error", or "event"
+	Type string `json:"type"`
+
+	// ID of the command, only for "success" and "error"
+	ID int `json:"id,omitempty"`
+
+	// Result of the "success" message
+	Result json.RawMessage `json:"result,omitempty"`
+
+	// Fields of the "error" message
+	ErrorCode    string `json:"error,omitempty"`
+	ErrorMessage string `json:"message,omitempty"`
+	Stacktrace   string `json:"stacktrace,omitempty"`
+
+	// Method of the "event" message
+	Method string `json:"method,omitempty"`
+
+	// Params of the "event" message
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Event from browser.
+type Event struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Client is a WebDriver BiDi connection instance.
+type Client struct {
+	count uint64
+
+	ws cdp.WebSocketable
+
+	pending sync.Map    // pending commands
+	event   chan *Event // events from browser
+
+	logger utils.Logger
+}
+
+// New creates a bidi connection, all messages from Client.Event must be received or they will block the client.
+func New() *Client {
+	return &Client{
+		event:  make(chan *Event),
+		logger: defaults.CDP,
+	}
+}
+
+// Logger sets the logger to log all the commands, results, and events transferred between Rod and the browser.
+func (c *Client) Logger(l utils.Logger) *Client {
+	c.logger = l
+	return c
+}
+
+// Start to browser.
+func (c *Client) Start(ws cdp.WebSocketable) *Client {
+	c.ws = ws
+	go c.consumeMessages()
+	return c
+}
+
+type result struct {
+	msg json.RawMessage
+	err error
+}
+
+// Call a method and wait for its result.
+func (c *Client) Call(ctx context.Context, method string, params interface{}) ([]byte, error) {
+	if params == nil {
+		params = struct{}{}
+	}
+
+	cmd := &Command{
+		ID:     int(atomic.AddUint64(&c.count, 1)),
+		Method: method,
+		Params: params,
+	}
+
+	c.logger.Println(cmd)
+
+	data, err := json.Marshal(cmd)
+	utils.E(err)
+
+	done := make(chan result)
+	once := sync.Once{}
+	c.pending.Store(cmd.ID, func(res result) {
+		once.Do(func() {
+			select {
+			case <-ctx.Done():
+			case done <- res:
+			}
+		})
+	})
+	defer c.pending.Delete(cmd.ID)
+
+	err = c.ws.Send(data)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-done:
+		return res.msg, res.err
+	}
+}
+
+// Event returns a channel that will emit the BiDi events. Must be consumed or will block producer.
+func (c *Client) Event() <-chan *Event {
+	return c.event
+}
+
+// SessionNewResult of [Client.NewSession].
+type SessionNewResult struct {
+	SessionID    string          `json:"sessionId"`
+	Capabilities json.RawMessage `json:"capabilities"`
+}
+
+// NewSession sends the session.new command, it's required when the client connects to the BiDi endpoint of the
+// browser directly, such as ws://127.0.0.1:9222/session of Firefox.
+// The capabilities can be nil.
+func (c *Client) NewSession(ctx context.Context, capabilities interface{}) (*SessionNewResult, error) {
+	if capabilities == nil {
+		capabilities = struct{}{}
+	}
+
+	res, err := c.Call(ctx, "session.new", map[string]interface{}{"capabilities": capabilities})
+	if err != nil {
+		return nil, err
+	}
+
+	var s SessionNewResult
+	err = json.Unmarshal(res, &s)
+	return &s, err
+}
+
+// Subscribe to the events or modules, such as "browsingContext.load" or "log".
+func (c *Client) Subscribe(ctx context.Context, events ...string) error {
+	_, err := c.Call(ctx, "session.subscribe", map[string]interface{}{"events": events})
+	return err
+}
+
+// Consume messages coming from the browser via the websocket.
+func (c *Client) consumeMessages() {
+	defer close(c.event)
+
+	for {
+		data, err := c.ws.Read()
+		if err != nil {
+			c.pending.Range(func(_, val interface{}) bool {
+				val.(func(result))(result{err: err}) //nolint: forcetypeassert
+				return true
+			})
+			return
+		}
+
+		var msg Message
+		err = json.Unmarshal(data, &msg)
+		utils.E(err)
+
+		c.logger.Println(&msg)
+
+		if msg.Type == "event" {
+			c.event <- &Event{Method: msg.Method, Params: msg.Params}
+			continue
+		}
+
+		val, ok := c.pending.Load(msg.ID)
+		if !ok {
+			continue
+		}
+		if msg.Type == "error" {
+			e := &Error{Code: msg.ErrorCode, Message: msg.ErrorMessage, Stacktrace: msg.Stacktrace}
+			val.(func(result))(result{nil, e}) //nolint: forcetypeassert
+		} else {
+			val.(func(result))(result{msg.Result, nil}) //nolint: forcetypeassert
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package bidi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/xyjwsj/grod/lib/bidi"
+	"github.com/xyjwsj/grod/lib/cdp"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/got"
+	"github.com/ysmood/gson"
+)
+
+var setup = got.Setup(nil)
+
+// newBrowser mocks the bidi endpoint of a browser, the handler returns the result or the error of a command.
+func newBrowser(handler func(cmd *bidi.Command, emit func(method string, params interface{})) (interface{}, *bidi.Error)) *MockWebSocket {
+	out := make(chan []byte, 10)
+	emit := func(method string, params interface{}) {
+		out <- utils.MustToJSONBytes(map[string]interface{}{"type": "event", "method": method, "params": params})
+	}
+
+	return &MockWebSocket{
+		send: func(data []byte) error {
+			var cmd bidi.Command
+			utils.E(json.Unmarshal(data, &cmd))
+			cmd.Params = gson.New(data).Get("params").Val()
+
+			if cmd.Method == "end" {
+				close(out)
+				return nil
+			}
+
+			res, err := handler(&cmd, emit)
+			if err != nil {
+				out <- utils.MustToJSONBytes(map[string]interface{}{
+					"type": "error", "id": cmd.ID, "error": err.Code, "message": err.Message,
+				})
+				return nil
+			}
+			out <- utils.MustToJSONBytes(map[string]interface{}{"type": "success", "id": cmd.ID, "result": res})
+			return nil
+		},
+		read: func() ([]byte, error) {
+			data, ok := <-out
+			if !ok {
+				return nil, io.EOF
+			}
+			return data, nil
+		},
+	}
+}
+
+func TestClient(t *testing.T) {
+	g := setup(t)
+
+	ws := newBrowser(func(cmd *bidi.Command, emit func(string, interface{})) (interface{}, *bidi.Error) {
+		switch cmd.Method {
+		case "session.new":
+			emit("log.entryAdded", map[string]string{"text": "hi"})
+			return map[string]interface{}{"sessionId": "s1", "capabilities": map[string]string{"browserName": "firefox"}}, nil
+		case "script.evaluate":
+			return map[string]interface{}{"type": "success", "result": map[string]interface{}{"type": "number", "value": 2}}, nil
+		default:
+			return nil, &bidi.Error{Code: "unknown command", Message: cmd.Method}
+		}
+	})
+
+	c := bidi.New().Logger(utils.LoggerQuiet).Start(ws)
+
+	events := make(chan *bidi.Event, 10)
+	go func() {
+		for e := range c.Event() {
+			events <- e
+		}
+		close(events)
+	}()
+
+	s, err := c.NewSession(g.Context(), nil)
+	g.E(err)
+	g.Eq(s.SessionID, "s1")
+	g.Eq(gson.New([]byte(s.Capabilities)).Get("browserName").Str(), "firefox")
+
+	e := <-events
+	g.Eq(e.Method, "log.entryAdded")
+	g.Eq(gson.New([]byte(e.Params)).Get("text").Str(), "hi")
+
+	res, err := c.Call(g.Context(), "script.evaluate", map[string]interface{}{"expression": "1+1"})
+	g.E(err)
+	g.Eq(gson.New(res).Get("result.value").Int(), 2)
+
+	_, err = c.Call(g.Context(), "foo.bar", nil)
+	g.Is(err, bidi.ErrUnknownCommand)
+	g.Eq(err.Error(), "unknown command: foo.bar")
+
+	_, _ = c.Call(g.Context(), "end", nil)
+	_, ok := <-events
+	g.False(ok)
+}
+
+func TestCDPAdapter(t *testing.T) {
+	g := setup(t)
+
+	ws := newBrowser(func(cmd *bidi.Command, emit func(string, interface{})) (interface{}, *bidi.Error) {
+		params := gson.New(cmd.Params)
+
+		switch cmd.Method {
+		case "session.subscribe":
+			g.Eq(params.Get("events").Arr()[0].Str(), bidi.CDPPrefix)
+			return map[string]interface{}{}, nil
+		case "goog:cdp.sendCommand":
+			method := params.Get("method").Str()
+			switch method {
+			case "Target.setDiscoverTargets":
+				emit("browsingContext.load", map[string]string{})
+				emit("goog:cdp.Target.targetCreated", map[string]interface{}{
+					"event":   "Target.targetCreated",
+					"params":  map[string]interface{}{"targetInfo": map[string]string{"targetId": "t1"}},
+					"session": params.Get("session").Str(),
+				})
+				return map[string]interface{}{"result": map[string]interface{}{}}, nil
+			case "Runtime.evaluate":
+				return nil, &bidi.Error{Code: "unknown error", Message: cdp.ErrCtxNotFound.Message}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"method": method}}, nil
+		}
+		return nil, &bidi.Error{Code: "unknown command", Message: cmd.Method}
+	})
+
+	c := bidi.New().Logger(utils.LoggerQuiet).Start(ws)
+	a := bidi.MustNewCDPAdapter(g.Context(), c)
+
+	res, err := a.Call(g.Context(), "", "Browser.getVersion", nil)
+	g.E(err)
+	g.Eq(gson.New(res).Get("method").Str(), "Browser.getVersion")
+
+	_, err = a.Call(g.Context(), "s1", "Target.setDiscoverTargets", map[string]bool{"discover": true})
+	g.E(err)
+
+	e := <-a.Event()
+	g.Eq(e.Method, "Target.targetCreated")
+	g.Eq(e.SessionID, "s1")
+	g.Eq(gson.New([]byte(e.Params)).Get("targetInfo.targetId").Str(), "t1")
+
+	_, err = a.Call(g.Context(), "s1", "Runtime.evaluate", nil)
+	g.Is(err, cdp.ErrCtxNotFound)
+
+	_, _ = c.Call(g.Context(), "end", nil)
+	_, ok := <-a.Event()
+	g.False(ok)
+}
+
+func TestCDPAdapterNotSupported(t *testing.T) {
+	g := setup(t)
+
+	ws := newBrowser(func(cmd *bidi.Command, emit func(string, interface{})) (interface{}, *bidi.Error) {
+		if cmd.Method == "emit" {
+			emit("log.entryAdded", map[string]string{})
+			return map[string]interface{}{}, nil
+		}
+		return nil, &bidi.Error{Code: "invalid argument", Message: "goog:cdp is not a valid event name"}
+	})
+
+	c := bidi.New().Logger(utils.LoggerQuiet).Start(ws)
+	_, err := bidi.NewCDPAdapter(g.Context(), c)
+	g.True(errors.Is(err, bidi.ErrCDPNotSupported))
+
+	// the failed adapter no longer takes the events of the client
+	go func() { _, _ = c.Call(g.Context(), "emit", nil) }()
+	g.Eq((<-c.Event()).Method, "log.entryAdded")
+}
+
+type MockWebSocket struct {
+	send func(data []byte) error
+	read func() ([]byte, error)
+}
+
+func (c *MockWebSocket) Send(data []byte) error {
+	return c.send(data)
+}
+
+func (c *MockWebSocket) Read() ([]byte, error) {
+	return c.read()
+}
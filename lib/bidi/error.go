@@ -0,0 +1,34 @@
+package bidi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error of the "error" message.
+type Error struct {
+	// Code of the error, such as "no such frame" or "unknown command"
+	Code       string `json:"error,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Stacktrace string `json:"stacktrace,omitempty"`
+}
+
+// Error stdlib interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Is stdlib interface.
+func (e *Error) Is(target error) bool {
+	err, ok := target.(*Error)
+	return ok && e.Code == err.Code
+}
+
+// ErrUnknownCommand type.
+var ErrUnknownCommand = &Error{Code: "unknown command"}
+
+// ErrNoSuchFrame type.
+var ErrNoSuchFrame = &Error{Code: "no such frame"}
+
+// ErrCDPNotSupported is returned by [CDPAdapter] when the browser doesn't support the CDP passthrough of BiDi.
+var ErrCDPNotSupported = errors.New("the browser doesn't support the cdp passthrough of bidi")
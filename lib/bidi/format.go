@@ -0,0 +1,22 @@
+package bidi
+
+import (
+	"fmt"
+
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+func (cmd Command) String() string {
+	return fmt.Sprintf("=> #%d %s %s", cmd.ID, cmd.Method, utils.MustToJSON(cmd.Params))
+}
+
+func (msg Message) String() string {
+	switch msg.Type {
+	case "event":
+		return fmt.Sprintf("<- %s %s", msg.Method, utils.MustToJSON(msg.Params))
+	case "error":
+		return fmt.Sprintf("<= #%d error: %s: %s", msg.ID, msg.ErrorCode, msg.ErrorMessage)
+	default:
+		return fmt.Sprintf("<= #%d %s", msg.ID, utils.MustToJSON(msg.Result))
+	}
+}
@@ -0,0 +1,26 @@
+package bidi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/xyjwsj/grod/lib/cdp"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// MustStartWithURL helper for StartWithURL.
+func MustStartWithURL(ctx context.Context, u string, h http.Header) *Client {
+	c, err := StartWithURL(ctx, u, h)
+	utils.E(err)
+	return c
+}
+
+// StartWithURL helper to connect to the u with the default websocket lib.
+func StartWithURL(ctx context.Context, u string, h http.Header) (*Client, error) {
+	ws := &cdp.WebSocket{}
+	err := ws.Connect(ctx, u, h)
+	if err != nil {
+		return nil, err
+	}
+	return New().Start(ws), nil
+}
@@ -4,8 +4,11 @@ package cdp
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/xyjwsj/grod/lib/defaults"
 	"github.com/xyjwsj/grod/lib/utils"
@@ -52,6 +55,12 @@ type Client struct {
 	event   chan *Event // events from browser
 
 	logger utils.Logger
+
+	keepAliveInterval time.Duration
+	keepAliveTimeout  time.Duration
+	lastSeen          int64 // unix nano of the last message from the browser
+	lost              int32 // 1 if the connection is lost
+	done              chan struct{}
 }
 
 // New creates a cdp connection, all messages from Client.Event must be received or they will block the client.
@@ -59,6 +68,7 @@ func New() *Client {
 	return &Client{
 		event:  make(chan *Event),
 		logger: defaults.CDP,
+		done:   make(chan struct{}),
 	}
 }
 
@@ -69,15 +79,89 @@ func (cdp *Client) Logger(l utils.Logger) *Client {
 	return cdp
 }
 
+// KeepAlive pings the browser every interval, if neither the pong nor any other message is received from the browser
+// within the timeout after the ping, the connection is treated as lost, the pending and future calls will fail with
+// [ErrConnectionLost]. If the ws implements [Pinger], such as the [WebSocket], the ping is the ping frame of the
+// transport, or the "Browser.getVersion" call is used. If the ws implements [io.Closer] it will be closed.
+// It must be called before [Client.Start].
+// It helps to detect the hung connections of remote browsers in seconds instead of waiting for the OS TCP timeout.
+func (cdp *Client) KeepAlive(interval, timeout time.Duration) *Client {
+	cdp.keepAliveInterval = interval
+	cdp.keepAliveTimeout = timeout
+	return cdp
+}
+
+// Pinger is the optional interface of the [WebSocketable] for the heartbeat of the [Client.KeepAlive].
+type Pinger interface {
+	// Ping the browser and wait for the pong until the ctx is done
+	Ping(ctx context.Context) error
+}
+
 // Start to browser.
 func (cdp *Client) Start(ws WebSocketable) *Client {
 	cdp.ws = ws
 
+	atomic.StoreInt64(&cdp.lastSeen, time.Now().UnixNano())
+
 	go cdp.consumeMessages()
 
+	if cdp.keepAliveInterval > 0 {
+		go cdp.keepAlive()
+	}
+
 	return cdp
 }
 
+func (cdp *Client) keepAlive() {
+	t := time.NewTicker(cdp.keepAliveInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-cdp.done:
+			return
+		case <-t.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cdp.keepAliveTimeout)
+		err := cdp.ping(ctx)
+		cancel()
+
+		// the browser may be too busy to answer the ping in time while it's still sending other messages
+		if errors.Is(err, context.DeadlineExceeded) &&
+			time.Since(time.Unix(0, atomic.LoadInt64(&cdp.lastSeen))) >= cdp.keepAliveTimeout {
+			select {
+			case <-cdp.done:
+			default:
+				cdp.connectionLost()
+			}
+			return
+		}
+	}
+}
+
+func (cdp *Client) ping(ctx context.Context) error {
+	if p, ok := cdp.ws.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	_, err := cdp.Call(ctx, "", "Browser.getVersion", nil)
+	return err
+}
+
+func (cdp *Client) connectionLost() {
+	atomic.StoreInt32(&cdp.lost, 1)
+
+	cdp.pending.Range(func(_, val interface{}) bool {
+		val.(func(result))(result{err: ErrConnectionLost}) //nolint: forcetypeassert
+		return true
+	})
+
+	if closer, ok := cdp.ws.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
 type result struct {
 	msg json.RawMessage
 	err error
@@ -92,6 +176,10 @@ func (cdp *Client) Call(ctx context.Context, sessionID, method string, params in
 		Params:    params,
 	}
 
+	if atomic.LoadInt32(&cdp.lost) == 1 {
+		return nil, ErrConnectionLost
+	}
+
 	cdp.logger.Println(req)
 
 	data, err := json.Marshal(req)
@@ -129,11 +217,15 @@ func (cdp *Client) Event() <-chan *Event {
 
 // Consume messages coming from the browser via the websocket.
 func (cdp *Client) consumeMessages() {
+	defer close(cdp.done)
 	defer close(cdp.event)
 
 	for {
 		data, err := cdp.ws.Read()
 		if err != nil {
+			if atomic.LoadInt32(&cdp.lost) == 1 {
+				err = ErrConnectionLost
+			}
 			cdp.pending.Range(func(_, val interface{}) bool {
 				val.(func(result))(result{err: err}) //nolint: forcetypeassert
 				return true
@@ -141,6 +233,8 @@ func (cdp *Client) consumeMessages() {
 			return
 		}
 
+		atomic.StoreInt64(&cdp.lastSeen, time.Now().UnixNano())
+
 		var id struct {
 			ID int `json:"id"`
 		}
@@ -1,11 +1,14 @@
 package cdp_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -343,6 +346,181 @@ func TestMassBrowserClose(t *testing.T) { //nolint: tparallel
 	}
 }
 
+func TestKeepAlive(t *testing.T) {
+	g := setup(t)
+
+	gotrace.CheckLeak(g, 0)
+
+	hung := make(chan struct{})
+	ws := &MockWebSocket{
+		send: func([]byte) error { return nil },
+		read: func() ([]byte, error) {
+			<-hung
+			return nil, io.EOF
+		},
+	}
+	defer close(hung)
+
+	c := cdp.New().KeepAlive(10*time.Millisecond, 50*time.Millisecond).Start(ws)
+
+	_, err := c.Call(g.Context(), "", "method", nil)
+	g.Is(err, cdp.ErrConnectionLost)
+
+	_, err = c.Call(g.Context(), "", "method", nil)
+	g.Is(err, cdp.ErrConnectionLost)
+}
+
+func TestKeepAliveLongInterval(t *testing.T) {
+	g := setup(t)
+
+	sent := make(chan []byte, 10)
+	ws := &MockWebSocket{
+		send: func(data []byte) error {
+			sent <- data
+			return nil
+		},
+		read: func() ([]byte, error) {
+			var req cdp.Request
+			g.E(json.Unmarshal(<-sent, &req))
+
+			if req.Method == "end" {
+				return nil, io.EOF
+			}
+			return json.Marshal(cdp.Response{ID: req.ID, Result: json.RawMessage(`{}`)})
+		},
+	}
+
+	// the interval is longer than the timeout, the ping is answered in time
+	c := cdp.New().KeepAlive(30*time.Millisecond, 10*time.Millisecond).Start(ws)
+
+	time.Sleep(150 * time.Millisecond)
+
+	_, err := c.Call(g.Context(), "", "method", nil)
+	g.E(err)
+
+	_, _ = c.Call(g.Context(), "", "end", nil)
+}
+
+type MockPinger struct {
+	MockWebSocket
+	ping func(ctx context.Context) error
+}
+
+func (c *MockPinger) Ping(ctx context.Context) error {
+	return c.ping(ctx)
+}
+
+func TestKeepAlivePinger(t *testing.T) {
+	g := setup(t)
+
+	hung := make(chan struct{})
+	defer close(hung)
+
+	var lost int32
+	ws := &MockPinger{
+		MockWebSocket: MockWebSocket{
+			send: func([]byte) error { return nil },
+			read: func() ([]byte, error) {
+				<-hung
+				return nil, io.EOF
+			},
+		},
+		ping: func(ctx context.Context) error {
+			if atomic.LoadInt32(&lost) == 0 {
+				return nil
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	c := cdp.New().KeepAlive(10*time.Millisecond, 30*time.Millisecond).Start(ws)
+
+	// the pongs keep the connection alive even though no message is received
+	ctx, cancel := context.WithTimeout(g.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err := c.Call(ctx, "", "method", nil)
+	g.Is(err, context.DeadlineExceeded)
+
+	atomic.StoreInt32(&lost, 1)
+
+	_, err = c.Call(g.Context(), "", "method", nil)
+	g.Is(err, cdp.ErrConnectionLost)
+}
+
+func TestRedactor(t *testing.T) {
+	g := setup(t)
+
+	logs := []string{}
+	r := cdp.NewRedactor(utils.Log(func(msg ...interface{}) {
+		logs = append(logs, fmt.Sprint(msg...))
+	}), cdp.DefaultRedactRules...)
+
+	r.Println(&cdp.Request{ID: 1, Method: "Input.insertText", Params: map[string]string{"text": "secret"}})
+	r.Println(&cdp.Request{ID: 2, Method: "Network.getCookies"})
+	r.Println(&cdp.Response{ID: 2, Result: []byte(`{"cookies":[{"name":"a","value":"secret"}]}`)})
+	r.Println(&cdp.Event{
+		Method: "Network.requestWillBeSent",
+		Params: []byte(`{"request":{"url":"http://a.com","headers":{"Authorization":"secret","Accept":"*/*"}}}`),
+	})
+	r.Println("other")
+
+	g.Eq(logs, []string{
+		`=> #1 @00000000 Input.insertText {"text":"[REDACTED]"}`,
+		`=> #2 @00000000 Network.getCookies null`,
+		`<= #2 {"cookies":[{"name":"a","value":"[REDACTED]"}]}`,
+		`<- @00000000 Network.requestWillBeSent {"request":{"headers":{"Accept":"*/*","Authorization":"[REDACTED]"},"url":"http://a.com"}}`,
+		"other",
+	})
+}
+
+func TestRecordReplay(t *testing.T) {
+	g := setup(t)
+
+	sent := make(chan []byte, 10)
+	ws := &MockWebSocket{
+		send: func(data []byte) error {
+			sent <- data
+			return nil
+		},
+		read: func() ([]byte, error) {
+			var req cdp.Request
+			g.E(json.Unmarshal(<-sent, &req))
+
+			if req.Method == "end" {
+				return nil, io.EOF
+			}
+			return json.Marshal(cdp.Response{ID: req.ID, Result: json.RawMessage(`"` + req.Method + `"`)})
+		},
+	}
+
+	call := func(c *cdp.Client, sessionID, method string) string {
+		res, err := c.Call(g.Context(), sessionID, method, nil)
+		g.E(err)
+		return string(res)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	rec := cdp.NewRecorder(ws, buf)
+	c := cdp.New().Start(rec)
+	g.Eq(call(c, "", "a"), `"a"`)
+	g.Eq(call(c, "s", "b"), `"b"`)
+	_, _ = c.Call(g.Context(), "", "end", nil)
+	g.E(rec.Err())
+
+	rp, err := cdp.NewReplayer(buf)
+	g.E(err)
+	c = cdp.New().Start(rp)
+	defer func() { _ = rp.Close() }()
+
+	// it also makes the ids of the new client different from the recorded ones
+	_, err = c.Call(g.Context(), "", "c", nil)
+	g.Is(err, cdp.ErrReplayMismatch)
+
+	g.Eq(call(c, "", "a"), `"a"`)
+	g.Eq(call(c, "s", "b"), `"b"`)
+}
+
 type MockWebSocket struct {
 	send func(data []byte) error
 	read func() ([]byte, error)
@@ -355,3 +533,36 @@ func (c *MockWebSocket) Send(data []byte) error {
 func (c *MockWebSocket) Read() ([]byte, error) {
 	return c.read()
 }
+
+func TestPipe(t *testing.T) {
+	g := setup(t)
+
+	// the fake browser reads from the browserIn, and writes to the browserOut
+	browserIn, w := io.Pipe()
+	r, browserOut := io.Pipe()
+
+	go func() {
+		buf := bufio.NewReader(browserIn)
+		for {
+			msg, err := buf.ReadBytes(0)
+			if err != nil {
+				return
+			}
+			id := gson.New(msg[:len(msg)-1]).Get("id").Int()
+			_, _ = browserOut.Write([]byte(fmt.Sprintf(`{"id":%d,"result":{"product":"pipe"}}`+"\x00", id)))
+			_, _ = browserOut.Write([]byte(`{"method":"Page.loadEventFired","params":{}}` + "\x00"))
+		}
+	}()
+
+	p := cdp.NewPipe(r, w)
+	c := cdp.New().Start(p)
+
+	res, err := c.Call(g.Context(), "", "Browser.getVersion", nil)
+	g.E(err)
+	g.Eq(gson.New([]byte(res)).Get("product").Str(), "pipe")
+	g.Eq((<-c.Event()).Method, "Page.loadEventFired")
+
+	g.E(p.Close())
+	_, err = c.Call(g.Context(), "", "Browser.getVersion", nil)
+	g.Err(err)
+}
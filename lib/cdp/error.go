@@ -1,6 +1,7 @@
 package cdp
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -63,3 +64,6 @@ var ErrNotAttachedToActivePage = &Error{
 	Code:    -32000,
 	Message: "Not attached to an active page",
 }
+
+// ErrConnectionLost is returned when the browser doesn't respond within the timeout of [Client.KeepAlive].
+var ErrConnectionLost = errors.New("cdp connection lost")
@@ -0,0 +1,61 @@
+package cdp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+)
+
+var _ WebSocketable = &Pipe{}
+
+// Pipe is the transport for the "--remote-debugging-pipe" flag of the browser, the browser reads the messages
+// from the file descriptor 3 and writes the messages to the file descriptor 4, each message ends with a NUL byte.
+// It doesn't expose a TCP port, so other processes can't control the browser.
+type Pipe struct {
+	lock sync.Mutex
+	w    io.Writer
+	r    *bufio.Reader
+
+	closers []io.Closer
+}
+
+// NewPipe creates a transport that writes the messages to the w and reads the messages from the r.
+// The w is usually connected to the fd 3 of the browser, the r is usually connected to the fd 4.
+// If the r or w implements [io.Closer], they will be closed when the pipe closes.
+func NewPipe(r io.Reader, w io.Writer) *Pipe {
+	p := &Pipe{w: w, r: bufio.NewReader(r)}
+	for _, c := range []interface{}{r, w} {
+		if closer, ok := c.(io.Closer); ok {
+			p.closers = append(p.closers, closer)
+		}
+	}
+	return p
+}
+
+// Send a message to the browser.
+func (p *Pipe) Send(msg []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, err := p.w.Write(append(msg, 0))
+	return err
+}
+
+// Read a message from the browser.
+func (p *Pipe) Read() ([]byte, error) {
+	b, err := p.r.ReadBytes(0)
+	if err != nil {
+		return nil, err
+	}
+	return b[:len(b)-1], nil
+}
+
+// Close the pipe.
+func (p *Pipe) Close() error {
+	errs := []error{}
+	for _, c := range p.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,207 @@
+package cdp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// RecordType of a [Record].
+type RecordType string
+
+const (
+	// RecordTypeSend is a message sent to the browser.
+	RecordTypeSend RecordType = "send"
+
+	// RecordTypeRead is a message read from the browser.
+	RecordTypeRead RecordType = "read"
+)
+
+// Record is a line of the file created by [Recorder].
+type Record struct {
+	Type RecordType      `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+var _ WebSocketable = &Recorder{}
+
+// Recorder wraps a [WebSocketable] to record all the messages to a writer as newline delimited json of [Record].
+// Use [Replayer] to serve the records back, so the logic that depends on the browser can be tested without it.
+type Recorder struct {
+	ws   WebSocketable
+	lock sync.Mutex
+	enc  *json.Encoder
+	err  error
+}
+
+// NewRecorder instance.
+func NewRecorder(ws WebSocketable, w io.Writer) *Recorder {
+	return &Recorder{ws: ws, enc: json.NewEncoder(w)}
+}
+
+// Send interface.
+func (r *Recorder) Send(data []byte) error {
+	// the underlying ws may modify the data
+	r.record(RecordTypeSend, append([]byte{}, data...))
+	return r.ws.Send(data)
+}
+
+// Read interface.
+func (r *Recorder) Read() ([]byte, error) {
+	data, err := r.ws.Read()
+	if err == nil {
+		r.record(RecordTypeRead, data)
+	}
+	return data, err
+}
+
+// Err returns the first error of writing the records.
+func (r *Recorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(t RecordType, data []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(Record{Type: t, Data: data})
+}
+
+// ErrReplayMismatch is returned by the [Replayer] when the request to send doesn't match the records.
+var ErrReplayMismatch = errors.New("cdp replay mismatch")
+
+var _ WebSocketable = &Replayer{}
+
+// Replayer serves the records created by the [Recorder].
+// The requests are matched by their methods and session ids in the recorded order,
+// the request ids in the responses are translated to the ids of the new requests.
+// A recorded message is read only after all the requests recorded before it are sent,
+// the Read returns [io.EOF] after all the records are read.
+type Replayer struct {
+	lock sync.Mutex
+	cond *sync.Cond
+
+	records []*replayRecord
+	cursor  int         // the next record to read
+	ids     map[int]int // recorded request id to the new request id
+	closed  bool
+}
+
+type replayRecord struct {
+	Record
+	req  *Request
+	sent bool
+}
+
+// NewReplayer reads all the records from the r.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	rp := &Replayer{ids: map[int]int{}}
+	rp.cond = sync.NewCond(&rp.lock)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<30)
+	for s.Scan() {
+		rec := &replayRecord{}
+		err := json.Unmarshal(s.Bytes(), &rec.Record)
+		if err != nil {
+			return nil, err
+		}
+
+		if rec.Type == RecordTypeSend {
+			rec.req = &Request{}
+			err = json.Unmarshal(rec.Data, rec.req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		rp.records = append(rp.records, rec)
+	}
+
+	return rp, s.Err()
+}
+
+// Send interface.
+func (rp *Replayer) Send(data []byte) error {
+	var req Request
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return err
+	}
+
+	rp.lock.Lock()
+	defer rp.lock.Unlock()
+
+	for _, rec := range rp.records {
+		if rec.req == nil || rec.sent || rec.req.Method != req.Method || rec.req.SessionID != req.SessionID {
+			continue
+		}
+
+		rec.sent = true
+		rp.ids[rec.req.ID] = req.ID
+		rp.cond.Broadcast()
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s %s", ErrReplayMismatch, req.Method, req.SessionID)
+}
+
+// Read interface.
+func (rp *Replayer) Read() ([]byte, error) {
+	rp.lock.Lock()
+	defer rp.lock.Unlock()
+
+	for {
+		// skip the sent requests
+		for rp.cursor < len(rp.records) && rp.records[rp.cursor].sent {
+			rp.cursor++
+		}
+
+		if rp.closed || rp.cursor >= len(rp.records) {
+			return nil, io.EOF
+		}
+
+		rec := rp.records[rp.cursor]
+		if rec.Type == RecordTypeRead {
+			rp.cursor++
+			return rp.translate(rec.Data)
+		}
+
+		rp.cond.Wait()
+	}
+}
+
+// Close the replayer, the pending and future Read will return [io.EOF].
+func (rp *Replayer) Close() error {
+	rp.lock.Lock()
+	defer rp.lock.Unlock()
+
+	rp.closed = true
+	rp.cond.Broadcast()
+	return nil
+}
+
+// translate the recorded request id to the new one.
+func (rp *Replayer) translate(data []byte) ([]byte, error) {
+	var res Response
+	err := json.Unmarshal(data, &res)
+	if err != nil || res.ID == 0 {
+		return data, nil //nolint: nilerr
+	}
+
+	id, has := rp.ids[res.ID]
+	if !has {
+		return data, nil
+	}
+	res.ID = id
+
+	return json.Marshal(res)
+}
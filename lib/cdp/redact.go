@@ -0,0 +1,176 @@
+package cdp
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// Redacted is the placeholder of the redacted values.
+const Redacted = "[REDACTED]"
+
+// RedactRule to match the fields of the cdp messages.
+type RedactRule struct {
+	// Method of the request or event, such as "Network.setCookie". Empty matches all methods.
+	// The responses are matched by the methods of their requests.
+	Method string
+
+	// Path of the field in the params or the result, such as "cookies.*.value".
+	// The "*" matches any key or index, the "**" matches any number of keys or indexes.
+	// The keys are case-insensitive, so "**.headers.cookie" matches both "Cookie" and "cookie".
+	Path string
+}
+
+// DefaultRedactRules redact the cookies, the auth headers, the auth credentials,
+// and the text typed via the Input domain, such as passwords.
+var DefaultRedactRules = []RedactRule{
+	{Path: "**.headers.authorization"},
+	{Path: "**.headers.proxy-authorization"},
+	{Path: "**.headers.cookie"},
+	{Path: "**.headers.set-cookie"},
+	{Path: "**.cookies.*.value"},
+	{Method: "Network.setCookie", Path: "value"},
+	{Method: "Fetch.continueWithAuth", Path: "authChallengeResponse.password"},
+	{Method: "Input.insertText", Path: "text"},
+	{Method: "Input.dispatchKeyEvent", Path: "text"},
+	{Method: "Input.dispatchKeyEvent", Path: "unmodifiedText"},
+	{Method: "Input.dispatchKeyEvent", Path: "key"},
+	{Method: "Input.dispatchKeyEvent", Path: "code"},
+	{Method: "Input.imeSetComposition", Path: "text"},
+}
+
+// Redactor is a logger that redacts the fields of the cdp messages before writing them to the underlying logger.
+// Such as:
+//
+//	cdp.New().Logger(cdp.NewRedactor(defaults.CDP, cdp.DefaultRedactRules...))
+type Redactor struct {
+	logger utils.Logger
+	rules  []RedactRule
+
+	lock    sync.Mutex
+	methods map[int]string // the methods of the pending requests
+}
+
+// NewRedactor instance.
+func NewRedactor(logger utils.Logger, rules ...RedactRule) *Redactor {
+	return &Redactor{
+		logger:  logger,
+		rules:   rules,
+		methods: map[int]string{},
+	}
+}
+
+// Println interface.
+func (r *Redactor) Println(msgs ...interface{}) {
+	list := make([]interface{}, len(msgs))
+	for i, msg := range msgs {
+		list[i] = r.redact(msg)
+	}
+	r.logger.Println(list...)
+}
+
+func (r *Redactor) redact(msg interface{}) interface{} {
+	switch m := msg.(type) {
+	case *Request:
+		r.lock.Lock()
+		r.methods[m.ID] = m.Method
+		r.lock.Unlock()
+
+		data, err := json.Marshal(m.Params)
+		if err != nil {
+			return msg
+		}
+		clone := *m
+		clone.Params = json.RawMessage(r.redactJSON(m.Method, data))
+		return &clone
+
+	case *Response:
+		r.lock.Lock()
+		method := r.methods[m.ID]
+		delete(r.methods, m.ID)
+		r.lock.Unlock()
+
+		clone := *m
+		clone.Result = r.redactJSON(method, m.Result)
+		return &clone
+
+	case *Event:
+		clone := *m
+		clone.Params = r.redactJSON(m.Method, m.Params)
+		return &clone
+	}
+
+	return msg
+}
+
+func (r *Redactor) redactJSON(method string, data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return data
+	}
+
+	paths := [][]string{}
+	for _, rule := range r.rules {
+		if rule.Method == "" || rule.Method == method {
+			paths = append(paths, strings.Split(strings.ToLower(rule.Path), "."))
+		}
+	}
+	if len(paths) == 0 {
+		return data
+	}
+
+	var v interface{}
+	if json.Unmarshal(data, &v) != nil {
+		return data
+	}
+
+	v = redactValue(v, nil, paths)
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+func redactValue(v interface{}, path []string, rules [][]string) interface{} {
+	for _, rule := range rules {
+		if matchPath(rule, path) {
+			return Redacted
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = redactValue(child, append(path, strings.ToLower(k)), rules)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(child, append(path, strconv.Itoa(i)), rules)
+		}
+	}
+	return v
+}
+
+func matchPath(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPath(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 || (pattern[0] != "*" && pattern[0] != path[0]) {
+		return false
+	}
+	return matchPath(pattern[1:], path[1:])
+}
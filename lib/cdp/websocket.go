@@ -3,6 +3,7 @@ package cdp
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
 	"crypto/sha1"
 	"encoding/base64"
 	"fmt"
@@ -26,8 +27,17 @@ type WebSocket struct {
 	lock sync.Mutex
 	conn net.Conn
 	r    *bufio.Reader
+	pong chan struct{}
 }
 
+// the opcodes of the frames
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
 // Connect to browser.
 func (ws *WebSocket) Connect(ctx context.Context, wsURL string, header http.Header) error {
 	if ws.conn != nil {
@@ -48,6 +58,7 @@ func (ws *WebSocket) Connect(ctx context.Context, wsURL string, header http.Head
 
 	ws.conn = conn
 	ws.r = bufio.NewReader(conn)
+	ws.pong = make(chan struct{}, 1)
 	return ws.handshake(ctx, u, header)
 }
 
@@ -75,16 +86,38 @@ func (ws *WebSocket) initDialer(u *url.URL) {
 // Because we use zero-copy design, it will modify the content of the msg.
 // It won't allocate new memory.
 func (ws *WebSocket) Send(msg []byte) error {
-	err := ws.send(msg)
+	err := ws.send(opText, msg)
 	if err != nil {
 		_ = ws.Close()
 	}
 	return err
 }
 
-func (ws *WebSocket) send(msg []byte) error {
-	// FIN is alway true, Opcode is always text frame.
-	header := [18]byte{0b1000_0001, 0b1000_0000}
+// Ping sends a ping frame to the browser and waits for the pong frame until the ctx is done.
+// The pong frame is consumed by the [WebSocket.Read], so the messages must be read concurrently.
+func (ws *WebSocket) Ping(ctx context.Context) error {
+	// drop the pong of the previous ping that has timed out
+	select {
+	case <-ws.pong:
+	default:
+	}
+
+	err := ws.send(opPing, nil)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ws.pong:
+		return nil
+	}
+}
+
+func (ws *WebSocket) send(opcode byte, msg []byte) error {
+	// FIN is alway true.
+	header := [18]byte{0b1000_0000 | opcode, 0b1000_0000}
 	mask := []byte{0, 1, 2, 3}
 
 	size := len(msg)
@@ -134,14 +167,41 @@ func (ws *WebSocket) read() ([]byte, error) {
 	ws.lock.Lock()
 	defer ws.lock.Unlock()
 
-	_, err := ws.r.ReadByte()
+	for {
+		opcode, data, err := ws.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPong:
+			select {
+			case ws.pong <- struct{}{}:
+			default:
+			}
+		case opPing:
+			err = ws.send(opPong, data)
+			if err != nil {
+				return nil, err
+			}
+		case opClose:
+			return nil, io.EOF
+		default:
+			return data, nil
+		}
+	}
+}
+
+func (ws *WebSocket) readFrame() (byte, []byte, error) {
+	opcode, err := ws.r.ReadByte()
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
+	opcode &= 0x0f
 
 	b, err := ws.r.ReadByte()
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
 
 	size := 0
@@ -160,7 +220,7 @@ func (ws *WebSocket) read() ([]byte, error) {
 	for i := 0; i < fieldLen; i++ {
 		b, err := ws.r.ReadByte()
 		if err != nil {
-			return nil, err
+			return 0, nil, err
 		}
 
 		size = size<<8 + int(b)
@@ -168,7 +228,7 @@ func (ws *WebSocket) read() ([]byte, error) {
 
 	data := make([]byte, size)
 	_, err = io.ReadFull(ws.r, data)
-	return data, err
+	return opcode, data, err
 }
 
 // BadHandshakeError type.
@@ -194,7 +254,10 @@ func verifyWebSocketAccept(responseHeaders http.Header, websocketKey string) boo
 }
 
 func (ws *WebSocket) handshake(ctx context.Context, u *url.URL, header http.Header) error {
-	defaultSecKey := "nil"
+	// some browsers, such as Firefox, require the key to be a base64 encoded nonce of 16 bytes
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	defaultSecKey := base64.StdEncoding.EncodeToString(nonce)
 	req := (&http.Request{Method: http.MethodGet, URL: u, Header: http.Header{
 		"Upgrade":               {"websocket"},
 		"Connection":            {"Upgrade"},
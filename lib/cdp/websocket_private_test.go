@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"io"
 	"net"
 	"net/url"
 	"sync"
@@ -107,3 +108,53 @@ func (c *MockConn) SetReadDeadline(_ time.Time) error {
 func (c *MockConn) SetWriteDeadline(_ time.Time) error {
 	return nil
 }
+
+func TestWebSocketPing(t *testing.T) {
+	g := setup(t)
+
+	conn, srv := net.Pipe()
+	ws := &WebSocket{conn: conn, r: bufio.NewReader(conn), pong: make(chan struct{}, 1)}
+
+	read := make(chan []byte)
+	go func() {
+		for {
+			data, err := ws.Read()
+			if err != nil {
+				close(read)
+				return
+			}
+			read <- data
+		}
+	}()
+
+	frame := func(size int) []byte {
+		b := make([]byte, size)
+		_, err := io.ReadFull(srv, b)
+		g.E(err)
+		return b
+	}
+
+	go func() {
+		g.Eq(frame(6)[0], byte(0b1000_1001))
+		_, _ = srv.Write([]byte{0b1000_1010, 0})
+	}()
+	g.E(ws.Ping(g.Timeout(time.Second)))
+
+	// the ping of the browser is answered with the same payload
+	go func() { _, _ = srv.Write([]byte{0b1000_1001, 1, 'a'}) }()
+	pong := frame(7)
+	g.Eq(pong[0], byte(0b1000_1010))
+	g.Eq(pong[6]^pong[2], byte('a'))
+
+	go func() { _, _ = srv.Write([]byte{0b1000_0001, 2, 'o', 'k'}) }()
+	g.Eq(string(<-read), "ok")
+
+	// no pong
+	go func() { frame(6) }()
+	g.Is(ws.Ping(g.Timeout(100*time.Millisecond)), context.DeadlineExceeded)
+
+	// the close frame ends the reading
+	go func() { _, _ = srv.Write([]byte{0b1000_1000, 0}) }()
+	_, ok := <-read
+	g.False(ok)
+}
@@ -0,0 +1,66 @@
+// Package main reports the code to change to port the chromedp code to the lib/chromedp of rod, such as:
+//
+//	go run github.com/xyjwsj/grod/lib/chromedp/chromedp-lint ./...
+//
+// It exits with code 1 if anything is found.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/chromedp"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+func main() {
+	flag.Parse()
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"./..."}
+	}
+
+	fset := token.NewFileSet()
+	found := false
+
+	for _, dir := range dirs {
+		recursive := strings.HasSuffix(dir, "/...")
+		dir = strings.TrimSuffix(dir, "/...")
+
+		utils.E(filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != dir && (!recursive || d.Name() == "vendor" || strings.HasPrefix(d.Name(), ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") {
+				return nil
+			}
+
+			f, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			for _, finding := range chromedp.Lint(fset, f) {
+				found = true
+				fmt.Println(finding)
+			}
+			return nil
+		}))
+	}
+
+	if found {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,98 @@
+// Package chromedp is a compatibility layer that maps the common actions of the github.com/chromedp/chromedp
+// onto rod, to ease porting large chromedp codebases. Usually you only need to replace the import path and
+// the allocator, such as:
+//
+//	page := rod.New().MustConnect().MustPage()
+//	ctx, cancel := chromedp.NewContext(context.Background(), page)
+//	defer cancel()
+//
+//	var text string
+//	err := chromedp.Run(ctx,
+//		chromedp.Navigate("https://example.com"),
+//		chromedp.WaitVisible("h1", chromedp.ByQuery),
+//		chromedp.Text("h1", &text),
+//	)
+//
+// The actions that are not supported can be found by the [Lint], once the code works, it's recommended to
+// rewrite it with the rod api gradually, the [Page] returns the page of the ctx to help the mixing.
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xyjwsj/grod"
+)
+
+// ErrNoPage is an error that indicates the ctx isn't created by [NewContext].
+var ErrNoPage = errors.New("no rod page in the context, use chromedp.NewContext to create it")
+
+// Action is the same as the chromedp.Action.
+type Action interface {
+	Do(ctx context.Context) error
+}
+
+// ActionFunc is the same as the chromedp.ActionFunc.
+type ActionFunc func(ctx context.Context) error
+
+// Do interface.
+func (f ActionFunc) Do(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Tasks is the same as the chromedp.Tasks, the actions run in order.
+type Tasks []Action
+
+// Do interface.
+func (t Tasks) Do(ctx context.Context) error {
+	for _, a := range t {
+		if err := a.Do(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type pageKey struct{}
+
+// NewContext returns a ctx that runs the actions on the page, it replaces the chromedp.NewContext.
+func NewContext(parent context.Context, page *rod.Page) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	return context.WithValue(ctx, pageKey{}, page), cancel
+}
+
+// Page returns the page of the ctx, it returns nil if the ctx isn't created by [NewContext].
+func Page(ctx context.Context) *rod.Page {
+	p, _ := ctx.Value(pageKey{}).(*rod.Page)
+	return p
+}
+
+// Run the actions in order, it's the same as the chromedp.Run.
+func Run(ctx context.Context, actions ...Action) error {
+	if Page(ctx) == nil {
+		return ErrNoPage
+	}
+	return Tasks(actions).Do(ctx)
+}
+
+// page returns the page of the ctx that will be canceled with the ctx.
+func page(ctx context.Context) (*rod.Page, error) {
+	p := Page(ctx)
+	if p == nil {
+		return nil, ErrNoPage
+	}
+	return p.Context(ctx), nil
+}
+
+// Sleep is the same as the chromedp.Sleep.
+func Sleep(d time.Duration) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+			return nil
+		}
+	})
+}
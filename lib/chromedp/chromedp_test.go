@@ -0,0 +1,103 @@
+package chromedp_test
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/chromedp"
+	"github.com/xyjwsj/grod/lib/rodmock"
+	"github.com/ysmood/got"
+)
+
+func TestRun(t *testing.T) {
+	g := got.T(t)
+
+	m := rodmock.New()
+	m.Route("https://example.com", &rodmock.Document{
+		Title: "Example",
+		Nodes: map[string][]*rodmock.Node{
+			"h1": {{Tag: "h1", Text: "Hello", HTML: "<h1>Hello</h1>", Attributes: map[string]string{"id": "title"}}},
+		},
+	})
+
+	page := rod.New().Client(m).MustConnect().MustPage()
+
+	ctx, cancel := chromedp.NewContext(g.Context(), page)
+	defer cancel()
+
+	var title, location, text, html, id, class string
+	var hasID, hasClass bool
+
+	g.E(chromedp.Run(ctx,
+		chromedp.Navigate("https://example.com"),
+		chromedp.WaitVisible("h1", chromedp.ByQuery),
+		chromedp.WaitNotPresent("table", chromedp.ByQuery),
+		chromedp.Tasks{
+			chromedp.Title(&title),
+			chromedp.Location(&location),
+		},
+		chromedp.Text("h1", &text, chromedp.ByQuery),
+		chromedp.OuterHTML("h1", &html, chromedp.ByQuery),
+		chromedp.AttributeValue("h1", "id", &id, &hasID, chromedp.ByQuery),
+		chromedp.AttributeValue("h1", "class", &class, &hasClass, chromedp.ByQuery),
+		chromedp.Sleep(time.Millisecond),
+	))
+
+	g.Eq(title, "Example")
+	g.Eq(location, "https://example.com")
+	g.Eq(text, "Hello")
+	g.Eq(html, "<h1>Hello</h1>")
+	g.Eq(id, "title")
+	g.True(hasID)
+	g.Eq(class, "")
+	g.False(hasClass)
+
+	g.Eq(chromedp.Page(ctx), page)
+	g.Eq(chromedp.Run(context.Background(), chromedp.Reload()), chromedp.ErrNoPage)
+
+	cancel()
+	g.Is(chromedp.Run(ctx, chromedp.Sleep(time.Minute)), context.Canceled)
+}
+
+func TestLint(t *testing.T) {
+	g := got.T(t)
+
+	src := `package main
+
+import (
+	"context"
+
+	cdp "github.com/chromedp/chromedp"
+	"github.com/chromedp/cdproto/network"
+)
+
+func main() {
+	ctx, _ := cdp.NewContext(context.Background())
+	cdp.ListenTarget(ctx, func(ev interface{}) {})
+	_ = cdp.Run(ctx, cdp.Click("a"), cdp.MouseClickNode(nil))
+	_ = network.Enable()
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", src, 0)
+	g.E(err)
+
+	list := chromedp.Lint(fset, f)
+
+	msgs := []string{}
+	for _, l := range list {
+		msgs = append(msgs, l.String())
+	}
+	g.Eq(msgs, []string{
+		"main.go:6:2: github.com/chromedp/chromedp: replace it with github.com/xyjwsj/grod/lib/chromedp",
+		"main.go:7:2: github.com/chromedp/cdproto/network: use github.com/xyjwsj/grod/lib/proto",
+		"main.go:11:12: cdp.NewContext: use chromedp.NewContext(ctx, page) with a rod page",
+		"main.go:12:2: cdp.ListenTarget: use rod.Page.EachEvent",
+		"main.go:13:35: cdp.MouseClickNode: not supported, rewrite it with the rod api, " +
+			"use chromedp.Page to get the page of the ctx",
+	})
+}
@@ -0,0 +1,130 @@
+package chromedp
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// ImportPath of this package.
+const ImportPath = "github.com/xyjwsj/grod/lib/chromedp"
+
+// LintFinding is a problem found by [Lint] to port the code of the chromedp.
+type LintFinding struct {
+	Pos token.Position
+
+	// Name of the identifier, such as "chromedp.ListenTarget"
+	Name string
+
+	// Message of how to port it
+	Message string
+}
+
+// String interface.
+func (f *LintFinding) String() string {
+	return f.Pos.String() + ": " + f.Name + ": " + f.Message
+}
+
+// supported identifiers of the chromedp by this package.
+var supported = map[string]bool{
+	"Action": true, "ActionFunc": true, "Tasks": true, "Run": true, "Sleep": true, "QueryOption": true,
+	"BySearch": true, "ByQuery": true, "ByID": true, "ByJSPath": true,
+	"WaitReady": true, "WaitVisible": true, "WaitNotVisible": true, "WaitEnabled": true, "WaitNotPresent": true,
+	"Click": true, "DoubleClick": true, "Focus": true, "ScrollIntoView": true, "SendKeys": true,
+	"SetValue": true, "Clear": true, "Submit": true, "Text": true, "Value": true, "OuterHTML": true,
+	"InnerHTML": true, "AttributeValue": true, "SetAttributeValue": true, "Screenshot": true, "Evaluate": true,
+	"Navigate": true, "Reload": true, "NavigateBack": true, "NavigateForward": true, "Title": true,
+	"Location": true, "CaptureScreenshot": true, "FullScreenshot": true, "EmulateViewport": true,
+}
+
+// hints of the unsupported identifiers of the chromedp.
+var hints = map[string]string{
+	"NewContext":         "use chromedp.NewContext(ctx, page) with a rod page",
+	"NewExecAllocator":   "use the launcher.New to launch the browser and rod.New().ControlURL to connect it",
+	"NewRemoteAllocator": "use rod.New().ControlURL to connect the browser",
+	"Cancel":             "use the cancel func of the chromedp.NewContext, or rod.Browser.Close",
+	"ListenTarget":       "use rod.Page.EachEvent",
+	"ListenBrowser":      "use rod.Browser.EachEvent",
+	"Nodes":              "use rod.Page.Elements",
+	"NodeIDs":            "use rod.Page.Elements",
+	"Poll":               "use rod.Page.Wait",
+	"PollFunction":       "use rod.Page.Wait",
+	"KeyEvent":           "use rod.Element.Type or rod.Page.Keyboard",
+	"MouseClickXY":       "use rod.Page.Mouse",
+	"WaitSelected":       "use rod.Element.Wait",
+	"WaitNotEnabled":     "use rod.Element.Wait",
+	"Emulate":            "use rod.Page.Emulate",
+	"ExecPath":           "use launcher.Launcher.Bin",
+	"Flag":               "use launcher.Launcher.Set",
+	"Headless":           "use launcher.Launcher.Headless",
+	"NoSandbox":          "use launcher.Launcher.NoSandbox",
+	"UserDataDir":        "use launcher.Launcher.UserDataDir",
+	"WindowSize":         "use launcher.Launcher.WindowSize",
+	"ProxyServer":        "use launcher.Launcher.Proxy",
+	"UserAgent":          "use rod.Page.SetUserAgent",
+	"DisableGPU":         "use launcher.Launcher.Set(\"disable-gpu\")",
+}
+
+// Lint the file that uses the chromedp, it reports the imports to replace and the identifiers that this package
+// doesn't support with the hints of the rod api. The lib/chromedp/chromedp-lint is the command line tool of it.
+func Lint(fset *token.FileSet, f *ast.File) []*LintFinding {
+	list := []*LintFinding{}
+	names := map[string]bool{}
+
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+
+		switch {
+		case path == "github.com/chromedp/chromedp":
+			name := "chromedp"
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			names[name] = true
+
+			list = append(list, &LintFinding{
+				Pos:     fset.Position(imp.Pos()),
+				Name:    path,
+				Message: "replace it with " + ImportPath,
+			})
+
+		case strings.HasPrefix(path, "github.com/chromedp/cdproto"):
+			list = append(list, &LintFinding{
+				Pos:     fset.Position(imp.Pos()),
+				Name:    path,
+				Message: "use github.com/xyjwsj/grod/lib/proto",
+			})
+		}
+	}
+
+	if len(names) == 0 {
+		return list
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || !names[x.Name] || supported[sel.Sel.Name] {
+			return true
+		}
+
+		hint, has := hints[sel.Sel.Name]
+		if !has {
+			hint = "not supported, rewrite it with the rod api, use chromedp.Page to get the page of the ctx"
+		}
+
+		list = append(list, &LintFinding{
+			Pos:     fset.Position(sel.Pos()),
+			Name:    x.Name + "." + sel.Sel.Name,
+			Message: hint,
+		})
+		return true
+	})
+
+	return list
+}
@@ -0,0 +1,132 @@
+package chromedp
+
+import (
+	"context"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// Navigate is the same as the chromedp.Navigate, it waits for the page to load.
+func Navigate(u string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = p.Navigate(u)
+		if err != nil {
+			return err
+		}
+		return p.WaitLoad()
+	})
+}
+
+// Reload is the same as the chromedp.Reload.
+func Reload() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+		return p.Reload()
+	})
+}
+
+// NavigateBack is the same as the chromedp.NavigateBack.
+func NavigateBack() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+		return p.NavigateBack()
+	})
+}
+
+// NavigateForward is the same as the chromedp.NavigateForward.
+func NavigateForward() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+		return p.NavigateForward()
+	})
+}
+
+// Title is the same as the chromedp.Title.
+func Title(title *string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+
+		info, err := p.Info()
+		if err != nil {
+			return err
+		}
+		*title = info.Title
+		return nil
+	})
+}
+
+// Location is the same as the chromedp.Location.
+func Location(u *string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+
+		info, err := p.Info()
+		if err != nil {
+			return err
+		}
+		*u = info.URL
+		return nil
+	})
+}
+
+// CaptureScreenshot is the same as the chromedp.CaptureScreenshot, it captures the png of the viewport.
+func CaptureScreenshot(buf *[]byte) Action {
+	return ActionFunc(func(ctx context.Context) (err error) {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+		*buf, err = p.Screenshot(false, nil)
+		return
+	})
+}
+
+// FullScreenshot is the same as the chromedp.FullScreenshot, it captures the jpeg of the whole page.
+func FullScreenshot(buf *[]byte, quality int) Action {
+	return ActionFunc(func(ctx context.Context) (err error) {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+		*buf, err = p.Screenshot(true, &proto.PageCaptureScreenshot{
+			Format:  proto.PageCaptureScreenshotFormatJpeg,
+			Quality: &quality,
+		})
+		return
+	})
+}
+
+// EmulateViewport is the same as the chromedp.EmulateViewport.
+func EmulateViewport(width, height int64) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+		return p.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+			Width:             int(width),
+			Height:            int(height),
+			DeviceScaleFactor: 1,
+		})
+	})
+}
@@ -0,0 +1,275 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// by is how to query the elements.
+type by int
+
+const (
+	bySearch by = iota
+	byQuery
+	byJSPath
+)
+
+// Selector of the elements, check [QueryOption].
+type Selector struct {
+	sel string
+	by  by
+}
+
+// QueryOption is the same as the chromedp.QueryOption, such as [ByQuery].
+type QueryOption func(*Selector)
+
+// BySearch queries the element via [rod.Page.Search], the selector can be a css selector, a xpath, or a text.
+// It's the default like the chromedp.
+func BySearch(s *Selector) { s.by = bySearch }
+
+// ByQuery queries the element via [rod.Page.Element] with the css selector.
+func ByQuery(s *Selector) { s.by = byQuery }
+
+// ByID is the same as [ByQuery], the selector should be like "#id".
+func ByID(s *Selector) { s.by = byQuery }
+
+// ByJSPath queries the element via the js expression, such as "document.body".
+func ByJSPath(s *Selector) { s.by = byJSPath }
+
+func newSelector(sel string, opts []QueryOption) *Selector {
+	s := &Selector{sel: sel}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// element waits for the element of the selector to appear.
+func (s *Selector) element(p *rod.Page) (*rod.Element, error) {
+	switch s.by {
+	case byQuery:
+		return p.Element(s.sel)
+	case byJSPath:
+		return p.ElementByJS(rod.Eval(fmt.Sprintf("() => (%s)", s.sel)))
+	default:
+		res, err := p.Search(s.sel)
+		if err != nil {
+			return nil, err
+		}
+		res.Release()
+		return res.First, nil
+	}
+}
+
+// has returns true if the element of the selector exists, it doesn't wait.
+func (s *Selector) has(p *rod.Page) (bool, error) {
+	p = p.Sleeper(rod.NotFoundSleeper)
+	_, err := s.element(p)
+	if errors.Is(err, &rod.ElementNotFoundError{}) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// elementAction runs the fn on the element of the selector.
+func elementAction(sel string, opts []QueryOption, fn func(el *rod.Element) error) Action {
+	s := newSelector(sel, opts)
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+
+		el, err := s.element(p)
+		if err != nil {
+			return err
+		}
+		return fn(el)
+	})
+}
+
+// WaitReady is the same as the chromedp.WaitReady, it waits for the element to appear.
+func WaitReady(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(_ *rod.Element) error { return nil })
+}
+
+// WaitVisible is the same as the chromedp.WaitVisible.
+func WaitVisible(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error { return el.WaitVisible() })
+}
+
+// WaitNotVisible is the same as the chromedp.WaitNotVisible.
+func WaitNotVisible(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error { return el.WaitInvisible() })
+}
+
+// WaitEnabled is the same as the chromedp.WaitEnabled.
+func WaitEnabled(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error { return el.WaitEnabled() })
+}
+
+// WaitNotPresent is the same as the chromedp.WaitNotPresent.
+func WaitNotPresent(sel string, opts ...QueryOption) Action {
+	s := newSelector(sel, opts)
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+
+		return utils.Retry(ctx, utils.BackoffSleeper(100*time.Millisecond, time.Second, nil), func() (bool, error) {
+			has, err := s.has(p)
+			return !has || err != nil, err
+		})
+	})
+}
+
+// Click is the same as the chromedp.Click.
+func Click(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error {
+		return el.Click(proto.InputMouseButtonLeft, 1)
+	})
+}
+
+// DoubleClick is the same as the chromedp.DoubleClick.
+func DoubleClick(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error {
+		return el.Click(proto.InputMouseButtonLeft, 2)
+	})
+}
+
+// Focus is the same as the chromedp.Focus.
+func Focus(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error { return el.Focus() })
+}
+
+// ScrollIntoView is the same as the chromedp.ScrollIntoView.
+func ScrollIntoView(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error { return el.ScrollIntoView() })
+}
+
+// SendKeys is the same as the chromedp.SendKeys, the v is inputted via [rod.Element.Input],
+// so the special keys of the chromedp/kb aren't supported, use the [rod.Element.Type] instead.
+func SendKeys(sel, v string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error { return el.Input(v) })
+}
+
+// SetValue is the same as the chromedp.SetValue.
+func SetValue(sel, v string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error {
+		_, err := el.Eval(`function (v) { this.value = v }`, v)
+		return err
+	})
+}
+
+// Clear is the same as the chromedp.Clear.
+func Clear(sel string, opts ...QueryOption) Action {
+	return SetValue(sel, "", opts...)
+}
+
+// Submit is the same as the chromedp.Submit, it submits the form of the element.
+func Submit(sel string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error {
+		_, err := el.Eval(`function () { (this.form || this).submit() }`)
+		return err
+	})
+}
+
+// Text is the same as the chromedp.Text.
+func Text(sel string, text *string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) (err error) {
+		*text, err = el.Text()
+		return
+	})
+}
+
+// Value is the same as the chromedp.Value.
+func Value(sel string, value *string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error {
+		v, err := el.Property("value")
+		if err != nil {
+			return err
+		}
+		*value = v.Str()
+		return nil
+	})
+}
+
+// OuterHTML is the same as the chromedp.OuterHTML.
+func OuterHTML(sel string, html *string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) (err error) {
+		*html, err = el.HTML()
+		return
+	})
+}
+
+// InnerHTML is the same as the chromedp.InnerHTML.
+func InnerHTML(sel string, html *string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error {
+		v, err := el.Property("innerHTML")
+		if err != nil {
+			return err
+		}
+		*html = v.Str()
+		return nil
+	})
+}
+
+// AttributeValue is the same as the chromedp.AttributeValue.
+func AttributeValue(sel, name string, value *string, ok *bool, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error {
+		v, err := el.Attribute(name)
+		if err != nil {
+			return err
+		}
+
+		*value = ""
+		if v != nil {
+			*value = *v
+		}
+		if ok != nil {
+			*ok = v != nil
+		}
+		return nil
+	})
+}
+
+// SetAttributeValue is the same as the chromedp.SetAttributeValue.
+func SetAttributeValue(sel, name, value string, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) error {
+		_, err := el.Eval(`function (k, v) { this.setAttribute(k, v) }`, name, value)
+		return err
+	})
+}
+
+// Screenshot is the same as the chromedp.Screenshot, it captures the png of the element.
+func Screenshot(sel string, buf *[]byte, opts ...QueryOption) Action {
+	return elementAction(sel, opts, func(el *rod.Element) (err error) {
+		*buf, err = el.Screenshot(proto.PageCaptureScreenshotFormatPng, 0)
+		return
+	})
+}
+
+// Evaluate is the same as the chromedp.Evaluate, the expression is evaluated in the page,
+// the result will be json decoded into the res if it's not nil.
+func Evaluate(expression string, res interface{}) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := page(ctx)
+		if err != nil {
+			return err
+		}
+
+		obj, err := p.Eval(fmt.Sprintf("() => (%s)", expression))
+		if err != nil || res == nil {
+			return err
+		}
+		return json.Unmarshal([]byte(obj.Value.JSON("", "")), res)
+	})
+}
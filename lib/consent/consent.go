@@ -0,0 +1,250 @@
+// Package consent dismisses the cookie consent banners of the common consent management platforms (CMPs),
+// such as OneTrust, Cookiebot, and Didomi. It's heuristic, the banners are detected and dismissed via
+// the css selectors of the [Rules], such as:
+//
+//	stop := consent.Enable(browser, &consent.Options{Policy: consent.Reject})
+//	defer stop()
+//
+//	page := browser.MustPage("https://example.com").MustWaitLoad()
+//
+// Use [Handle] to dismiss the banner of a single page.
+package consent
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// Policy to dismiss the banners.
+type Policy string
+
+const (
+	// Reject clicks the "reject all" button of the banner.
+	Reject Policy = "reject"
+
+	// Accept clicks the "accept all" button of the banner.
+	Accept Policy = "accept"
+)
+
+// Rule to detect and dismiss the banner of a CMP.
+type Rule struct {
+	// Name of the CMP
+	Name string
+
+	// Detect is the css selector of the banner, the rule applies when the banner is visible
+	Detect string
+
+	// Reject is the css selectors of the "reject all" buttons, the first visible one is clicked
+	Reject []string
+
+	// Accept is the css selectors of the "accept all" buttons, the first visible one is clicked
+	Accept []string
+}
+
+// buttons returns the selectors of the buttons for the policy.
+func (r *Rule) buttons(policy Policy) []string {
+	if policy == Accept {
+		return r.Accept
+	}
+	return r.Reject
+}
+
+var (
+	// OneTrust is the rule of https://www.onetrust.com
+	OneTrust = &Rule{
+		Name:   "OneTrust",
+		Detect: "#onetrust-banner-sdk",
+		Reject: []string{"#onetrust-reject-all-handler", ".ot-pc-refuse-all-handler"},
+		Accept: []string{"#onetrust-accept-btn-handler", "#accept-recommended-btn-handler"},
+	}
+
+	// Cookiebot is the rule of https://www.cookiebot.com
+	Cookiebot = &Rule{
+		Name:   "Cookiebot",
+		Detect: "#CybotCookiebotDialog",
+		Reject: []string{"#CybotCookiebotDialogBodyButtonDecline"},
+		Accept: []string{
+			"#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll",
+			"#CybotCookiebotDialogBodyButtonAccept",
+		},
+	}
+
+	// Didomi is the rule of https://www.didomi.io
+	Didomi = &Rule{
+		Name:   "Didomi",
+		Detect: "#didomi-notice",
+		Reject: []string{"#didomi-notice-disagree-button", ".didomi-continue-without-agreeing"},
+		Accept: []string{"#didomi-notice-agree-button"},
+	}
+)
+
+// Rules is the default rules of the [Options].
+var Rules = []*Rule{OneTrust, Cookiebot, Didomi}
+
+// ErrNoButton is returned by [Handle] if the banner is detected but the button for the policy isn't found,
+// such as the banner doesn't offer the "reject all" button.
+var ErrNoButton = errors.New("no button of the consent banner for the policy")
+
+// Options for [Handle] and [Enable].
+type Options struct {
+	// Policy to dismiss the banners, defaults to [Reject]
+	Policy Policy
+
+	// Rules to detect the banners, defaults to [Rules]
+	Rules []*Rule
+
+	// Timeout to wait for the banner to show up after the page is loaded, defaults to 5 seconds.
+	// The banners are usually rendered by the async scripts after the load event.
+	Timeout time.Duration
+
+	// OnHandle is called by [Enable] after a page is handled, the rule is nil if no banner shows up.
+	// The pages are handled concurrently, it may be called from different goroutines.
+	OnHandle func(p *rod.Page, rule *Rule, err error)
+}
+
+func (o *Options) defaults() *Options {
+	opts := Options{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Policy == "" {
+		opts.Policy = Reject
+	}
+	if opts.Rules == nil {
+		opts.Rules = Rules
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &opts
+}
+
+// Handle waits for a banner of the rules to show up on the page and clicks its button for the policy.
+// It returns the rule of the dismissed banner, the rule is nil if no banner shows up before the timeout.
+// If a banner shows up but the button isn't found before the timeout, the [ErrNoButton] is returned.
+// The opts can be nil.
+func Handle(p *rod.Page, opts *Options) (*Rule, error) {
+	opts = opts.defaults()
+
+	var detected, clicked *Rule
+
+	page, cancel := p.WithCancel()
+	defer cancel()
+
+	page = page.Timeout(opts.Timeout)
+	ctx := page.GetContext()
+
+	err := utils.Retry(ctx, utils.BackoffSleeper(100*time.Millisecond, time.Second, nil), func() (bool, error) {
+		res, err := page.Eval(jsHandle, rulesJS(opts.Rules, opts.Policy))
+		if err != nil {
+			return true, err
+		}
+
+		i := res.Value.Get("rule").Int()
+		if i < 0 {
+			return false, nil
+		}
+
+		detected = opts.Rules[i]
+		if res.Value.Get("clicked").Bool() {
+			clicked = detected
+			return true, nil
+		}
+		return false, nil
+	})
+
+	switch {
+	case clicked != nil:
+		return clicked, nil
+	case ctx.Err() != nil && p.GetContext().Err() == nil:
+		if detected != nil {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoButton, detected.Name, opts.Policy)
+		}
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// Enable handles the banners of the pages of the browser on each page load until stop is called,
+// check [Handle]. The opts can be nil. Each call of it toggles the handling for the browser independently,
+// such as enabling it for one browser and not for the other.
+// The page passed to the [Options.OnHandle] is created from the session of the load event.
+// Only the banners in the documents of the pages are handled, the banners rendered inside iframes,
+// such as the ones of some CMPs that isolate the banner in a cross-origin frame, are not handled,
+// use [Handle] with the page of the iframe for them, such as:
+//
+//	consent.Handle(page.MustElement("iframe#cmp").MustFrame(), nil)
+func Enable(b *rod.Browser, opts *Options) (stop func()) {
+	opts = opts.defaults()
+
+	b, cancel := b.WithCancel()
+
+	// the pages are resolved from the session ids, so no cdp call is needed on each load,
+	// the callbacks are called sequentially, so the map needs no lock
+	pages := map[proto.TargetSessionID]*rod.Page{}
+
+	wait := b.EachEvent(func(_ *proto.PageLoadEventFired, id proto.TargetSessionID) {
+		p, has := pages[id]
+		if !has {
+			p = b.PageFromSession(id)
+			pages[id] = p
+		}
+
+		go func() {
+			rule, err := Handle(p, opts)
+			if opts.OnHandle != nil && b.GetContext().Err() == nil {
+				opts.OnHandle(p, rule, err)
+			}
+		}()
+	}, func(e *proto.TargetDetachedFromTarget) {
+		delete(pages, e.SessionID)
+	})
+
+	go wait()
+
+	return cancel
+}
+
+// ruleJS is the rule passed to the jsHandle.
+type ruleJS struct {
+	Detect  string   `json:"detect"`
+	Buttons []string `json:"buttons"`
+}
+
+func rulesJS(rules []*Rule, policy Policy) []ruleJS {
+	list := make([]ruleJS, 0, len(rules))
+	for _, r := range rules {
+		list = append(list, ruleJS{r.Detect, r.buttons(policy)})
+	}
+	return list
+}
+
+// jsHandle finds the first visible banner of the rules and clicks the first visible button of it,
+// it returns the index of the rule, -1 if no banner is visible.
+const jsHandle = `(rules) => {
+	const visible = (el) => {
+		if (!el || el.getClientRects().length === 0) return false
+		const style = getComputedStyle(el)
+		return style.visibility !== 'hidden' && style.display !== 'none' && style.opacity !== '0'
+	}
+
+	for (let i = 0; i < rules.length; i++) {
+		if (!visible(document.querySelector(rules[i].detect))) continue
+
+		for (const selector of rules[i].buttons || []) {
+			const btn = document.querySelector(selector)
+			if (visible(btn)) {
+				btn.click()
+				return { rule: i, clicked: true }
+			}
+		}
+		return { rule: i, clicked: false }
+	}
+	return { rule: -1, clicked: false }
+}`
@@ -0,0 +1,147 @@
+package consent_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/consent"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/got"
+)
+
+var setup = got.Setup(nil)
+
+// banner is a fake banner of the rule that records the clicked button into window.clicked,
+// the banner is rendered after the load event like the real CMPs.
+func banner(rule *consent.Rule, buttons ...string) string {
+	html := `<html><body><script>
+		window.onload = () => setTimeout(() => {
+			const banner = document.createElement('div')
+			banner.setAttribute('style', 'position: fixed; bottom: 0')`
+
+	if rule.Detect[0] == '#' {
+		html += `
+			banner.id = '` + rule.Detect[1:] + `'`
+	}
+
+	for _, b := range buttons {
+		html += `
+			{
+				const btn = document.createElement('button')
+				btn.textContent = 'btn'
+				btn.setAttribute('` + attr(b) + `', '` + b[1:] + `')
+				btn.onclick = () => { window.clicked = '` + b + `'; banner.remove() }
+				banner.appendChild(btn)
+			}`
+	}
+
+	return html + `
+			document.body.appendChild(banner)
+		}, 300)
+	</script></body></html>`
+}
+
+func attr(selector string) string {
+	if selector[0] == '.' {
+		return "class"
+	}
+	return "id"
+}
+
+func TestHandle(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/onetrust", ".html", banner(consent.OneTrust, consent.OneTrust.Accept[0], consent.OneTrust.Reject[0]))
+	s.Route("/cookiebot", ".html", banner(consent.Cookiebot, consent.Cookiebot.Accept[1], consent.Cookiebot.Reject[0]))
+	s.Route("/didomi", ".html", banner(consent.Didomi, consent.Didomi.Accept[0], consent.Didomi.Reject[1]))
+	s.Route("/no-reject", ".html", banner(consent.Didomi, consent.Didomi.Accept[0]))
+	s.Route("/none", ".html", `<html><body>ok</body></html>`)
+
+	browser := rod.New().MustConnect()
+	defer browser.MustClose()
+
+	page := browser.MustPage()
+
+	for _, c := range []struct {
+		path   string
+		policy consent.Policy
+		rule   *consent.Rule
+		button string
+	}{
+		{"/onetrust", consent.Reject, consent.OneTrust, consent.OneTrust.Reject[0]},
+		{"/onetrust", consent.Accept, consent.OneTrust, consent.OneTrust.Accept[0]},
+		{"/cookiebot", "", consent.Cookiebot, consent.Cookiebot.Reject[0]},
+		{"/cookiebot", consent.Accept, consent.Cookiebot, consent.Cookiebot.Accept[1]},
+		{"/didomi", consent.Reject, consent.Didomi, consent.Didomi.Reject[1]},
+	} {
+		page.MustNavigate(s.URL(c.path)).MustWaitLoad()
+
+		rule, err := consent.Handle(page, &consent.Options{Policy: c.policy})
+		g.E(err)
+		g.Eq(rule, c.rule)
+		g.Eq(page.MustEval(`() => window.clicked`).Str(), c.button)
+	}
+
+	page.MustNavigate(s.URL("/no-reject")).MustWaitLoad()
+	_, err := consent.Handle(page, &consent.Options{Timeout: time.Second})
+	g.Is(err, consent.ErrNoButton)
+	g.Has(err.Error(), "Didomi reject")
+
+	page.MustNavigate(s.URL("/none")).MustWaitLoad()
+	rule, err := consent.Handle(page, &consent.Options{Timeout: time.Second})
+	g.E(err)
+	g.Nil(rule)
+
+	// only the custom rules are used
+	page.MustNavigate(s.URL("/onetrust")).MustWaitLoad()
+	rule, err = consent.Handle(page, &consent.Options{Rules: []*consent.Rule{consent.Didomi}, Timeout: time.Second})
+	g.E(err)
+	g.Nil(rule)
+
+	_, err = consent.Handle(page.Timeout(time.Millisecond), nil)
+	g.Err(err)
+}
+
+func TestEnable(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", banner(consent.OneTrust, consent.OneTrust.Accept[0], consent.OneTrust.Reject[0]))
+
+	browser := rod.New().MustConnect()
+	defer browser.MustClose()
+
+	handled := make(chan *consent.Rule, 10)
+	sessions := make(chan proto.TargetSessionID, 10)
+
+	stop := consent.Enable(browser, &consent.Options{
+		Policy: consent.Accept,
+		OnHandle: func(p *rod.Page, rule *consent.Rule, err error) {
+			g.E(err)
+			sessions <- p.SessionID
+			handled <- rule
+		},
+	})
+
+	page := browser.MustPage(s.URL())
+	g.Eq(<-handled, consent.OneTrust)
+	g.Eq(<-sessions, page.SessionID)
+	g.Eq(page.MustEval(`() => window.clicked`).Str(), consent.OneTrust.Accept[0])
+
+	// each load is handled
+	page.MustReload()
+	g.Eq(<-handled, consent.OneTrust)
+	g.Eq(<-sessions, page.SessionID)
+
+	stop()
+
+	// the banner stays after the handling is disabled
+	page.MustReload()
+	page.MustElement(consent.OneTrust.Detect)
+	utils.Sleep(1)
+	g.Len(handled, 0)
+	g.True(page.MustHas(consent.OneTrust.Detect))
+}
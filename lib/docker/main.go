@@ -103,7 +103,7 @@ func description(dev bool) string {
 	return `--label=org.opencontainers.image.description=https://github.com/xyjwsj/grod/blob/` + headSha + "/lib/docker/" + f
 }
 
-const registry = "ghcr.io/go-rod/rod"
+const registry = "ghcr.io/xyjwsj/grod"
 
 type archType int
 
@@ -16,7 +16,7 @@ func main() {
 	//
 	// Or use docker:
 	//
-	//     docker run -p 9222:9222 ghcr.io/go-rod/rod chrome --headless --no-sandbox --remote-debugging-port=9222 --remote-debugging-address=0.0.0.0
+	//     docker run -p 9222:9222 ghcr.io/xyjwsj/grod chrome --headless --no-sandbox --remote-debugging-port=9222 --remote-debugging-address=0.0.0.0
 	//
 	u := launcher.MustResolveURL("")
 
@@ -0,0 +1,139 @@
+// Package hijack contains composable presets of handlers for [rod.HijackRouter].
+// They are useful to standardize noisy third-party behaviors in tests, such as:
+//
+//	router := page.HijackRequests()
+//	utils.E(hijack.Mount(router, hijack.BlockTrackers(), hijack.StubImagePixels()))
+//	go router.Run()
+package hijack
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// Preset adds a group of handlers to the router.
+type Preset func(r *rod.HijackRouter) error
+
+// Mount the presets on the router. Call it before the [rod.HijackRouter.Run].
+func Mount(r *rod.HijackRouter, presets ...Preset) error {
+	for _, p := range presets {
+		err := p(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trackers is the default url patterns for [BlockTrackers].
+var Trackers = []string{
+	"*google-analytics.com/*",
+	"*googletagmanager.com/*",
+	"*googlesyndication.com/*",
+	"*doubleclick.net/*",
+	"*connect.facebook.net/*",
+	"*hotjar.com/*",
+	"*segment.io/*",
+	"*cdn.segment.com/*",
+	"*mixpanel.com/*",
+	"*amplitude.com/*",
+	"*clarity.ms/*",
+	"*newrelic.com/*",
+	"*nr-data.net/*",
+	"*sentry.io/*",
+}
+
+// BlockTrackers fails the requests that match the url patterns with [proto.NetworkErrorReasonBlockedByClient].
+// If patterns is empty, [Trackers] will be used.
+// The doc of the pattern is the same as [proto.FetchRequestPattern.URLPattern].
+func BlockTrackers(patterns ...string) Preset {
+	if len(patterns) == 0 {
+		patterns = Trackers
+	}
+
+	return func(r *rod.HijackRouter) error {
+		for _, p := range patterns {
+			err := r.Add(p, "", func(h *rod.Hijack) {
+				h.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Pixel is a 1x1 transparent gif.
+var Pixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// StubImagePixels responds every image request with the [Pixel],
+// so the rendering won't depend on the network or the content of the images.
+func StubImagePixels() Preset {
+	return func(r *rod.HijackRouter) error {
+		return r.Add("*", proto.NetworkResourceTypeImage, func(h *rod.Hijack) {
+			h.Response.SetHeader("Content-Type", "image/gif")
+			h.Response.SetBody(Pixel)
+		})
+	}
+}
+
+// FixTimeEndpoint responds the requests that match the url pattern with the fixed time t,
+// it's useful to stub the endpoints that the page uses to sync the clock with the server.
+// The body is a json like:
+//
+//	{"time": "2006-01-02T15:04:05Z", "unix": 1136214245, "unixMilli": 1136214245000}
+//
+// The "Date" header is also set to t.
+func FixTimeEndpoint(pattern string, t time.Time) Preset {
+	return func(r *rod.HijackRouter) error {
+		return r.Add(pattern, "", func(h *rod.Hijack) {
+			h.Response.SetHeader(
+				"Content-Type", "application/json",
+				"Date", t.UTC().Format(http.TimeFormat),
+			)
+			h.Response.SetBody(map[string]interface{}{
+				"time":      t.Format(time.RFC3339Nano),
+				"unix":      t.Unix(),
+				"unixMilli": t.UnixMilli(),
+			})
+		})
+	}
+}
+
+// ClientTLS sends the requests that match the url pattern with the TLS config from Go instead of the browser,
+// so pages can access the mTLS-protected origins with the client certificates in the config, such as:
+//
+//	cert, _ := tls.LoadX509KeyPair("client.crt", "client.key")
+//	hijack.ClientTLS("https://internal.example.com/*", &tls.Config{Certificates: []tls.Certificate{cert}})
+//
+// Redirects won't be followed, the browser will handle them.
+func ClientTLS(pattern string, config *tls.Config) Preset {
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: config,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return func(r *rod.HijackRouter) error {
+		return r.Add(pattern, "", func(h *rod.Hijack) {
+			err := h.LoadResponse(client, true)
+			if err != nil {
+				h.OnError(err)
+				h.Response.Fail(proto.NetworkErrorReasonConnectionFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package hijack_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/hijack"
+	"github.com/ysmood/got"
+)
+
+var setup = got.Setup(nil)
+
+func TestPresets(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html>
+		<img src="/img.png">
+		<script>
+			window.tracked = fetch('/track').then(() => 'ok', () => 'blocked')
+			window.now = fetch('/now').then(res => res.json())
+		</script>
+	</html>`)
+	s.Route("/img.png", ".png", "not an image")
+	s.Route("/track", ".txt", "ok")
+
+	browser := rod.New().MustConnect()
+	defer browser.MustClose()
+
+	page := browser.MustPage()
+
+	router := page.HijackRequests()
+	defer router.MustStop()
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	g.E(hijack.Mount(router,
+		hijack.BlockTrackers("*/track"),
+		hijack.StubImagePixels(),
+		hijack.FixTimeEndpoint("*/now", now),
+	))
+
+	go router.Run()
+
+	page.MustNavigate(s.URL()).MustWaitLoad()
+
+	g.Eq(page.MustEval(`() => window.tracked`).Str(), "blocked")
+	g.Eq(page.MustEval(`() => document.querySelector('img').naturalWidth`).Int(), 1)
+
+	res := page.MustEval(`() => window.now`)
+	g.Eq(res.Get("time").Str(), "2020-01-02T03:04:05Z")
+	g.Eq(res.Get("unix").Int(), now.Unix())
+}
+
+func TestMountErr(t *testing.T) {
+	g := setup(t)
+
+	errMount := errors.New("err")
+
+	err := hijack.Mount(nil, func(*rod.HijackRouter) error {
+		return errMount
+	})
+	g.Is(err, errMount)
+}
+
+func TestClientTLS(t *testing.T) {
+	g := setup(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.E(err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	g.E(err)
+	clientCert, err := x509.ParseCertificate(der)
+	g.E(err)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html>" + r.TLS.PeerCertificates[0].Subject.CommonName + "</html>"))
+	}))
+	s.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs} //nolint: gosec
+	s.StartTLS()
+	defer s.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(s.Certificate())
+
+	browser := rod.New().MustConnect()
+	defer browser.MustClose()
+
+	page := browser.MustPage()
+
+	router := page.HijackRequests()
+	defer router.MustStop()
+
+	g.E(hijack.Mount(router, hijack.ClientTLS(s.URL+"/*", &tls.Config{ //nolint: gosec
+		RootCAs:      rootCAs,
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})))
+
+	go router.Run()
+
+	page.MustNavigate(s.URL + "/").MustWaitLoad()
+	g.Eq(page.MustElement("html").MustText(), "client")
+
+	// without the client certificate
+	router.MustRemove(s.URL + "/*")
+	g.E(hijack.Mount(router, hijack.ClientTLS(s.URL+"/*", &tls.Config{RootCAs: rootCAs}))) //nolint: gosec
+	g.Err(page.Navigate(s.URL + "/"))
+}
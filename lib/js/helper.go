@@ -226,6 +226,69 @@ var ExposeFunc = &Function{
 	Dependencies: []*Function{},
 }
 
+// ObserveMutations ...
+var ObserveMutations = &Function{
+	Name:         "observeMutations",
+	Definition:   `function(t,n,o){const r=e=>({nodeName:e.nodeName,id:e.id||"",text:(e.textContent||"").slice(0,1e3)}),e=()=>{var e=t?document.querySelector(t):document.documentElement;if(!e)return!1;const i=new MutationObserver(e=>{window[n](JSON.stringify(e.map(e=>({type:e.type,target:r(e.target),addedNodes:Array.from(e.addedNodes,r),removedNodes:Array.from(e.removedNodes,r),attributeName:e.attributeName||"",oldValue:e.oldValue||""}))))});return i.observe(e,o),window[n+"_observer"]=i,!0};e()||document.addEventListener("DOMContentLoaded",e)}`,
+	Dependencies: []*Function{},
+}
+
+// SetOriginTrialTokens ...
+var SetOriginTrialTokens = &Function{
+	Name:         "setOriginTrialTokens",
+	Definition:   `function(t){const e=()=>{for(const n of t){const o=document.createElement("meta");o.httpEquiv="origin-trial",o.content=n,(document.head||document.documentElement).appendChild(o)}};if(document.documentElement)e();else{const n=new MutationObserver(()=>{document.documentElement&&(n.disconnect(),e())});n.observe(document,{childList:!0})}}`,
+	Dependencies: []*Function{},
+}
+
+// ObserveLongTasks ...
+var ObserveLongTasks = &Function{
+	Name:         "observeLongTasks",
+	Definition:   `function(t){if(!PerformanceObserver.supportedEntryTypes.includes("longtask"))return!1;const e=new PerformanceObserver(e=>window[t](JSON.stringify(e.getEntries().map(e=>({startTime:e.startTime,duration:e.duration,name:e.name})))));return e.observe({type:"longtask"}),window[t+"_stop"]=()=>e.disconnect(),!0}`,
+	Dependencies: []*Function{},
+}
+
+// RecordCanvas ...
+var RecordCanvas = &Function{
+	Name:         "recordCanvas",
+	Definition:   `function(){const r=window.CanvasRenderingContext2D&&CanvasRenderingContext2D.prototype;if(!r||r.__rodRecordCanvas)return;r.__rodRecordCanvas=!0;const o=e=>e===null||["number","string","boolean"].includes(typeof e)?e:Array.isArray(e)?e.map(o):String(e),i=1e4,s=(e,t,n,a)=>{const c=e.canvas;c.__rodCanvasLog||(c.__rodCanvasLog=[]);const l=c.__rodCanvasLog;l.push({name:t,args:Array.from(n,o),setter:a}),l.length>i&&l.splice(0,l.length-i)},d=/^(get|is|create|measureText$)/;for(const e of Object.getOwnPropertyNames(r)){const t=Object.getOwnPropertyDescriptor(r,e);if(typeof t.value=="function"&&e!=="constructor"){if(d.test(e))continue;const n=t.value;r[e]=function(...a){return s(this,e,a,!1),n.apply(this,a)}}else if(t.set){const n=t.set;Object.defineProperty(r,e,{...t,set(a){s(this,e,[a],!0),n.call(this,a)}})}}}`,
+	Dependencies: []*Function{},
+}
+
+// CanvasCommandsLog ...
+var CanvasCommandsLog = &Function{
+	Name:         "canvasCommandsLog",
+	Definition:   `function(e){const t=this.__rodCanvasLog||[];return e&&(this.__rodCanvasLog=[]),t}`,
+	Dependencies: []*Function{},
+}
+
+// Hydrated ...
+var Hydrated = &Function{
+	Name:         "hydrated",
+	Definition:   `function(n){const o=()=>document.body?[document,document.body,...document.body.children]:[document],r=(e,t)=>Object.keys(e).find(n=>t.some(t=>n.startsWith(t))),t={react:{detect:()=>!!(window.__NEXT_DATA__||document.querySelector("[data-reactroot], #__next")),hydrated:()=>o().some(e=>{if(e._reactRootContainer)return!0;const t=r(e,["__reactContainer$"]),n=t&&e[t],s=n&&n.stateNode&&n.stateNode.current.memoizedState;return!!s&&s.isDehydrated===!1})},vue:{detect:()=>!!(window.__NUXT__||document.querySelector("[data-server-rendered], #__nuxt")),hydrated:()=>!!document.querySelector("[data-v-app]")||o().some(e=>r(e,["__vue_app__","__vue__"]))},svelte:{detect:()=>!!document.querySelector("[data-sveltekit-preload-data], [data-svelte-h]"),hydrated:()=>Array.from(document.querySelectorAll("a, button, input, select, textarea, form, [tabindex]")).some(e=>r(e,["__click","__input","__change","__submit"]))||!!window.__svelte&&document.readyState==="complete"}};if(n)return t[n].hydrated();const e=Object.values(t).filter(e=>e.detect());return e.length?e.every(e=>e.hydrated()):document.readyState==="complete"}`,
+	Dependencies: []*Function{},
+}
+
+// OverrideExperiments ...
+var OverrideExperiments = &Function{
+	Name:         "overrideExperiments",
+	Definition:   `function(e){const s=e=>typeof e=="string"?e:JSON.stringify(e),c=e=>e!==null&&typeof e=="object",r={};for(const[n,o]of Object.entries(e)){const t=n.indexOf(":"),i=n.slice(0,t),l=n.slice(t+1);if(i==="cookie"){const u=encodeURIComponent(l).replace(/%(2[346B]|5E|60|7C)/g,decodeURIComponent).replace(/[()]/g,escape),d=encodeURIComponent(s(o)).replace(/%(2[346BF]|3[AC-F]|40|5[BDE]|60|7[BCD])/g,decodeURIComponent);document.cookie=u+"="+d+"; path=/"}else if(i==="localStorage"||i==="sessionStorage")try{window[i].setItem(l,s(o))}catch(e){}else if(i==="window"){const[a,...f]=l.split(".");(r[a]=r[a]||[]).push([f,o])}}const a=(e,t)=>{if(!c(e))return e;for(const[n,o]of t){let t=e;for(const e of n.slice(0,-1))t=t[e]=c(t[e])?t[e]:{};t[n[n.length-1]]=o}return e};for(const[n,o]of Object.entries(r)){const t=o.find(([e])=>!e.length),i=o.filter(([e])=>e.length);let l=a(t?t[1]:{},i);Object.defineProperty(window,n,{configurable:!0,get:()=>l,set:e=>{t||(l=a(c(e)?e:{},i))}})}}`,
+	Dependencies: []*Function{},
+}
+
+// BusClient ...
+var BusClient = &Function{
+	Name:         "busClient",
+	Definition:   `function(n){if(window.rodBus)return;const r={};window.rodBus={publish:(t,e)=>window[n](JSON.stringify({topic:t,data:e})),subscribe:(t,e)=>((r[t]=r[t]||[]).push(e),()=>{r[t]=r[t].filter(t=>t!==e)})},window[n+"_deliver"]=(t,e)=>(r[t]||[]).forEach(n=>n(e,t))}`,
+	Dependencies: []*Function{},
+}
+
+// WaitAnimations ...
+var WaitAnimations = &Function{
+	Name:         "waitAnimations",
+	Definition:   `async function(){const t=()=>this.getAnimations({subtree:!0}).filter(t=>"running"===t.playState&&(!t.effect||t.effect.getComputedTiming().iterations!==1/0));for(let n=t();n.length;n=t())await Promise.all(n.map(t=>t.finished.catch(()=>{})))}`,
+	Dependencies: []*Function{},
+}
+
 // GetXPath ...
 var GetXPath = &Function{
 	Name:         "getXPath",
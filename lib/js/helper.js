@@ -451,6 +451,270 @@ const functions = {
       })
   },
 
+  observeMutations(selector, bind, opts) {
+    const describe = (n) => ({
+      nodeName: n.nodeName,
+      id: n.id || '',
+      text: (n.textContent || '').slice(0, 1000)
+    })
+
+    const observe = () => {
+      const target = selector
+        ? document.querySelector(selector)
+        : document.documentElement
+      if (!target) return false
+
+      const observer = new MutationObserver((list) => {
+        window[bind](
+          JSON.stringify(
+            list.map((r) => ({
+              type: r.type,
+              target: describe(r.target),
+              addedNodes: Array.from(r.addedNodes, describe),
+              removedNodes: Array.from(r.removedNodes, describe),
+              attributeName: r.attributeName || '',
+              oldValue: r.oldValue || ''
+            }))
+          )
+        )
+      })
+      observer.observe(target, opts)
+      window[bind + '_observer'] = observer
+      return true
+    }
+
+    if (!observe()) document.addEventListener('DOMContentLoaded', observe)
+  },
+
+  setOriginTrialTokens(tokens) {
+    const add = () => {
+      for (const token of tokens) {
+        const meta = document.createElement('meta')
+        meta.httpEquiv = 'origin-trial'
+        meta.content = token
+        ;(document.head || document.documentElement).appendChild(meta)
+      }
+    }
+
+    if (document.documentElement) {
+      add()
+      return
+    }
+
+    const observer = new MutationObserver(() => {
+      if (!document.documentElement) return
+      observer.disconnect()
+      add()
+    })
+    observer.observe(document, { childList: true })
+  },
+
+  observeLongTasks(bind) {
+    // the caller falls back to the tracing if the longtask entries aren't supported
+    if (!PerformanceObserver.supportedEntryTypes.includes('longtask')) return false
+
+    const observer = new PerformanceObserver((l) =>
+      window[bind](
+        JSON.stringify(
+          l.getEntries().map((e) => ({
+            startTime: e.startTime,
+            duration: e.duration,
+            name: e.name
+          }))
+        )
+      )
+    )
+    observer.observe({ type: 'longtask' })
+    window[bind + '_stop'] = () => observer.disconnect()
+    return true
+  },
+
+  recordCanvas() {
+    const proto = window.CanvasRenderingContext2D && CanvasRenderingContext2D.prototype
+    if (!proto || proto.__rodRecordCanvas) return
+    proto.__rodRecordCanvas = true
+
+    const serialize = (v) => {
+      if (v === null || ['number', 'string', 'boolean'].includes(typeof v)) return v
+      if (Array.isArray(v)) return v.map(serialize)
+      return String(v)
+    }
+    // keep the latest calls only, so that an animated canvas won't exhaust the memory
+    const limit = 10000
+    const record = (ctx, name, args, setter) => {
+      const canvas = ctx.canvas
+      if (!canvas.__rodCanvasLog) canvas.__rodCanvasLog = []
+      const log = canvas.__rodCanvasLog
+      log.push({ name, args: Array.from(args, serialize), setter })
+      if (log.length > limit) log.splice(0, log.length - limit)
+    }
+
+    // the read-only methods, such as getImageData and measureText, don't change what is drawn
+    const readOnly = /^(get|is|create|measureText$)/
+
+    for (const name of Object.getOwnPropertyNames(proto)) {
+      const desc = Object.getOwnPropertyDescriptor(proto, name)
+      if (typeof desc.value === 'function' && name !== 'constructor') {
+        if (readOnly.test(name)) continue
+        const fn = desc.value
+        proto[name] = function (...args) {
+          record(this, name, args, false)
+          return fn.apply(this, args)
+        }
+      } else if (desc.set) {
+        const set = desc.set
+        Object.defineProperty(proto, name, {
+          ...desc,
+          set(v) {
+            record(this, name, [v], true)
+            set.call(this, v)
+          }
+        })
+      }
+    }
+  },
+
+  canvasCommandsLog(clear) {
+    const list = this.__rodCanvasLog || []
+    if (clear) this.__rodCanvasLog = []
+    return list
+  },
+
+  hydrated(framework) {
+    // the apps are usually mounted to the document, the body, or a direct child of the body,
+    // so only they are checked instead of the whole tree on each poll
+    const roots = () => (document.body ? [document, document.body, ...document.body.children] : [document])
+    const key = (el, prefixes) => Object.keys(el).find((k) => prefixes.some((p) => k.startsWith(p)))
+
+    const frameworks = {
+      react: {
+        detect: () =>
+          !!(window.__NEXT_DATA__ || document.querySelector('[data-reactroot], #__next')),
+        hydrated: () =>
+          roots().some((el) => {
+            // the legacy ReactDOM.hydrate is synchronous
+            if (el._reactRootContainer) return true
+
+            // the container is marked when hydrateRoot is called, the hydration of the root
+            // is done when its state isn't dehydrated anymore
+            const k = key(el, ['__reactContainer$'])
+            const fiber = k && el[k]
+            const state = fiber && fiber.stateNode && fiber.stateNode.current.memoizedState
+            return !!state && state.isDehydrated === false
+          })
+      },
+      vue: {
+        detect: () =>
+          !!(window.__NUXT__ || document.querySelector('[data-server-rendered], #__nuxt')),
+        // the markers are set after the app is mounted
+        hydrated: () =>
+          !!document.querySelector('[data-v-app]') ||
+          roots().some((el) => key(el, ['__vue_app__', '__vue__']))
+      },
+      svelte: {
+        detect: () =>
+          !!document.querySelector('[data-sveltekit-preload-data], [data-svelte-h]'),
+        // svelte 5 hydrates synchronously and stores the delegated event listeners on the elements,
+        // such as el.__click, so only the interactive elements are checked
+        hydrated: () =>
+          Array.from(document.querySelectorAll('a, button, input, select, textarea, form, [tabindex]')).some(
+            (el) => key(el, ['__click', '__input', '__change', '__submit'])
+          ) ||
+          (!!window.__svelte && document.readyState === 'complete')
+      }
+    }
+
+    if (framework) return frameworks[framework].hydrated()
+
+    const detected = Object.values(frameworks).filter((f) => f.detect())
+    if (!detected.length) return document.readyState === 'complete'
+    return detected.every((f) => f.hydrated())
+  },
+
+  overrideExperiments(overrides) {
+    const encode = (v) => (typeof v === 'string' ? v : JSON.stringify(v))
+    const isObj = (v) => v !== null && typeof v === 'object'
+    const globals = {}
+
+    for (const [key, value] of Object.entries(overrides)) {
+      const i = key.indexOf(':')
+      const kind = key.slice(0, i)
+      const name = key.slice(i + 1)
+
+      if (kind === 'cookie') {
+        // the same encoding as the js-cookie, only the chars that are invalid in cookies are escaped
+        const n = encodeURIComponent(name)
+          .replace(/%(2[346B]|5E|60|7C)/g, decodeURIComponent)
+          .replace(/[()]/g, escape)
+        const v = encodeURIComponent(encode(value)).replace(
+          /%(2[346BF]|3[AC-F]|40|5[BDE]|60|7[BCD])/g,
+          decodeURIComponent
+        )
+        document.cookie = `${n}=${v}; path=/`
+      } else if (kind === 'localStorage' || kind === 'sessionStorage') {
+        try {
+          window[kind].setItem(name, encode(value))
+        } catch (e) {} // the storage is denied, such as the opaque origins
+      } else if (kind === 'window') {
+        const [root, ...path] = name.split('.')
+        ;(globals[root] = globals[root] || []).push([path, value])
+      }
+    }
+
+    const apply = (obj, list) => {
+      if (!isObj(obj)) return obj
+      for (const [path, value] of list) {
+        let o = obj
+        for (const k of path.slice(0, -1)) o = o[k] = isObj(o[k]) ? o[k] : {}
+        o[path[path.length - 1]] = value
+      }
+      return obj
+    }
+
+    // keep the overrides even if the scripts of the page assign the globals later
+    for (const [root, list] of Object.entries(globals)) {
+      const whole = list.find(([path]) => !path.length)
+      const nested = list.filter(([path]) => path.length)
+      let current = apply(whole ? whole[1] : {}, nested)
+      Object.defineProperty(window, root, {
+        configurable: true,
+        get: () => current,
+        set: (v) => {
+          if (!whole) current = apply(isObj(v) ? v : {}, nested)
+        }
+      })
+    }
+  },
+
+  busClient(bind) {
+    if (window.rodBus) return
+    const subs = {}
+    window.rodBus = {
+      publish: (topic, data) => window[bind](JSON.stringify({ topic, data })),
+      subscribe: (topic, fn) => {
+        ;(subs[topic] = subs[topic] || []).push(fn)
+        return () => {
+          subs[topic] = subs[topic].filter((f) => f !== fn)
+        }
+      }
+    }
+    window[bind + '_deliver'] = (topic, data) =>
+      (subs[topic] || []).forEach((fn) => fn(data, topic))
+  },
+
+  async waitAnimations() {
+    const running = () =>
+      this.getAnimations({ subtree: true }).filter(
+        (a) =>
+          a.playState === 'running' &&
+          (!a.effect || a.effect.getComputedTiming().iterations !== Infinity)
+      )
+
+    for (let list = running(); list.length; list = running()) {
+      await Promise.all(list.map((a) => a.finished.catch(() => {})))
+    }
+  },
+
   getXPath(optimized) {
     class Step {
       constructor(value, optimized) {
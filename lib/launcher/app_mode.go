@@ -0,0 +1,98 @@
+package launcher
+
+import (
+	"crypto/subtle"
+	"io/fs"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/cdp"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// AppModeCSP is the Content-Security-Policy header of the pages served by [NewAppModeFS].
+var AppModeCSP = "default-src 'self'; img-src 'self' data: blob:; style-src 'self' 'unsafe-inline'"
+
+const appModeTokenName = "rod-app-token"
+
+// MustNewAppModeFS is similar to [NewAppModeFS].
+func MustNewAppModeFS(fsys fs.FS) *Launcher {
+	l, err := NewAppModeFS(fsys)
+	utils.E(err)
+	return l
+}
+
+// NewAppModeFS is similar to [NewAppMode], but it serves the fsys via an internal http server on localhost,
+// such as the frontend embedded via the embed.FS, so that a single binary is enough to ship a desktop app.
+// The "index.html" of the fsys is the start page. Each response has the [AppModeCSP] header.
+// To prevent the other local processes from reading the files, a random token is required,
+// the launcher sets it as a cookie of the browser via CDP after the launch, so it never shows up in the
+// command line of the browser. The [Launcher.Pipe] isn't supported.
+// The server closes when the [Launcher.Cleanup] is called or the launch fails, the [Launcher.Relaunch]
+// hands it over to the new launcher.
+func NewAppModeFS(fsys fs.FS) (*Launcher, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	token := utils.RandString(16)
+	u := "http://" + ln.Addr().String() + "/"
+
+	srv := &http.Server{
+		Handler:           appModeHandler(http.FileServer(http.FS(fsys)), token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() { _ = srv.Serve(ln) }()
+
+	l := NewAppMode(u)
+	l.launched = append(l.launched, func(l *Launcher) error { return l.setAppModeCookie(u, token) })
+	l.cleanups = append(l.cleanups, func() { _ = srv.Close() })
+
+	return l, nil
+}
+
+// setAppModeCookie sets the token cookie of the u via the CDP of the launched browser.
+func (l *Launcher) setAppModeCookie(u, token string) error {
+	if IsPipeURL(l.controlURL) {
+		return ErrAppModePipe
+	}
+
+	ws := &cdp.WebSocket{}
+	err := ws.Connect(l.ctx, l.controlURL, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = ws.Close() }()
+
+	_, err = cdp.New().Start(ws).Call(l.ctx, "", "Storage.setCookies", map[string]any{
+		"cookies": []map[string]any{{
+			"name":     appModeTokenName,
+			"value":    token,
+			"url":      u,
+			"httpOnly": true,
+			"sameSite": "Strict",
+		}},
+	})
+	return err
+}
+
+// appModeHandler only serves the requests that have the token cookie.
+// Without it the start page keeps reloading, because the browser may open it before the cookie is set.
+func appModeHandler(h http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie(appModeTokenName)
+		if err != nil || subtle.ConstantTimeCompare([]byte(c.Value), []byte(token)) != 1 {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(appModeWaitPage))
+			return
+		}
+
+		w.Header().Set("Content-Security-Policy", AppModeCSP)
+		h.ServeHTTP(w, r)
+	})
+}
+
+const appModeWaitPage = `<html><body><script>setTimeout(() => location.reload(), 100)</script></body></html>`
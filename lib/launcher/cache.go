@@ -0,0 +1,158 @@
+package launcher
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/leakless"
+)
+
+// CleanupReport of the [Browser.CleanupRevisions] and the [ChannelBrowser.Prune].
+type CleanupReport struct {
+	// Removed dirs and files
+	Removed []string
+
+	// Reclaimed disk space in bytes
+	Reclaimed int64
+}
+
+// String interface.
+func (r *CleanupReport) String() string {
+	return fmt.Sprintf("removed %d, reclaimed %.1fMB", len(r.Removed), float64(r.Reclaimed)/1024/1024)
+}
+
+// regRevisionDir matches the dirs of the downloaded browsers, such as "chromium-1321438", "chrome-120.0.6099.71",
+// or "firefox-128.0". The dirs of the channels, such as "chrome-stable", are not matched.
+var regRevisionDir = regexp.MustCompile(`^(chromium|chrome-headless-shell|chrome|firefox)-(\d+(?:\.\d+)*)$`)
+
+// CleanupRevisions is the same as the [Browser.CleanupRevisions] of the [NewBrowser].
+func CleanupRevisions(keepLatest int) (*CleanupReport, error) {
+	return NewBrowser().CleanupRevisions(keepLatest)
+}
+
+// CleanupRevisions removes the old downloaded browsers under the [Browser.RootDir], for each kind of the browsers,
+// such as the chromium or the chrome-headless-shell, only the latest keepLatest revisions are kept.
+// The dir of the [Browser.Revision] or the [Browser.Version], and the one the [Browser.CurrentDir] points to are
+// always kept. The leftovers of the interrupted [Browser.Update] are removed too.
+// Don't remove a revision that the running browsers are using, they may crash.
+func (lc *Browser) CleanupRevisions(keepLatest int) (*CleanupReport, error) {
+	defer leakless.LockPort(lc.LockPort)()
+	return lc.cleanupRevisions(keepLatest)
+}
+
+func (lc *Browser) cleanupRevisions(keepLatest int) (*CleanupReport, error) {
+	entries, err := os.ReadDir(lc.RootDir)
+	if os.IsNotExist(err) {
+		return &CleanupReport{Removed: []string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[string]bool{lc.Dir(): true}
+	if current, err := os.Readlink(lc.CurrentDir()); err == nil {
+		if !filepath.IsAbs(current) {
+			current = filepath.Join(lc.RootDir, current)
+		}
+		keep[filepath.Clean(current)] = true
+	}
+
+	kinds := map[string][]string{}
+	remove := []string{}
+
+	for _, e := range entries {
+		name := e.Name()
+		path := filepath.Join(lc.RootDir, name)
+
+		if strings.HasPrefix(name, ".tmp-") {
+			remove = append(remove, path)
+			continue
+		}
+
+		m := regRevisionDir.FindStringSubmatch(name)
+		if m == nil || !e.IsDir() {
+			continue
+		}
+		kinds[m[1]] = append(kinds[m[1]], m[2])
+	}
+
+	for kind, versions := range kinds {
+		sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) > 0 })
+
+		for i, v := range versions {
+			path := filepath.Join(lc.RootDir, kind+"-"+v)
+			if i < keepLatest || keep[path] {
+				continue
+			}
+
+			remove = append(remove, path)
+
+			partials, _ := filepath.Glob(path + "-*.download")
+			remove = append(remove, partials...)
+		}
+	}
+
+	sort.Strings(remove)
+
+	report := &CleanupReport{Removed: []string{}}
+	for _, path := range remove {
+		size := diskUsage(path)
+
+		err := os.RemoveAll(path)
+		if err != nil {
+			return report, err
+		}
+
+		report.Removed = append(report.Removed, path)
+		report.Reclaimed += size
+	}
+
+	return report, nil
+}
+
+// compareVersions compares the dot separated numbers, such as "120.0.6099.71".
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+// diskUsage returns the total size of the regular files under the path.
+func diskUsage(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil //nolint: nilerr
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// MustCleanupRevisions is similar with [Browser.CleanupRevisions].
+func (lc *Browser) MustCleanupRevisions(keepLatest int) *CleanupReport {
+	r, err := lc.CleanupRevisions(keepLatest)
+	utils.E(err)
+	return r
+}
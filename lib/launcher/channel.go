@@ -0,0 +1,426 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/defaults"
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/fetchup"
+	"github.com/ysmood/leakless"
+)
+
+// Channel of the branded browsers, it's similar to the channel concept of Playwright.
+type Channel string
+
+const (
+	// ChannelStable of Google Chrome.
+	ChannelStable Channel = "stable"
+
+	// ChannelBeta of Google Chrome.
+	ChannelBeta Channel = "beta"
+
+	// ChannelDev of Google Chrome.
+	ChannelDev Channel = "dev"
+
+	// ChannelCanary of Google Chrome.
+	ChannelCanary Channel = "canary"
+
+	// ChannelMSEdge is the stable channel of Microsoft Edge.
+	ChannelMSEdge Channel = "msedge"
+
+	// ChannelMSEdgeBeta of Microsoft Edge.
+	ChannelMSEdgeBeta Channel = "msedge-beta"
+
+	// ChannelMSEdgeDev of Microsoft Edge.
+	ChannelMSEdgeDev Channel = "msedge-dev"
+
+	// ChannelMSEdgeCanary of Microsoft Edge.
+	ChannelMSEdgeCanary Channel = "msedge-canary"
+)
+
+// channelAliases are the Playwright style names of the channels.
+var channelAliases = map[Channel]Channel{
+	"chrome":        ChannelStable,
+	"chrome-beta":   ChannelBeta,
+	"chrome-dev":    ChannelDev,
+	"chrome-canary": ChannelCanary,
+}
+
+// Normalize the channel name, such as "chrome-beta" to "beta". It returns false if the channel is unknown.
+func (c Channel) Normalize() (Channel, bool) {
+	if alias, has := channelAliases[c]; has {
+		c = alias
+	}
+
+	switch c {
+	case ChannelStable, ChannelBeta, ChannelDev, ChannelCanary,
+		ChannelMSEdge, ChannelMSEdgeBeta, ChannelMSEdgeDev, ChannelMSEdgeCanary:
+		return c, true
+	}
+	return c, false
+}
+
+// IsEdge returns true if it's a channel of Microsoft Edge.
+func (c Channel) IsEdge() bool {
+	return strings.HasPrefix(string(c), string(ChannelMSEdge))
+}
+
+// Channel of the browser to launch, such as "beta" or "msedge".
+// When the [flags.Bin] is empty, the launcher searches the installed channel via [LookPathChannel],
+// if it's not found, the Google Chrome channels will be downloaded via [ChannelBrowser],
+// the Microsoft Edge channels must be installed manually.
+func (l *Launcher) Channel(c Channel) *Launcher {
+	return l.Set(flags.Channel, string(c))
+}
+
+// getChannelBin returns the installed channel, or downloads it if not found.
+func (l *Launcher) getChannelBin() (string, error) {
+	c, ok := Channel(l.Get(flags.Channel)).Normalize()
+	if !ok {
+		return "", fmt.Errorf("unknown browser channel: %s", c)
+	}
+
+	if bin, has := LookPathChannel(c); has {
+		return bin, nil
+	}
+
+	if c.IsEdge() {
+		return "", fmt.Errorf("the %s channel is not installed, please install it from https://www.microsoft.com/edge", c)
+	}
+
+	b := NewChannelBrowser(c)
+	b.Context = l.ctx
+	return b.Get()
+}
+
+// LookPathChannel searches for the executable of the channel from often used paths on current operating system.
+func LookPathChannel(c Channel) (found string, has bool) {
+	c, ok := c.Normalize()
+	if !ok {
+		return "", false
+	}
+
+	list := map[string]map[Channel][]string{
+		"darwin": {
+			ChannelStable:       {"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome"},
+			ChannelBeta:         {"/Applications/Google Chrome Beta.app/Contents/MacOS/Google Chrome Beta"},
+			ChannelDev:          {"/Applications/Google Chrome Dev.app/Contents/MacOS/Google Chrome Dev"},
+			ChannelCanary:       {"/Applications/Google Chrome Canary.app/Contents/MacOS/Google Chrome Canary"},
+			ChannelMSEdge:       {"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge"},
+			ChannelMSEdgeBeta:   {"/Applications/Microsoft Edge Beta.app/Contents/MacOS/Microsoft Edge Beta"},
+			ChannelMSEdgeDev:    {"/Applications/Microsoft Edge Dev.app/Contents/MacOS/Microsoft Edge Dev"},
+			ChannelMSEdgeCanary: {"/Applications/Microsoft Edge Canary.app/Contents/MacOS/Microsoft Edge Canary"},
+		},
+		"linux": {
+			ChannelStable:     {"/opt/google/chrome/chrome", "google-chrome-stable"},
+			ChannelBeta:       {"/opt/google/chrome-beta/chrome", "google-chrome-beta"},
+			ChannelDev:        {"/opt/google/chrome-unstable/chrome", "google-chrome-unstable"},
+			ChannelCanary:     {"/opt/google/chrome-canary/chrome", "google-chrome-canary"},
+			ChannelMSEdge:     {"/opt/microsoft/msedge/msedge", "microsoft-edge-stable"},
+			ChannelMSEdgeBeta: {"/opt/microsoft/msedge-beta/msedge", "microsoft-edge-beta"},
+			ChannelMSEdgeDev:  {"/opt/microsoft/msedge-dev/msedge", "microsoft-edge-dev"},
+		},
+		"windows": {
+			ChannelStable:       expandWindowsExePaths(`Google\Chrome\Application\chrome.exe`),
+			ChannelBeta:         expandWindowsExePaths(`Google\Chrome Beta\Application\chrome.exe`),
+			ChannelDev:          expandWindowsExePaths(`Google\Chrome Dev\Application\chrome.exe`),
+			ChannelCanary:       expandWindowsExePaths(`Google\Chrome SxS\Application\chrome.exe`),
+			ChannelMSEdge:       expandWindowsExePaths(`Microsoft\Edge\Application\msedge.exe`),
+			ChannelMSEdgeBeta:   expandWindowsExePaths(`Microsoft\Edge Beta\Application\msedge.exe`),
+			ChannelMSEdgeDev:    expandWindowsExePaths(`Microsoft\Edge Dev\Application\msedge.exe`),
+			ChannelMSEdgeCanary: expandWindowsExePaths(`Microsoft\Edge SxS\Application\msedge.exe`),
+		},
+	}[runtime.GOOS][c]
+
+	for _, path := range list {
+		var err error
+		found, err = exec.LookPath(path)
+		has = err == nil
+		if has {
+			break
+		}
+	}
+
+	return
+}
+
+// cftLastKnownGoodURL lists the latest version of each channel of the Chrome for Testing.
+const cftLastKnownGoodURL = "https://googlechromelabs.github.io/chrome-for-testing/last-known-good-versions-with-downloads.json"
+
+// cftPlatform is the platform name of the Chrome for Testing for the current os.
+var cftPlatform = map[string]string{
+	"darwin_amd64":  "mac-x64",
+	"darwin_arm64":  "mac-arm64",
+	"linux_amd64":   "linux64",
+	"windows_386":   "win32",
+	"windows_amd64": "win64",
+}[runtime.GOOS+"_"+runtime.GOARCH]
+
+// ChannelBrowser is a helper to download the Google Chrome channels via the Chrome for Testing.
+// Microsoft Edge doesn't provide portable builds, so it's not supported.
+type ChannelBrowser struct {
+	Context context.Context
+
+	// Channel to download, it must be one of the Google Chrome channels
+	Channel Channel
+
+	// VersionsURL is the endpoint that lists the latest version of each channel,
+	// default is the last-known-good-versions-with-downloads.json of the Chrome for Testing.
+	VersionsURL string
+
+	// RootDir to download different channels, each version of a channel has its own dir,
+	// the old versions are only removed by the [ChannelBrowser.Prune].
+	RootDir string
+
+	// TTL of the resolved latest version, the [ChannelBrowser.Get] won't request the VersionsURL again
+	// within the TTL since the last time the downloaded version is confirmed as the latest.
+	// Default is 1 hour, zero means always requesting it.
+	TTL time.Duration
+
+	// Log to print output
+	Logger utils.Logger
+
+	// LockPort a tcp port to prevent race downloading.
+	LockPort int
+
+	// HTTPClient to download the browser
+	HTTPClient *http.Client
+}
+
+// NewChannelBrowser with default values.
+func NewChannelBrowser(c Channel) *ChannelBrowser {
+	c, _ = c.Normalize()
+	return &ChannelBrowser{
+		Context:     context.Background(),
+		Channel:     c,
+		VersionsURL: cftLastKnownGoodURL,
+		RootDir:     DefaultBrowserDir,
+		TTL:         time.Hour,
+		Logger:      log.New(os.Stdout, "[launcher.ChannelBrowser]", log.LstdFlags),
+		LockPort:    defaults.LockPort,
+	}
+}
+
+// Dir to download the channel.
+func (lc *ChannelBrowser) Dir() string {
+	return filepath.Join(lc.RootDir, "chrome-"+string(lc.Channel))
+}
+
+// versionDir to download the version of the channel, each version has its own dir, so updating the channel
+// won't remove the files that the running browsers of the old version are using.
+func (lc *ChannelBrowser) versionDir(version string) string {
+	return filepath.Join(lc.Dir(), version)
+}
+
+// BinPath of the downloaded executable of the current version.
+func (lc *ChannelBrowser) BinPath() string {
+	current, _ := utils.ReadString(lc.versionFile())
+	return lc.binPath(current)
+}
+
+func (lc *ChannelBrowser) binPath(version string) string {
+	bin := map[string]string{
+		"darwin":  "Google Chrome for Testing.app/Contents/MacOS/Google Chrome for Testing",
+		"linux":   "chrome",
+		"windows": "chrome.exe",
+	}[runtime.GOOS]
+
+	return filepath.Join(lc.versionDir(version), filepath.FromSlash(bin))
+}
+
+// versionFile records the current version of the downloaded channel,
+// its modification time is the last time the version is confirmed as the latest.
+func (lc *ChannelBrowser) versionFile() string {
+	return filepath.Join(lc.Dir(), ".rod-version")
+}
+
+// checked returns true if the current version is confirmed as the latest within the TTL.
+func (lc *ChannelBrowser) checked() bool {
+	info, err := os.Stat(lc.versionFile())
+	return err == nil && time.Since(info.ModTime()) < lc.TTL
+}
+
+// Latest returns the latest version of the channel and its download url for the current platform.
+func (lc *ChannelBrowser) Latest() (version, u string, err error) {
+	platform := cftPlatform
+	if platform == "" {
+		return "", "", fmt.Errorf("downloading chrome is not supported on %s/%s, please install it", runtime.GOOS, runtime.GOARCH)
+	}
+
+	client := lc.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ch, err := cftLatest(lc.Context, client, lc.VersionsURL, lc.Channel)
+	if err != nil {
+		return "", "", err
+	}
+
+	if u := ch.download(cftChrome); u != "" {
+		return ch.Version, u, nil
+	}
+	return "", "", fmt.Errorf("chrome %s %s is not available for %s", lc.Channel, ch.Version, platform)
+}
+
+// cftLatest gets the latest version of the channel from the u, the format of it is the same as the
+// last-known-good-versions-with-downloads.json of the Chrome for Testing.
+func cftLatest(ctx context.Context, client *http.Client, u string, c Channel) (*cftVersion, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the chrome versions: %s", res.Status)
+	}
+
+	var list struct {
+		Channels map[string]*cftVersion `json:"channels"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&list)
+	if err != nil {
+		return nil, err
+	}
+
+	// the keys of the Chrome for Testing are "Stable", "Beta", "Dev", and "Canary"
+	name := string(c)
+	if name != "" {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	ch, has := list.Channels[name]
+	if !has {
+		return nil, fmt.Errorf("chrome channel not found: %s", c)
+	}
+	return ch, nil
+}
+
+// Download the version of the channel from the url and record it as the current version.
+func (lc *ChannelBrowser) Download(version, u string) error {
+	dir := lc.versionDir(version)
+
+	// the leftover of the interrupted download
+	_ = os.RemoveAll(dir)
+
+	fu := fetchup.New(u)
+	fu.Ctx = lc.Context
+	fu.Logger = lc.Logger
+	fu.SaveTo = dir
+	if lc.HTTPClient != nil {
+		fu.HttpClient = lc.HTTPClient
+	}
+
+	err := fu.Download(u)
+	if err != nil {
+		return fmt.Errorf("failed to download chrome %s: %w", lc.Channel, err)
+	}
+
+	err = fetchup.StripFirstDir(dir)
+	if err != nil {
+		return err
+	}
+
+	return utils.OutputFile(lc.versionFile(), version)
+}
+
+// Get the executable path of the channel, it downloads the latest version of the channel if the downloaded
+// one is outdated. If the latest version can't be resolved, such as offline, the downloaded one will be used.
+// The latest version is resolved at most once within the [ChannelBrowser.TTL].
+// The old versions are kept, use [ChannelBrowser.Prune] to remove them.
+func (lc *ChannelBrowser) Get() (string, error) {
+	if lc.Channel.IsEdge() {
+		return "", fmt.Errorf("downloading %s is not supported, please install it", lc.Channel)
+	}
+
+	defer leakless.LockPort(lc.LockPort)()
+
+	current, _ := utils.ReadString(lc.versionFile())
+	bin := lc.binPath(current)
+	_, statErr := os.Stat(bin)
+
+	if statErr == nil && current != "" && lc.checked() {
+		return bin, nil
+	}
+
+	version, u, err := lc.Latest()
+	if err != nil {
+		if statErr == nil {
+			return bin, nil
+		}
+		return "", err
+	}
+
+	if statErr == nil && current == version {
+		now := time.Now()
+		_ = os.Chtimes(lc.versionFile(), now, now)
+		return bin, nil
+	}
+
+	err = lc.Download(version, u)
+	if err != nil {
+		return "", err
+	}
+	return lc.binPath(version), nil
+}
+
+// Prune removes the downloaded versions of the channel except the current one.
+// Don't call it while the browsers of the old versions are running, they may crash.
+func (lc *ChannelBrowser) Prune() (*CleanupReport, error) {
+	defer leakless.LockPort(lc.LockPort)()
+
+	report := &CleanupReport{Removed: []string{}}
+
+	entries, err := os.ReadDir(lc.Dir())
+	if os.IsNotExist(err) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	current, _ := utils.ReadString(lc.versionFile())
+	if current == "" {
+		return report, nil
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(lc.Dir(), e.Name())
+		if path == lc.versionFile() || path == lc.versionDir(current) {
+			continue
+		}
+
+		size := diskUsage(path)
+		err := os.RemoveAll(path)
+		if err != nil {
+			return report, err
+		}
+		report.Removed = append(report.Removed, path)
+		report.Reclaimed += size
+	}
+
+	return report, nil
+}
+
+// MustGet is similar with Get.
+func (lc *ChannelBrowser) MustGet() string {
+	p, err := lc.Get()
+	utils.E(err)
+	return p
+}
@@ -0,0 +1,134 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// stderrTailSize is the max size of the [CrashReport.Stderr].
+const stderrTailSize = 16 * 1024
+
+// CrashReport of the browser process, check [Launcher.CrashReport].
+type CrashReport struct {
+	// ExitCode of the process, it's -1 if the process is terminated by a signal.
+	ExitCode int
+
+	// Signal that terminated the process, such as "segmentation fault". It's always empty on Windows.
+	Signal string
+
+	// Stderr is the tail of the stderr of the browser.
+	Stderr string
+
+	// Minidumps are the paths of the minidump files created by Crashpad during the browser's lifetime.
+	Minidumps []string
+}
+
+// Error interface.
+func (r *CrashReport) Error() string {
+	reason := fmt.Sprintf("exit code %d", r.ExitCode)
+	if r.Signal != "" {
+		reason = "signal: " + r.Signal
+	}
+
+	msg := "[launcher] the browser crashed (" + reason + ")"
+	if len(r.Minidumps) > 0 {
+		msg += ", minidumps: " + strings.Join(r.Minidumps, ", ")
+	}
+	if r.Stderr != "" {
+		msg += "\n" + r.Stderr
+	}
+	return msg
+}
+
+// Exit returns a channel that will be closed after the browser process exits.
+func (l *Launcher) Exit() <-chan struct{} {
+	return l.exit
+}
+
+// CrashReport returns nil if the browser is still running, or it exits normally, or it's killed by [Launcher.Kill].
+// Otherwise, it returns the report of the crash. Use [Launcher.Exit] to wait for the browser to exit.
+func (l *Launcher) CrashReport() *CrashReport {
+	select {
+	case <-l.exit:
+	default:
+		return nil
+	}
+
+	if atomic.LoadInt32(&l.killed) == 1 || l.cmd == nil || l.cmd.ProcessState == nil {
+		return nil
+	}
+
+	state := l.cmd.ProcessState
+	signal := exitSignal(state)
+	if state.ExitCode() == 0 && signal == "" {
+		return nil
+	}
+
+	r := &CrashReport{
+		ExitCode:  state.ExitCode(),
+		Signal:    signal,
+		Minidumps: l.minidumps(),
+	}
+	if l.stderr != nil {
+		r.Stderr = l.stderr.String()
+	}
+	return r
+}
+
+// minidumps created after the launch.
+func (l *Launcher) minidumps() []string {
+	dirs := []string{}
+	if dir := l.Get(flags.CrashDumpsDir); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if dir := l.Get(flags.UserDataDir); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, "Crashpad"))
+	}
+
+	list := []string{}
+	for _, dir := range dirs {
+		_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && filepath.Ext(path) == ".dmp" && !info.ModTime().Before(l.startedAt) {
+				list = append(list, path)
+			}
+			return nil
+		})
+	}
+	sort.Strings(list)
+	return list
+}
+
+// tailBuffer keeps the last size bytes written to it.
+type tailBuffer struct {
+	lock sync.Mutex
+	size int
+	buf  []byte
+}
+
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{size: size}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.size; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return string(t.buf)
+}
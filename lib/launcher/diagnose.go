@@ -0,0 +1,201 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Finding is a problem found by [Diagnose] that may prevent the browser from starting.
+type Finding struct {
+	// Check is the name of the check, such as "user-namespace", "suid-sandbox", "shared-library", or "font"
+	Check string
+
+	// Problem found by the check
+	Problem string
+
+	// Fix is the suggestion to solve the problem
+	Fix string
+}
+
+// String interface.
+func (f *Finding) String() string {
+	return fmt.Sprintf("[%s] %s, fix: %s", f.Check, f.Problem, f.Fix)
+}
+
+// interfaces for testing.
+var (
+	diagnoseProcDir  = "/proc"
+	diagnoseLDD      = "ldd"
+	diagnoseFontDirs = []string{
+		"/usr/share/fonts",
+		"/usr/local/share/fonts",
+		filepath.Join(os.Getenv("HOME"), ".fonts"),
+		filepath.Join(os.Getenv("HOME"), ".local/share/fonts"),
+	}
+)
+
+// Diagnose checks the prerequisites of the browser binary that [Launcher] will use by default, such as the one
+// found by [LookPath] or the downloaded one of [NewBrowser], check [DiagnoseBin] for the details.
+func Diagnose() []*Finding {
+	bin, has := LookPath()
+	if !has {
+		bin = NewBrowser().BinPath()
+	}
+	return DiagnoseBin(bin)
+}
+
+// DiagnoseBin checks the prerequisites of the browser binary, it's useful when the browser won't start in
+// a container. It checks the kernel user namespace settings and the SUID sandbox that the sandbox of the browser
+// depends on, the missing shared libraries of the binary via ldd, and the fonts to render the text.
+// It returns nil if nothing is found. The checks only run on Linux.
+func DiagnoseBin(bin string) []*Finding {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	list := []*Finding{}
+
+	if f := checkBin(bin); f != nil {
+		return append(list, f)
+	}
+
+	if f := checkUserNamespace(); f != nil {
+		list = append(list, f)
+
+		if f := checkSUIDSandbox(bin); f != nil {
+			list = append(list, f)
+		}
+	}
+
+	list = append(list, checkSharedLibraries(bin)...)
+
+	if f := checkFonts(); f != nil {
+		list = append(list, f)
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+func checkBin(bin string) *Finding {
+	if _, err := os.Stat(bin); err != nil {
+		return &Finding{
+			Check:   "binary",
+			Problem: err.Error(),
+			Fix:     "install the browser, or use the launcher.Browser to download it",
+		}
+	}
+	return nil
+}
+
+// checkUserNamespace returns a finding if the unprivileged user namespaces, which the sandbox of the browser uses,
+// are disabled by the kernel settings.
+func checkUserNamespace() *Finding {
+	read := func(name string) string {
+		b, _ := os.ReadFile(filepath.Join(diagnoseProcDir, "sys", filepath.FromSlash(name)))
+		return strings.TrimSpace(string(b))
+	}
+
+	fix := "enable it via \"sysctl -w %s=%s\", or disable the sandbox via Launcher.NoSandbox(true)"
+
+	switch {
+	case read("kernel/unprivileged_userns_clone") == "0":
+		return &Finding{
+			Check:   "user-namespace",
+			Problem: "the unprivileged user namespace is disabled",
+			Fix:     fmt.Sprintf(fix, "kernel.unprivileged_userns_clone", "1"),
+		}
+	case read("user/max_user_namespaces") == "0":
+		return &Finding{
+			Check:   "user-namespace",
+			Problem: "the max user namespaces is 0",
+			Fix:     fmt.Sprintf(fix, "user.max_user_namespaces", "10000"),
+		}
+	case read("kernel/apparmor_restrict_unprivileged_userns") == "1":
+		return &Finding{
+			Check:   "user-namespace",
+			Problem: "the unprivileged user namespace is restricted by AppArmor",
+			Fix:     fmt.Sprintf(fix, "kernel.apparmor_restrict_unprivileged_userns", "0"),
+		}
+	}
+
+	return nil
+}
+
+// checkSUIDSandbox returns a finding if the chrome-sandbox beside the bin, which is the fallback of the user
+// namespace sandbox, is missing or not a SUID binary owned by root.
+func checkSUIDSandbox(bin string) *Finding {
+	path := filepath.Join(filepath.Dir(bin), "chrome-sandbox")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return &Finding{
+			Check:   "suid-sandbox",
+			Problem: "the SUID sandbox is missing: " + path,
+			Fix: "use a browser package that includes the chrome-sandbox, " +
+				"or disable the sandbox via Launcher.NoSandbox(true)",
+		}
+	}
+
+	if info.Mode()&os.ModeSetuid == 0 || !isOwnedByRoot(info) {
+		return &Finding{
+			Check:   "suid-sandbox",
+			Problem: "the SUID sandbox is not a SUID binary owned by root: " + path,
+			Fix:     fmt.Sprintf("run \"sudo chown root:root %s && sudo chmod 4755 %s\"", path, path),
+		}
+	}
+
+	return nil
+}
+
+// checkSharedLibraries returns a finding for each missing shared library of the bin reported by ldd.
+func checkSharedLibraries(bin string) []*Finding {
+	out, err := exec.Command(diagnoseLDD, bin).CombinedOutput()
+	if err != nil && len(out) == 0 {
+		return nil
+	}
+
+	list := []*Finding{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.Contains(line, "not found") {
+			continue
+		}
+
+		lib := strings.TrimSpace(strings.SplitN(line, "=>", 2)[0])
+		list = append(list, &Finding{
+			Check:   "shared-library",
+			Problem: "the shared library is missing: " + lib,
+			Fix:     "install the package that provides " + lib + ", such as via \"apt-file search " + lib + "\"",
+		})
+	}
+	return list
+}
+
+// checkFonts returns a finding if no font file is found, the pages will render without text.
+func checkFonts() *Finding {
+	for _, dir := range diagnoseFontDirs {
+		found := false
+		_ = filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				found = true
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if found {
+			return nil
+		}
+	}
+
+	return &Finding{
+		Check:   "font",
+		Problem: "no font is found in " + strings.Join(diagnoseFontDirs, ", "),
+		Fix:     "install the fonts, such as the fonts-liberation and fonts-noto-cjk packages",
+	}
+}
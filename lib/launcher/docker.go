@@ -0,0 +1,212 @@
+package launcher
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// DockerImageDefault is the default image of [NewDocker], it's built from the lib/docker/Dockerfile .
+const DockerImageDefault = "ghcr.io/xyjwsj/grod"
+
+const (
+	dockerDevtoolsPort = "9222"
+	dockerUserDataDir  = "/rod/user-data"
+
+	// dockerLabel marks the containers and the random volumes of the [NewDocker] for the [CleanupDocker]
+	dockerLabel = "managed-by=rod"
+)
+
+// dockerBin is the path of the docker cli.
+var dockerBin = "docker"
+
+// NewDocker is a preset to launch the browser in a Docker container instead of the local os.
+// The image will be pulled if it doesn't exist locally, use [DockerImageDefault] if the image is empty.
+// The devtools port of the container is mapped to a random port of 127.0.0.1, and a named volume
+// is mounted as the [flags.UserDataDir], so the returned control url can be used by rod.New().ControlURL directly.
+// The [flags.Bin] is the browser executable path inside the container.
+// [Launcher.Kill] removes the container, [Launcher.Cleanup] removes the volume unless the
+// [Launcher.KeepUserDataDir] is set. The container is started with the "--rm" flag, and it's labeled
+// so that [CleanupDocker] can sweep the leftovers of the crashed processes.
+// The [Manager] refuses it from the remote clients by default, check [ManagerDeniedFlags]. Such as:
+//
+//	l := launcher.NewDocker("")
+//	defer l.Cleanup()
+//	defer l.Kill()
+//
+//	browser := rod.New().ControlURL(l.MustLaunch()).MustConnect()
+func NewDocker(image string) *Launcher {
+	if image == "" {
+		image = DockerImageDefault
+	}
+
+	l := New()
+	l.Delete(flags.UserDataDir).Delete(flags.RemoteDebuggingPort)
+	l.Set(flags.Docker, image)
+	l.Set(flags.DockerVolume, dockerVolumePrefix+utils.RandString(8))
+	l.Set(flags.Bin, "chrome")
+	l.Set(flags.NoSandbox)
+
+	return l
+}
+
+// dockerVolumePrefix is the prefix of the random volumes of the [NewDocker].
+const dockerVolumePrefix = "rod-user-data-"
+
+// DockerVolume sets the volume to mount as the user data dir of the container, check [NewDocker].
+// It can be the name of a Docker volume or an absolute path of the host to bind mount,
+// such as reusing the same name to keep the cookies between launches with [Launcher.KeepUserDataDir].
+func (l *Launcher) DockerVolume(name string) *Launcher {
+	return l.Set(flags.DockerVolume, name)
+}
+
+// dockerArgs returns the arguments of the docker cli to run the browser in a container with the name.
+func (l *Launcher) dockerArgs(name string) []string {
+	args := []string{
+		"run", "--rm", "--name", name, "--label", dockerLabel,
+		"-p", "127.0.0.1::" + dockerDevtoolsPort,
+	}
+	if volume := l.Get(flags.DockerVolume); volume != "" {
+		args = append(args, "-v", volume+":"+dockerUserDataDir)
+	}
+	if env, has := l.GetFlags(flags.Env); has {
+		for _, e := range env {
+			args = append(args, "-e", e)
+		}
+	}
+
+	args = append(args, l.Get(flags.Docker), l.Get(flags.Bin))
+
+	c := &Launcher{Flags: map[flags.Flag][]string{}}
+	for k, v := range l.flagsSnapshot() {
+		if k != flags.Env {
+			c.Flags[k] = v
+		}
+	}
+	c.Set(flags.RemoteDebuggingPort, dockerDevtoolsPort)
+	c.Set("remote-debugging-address", "0.0.0.0")
+	c.Delete(flags.UserDataDir)
+
+	args = append(args, c.FormatArgs()...)
+
+	// the path is inside the container, it shouldn't be converted to the absolute path of the host
+	return append(args, "--"+string(flags.UserDataDir)+"="+dockerUserDataDir)
+}
+
+// launchDocker runs the browser in a container and returns the control url.
+func (l *Launcher) launchDocker() (string, error) {
+	image := l.Get(flags.Docker)
+
+	if exec.CommandContext(l.ctx, dockerBin, "image", "inspect", image).Run() != nil {
+		_, _ = fmt.Fprintln(l.logger, "[launcher] pull docker image:", image)
+
+		pull := exec.CommandContext(l.ctx, dockerBin, "pull", image)
+		pull.Stdout = l.logger
+		pull.Stderr = l.logger
+		err := pull.Run()
+		if err != nil {
+			return "", fmt.Errorf("failed to pull the docker image %s: %w", image, err)
+		}
+	}
+
+	// label the random volume, the volumes named by the user are left to the user
+	if volume := l.Get(flags.DockerVolume); strings.HasPrefix(volume, dockerVolumePrefix) {
+		out, err := exec.CommandContext(l.ctx, dockerBin, "volume", "create", "--label", dockerLabel, volume).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("failed to create the docker volume %s: %w %s", volume, err, out)
+		}
+	}
+
+	l.container = "rod-" + utils.RandString(8)
+
+	l.startedAt = time.Now()
+
+	done, err := l.start(dockerBin, l.dockerArgs(l.container))
+	if err != nil {
+		return "", err
+	}
+
+	l.osTrack(l.pid)
+
+	attempt := l.attempt
+	go func() {
+		<-done
+		close(attempt)
+	}()
+
+	// the browser prints the devtools url when it's ready, the host in the url is inside the container
+	_, err = l.getURL()
+	if err != nil {
+		l.Kill()
+		return "", err
+	}
+
+	out, err := exec.CommandContext(l.ctx, dockerBin, "port", l.container, dockerDevtoolsPort+"/tcp").Output()
+	if err != nil {
+		l.Kill()
+		return "", fmt.Errorf("failed to get the devtools port of the container: %w", err)
+	}
+
+	// the output may contain both the ipv4 and ipv6 addresses, use the first one
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+
+	u, err := ResolveURL(addr)
+	if err != nil {
+		l.Kill()
+		return "", err
+	}
+	return u, nil
+}
+
+// removeContainer removes the container of the [NewDocker].
+func (l *Launcher) removeContainer() {
+	if l.container == "" {
+		return
+	}
+
+	out, err := exec.Command(dockerBin, "rm", "-f", l.container).CombinedOutput()
+	if err != nil && !bytes.Contains(out, []byte("No such container")) {
+		_, _ = fmt.Fprintf(l.logger, "[launcher] failed to remove the container %s: %v %s\n", l.container, err, out)
+	}
+}
+
+// removeVolume removes the volume of the [NewDocker] unless it's a path of the host.
+func (l *Launcher) removeVolume() {
+	volume := l.Get(flags.DockerVolume)
+	if volume == "" || l.Has(flags.KeepUserDataDir) || strings.ContainsAny(volume, `/\`) {
+		return
+	}
+
+	out, err := exec.Command(dockerBin, "volume", "rm", "-f", volume).CombinedOutput()
+	if err != nil {
+		_, _ = fmt.Fprintf(l.logger, "[launcher] failed to remove the volume %s: %v %s\n", volume, err, out)
+	}
+}
+
+// CleanupDocker removes all the containers and the random volumes created by the [NewDocker],
+// such as the leftovers of the processes that crashed before the [Launcher.Kill] or [Launcher.Cleanup].
+// Don't call it while other launchers of the [NewDocker] are running, their containers will be removed too.
+func CleanupDocker() error {
+	for _, kind := range [][]string{{"container", "ls", "-aq"}, {"volume", "ls", "-q"}} {
+		out, err := exec.Command(dockerBin, append(kind, "--filter", "label="+dockerLabel)...).Output()
+		if err != nil {
+			return fmt.Errorf("failed to list the docker %ss: %w", kind[0], err)
+		}
+
+		ids := strings.Fields(string(out))
+		if len(ids) == 0 {
+			continue
+		}
+
+		out, err = exec.Command(dockerBin, append([]string{kind[0], "rm", "-f"}, ids...)...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to remove the docker %ss: %w %s", kind[0], err, out)
+		}
+	}
+	return nil
+}
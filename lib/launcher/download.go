@@ -0,0 +1,193 @@
+package launcher
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ysmood/fetchup"
+)
+
+// DownloadProgress of the browser archive, check [Browser.Progress].
+type DownloadProgress struct {
+	// URL of the archive
+	URL string
+
+	// Downloaded bytes, including the ones downloaded by the previous attempts
+	Downloaded int64
+
+	// Total bytes of the archive, it's -1 if the server doesn't tell
+	Total int64
+
+	// Speed of the current attempt in bytes per second
+	Speed float64
+}
+
+// String interface.
+func (p DownloadProgress) String() string {
+	mb := func(n float64) string { return fmt.Sprintf("%.1fMB", n/1024/1024) }
+
+	total := "?"
+	if p.Total >= 0 {
+		total = mb(float64(p.Total))
+	}
+	return fmt.Sprintf("%s/%s %s/s", mb(float64(p.Downloaded)), total, mb(p.Speed))
+}
+
+// progressSpan is the min interval to report the progress.
+var progressSpan = time.Second
+
+// resumableDownload downloads the u to the path. If the path already has a part of the file,
+// such as the previous attempt failed, it resumes via the http range request.
+// It retries the times when the download fails.
+func resumableDownload(
+	ctx context.Context, client *http.Client, u, path string, retries int, report func(DownloadProgress),
+) error {
+	for attempt := 0; ; attempt++ {
+		err := downloadRange(ctx, client, u, path, report)
+		if err == nil || ctx.Err() != nil || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		}
+	}
+}
+
+// downloadRange downloads the rest of the file from the size of the path.
+func downloadRange(ctx context.Context, client *http.Client, u, path string, report func(DownloadProgress)) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := info.Size()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// the server doesn't support the range request, restart from zero
+		offset = 0
+		err = f.Truncate(0)
+		if err != nil {
+			return err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 {
+			// the file is already complete
+			return nil
+		}
+		fallthrough
+	default:
+		return fmt.Errorf("failed to download %s: %s", u, res.Status)
+	}
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	p := DownloadProgress{URL: u, Downloaded: offset, Total: -1}
+	if res.ContentLength >= 0 {
+		p.Total = offset + res.ContentLength
+	}
+
+	start := time.Now()
+	last := time.Time{}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := res.Body.Read(buf)
+		if n > 0 {
+			if _, err := f.Write(buf[:n]); err != nil {
+				return err
+			}
+			p.Downloaded += int64(n)
+			p.Speed = float64(p.Downloaded-offset) / time.Since(start).Seconds()
+		}
+
+		done := err == io.EOF
+		if done || time.Since(last) >= progressSpan {
+			last = time.Now()
+			report(p)
+		}
+
+		if done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.Total >= 0 && p.Downloaded != p.Total {
+		return fmt.Errorf("failed to download %s: got %d bytes, expected %d", u, p.Downloaded, p.Total)
+	}
+	return nil
+}
+
+// partialPath is the path to keep the partial archive of u for the dir, so that the next attempt can resume it.
+func partialPath(dir, u string) string {
+	return fmt.Sprintf("%s-%08x.download", dir, crc32.ChecksumIEEE([]byte(u)))
+}
+
+// unpack the archive of u at the path to the dir.
+func unpack(fu *fetchup.Fetchup, u, path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	fu.SaveTo = dir
+
+	var r io.Reader = f
+	name := strings.ToLower(strings.Split(u, "?")[0])
+
+	if strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		r = gz
+		name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".tgz") + ".tar"
+	}
+
+	if strings.HasSuffix(name, ".tar") {
+		return fu.UnTar(r)
+	}
+	return fu.UnZip(r)
+}
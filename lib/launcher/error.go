@@ -4,3 +4,32 @@ import "errors"
 
 // ErrAlreadyLaunched is an error that indicates the launcher has already been launched.
 var ErrAlreadyLaunched = errors.New("already launched")
+
+// ErrProxyUpstream is an error that indicates the upstream proxy refused the connection.
+var ErrProxyUpstream = errors.New("upstream proxy refused the connection")
+
+// ErrInvalidLaunch is an error that indicates the [Launcher.Validate] found problems of the launch settings.
+var ErrInvalidLaunch = errors.New("invalid launch settings")
+
+// ErrLauncherJSON is an error that indicates the JSON of a [Launcher] is invalid.
+var ErrLauncherJSON = errors.New("invalid launcher json")
+
+// ErrLeaklessBlocked is an error that indicates the leakless guard process can't run,
+// such as it's blocked by anti-virus software.
+var ErrLeaklessBlocked = errors.New("leakless is blocked")
+
+// ErrAlwaysOnTopNotSupported is an error that indicates [AlwaysOnTop] can't work on the current platform,
+// such as the required tool is not installed.
+var ErrAlwaysOnTopNotSupported = errors.New("always on top is not supported")
+
+// ErrBrowserRunning is an error that indicates the browser hasn't exited yet, check [Launcher.Relaunch].
+var ErrBrowserRunning = errors.New("the browser is still running")
+
+// ErrXVFB is an error that indicates the Xvfb of the [Launcher.ManagedXVFB] failed to start.
+var ErrXVFB = errors.New("failed to start Xvfb")
+
+// ErrStatsNotSupported is an error that indicates [Launcher.Stats] can't work on the current platform.
+var ErrStatsNotSupported = errors.New("process stats are not supported")
+
+// ErrAppModePipe is an error that indicates the [NewAppModeFS] is launched with the [Launcher.Pipe].
+var ErrAppModePipe = errors.New("the app mode fs doesn't support the pipe")
@@ -0,0 +1,50 @@
+package launcher
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// Extension loads the unpacked extensions of the paths, the relative paths will be converted to absolute ones.
+// It sets the [flags.LoadExtension] and [flags.DisableExtensionsExcept], removes the "disable-extensions",
+// and re-enables the command line switch of loading extensions that the branded Chrome disables by default.
+// The old headless mode doesn't support extensions, so the [Launcher.Headless] will be switched to
+// the [Launcher.HeadlessNew], the chrome-headless-shell will be reported by the [Launcher.Conflicts].
+// Use the rod.Browser.ExtensionTarget to get the background target of the extension.
+func (l *Launcher) Extension(paths ...string) *Launcher {
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		utils.E(err)
+
+		for _, name := range []flags.Flag{flags.LoadExtension, flags.DisableExtensionsExcept} {
+			if values, _ := l.GetFlags(name); !slices.Contains(values, abs) {
+				l.Append(name, abs)
+			}
+		}
+	}
+
+	if values, has := l.GetFlags(flags.Headless); has && len(values) == 0 {
+		l.HeadlessNew(true)
+	}
+
+	return l.Delete("disable-extensions").DisableFeatures("DisableLoadExtensionCommandLineSwitch")
+}
+
+// extensionConflicts returns the conflicts of the headless modes that don't support extensions.
+func (l *Launcher) extensionConflicts() []*FlagConflict {
+	if !l.Has(flags.LoadExtension) {
+		return nil
+	}
+
+	list := []*FlagConflict{}
+	if values, has := l.GetFlags(flags.Headless); has && len(values) == 0 {
+		list = append(list, &FlagConflict{Flags: []flags.Flag{flags.Headless, flags.LoadExtension}})
+	}
+	if l.Has(flags.HeadlessShell) {
+		list = append(list, &FlagConflict{Flags: []flags.Flag{flags.HeadlessShell, flags.LoadExtension}})
+	}
+	return list
+}
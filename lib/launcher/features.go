@@ -0,0 +1,151 @@
+package launcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// featureFlags maps the flags of feature lists to their opposite flags.
+var featureFlags = map[flags.Flag]flags.Flag{
+	flags.EnableFeatures:     flags.DisableFeatures,
+	flags.DisableFeatures:    flags.EnableFeatures,
+	"enable-blink-features":  "disable-blink-features",
+	"disable-blink-features": "enable-blink-features",
+}
+
+// exclusiveFlags are the pairs of flags that shouldn't be used together.
+var exclusiveFlags = [][2]flags.Flag{
+	{flags.Headless, flags.App},
+	{flags.Headless, "auto-open-devtools-for-tabs"},
+	{flags.ProxyServer, "no-proxy-server"},
+	{flags.ProxyServer, flags.ProxyUpstream},
+	{"single-process", "site-per-process"},
+	{flags.RemoteDebuggingPort, "remote-debugging-pipe"},
+	{flags.Headless, flags.ManagedXVFB},
+	{flags.XVFB, flags.ManagedXVFB},
+}
+
+// FlagConflict is a conflict between flags that may cause unexpected browser behaviors.
+type FlagConflict struct {
+	Flags []flags.Flag
+
+	// Feature is the feature that is both enabled and disabled, it's empty for mutually exclusive flags.
+	Feature string
+}
+
+// String interface.
+func (c *FlagConflict) String() string {
+	if c.Feature != "" {
+		return fmt.Sprintf("feature %s is in both --%s and --%s", c.Feature, c.Flags[0], c.Flags[1])
+	}
+	return fmt.Sprintf("--%s and --%s are mutually exclusive", c.Flags[0], c.Flags[1])
+}
+
+// Conflicts returns the features that are both enabled and disabled,
+// and the mutually exclusive flags, such as "headless" and "app".
+// The conflicts will also be printed to the [Launcher.Logger] before launching.
+func (l *Launcher) Conflicts() []*FlagConflict {
+	list := []*FlagConflict{}
+
+	for _, pair := range exclusiveFlags {
+		if l.Has(pair[0]) && l.Has(pair[1]) {
+			list = append(list, &FlagConflict{Flags: []flags.Flag{pair[0], pair[1]}})
+		}
+	}
+
+	list = append(list, l.extensionConflicts()...)
+
+	enables := []flags.Flag{}
+	for name := range featureFlags {
+		if strings.HasPrefix(string(name), "enable-") {
+			enables = append(enables, name)
+		}
+	}
+	sort.Slice(enables, func(i, j int) bool { return enables[i] < enables[j] })
+
+	for _, enable := range enables {
+		disable := featureFlags[enable]
+		disabled := map[string]bool{}
+		values, _ := l.GetFlags(disable)
+		for _, f := range splitFeatures(values) {
+			disabled[featureName(f)] = true
+		}
+
+		values, _ = l.GetFlags(enable)
+		for _, f := range splitFeatures(values) {
+			if disabled[featureName(f)] {
+				list = append(list, &FlagConflict{
+					Flags:   []flags.Flag{enable, disable},
+					Feature: featureName(f),
+				})
+			}
+		}
+	}
+
+	return list
+}
+
+// mergeFeatures into the flag as a set, the comma separated values are split into the features.
+func (l *Launcher) mergeFeatures(name flags.Flag, features []string) *Launcher {
+	name.Check()
+	return l.updateFlag(name, func(list []string, _ bool) ([]string, bool) {
+		merged := []string{}
+
+		for _, f := range append(splitFeatures(list), splitFeatures(features)...) {
+			if indexFeature(merged, f) < 0 {
+				merged = append(merged, f)
+			}
+		}
+
+		return merged, true
+	})
+}
+
+// removeFeatures from the flag, the flag will be deleted if it becomes empty.
+func (l *Launcher) removeFeatures(name flags.Flag, features []string) *Launcher {
+	features = splitFeatures(features)
+	return l.updateFlag(name, func(list []string, _ bool) ([]string, bool) {
+		rest := []string{}
+		for _, f := range splitFeatures(list) {
+			if indexFeature(features, f) < 0 {
+				rest = append(rest, f)
+			}
+		}
+
+		return rest, len(rest) > 0
+	})
+}
+
+// splitFeatures splits the comma separated values into the features, the empty ones are dropped.
+func splitFeatures(values []string) []string {
+	list := []string{}
+	for _, v := range values {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				list = append(list, f)
+			}
+		}
+	}
+	return list
+}
+
+// indexFeature returns the index of the feature in the list by the feature name, -1 if not found.
+func indexFeature(list []string, feature string) int {
+	for i, f := range list {
+		if featureName(f) == featureName(feature) {
+			return i
+		}
+	}
+	return -1
+}
+
+// featureName removes the field trial and params from a feature, such as "Name<Trial:k/v" to "Name".
+func featureName(feature string) string {
+	if i := strings.IndexAny(feature, "<:"); i >= 0 {
+		feature = feature[:i]
+	}
+	return strings.TrimSpace(feature)
+}
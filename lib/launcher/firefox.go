@@ -0,0 +1,262 @@
+package launcher
+
+import (
+	"compress/bzip2"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/defaults"
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/fetchup"
+	"github.com/ysmood/leakless"
+)
+
+// FirefoxVersionDefault is the default version of Firefox to download.
+// It's the last ESR that supports the CDP, Firefox 129 and later only support the WebDriver BiDi.
+const FirefoxVersionDefault = "128.0esr"
+
+// firefoxPrefs are the prefs required to automate Firefox via the CDP.
+var firefoxPrefs = map[string]string{
+	// enable both the WebDriver BiDi and the CDP
+	"remote.active-protocols": "3",
+
+	// the CDP implementation of Firefox doesn't support the site isolation
+	"fission.webContentIsolationStrategy": "0",
+	"fission.bfcacheInParent":             "false",
+
+	"app.update.disabledForTesting":              "true",
+	"browser.shell.checkDefaultBrowser":          "false",
+	"browser.startup.homepage_override.mstone":   `"ignore"`,
+	"browser.startup.page":                       "0",
+	"browser.tabs.warnOnClose":                   "false",
+	"browser.sessionstore.resume_from_crash":     "false",
+	"datareporting.policy.dataSubmissionEnabled": "false",
+	"dom.disable_open_during_load":               "false",
+	"toolkit.telemetry.reportingpolicy.firstRun": "false",
+}
+
+// NewFirefox is a preset to launch Firefox with the remote debugging protocol enabled,
+// so that the returned control url can be used by rod.New().ControlURL directly.
+// If the [flags.Bin] is empty, it searches the Firefox installed on the system via [LookPathFirefox],
+// then downloads the [FirefoxVersionDefault] if not found, downloading is only supported on Linux.
+// The [Launcher.Preferences] of it is the content of the user.js of the profile, such as:
+//
+//	user_pref("intl.accept_languages", "fr");
+//
+// Some of the methods of rod may not work, because the CDP implementation of Firefox is incomplete.
+func NewFirefox() *Launcher {
+	dir := defaults.Dir
+	if dir == "" {
+		dir = filepath.Join(DefaultUserDataDirPrefix, utils.RandString(8))
+	}
+
+	l := New()
+	l.Flags = map[flags.Flag][]string{
+		flags.Firefox:             nil,
+		flags.Bin:                 {defaults.Bin},
+		flags.Leakless:            nil,
+		flags.FirefoxProfile:      {dir},
+		flags.RemoteDebuggingPort: {defaults.Port},
+		flags.Headless:            nil,
+		"no-remote":               nil,
+		"new-instance":            nil,
+	}
+	if defaults.Show {
+		l.Delete(flags.Headless)
+	}
+	if defaults.Proxy != "" {
+		l.Preferences(firefoxProxyPrefs(defaults.Proxy))
+	}
+
+	return l
+}
+
+// firefoxProxyPrefs returns the user.js prefs to use the http proxy of the host, such as "127.0.0.1:8080".
+func firefoxProxyPrefs(host string) string {
+	h, port, _ := strings.Cut(host, ":")
+	return fmt.Sprintf(
+		"user_pref(\"network.proxy.type\", 1);\n"+
+			"user_pref(\"network.proxy.http\", %q);\nuser_pref(\"network.proxy.http_port\", %s);\n"+
+			"user_pref(\"network.proxy.ssl\", %q);\nuser_pref(\"network.proxy.ssl_port\", %s);\n",
+		h, port, h, port,
+	)
+}
+
+// setupFirefoxProfile writes the user.js of the profile, it contains the prefs required by the CDP
+// and the [flags.Preferences].
+func (l *Launcher) setupFirefoxProfile() {
+	dir, err := filepath.Abs(l.Get(flags.FirefoxProfile))
+	utils.E(err)
+
+	lines := []string{}
+	for k, v := range firefoxPrefs {
+		lines = append(lines, fmt.Sprintf("user_pref(%q, %s);", k, v))
+	}
+	sort.Strings(lines)
+
+	utils.E(utils.OutputFile(
+		filepath.Join(dir, "user.js"),
+		strings.Join(lines, "\n")+"\n"+l.Get(flags.Preferences),
+	))
+}
+
+// getFirefoxBin returns the installed Firefox, or downloads it if not found.
+func (l *Launcher) getFirefoxBin() (string, error) {
+	if bin, has := LookPathFirefox(); has {
+		return bin, nil
+	}
+
+	b := NewFirefoxBrowser()
+	b.Context = l.ctx
+	return b.Get()
+}
+
+// LookPathFirefox searches for the Firefox executable from often used paths on current operating system.
+func LookPathFirefox() (found string, has bool) {
+	list := map[string][]string{
+		"darwin": {
+			"/Applications/Firefox.app/Contents/MacOS/firefox",
+			"/Applications/Firefox Nightly.app/Contents/MacOS/firefox",
+		},
+		"linux": {
+			"firefox",
+			"firefox-esr",
+			"/usr/bin/firefox",
+			"/usr/bin/firefox-esr",
+			"/snap/bin/firefox",
+		},
+		"openbsd": {
+			"firefox",
+		},
+		"windows": append([]string{"firefox"}, expandWindowsExePaths(
+			`Mozilla Firefox\firefox.exe`,
+		)...),
+	}[runtime.GOOS]
+
+	for _, path := range list {
+		var err error
+		found, err = exec.LookPath(path)
+		has = err == nil
+		if has {
+			break
+		}
+	}
+
+	return
+}
+
+// FirefoxBrowser is a helper to download Firefox.
+type FirefoxBrowser struct {
+	Context context.Context
+
+	// Version of Firefox, such as "128.0esr"
+	Version string
+
+	// Host to download Firefox, default is "https://archive.mozilla.org/pub/firefox/releases"
+	Host string
+
+	// RootDir to download different Firefox versions.
+	RootDir string
+
+	// Log to print output
+	Logger utils.Logger
+
+	// LockPort a tcp port to prevent race downloading.
+	LockPort int
+
+	// HTTPClient to download Firefox
+	HTTPClient *http.Client
+}
+
+// NewFirefoxBrowser with default values.
+func NewFirefoxBrowser() *FirefoxBrowser {
+	return &FirefoxBrowser{
+		Context:  context.Background(),
+		Version:  FirefoxVersionDefault,
+		Host:     "https://archive.mozilla.org/pub/firefox/releases",
+		RootDir:  DefaultBrowserDir,
+		Logger:   log.New(os.Stdout, "[launcher.FirefoxBrowser]", log.LstdFlags),
+		LockPort: defaults.LockPort,
+	}
+}
+
+// Dir to download Firefox.
+func (lc *FirefoxBrowser) Dir() string {
+	return filepath.Join(lc.RootDir, "firefox-"+lc.Version)
+}
+
+// BinPath of the downloaded Firefox executable.
+func (lc *FirefoxBrowser) BinPath() string {
+	return filepath.Join(lc.Dir(), "firefox")
+}
+
+// URL to download Firefox for the current platform.
+func (lc *FirefoxBrowser) URL() (string, error) {
+	arch := map[string]string{
+		"linux_amd64": "linux-x86_64",
+		"linux_386":   "linux-i686",
+		"linux_arm64": "linux-aarch64",
+	}[runtime.GOOS+"_"+runtime.GOARCH]
+
+	if arch == "" {
+		return "", fmt.Errorf("downloading firefox is not supported on %s/%s, please install it", runtime.GOOS, runtime.GOARCH)
+	}
+
+	return fmt.Sprintf("%s/%s/%s/en-US/firefox-%s.tar.bz2", lc.Host, lc.Version, arch, lc.Version), nil
+}
+
+// Download Firefox.
+func (lc *FirefoxBrowser) Download() error {
+	u, err := lc.URL()
+	if err != nil {
+		return err
+	}
+
+	dir := lc.Dir()
+
+	fu := fetchup.New(u)
+	fu.Ctx = lc.Context
+	fu.Logger = lc.Logger
+	fu.SaveTo = dir
+	if lc.HTTPClient != nil {
+		fu.HttpClient = lc.HTTPClient
+	}
+
+	lc.Logger.Println(fetchup.EventDownload, u)
+
+	res, err := fu.Request(u)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	err = fu.UnTar(bzip2.NewReader(res.ProgressedBody))
+	if err != nil {
+		return fmt.Errorf("failed to download firefox: %w", err)
+	}
+
+	return fetchup.StripFirstDir(dir)
+}
+
+// Get the Firefox executable path, it downloads Firefox if the [FirefoxBrowser.BinPath] doesn't exist.
+func (lc *FirefoxBrowser) Get() (string, error) {
+	defer leakless.LockPort(lc.LockPort)()
+
+	if _, err := os.Stat(lc.BinPath()); err == nil {
+		return lc.BinPath(), nil
+	}
+
+	// Try to cleanup before downloading
+	_ = os.RemoveAll(lc.Dir())
+
+	return lc.BinPath(), lc.Download()
+}
@@ -18,15 +18,36 @@ const (
 	// App flag.
 	App Flag = "app"
 
+	// LoadExtension flag, the paths of the unpacked extensions to load.
+	LoadExtension Flag = "load-extension"
+
+	// DisableExtensionsExcept flag, the paths of the extensions that won't be disabled.
+	DisableExtensionsExcept Flag = "disable-extensions-except"
+
 	// RemoteDebuggingPort flag.
 	RemoteDebuggingPort Flag = "remote-debugging-port"
 
+	// RemoteDebuggingPipe flag, check launcher.Launcher.Pipe .
+	RemoteDebuggingPipe Flag = "remote-debugging-pipe"
+
 	// NoSandbox flag.
 	NoSandbox Flag = "no-sandbox"
 
 	// ProxyServer flag.
 	ProxyServer Flag = "proxy-server"
 
+	// EnableFeatures flag.
+	EnableFeatures Flag = "enable-features"
+
+	// DisableFeatures flag.
+	DisableFeatures Flag = "disable-features"
+
+	// HostResolverRules flag.
+	HostResolverRules Flag = "host-resolver-rules"
+
+	// CrashDumpsDir flag.
+	CrashDumpsDir Flag = "crash-dumps-dir"
+
 	// WindowSize flag.
 	WindowSize Flag = "window-size"
 
@@ -42,18 +63,56 @@ const (
 	// XVFB flag.
 	XVFB Flag = "rod-xvfb"
 
+	// ManagedXVFB is the display and the screen of the Xvfb started by the launcher,
+	// check launcher.Launcher.ManagedXVFB .
+	ManagedXVFB Flag = "rod-managed-xvfb"
+
 	// ProfileDir flag.
 	ProfileDir = "profile-directory"
 
 	// Preferences flag.
 	Preferences Flag = "rod-preferences"
 
+	// Policies is the json of the enterprise policies, check launcher.Launcher.Policies .
+	Policies Flag = "rod-policies"
+
+	// ManagedPolicies enables writing the [Policies] into the system-wide managed policy dirs,
+	// check launcher.Launcher.ManagedPolicies .
+	ManagedPolicies Flag = "rod-managed-policies"
+
 	// Leakless flag.
 	Leakless Flag = "rod-leakless"
 
 	// Bin is the browser executable file path. If it's empty, launcher will automatically search or download the bin.
 	Bin Flag = "rod-bin"
 
+	// ProxyUpstream flag.
+	ProxyUpstream Flag = "rod-proxy-upstream"
+
+	// Firefox flag marks the launcher to launch Firefox instead of Chromium, check launcher.NewFirefox .
+	Firefox Flag = "rod-firefox"
+
+	// FirefoxProfile is the profile dir of Firefox, it's like the [UserDataDir] of Chromium.
+	FirefoxProfile Flag = "profile"
+
+	// Channel of the branded browser to launch, check launcher.Launcher.Channel .
+	Channel Flag = "rod-channel"
+
+	// HeadlessShell flag to use the chrome-headless-shell, check launcher.Launcher.HeadlessShell .
+	HeadlessShell Flag = "rod-headless-shell"
+
+	// Version of the Chrome for Testing to launch, check launcher.Launcher.Version .
+	Version Flag = "rod-version"
+
+	// Docker is the image to launch the browser in a Docker container, check launcher.NewDocker .
+	Docker Flag = "rod-docker"
+
+	// DockerVolume is the volume mounted as the user data dir of the browser in the Docker container.
+	DockerVolume Flag = "rod-docker-volume"
+
+	// UserDataDirTemplate is the dir copied into the user data dir before launch, check launcher.Launcher.UserDataDirTemplate .
+	UserDataDirTemplate Flag = "rod-user-data-dir-template"
+
 	// KeepUserDataDir flag.
 	KeepUserDataDir Flag = "rod-keep-user-data-dir"
 
@@ -0,0 +1,150 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/ysmood/leakless"
+)
+
+// Guard is the mechanism that kills the browser after the current process exits.
+type Guard string
+
+const (
+	// GuardNone means the browser won't be killed, it's used when the [Launcher.Leakless] is disabled.
+	GuardNone Guard = "none"
+
+	// GuardLeakless uses the leakless guard process, it's the default.
+	GuardLeakless Guard = "leakless"
+
+	// GuardPdeathsig uses the parent death signal of Linux.
+	GuardPdeathsig Guard = "pdeathsig"
+
+	// GuardWatchdog uses a shell process that polls the pid of the current process.
+	GuardWatchdog Guard = "watchdog"
+
+	// GuardJobObject uses a job object of Windows that is closed with the current process.
+	GuardJobObject Guard = "job-object"
+)
+
+// Guard returns the mechanism in use to kill the browser after the current process exits.
+// It's empty before the browser is launched.
+// When the leakless guard process is blocked, such as by anti-virus software or corporate policies,
+// the launcher will fall back to the native mechanism of the OS, use [Launcher.GuardErr] to check why.
+func (l *Launcher) Guard() Guard {
+	return l.guard
+}
+
+// GuardErr returns the reason why the leakless guard process isn't used when the [Launcher.Leakless] is enabled.
+// It wraps [ErrLeaklessBlocked].
+func (l *Launcher) GuardErr() error {
+	return l.guardErr
+}
+
+// start the browser process, the returned channel will be closed after the process exits.
+func (l *Launcher) start(bin string, args []string) (<-chan struct{}, error) {
+	if !l.Has(flags.Leakless) {
+		l.guard = GuardNone
+		cmd := exec.Command(bin, args...)
+		l.setupCmd(cmd)
+		return l.startCmd(cmd, func() error { return nil })
+	}
+
+	if l.Has(flags.RemoteDebuggingPipe) {
+		l.guardErr = fmt.Errorf("%w: it can't pass the pipes to the browser", ErrLeaklessBlocked)
+	} else if leakless.Support() {
+		done, err := l.startLeakless(bin, args)
+		if !errors.Is(err, ErrLeaklessBlocked) {
+			l.guard = GuardLeakless
+			return done, err
+		}
+		l.guardErr = err
+	} else {
+		l.guardErr = fmt.Errorf("%w: the platform is not supported", ErrLeaklessBlocked)
+	}
+
+	cmd := exec.Command(bin, args...)
+	l.setupCmd(cmd)
+
+	guard, afterStart := l.osGuard(cmd)
+	l.guard = guard
+
+	_, _ = fmt.Fprintf(l.logger, "[launcher] %v, fall back to the %s guard\n", l.guardErr, guard)
+
+	return l.startCmd(cmd, afterStart)
+}
+
+func (l *Launcher) startCmd(cmd *exec.Cmd, afterStart func() error) (<-chan struct{}, error) {
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	l.cmd = cmd
+	l.pid = cmd.Process.Pid
+
+	err = afterStart()
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return nil, err
+	}
+
+	return wait(cmd), nil
+}
+
+// startLeakless returns an error that wraps [ErrLeaklessBlocked] if the guard process can't run.
+func (l *Launcher) startLeakless(bin string, args []string) (done <-chan struct{}, err error) {
+	ll := leakless.New()
+
+	var cmd *exec.Cmd
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%w: %v", ErrLeaklessBlocked, r)
+			}
+		}()
+		cmd = ll.Command(bin, args...)
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	l.setupCmd(cmd)
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLeaklessBlocked, err)
+	}
+
+	l.cmd = cmd
+	done = wait(cmd)
+
+	select {
+	case l.pid = <-ll.Pid():
+		if ll.Err() != "" {
+			return nil, errors.New(ll.Err())
+		}
+		return done, nil
+
+	case <-done:
+		select {
+		case <-ll.Pid():
+			if ll.Err() != "" {
+				return nil, errors.New(ll.Err())
+			}
+		default:
+		}
+		return nil, fmt.Errorf("%w: the guard process exited: %v", ErrLeaklessBlocked, cmd.ProcessState)
+	}
+}
+
+func wait(cmd *exec.Cmd) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		osWait(cmd)
+		close(done)
+	}()
+	return done
+}
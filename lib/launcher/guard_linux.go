@@ -0,0 +1,16 @@
+package launcher
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// osGuard kills the browser when the thread that started it exits.
+func (l *Launcher) osGuard(cmd *exec.Cmd) (Guard, func() error) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Pdeathsig = syscall.SIGKILL
+
+	return GuardPdeathsig, func() error { return nil }
+}
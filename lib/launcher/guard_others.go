@@ -0,0 +1,29 @@
+//go:build !windows && !linux
+
+package launcher
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// watchdogScript runs the command in background and kills it after the parent pid is gone.
+const watchdogScript = `p=$1; shift; "$@" & c=$!
+trap 'kill -9 $c 2>/dev/null' TERM INT
+while kill -0 $p 2>/dev/null && kill -0 $c 2>/dev/null; do sleep 1; done
+kill -9 $c 2>/dev/null; wait $c`
+
+// osGuard wraps the command with a shell watchdog, the browser and the watchdog are in the same process group.
+func (l *Launcher) osGuard(cmd *exec.Cmd) (Guard, func() error) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return GuardNone, func() error { return nil }
+	}
+
+	args := []string{"sh", "-c", watchdogScript, "sh", strconv.Itoa(os.Getpid()), cmd.Path}
+	cmd.Args = append(args, cmd.Args[1:]...)
+	cmd.Path = sh
+
+	return GuardWatchdog, func() error { return nil }
+}
@@ -0,0 +1,113 @@
+//go:build windows
+
+package launcher
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+)
+
+const (
+	jobObjectExtendedLimitInformation = 9
+	jobObjectLimitKillOnJobClose      = 0x2000
+	processSetQuota                   = 0x0100
+)
+
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type jobObjectExtendedLimitInformationT struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// newJobObject creates a job object. If killOnClose is true, the job kills all its processes when the handle is closed,
+// the handle is closed by the OS after the current process exits.
+func newJobObject(killOnClose bool) (syscall.Handle, error) {
+	h, _, err := procCreateJobObjectW.Call(0, 0)
+	if h == 0 {
+		return 0, fmt.Errorf("create job object: %w", err)
+	}
+
+	if !killOnClose {
+		return syscall.Handle(h), nil
+	}
+
+	info := jobObjectExtendedLimitInformationT{}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+
+	ok, _, err := procSetInformationJobObject.Call(
+		h,
+		jobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)),
+		unsafe.Sizeof(info),
+	)
+	if ok == 0 {
+		_ = syscall.CloseHandle(syscall.Handle(h))
+		return 0, fmt.Errorf("set job object information: %w", err)
+	}
+
+	return syscall.Handle(h), nil
+}
+
+// assignJobObject assigns the process to the job, the children of the process will also be in the job.
+func assignJobObject(job syscall.Handle, pid int) error {
+	p, err := syscall.OpenProcess(processSetQuota|syscall.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = syscall.CloseHandle(p) }()
+
+	ok, _, err := procAssignProcessToJobObject.Call(uintptr(job), uintptr(p))
+	if ok == 0 {
+		return fmt.Errorf("assign job object: %w", err)
+	}
+	return nil
+}
+
+// osGuard assigns the browser to a job object that is closed with the current process.
+func (l *Launcher) osGuard(cmd *exec.Cmd) (Guard, func() error) {
+	job, err := newJobObject(true)
+	if err != nil {
+		return GuardNone, func() error { return nil }
+	}
+
+	return GuardJobObject, func() error {
+		err := assignJobObject(job, cmd.Process.Pid)
+		if err == nil {
+			l.group = uintptr(job)
+		}
+		return err
+	}
+}
@@ -0,0 +1,47 @@
+package launcher
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// HeadlessShell switch. If enabled, the launcher uses the chrome-headless-shell, a separate binary of the
+// old headless mode that is faster for pure headless work. When the [flags.Bin] is empty, it will be downloaded
+// via the [Browser.HeadlessShell]. It also enables the [Launcher.Headless].
+func (l *Launcher) HeadlessShell(enable bool) *Launcher {
+	if enable {
+		return l.Set(flags.HeadlessShell).Headless(true)
+	}
+	return l.Delete(flags.HeadlessShell)
+}
+
+// headlessShellURL resolves the download url of the chrome-headless-shell for the [Browser.Revision].
+func (lc *Browser) headlessShellURL() (string, error) {
+	if cftPlatform == "" {
+		return "", fmt.Errorf("chrome-headless-shell is not available for your OS")
+	}
+
+	list, err := lc.cftVersions()
+	if err != nil {
+		return "", err
+	}
+
+	found, foundRev := "", 0
+	for _, v := range list {
+		rev, err := strconv.Atoi(v.Revision)
+		if err != nil || rev > lc.Revision || rev <= foundRev {
+			continue
+		}
+
+		if u := v.download(cftHeadlessShell); u != "" {
+			found, foundRev = u, rev
+		}
+	}
+
+	if found == "" {
+		return "", fmt.Errorf("no chrome-headless-shell is available for revision %d on %s", lc.Revision, cftPlatform)
+	}
+	return found, nil
+}
@@ -0,0 +1,466 @@
+// Package k8s launches the browser in a Kubernetes Pod via the kubectl cli, such as:
+//
+//	l := k8s.New().Namespace("browsers")
+//	defer l.Cleanup()
+//
+//	browser := rod.New().ControlURL(l.MustLaunch()).MustConnect()
+//
+// The Pod is created from the [DefaultTemplate] or the [Launcher.Template], after it's ready the devtools port
+// is port-forwarded to a random port of 127.0.0.1, or the ip of the Pod is used if the [Launcher.Expose] is set.
+// The kubectl uses the current context of the kubeconfig, use [Launcher.Kubectl] to customize it.
+// The Pods are labeled with the [Labels], use [Launcher.CleanupAll] to remove the Pods left behind by the crashed processes.
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/launcher"
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// DevtoolsPort is the devtools port of the browser inside the Pod.
+const DevtoolsPort = 9222
+
+// DefaultTemplate is the default Pod manifest of [Launcher.Template].
+// The readiness probe makes sure the devtools port is listening when the Pod is ready, the devtools only
+// listens on the loopback of the Pod if it's not exposed, so the probe runs inside the container via bash.
+const DefaultTemplate = `{
+	"apiVersion": "v1",
+	"kind": "Pod",
+	"metadata": {
+		"name": {{json .Name}},
+		{{- if .Namespace}}
+		"namespace": {{json .Namespace}},
+		{{- end}}
+		"labels": {{json .Labels}}
+	},
+	"spec": {
+		"restartPolicy": "Never",
+		{{- if .ActiveDeadline}}
+		"activeDeadlineSeconds": {{.ActiveDeadline}},
+		{{- end}}
+		"containers": [{
+			"name": "browser",
+			"image": {{json .Image}},
+			"command": [{{json .Bin}}],
+			"args": {{json .Args}},
+			"env": {{json .Env}},
+			"ports": [{"containerPort": {{.Port}}}],
+			{{- if .Expose}}
+			"readinessProbe": {"tcpSocket": {"port": {{.Port}}}, "periodSeconds": 1}
+			{{- else}}
+			"readinessProbe": {
+				"exec": {"command": ["bash", "-c", "exec 3<>/dev/tcp/127.0.0.1/{{.Port}}"]},
+				"periodSeconds": 1
+			}
+			{{- end}}
+		}]
+	}
+}`
+
+// Labels of the Pods created by the [Launcher], use [Launcher.Label] to add more.
+var Labels = map[string]string{
+	"app.kubernetes.io/name":       "rod-browser",
+	"app.kubernetes.io/managed-by": "rod",
+}
+
+// ErrNotReady is returned by [Launcher.Launch] if the Pod isn't ready before the [Launcher.Timeout].
+var ErrNotReady = errors.New("the browser pod isn't ready")
+
+// EnvVar of the container of the Pod.
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Pod is the data to render the [Launcher.Template].
+type Pod struct {
+	// Name of the Pod, it's random for each launch
+	Name string
+
+	// Namespace of the Pod, empty means the namespace of the current kubectl context
+	Namespace string
+
+	// Image of the browser
+	Image string
+
+	// Bin is the browser executable path inside the image
+	Bin string
+
+	// Args of the browser
+	Args []string
+
+	// Env of the container, set via the [flags.Env]
+	Env []EnvVar
+
+	// Port of the devtools, it's the [DevtoolsPort]
+	Port int
+
+	// Expose is true if the devtools listens on the ip of the Pod, check [Launcher.Expose]
+	Expose bool
+
+	// Labels of the Pod, check [Labels]
+	Labels map[string]string
+
+	// ActiveDeadline is the max lifetime of the Pod in seconds, 0 means no limit, check [Launcher.ActiveDeadline]
+	ActiveDeadline int
+}
+
+// regForwarding matches the output of the "kubectl port-forward".
+var regForwarding = regexp.MustCompile(`Forwarding from 127\.0\.0\.1:(\d+) ->`)
+
+// Launcher to launch the browser in a Kubernetes Pod.
+type Launcher struct {
+	ctx     context.Context
+	browser *launcher.Launcher
+	logger  io.Writer
+
+	namespace   string
+	template    string
+	kubectl     string
+	kubectlArgs []string
+	expose      bool
+	timeout     time.Duration
+	deadline    time.Duration
+	labels      map[string]string
+
+	lock    sync.Mutex
+	pod     string
+	forward *exec.Cmd
+}
+
+// New returns a launcher that runs the [launcher.DockerImageDefault] image in a Pod.
+// The flags of the browser are the same as the [launcher.New], use [Launcher.Set] to customize them.
+func New() *Launcher {
+	browser := launcher.New().Leakless(false)
+	browser.Delete(flags.UserDataDir).Delete(flags.RemoteDebuggingPort)
+	browser.Set(flags.Docker, launcher.DockerImageDefault)
+	browser.Set(flags.Bin, "chrome")
+	browser.Set(flags.NoSandbox)
+
+	labels := map[string]string{}
+	for k, v := range Labels {
+		labels[k] = v
+	}
+
+	return &Launcher{
+		ctx:      context.Background(),
+		browser:  browser,
+		logger:   io.Discard,
+		template: DefaultTemplate,
+		kubectl:  "kubectl",
+		timeout:  3 * time.Minute,
+		deadline: time.Hour,
+		labels:   labels,
+	}
+}
+
+// Context sets the context of the kubectl calls.
+func (l *Launcher) Context(ctx context.Context) *Launcher {
+	l.ctx = ctx
+	return l
+}
+
+// Logger to print the kubectl outputs, defaults to [io.Discard].
+func (l *Launcher) Logger(w io.Writer) *Launcher {
+	l.logger = w
+	return l
+}
+
+// Image of the browser, the [flags.Bin] is the browser executable path inside the image.
+func (l *Launcher) Image(image string) *Launcher {
+	l.browser.Set(flags.Docker, image)
+	return l
+}
+
+// Namespace of the Pod, defaults to the namespace of the current kubectl context.
+func (l *Launcher) Namespace(ns string) *Launcher {
+	l.namespace = ns
+	return l
+}
+
+// Template of the Pod manifest, it's a [text/template] of a json or yaml manifest rendered with the [Pod],
+// the "json" function is available to encode the values. Check the [DefaultTemplate] as an example,
+// such as to add the resource limits or the node selector.
+func (l *Launcher) Template(tpl string) *Launcher {
+	l.template = tpl
+	return l
+}
+
+// Kubectl sets the path of the kubectl cli and the global args of it, such as:
+//
+//	l.Kubectl("kubectl", "--context", "staging", "--kubeconfig", "/path/to/config")
+//
+// An empty bin means the default "kubectl".
+func (l *Launcher) Kubectl(bin string, args ...string) *Launcher {
+	if bin != "" {
+		l.kubectl = bin
+	}
+	l.kubectlArgs = args
+	return l
+}
+
+// Expose the devtools endpoint via the ip of the Pod instead of the port-forward,
+// use it when the program runs inside the same cluster. By default the devtools only listens on
+// the loopback of the Pod, because the devtools has no auth, anyone who can reach the ip of the Pod
+// can control the browser.
+func (l *Launcher) Expose(enable bool) *Launcher {
+	l.expose = enable
+	return l
+}
+
+// Timeout to wait for the Pod to be ready, defaults to 3 minutes.
+func (l *Launcher) Timeout(d time.Duration) *Launcher {
+	l.timeout = d
+	return l
+}
+
+// ActiveDeadline is the max lifetime of the Pod, the cluster kills the Pod after it even if the
+// [Launcher.Cleanup] isn't called, such as the process crashed. Defaults to 1 hour, 0 means no limit.
+func (l *Launcher) ActiveDeadline(d time.Duration) *Launcher {
+	l.deadline = d
+	return l
+}
+
+// Label adds a label to the Pods, such as the id of the test run to [Launcher.CleanupAll] only its Pods.
+func (l *Launcher) Label(key, value string) *Launcher {
+	l.labels[key] = value
+	return l
+}
+
+// Set a flag of the browser, check [launcher.Launcher.Set].
+func (l *Launcher) Set(name flags.Flag, values ...string) *Launcher {
+	l.browser.Set(name, values...)
+	return l
+}
+
+// Delete a flag of the browser, check [launcher.Launcher.Delete].
+func (l *Launcher) Delete(name flags.Flag) *Launcher {
+	l.browser.Delete(name)
+	return l
+}
+
+// PodName returns the name of the Pod of the last launch, empty if it's not launched or cleaned up.
+func (l *Launcher) PodName() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.pod
+}
+
+// Manifest renders the [Launcher.Template] for the Pod with the name.
+func (l *Launcher) Manifest(name string) (string, error) {
+	tpl, err := template.New("pod").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(l.template)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = tpl.Execute(buf, l.podOf(name))
+	return buf.String(), err
+}
+
+// podOf returns the data of the Pod with the name to render the template.
+func (l *Launcher) podOf(name string) *Pod {
+	c := l.browser.Clone()
+	c.Delete(flags.Bin).Delete(flags.Docker).Delete(flags.Env).Delete(flags.UserDataDir)
+	c.Set(flags.RemoteDebuggingPort, strconv.Itoa(DevtoolsPort))
+	if l.expose {
+		c.Set("remote-debugging-address", "0.0.0.0")
+	} else {
+		c.Set("remote-debugging-address", "127.0.0.1")
+	}
+
+	env := []EnvVar{}
+	list, _ := l.browser.GetFlags(flags.Env)
+	for _, e := range list {
+		k, v, _ := strings.Cut(e, "=")
+		env = append(env, EnvVar{k, v})
+	}
+
+	return &Pod{
+		Name:           name,
+		Namespace:      l.namespace,
+		Image:          l.browser.Get(flags.Docker),
+		Bin:            l.browser.Get(flags.Bin),
+		Args:           c.FormatArgs(),
+		Env:            env,
+		Port:           DevtoolsPort,
+		Expose:         l.expose,
+		Labels:         l.labels,
+		ActiveDeadline: int(l.deadline.Seconds()),
+	}
+}
+
+// Launch creates the Pod, waits for it to be ready, and returns the control url of the browser.
+// If it fails the Pod will be removed. The Pod of the previous launch is removed before the launch.
+func (l *Launcher) Launch() (string, error) {
+	l.Cleanup()
+
+	name := "rod-browser-" + utils.RandString(8)
+
+	manifest, err := l.Manifest(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to render the pod template: %w", err)
+	}
+
+	l.lock.Lock()
+	l.pod = name
+	l.lock.Unlock()
+
+	create := l.command("create", "-f", "-")
+	create.Stdin = strings.NewReader(manifest)
+	if out, err := create.CombinedOutput(); err != nil {
+		l.Cleanup()
+		return "", fmt.Errorf("failed to create the browser pod: %w %s", err, out)
+	}
+
+	u, err := l.connect(name)
+	if err != nil {
+		l.Cleanup()
+		return "", err
+	}
+	return u, nil
+}
+
+// MustLaunch is similar to [Launcher.Launch].
+func (l *Launcher) MustLaunch() string {
+	u, err := l.Launch()
+	utils.E(err)
+	return u
+}
+
+// connect waits for the Pod to be ready and returns the control url of it.
+func (l *Launcher) connect(name string) (string, error) {
+	wait := l.command("wait", "--for=condition=Ready", "pod/"+name,
+		"--timeout="+l.timeout.String())
+	if out, err := wait.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%w: %s: %w %s", ErrNotReady, name, err, out)
+	}
+
+	if l.expose {
+		out, err := l.command("get", "pod/"+name, "-o", "jsonpath={.status.podIP}").Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to get the ip of the browser pod: %w", err)
+		}
+		return launcher.ResolveURL(strings.TrimSpace(string(out)) + ":" + strconv.Itoa(DevtoolsPort))
+	}
+
+	port, err := l.portForward(name)
+	if err != nil {
+		return "", err
+	}
+	return launcher.ResolveURL("127.0.0.1:" + port)
+}
+
+// portForward forwards a random local port to the devtools port of the Pod and returns the local port.
+func (l *Launcher) portForward(name string) (string, error) {
+	cmd := l.command("port-forward", "--address", "127.0.0.1", "pod/"+name, ":"+strconv.Itoa(DevtoolsPort))
+	cmd.Stderr = l.logger
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to port-forward the browser pod: %w", err)
+	}
+
+	l.lock.Lock()
+	l.forward = cmd
+	l.lock.Unlock()
+
+	r := bufio.NewReader(out)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("failed to port-forward the browser pod: %w", err)
+		}
+		_, _ = io.WriteString(l.logger, line)
+
+		if m := regForwarding.FindStringSubmatch(line); m != nil {
+			go func() { _, _ = io.Copy(l.logger, r) }()
+			return m[1], nil
+		}
+	}
+}
+
+// Cleanup stops the port-forward and removes the Pod, it's safe to call it multiple times.
+func (l *Launcher) Cleanup() {
+	l.lock.Lock()
+	pod, forward := l.pod, l.forward
+	l.pod, l.forward = "", nil
+	l.lock.Unlock()
+
+	if forward != nil {
+		_ = forward.Process.Kill()
+		_ = forward.Wait()
+	}
+
+	if pod == "" {
+		return
+	}
+
+	// the ctx may be canceled already, the pod should still be removed
+	out, err := exec.Command(l.kubectl, l.args("delete", "pod/"+pod, "--ignore-not-found", "--wait=false")...).
+		CombinedOutput()
+	if err != nil {
+		_, _ = fmt.Fprintf(l.logger, "[k8s] failed to remove the pod %s: %v %s\n", pod, err, out)
+	}
+}
+
+// CleanupAll removes all the Pods that have the labels of the launcher in the namespace, including the ones
+// created by the other launchers or the crashed processes, use [Launcher.Label] to narrow them down.
+func (l *Launcher) CleanupAll() error {
+	l.Cleanup()
+
+	keys := make([]string, 0, len(l.labels))
+	for k := range l.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	selector := make([]string, 0, len(keys))
+	for _, k := range keys {
+		selector = append(selector, k+"="+l.labels[k])
+	}
+
+	out, err := exec.Command(l.kubectl, l.args("delete", "pod", "-l", strings.Join(selector, ","),
+		"--ignore-not-found", "--wait=false")...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to remove the browser pods: %w %s", err, out)
+	}
+	return nil
+}
+
+// command returns the kubectl command with the args.
+func (l *Launcher) command(args ...string) *exec.Cmd {
+	return exec.CommandContext(l.ctx, l.kubectl, l.args(args...)...)
+}
+
+// args prepends the global args and the namespace to the args of the kubectl.
+func (l *Launcher) args(args ...string) []string {
+	list := append([]string{}, l.kubectlArgs...)
+	if l.namespace != "" {
+		list = append(list, "--namespace", l.namespace)
+	}
+	return append(list, args...)
+}
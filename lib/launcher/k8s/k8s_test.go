@@ -0,0 +1,209 @@
+//go:build !windows
+
+package k8s_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/launcher"
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/launcher/k8s"
+	"github.com/ysmood/got"
+)
+
+// fakeKubectl returns a fake kubectl cli that records the calls to the log and the manifest to the manifest file,
+// the browser of the pod is the devtools server s.
+func fakeKubectl(t *testing.T, s *httptest.Server, ready bool) (bin, log, manifest string) {
+	g := got.T(t)
+	host, port, _ := strings.Cut(strings.TrimPrefix(s.URL, "http://"), ":")
+
+	dir := t.TempDir()
+	log = filepath.Join(dir, "log")
+	manifest = filepath.Join(dir, "manifest")
+	bin = filepath.Join(dir, "kubectl")
+
+	exit := "0"
+	if !ready {
+		exit = "1"
+	}
+
+	g.E(os.WriteFile(bin, []byte(`#!/bin/sh
+echo "$@" >> `+log+`
+case "$*" in
+*create*) cat > `+manifest+` ;;
+*wait*) exit `+exit+` ;;
+*get*) echo "`+host+`" ;;
+*port-forward*) echo "Forwarding from 127.0.0.1:`+port+` -> 9222"; exec sleep 30 ;;
+esac
+`), 0o755))
+
+	return
+}
+
+func devtools(g got.G) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"webSocketDebuggerUrl": "ws://test.com"}`))
+	}))
+	g.Cleanup(s.Close)
+	return s
+}
+
+func TestLaunch(t *testing.T) {
+	g := got.T(t)
+
+	s := devtools(g)
+	bin, log, manifest := fakeKubectl(t, s, true)
+
+	l := k8s.New().Kubectl(bin, "--context", "test").Namespace("browsers").
+		Image("img").Set(flags.Env, "A=1").Set("lang", "en")
+
+	// the host of the control url is replaced with the forwarded address
+	g.Eq(l.MustLaunch(), "ws://"+strings.TrimPrefix(s.URL, "http://"))
+
+	name := l.PodName()
+	g.Has(name, "rod-browser-")
+
+	pod := readPod(g, manifest)
+	g.Eq(pod.Metadata.Name, name)
+	g.Eq(pod.Metadata.Namespace, "browsers")
+	g.Eq(pod.Metadata.Labels, map[string]string{
+		"app.kubernetes.io/name":       "rod-browser",
+		"app.kubernetes.io/managed-by": "rod",
+	})
+	g.Eq(pod.Spec.ActiveDeadlineSeconds, 3600)
+
+	c := pod.Spec.Containers[0]
+	g.Eq(c.Image, "img")
+	g.Eq(c.Command, []string{"chrome"})
+	g.Eq(c.Env, []k8s.EnvVar{{Name: "A", Value: "1"}})
+	g.Has(c.Args, "--lang=en")
+	g.Has(c.Args, "--remote-debugging-address=127.0.0.1")
+	g.Eq(c.ReadinessProbe.Exec.Command, []string{"bash", "-c", "exec 3<>/dev/tcp/127.0.0.1/9222"})
+	g.Nil(c.ReadinessProbe.TCPSocket)
+	g.Has(c.Args, "--remote-debugging-port=9222")
+	g.Has(c.Args, "--no-sandbox")
+	for _, arg := range c.Args {
+		g.False(strings.HasPrefix(arg, "--rod-"))
+		g.False(strings.HasPrefix(arg, "--user-data-dir"))
+	}
+
+	l.Cleanup()
+	l.Cleanup()
+	g.Eq(l.PodName(), "")
+
+	calls := strings.Split(strings.TrimSpace(g.Read(log).String()), "\n")
+	g.Len(calls, 4)
+	g.Eq(calls[0], "--context test --namespace browsers create -f -")
+	g.Eq(calls[1], "--context test --namespace browsers wait --for=condition=Ready pod/"+name+" --timeout=3m0s")
+	g.Eq(calls[2], "--context test --namespace browsers port-forward --address 127.0.0.1 pod/"+name+" :9222")
+	g.Eq(calls[3], "--context test --namespace browsers delete pod/"+name+" --ignore-not-found --wait=false")
+}
+
+type pod struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		ActiveDeadlineSeconds int `json:"activeDeadlineSeconds"`
+		Containers            []struct {
+			Image          string       `json:"image"`
+			Command        []string     `json:"command"`
+			Args           []string     `json:"args"`
+			Env            []k8s.EnvVar `json:"env"`
+			ReadinessProbe struct {
+				Exec *struct {
+					Command []string `json:"command"`
+				} `json:"exec"`
+				TCPSocket *struct {
+					Port int `json:"port"`
+				} `json:"tcpSocket"`
+			} `json:"readinessProbe"`
+		} `json:"containers"`
+	} `json:"spec"`
+}
+
+func readPod(g got.G, manifest string) *pod {
+	p := &pod{}
+	g.E(json.Unmarshal(g.Read(manifest).Bytes(), p))
+	return p
+}
+
+func TestExpose(t *testing.T) {
+	g := got.T(t)
+
+	bin, log, manifest := fakeKubectl(t, devtools(g), true)
+
+	// the devtools port of the fake pod ip isn't listening
+	_, err := k8s.New().Kubectl(bin).Expose(true).ActiveDeadline(0).Label("run", "1").Launch()
+	g.Has(err.Error(), ":9222")
+
+	p := readPod(g, manifest)
+	g.Eq(p.Metadata.Labels["run"], "1")
+	g.Eq(p.Spec.ActiveDeadlineSeconds, 0)
+	g.Has(p.Spec.Containers[0].Args, "--remote-debugging-address=0.0.0.0")
+	g.Eq(p.Spec.Containers[0].ReadinessProbe.TCPSocket.Port, 9222)
+	g.Nil(p.Spec.Containers[0].ReadinessProbe.Exec)
+
+	calls := strings.Split(strings.TrimSpace(g.Read(log).String()), "\n")
+	g.Len(calls, 4)
+	g.Has(calls[2], "get pod/rod-browser-")
+	g.Has(calls[2], "-o jsonpath={.status.podIP}")
+	g.Has(calls[3], "delete pod/rod-browser-")
+}
+
+func TestNotReady(t *testing.T) {
+	g := got.T(t)
+
+	bin, log, _ := fakeKubectl(t, devtools(g), false)
+
+	l := k8s.New().Kubectl(bin).Timeout(time.Second).Context(context.Background())
+
+	_, err := l.Launch()
+	g.Is(err, k8s.ErrNotReady)
+	g.Eq(l.PodName(), "")
+
+	calls := strings.Split(strings.TrimSpace(g.Read(log).String()), "\n")
+	g.Len(calls, 3)
+	g.Has(calls[1], "--timeout=1s")
+	g.Has(calls[2], "delete pod/rod-browser-")
+}
+
+func TestCleanupAll(t *testing.T) {
+	g := got.T(t)
+
+	bin, log, _ := fakeKubectl(t, devtools(g), true)
+
+	g.E(k8s.New().Kubectl(bin).Namespace("browsers").Label("run", "1").CleanupAll())
+	g.Eq(strings.TrimSpace(g.Read(log).String()), "--namespace browsers delete pod -l "+
+		"app.kubernetes.io/managed-by=rod,app.kubernetes.io/name=rod-browser,run=1 --ignore-not-found --wait=false")
+
+	// the default labels are not changed by the launchers
+	g.Len(k8s.Labels, 2)
+
+	g.Has(k8s.New().Kubectl(filepath.Join(t.TempDir(), "none")).CleanupAll().Error(), "failed to remove the browser pods")
+}
+
+func TestTemplate(t *testing.T) {
+	g := got.T(t)
+
+	l := k8s.New().Template(`name: {{.Name}}, image: {{.Image}}, port: {{.Port}}`)
+
+	manifest, err := l.Manifest("pod")
+	g.E(err)
+	g.Eq(manifest, "name: pod, image: "+launcher.DockerImageDefault+", port: 9222")
+
+	g.Err(l.Template(`{{`).Manifest("pod"))
+
+	_, err = l.Launch()
+	g.Has(err.Error(), "failed to render the pod template")
+}
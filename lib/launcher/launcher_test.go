@@ -1,21 +1,31 @@
 package launcher_test
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bytes"
 	"crypto"
 	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"flag"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/xyjwsj/grod"
 	"github.com/xyjwsj/grod/lib/defaults"
 	"github.com/xyjwsj/grod/lib/launcher"
 	"github.com/xyjwsj/grod/lib/launcher/flags"
@@ -59,6 +69,178 @@ func TestDownload(t *testing.T) {
 	g.PathExists(b.Dir())
 }
 
+func TestDownloadProxy(t *testing.T) {
+	g := got.T(t)
+
+	buf := bytes.NewBuffer(nil)
+	z := zip.NewWriter(buf)
+	f, _ := z.Create("chrome/bin")
+	_, _ = f.Write([]byte("bin"))
+	_ = z.Close()
+	data := buf.Bytes()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "a.zip", time.Time{}, bytes.NewReader(data))
+	}))
+	defer ts.Close()
+
+	tunnels := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+		if r.Method != http.MethodConnect || r.Header.Get("Proxy-Authorization") != auth {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		tunnels++
+
+		dst, err := net.Dial("tcp", r.Host)
+		g.E(err)
+		w.WriteHeader(http.StatusOK)
+		src, _, err := w.(http.Hijacker).Hijack()
+		g.E(err)
+
+		go func() { _, _ = io.Copy(dst, src) }()
+		_, _ = io.Copy(src, dst)
+		_ = src.Close()
+		_ = dst.Close()
+	}))
+	defer proxy.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+
+	newBrowser := func() *launcher.Browser {
+		b := launcher.NewBrowser()
+		b.RootDir = t.TempDir()
+		b.Logger = utils.LoggerQuiet
+		b.Retries = 0
+		b.Hosts = []launcher.Host{func(_ int) string { return ts.URL + "/a.zip" }}
+		b.Proxy = proxy.URL
+		b.ProxyAuth = url.UserPassword("user", "pass")
+		b.RootCAs = pool
+		return b
+	}
+
+	b := newBrowser()
+	g.Eq(b.MustGet(), b.BinPath())
+	g.Eq(tunnels, 1)
+
+	// the credentials in the url
+	b = newBrowser()
+	b.Proxy = strings.Replace(proxy.URL, "http://", "http://user:pass@", 1)
+	b.ProxyAuth = nil
+	g.E(b.Download())
+
+	b = newBrowser()
+	b.ProxyAuth = url.UserPassword("user", "wrong")
+	g.Has(b.Download().Error(), "Proxy Authentication Required")
+
+	b = newBrowser()
+	b.RootCAs = nil
+	g.Has(b.Download().Error(), "certificate")
+
+	b = newBrowser()
+	b.Proxy = "proxy.corp:3128"
+	g.Eq(b.Download().Error(), `invalid proxy to download the browser: "proxy.corp:3128"`)
+}
+
+func TestCleanupRevisions(t *testing.T) {
+	g := got.T(t)
+
+	b := launcher.NewBrowser()
+	b.RootDir = t.TempDir()
+	b.Revision = 10
+	b.Logger = utils.LoggerQuiet
+
+	for _, name := range []string{
+		"chromium-9", "chromium-10", "chromium-11", "chromium-12", "chromium-8",
+		"chrome-120.0.6099.71", "chrome-99.0.1.1", "chrome-headless-shell-10",
+		"chrome-stable", "firefox-128.0", "firefox-99.0", ".tmp-abc",
+	} {
+		g.E(utils.OutputFile(filepath.Join(b.RootDir, name, "bin"), "12345"))
+	}
+	g.E(utils.OutputFile(filepath.Join(b.RootDir, "chromium-8-00000000.download"), "123"))
+	g.E(os.Symlink(filepath.Join(b.RootDir, "chromium-9"), b.CurrentDir()))
+
+	r := b.MustCleanupRevisions(1)
+
+	removed := []string{}
+	for _, p := range r.Removed {
+		removed = append(removed, filepath.Base(p))
+	}
+	g.Eq(removed, []string{".tmp-abc", "chrome-99.0.1.1", "chromium-11", "chromium-8",
+		"chromium-8-00000000.download", "firefox-99.0"})
+	g.Eq(r.Reclaimed, int64(5*5+3))
+	g.Has(r.String(), "removed 6")
+
+	for _, name := range []string{"chromium-9", "chromium-10", "chromium-12", "chrome-stable", "chrome-headless-shell-10"} {
+		g.PathExists(filepath.Join(b.RootDir, name))
+	}
+
+	r = b.MustCleanupRevisions(1)
+	g.Len(r.Removed, 0)
+}
+
+func TestDownloadResume(t *testing.T) {
+	g := got.T(t)
+
+	buf := bytes.NewBuffer(nil)
+	z := zip.NewWriter(buf)
+	f, _ := z.Create(filepath.FromSlash("a/b/c.txt"))
+	_, _ = f.Write([]byte(g.RandStr(500 * 1024)))
+	_ = z.Close()
+	data := buf.Bytes()
+
+	ranges := []string{}
+	s := g.Serve()
+	s.Mux.HandleFunc("/a.zip", func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+
+		// the first attempt breaks in the middle
+		if len(ranges) == 1 {
+			w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+			_, _ = w.Write(data[:len(data)/2])
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "a.zip", time.Time{}, bytes.NewReader(data))
+	})
+
+	var last launcher.DownloadProgress
+	b := launcher.NewBrowser()
+	b.Revision = 2
+	b.Logger = utils.LoggerQuiet
+	b.Hosts = []launcher.Host{func(_ int) string {
+		return s.URL("/a.zip")
+	}}
+	b.Progress = func(p launcher.DownloadProgress) { last = p }
+
+	g.Cleanup(func() { _ = os.RemoveAll(b.Dir()) })
+
+	b.MustGet()
+
+	g.PathExists(b.Dir())
+	g.Len(ranges, 2)
+	g.Eq(ranges[0], "")
+	g.Has(ranges[1], "bytes=")
+	g.Neq(ranges[1], "bytes=0-")
+	g.Eq(last.Downloaded, int64(len(data)))
+	g.Eq(last.Total, int64(len(data)))
+	g.Has(last.String(), "MB/s")
+
+	list, err := filepath.Glob(b.Dir() + "-*.download")
+	g.E(err)
+	g.Len(list, 0)
+}
+
+func TestDownloadNoHosts(t *testing.T) {
+	g := got.T(t)
+
+	b := launcher.NewBrowser()
+	b.Hosts = nil
+
+	g.Has(b.Download().Error(), "can't find a browser binary")
+}
+
 func TestLaunch(t *testing.T) {
 	g := setup(t)
 
@@ -161,6 +343,42 @@ func TestAppMode(t *testing.T) {
 	l := launcher.NewAppMode("http://example.com")
 
 	g.Eq(l.Get(flags.App), "http://example.com")
+	g.False(l.Has("enable-automation"))
+	g.True(l.Has("test-type"))
+
+	l.NoInfobars(false)
+	g.True(l.Has("enable-automation"))
+	g.False(l.Has("test-type"))
+	g.False(l.Has("no-default-browser-check"))
+}
+
+func TestAppModeFS(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.MustNewAppModeFS(fstest.MapFS{
+		"index.html": {Data: []byte("<html>app</html>")},
+	})
+	g.False(l.Has(flags.Headless))
+
+	u, err := url.Parse(l.Get(flags.App))
+	g.E(err)
+	g.Eq(u.Hostname(), "127.0.0.1")
+	g.Eq(u.RawQuery, "")
+
+	// without the token the page waits for the cookie
+	res, err := http.Get(u.String())
+	g.E(err)
+	b, err := io.ReadAll(res.Body)
+	g.E(err)
+	_ = res.Body.Close()
+	g.Eq(res.StatusCode, http.StatusForbidden)
+	g.Has(string(b), "location.reload()")
+	g.Eq(res.Header.Get("Content-Security-Policy"), "")
+
+	// the server closes even if the launcher is never launched
+	l.Cleanup()
+	_, err = http.Get(u.String())
+	g.Err(err)
 }
 
 func TestGetWebSocketDebuggerURLErr(t *testing.T) {
@@ -210,6 +428,211 @@ func TestProfileDir(t *testing.T) {
 	g.True(file.IsDir())
 }
 
+func TestHostResolverRules(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().HostResolverRules(map[string]string{
+		"b.com":   "127.0.0.1:8443",
+		"*.a.com": "localhost",
+	})
+
+	g.Has(l.FormatArgs(), "--host-resolver-rules=MAP *.a.com localhost,MAP b.com 127.0.0.1:8443")
+}
+
+func TestFeatures(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().
+		EnableFeatures("A", "NetworkService", "A").
+		DisableFeatures("B")
+
+	g.Eq(l.Get(flags.EnableFeatures), "NetworkService")
+	list, _ := l.GetFlags(flags.EnableFeatures)
+	g.Eq(list, []string{"NetworkService", "NetworkServiceInProcess", "A"})
+	list, _ = l.GetFlags(flags.DisableFeatures)
+	g.Eq(list, []string{"site-per-process", "TranslateUI", "B"})
+
+	l = launcher.New().Delete(flags.DisableFeatures).DisableFeatures("B")
+	g.Has(l.FormatArgs(), "--disable-features=B")
+}
+
+func TestFeaturesMerge(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().Delete(flags.EnableFeatures).Delete(flags.DisableFeatures).
+		Append(flags.EnableFeatures, "A", "B").
+		Append(flags.EnableFeatures, "B", "C").
+		Append("--disable-blink-features", "X")
+	list, _ := l.GetFlags(flags.EnableFeatures)
+	g.Eq(list, []string{"A", "B", "C"})
+	g.Len(l.Conflicts(), 0)
+
+	l.Append(flags.DisableFeatures, "B<Trial", "D").Append("enable-blink-features", "X")
+	g.Eq(l.Conflicts(), []*launcher.FlagConflict{
+		{Flags: []flags.Flag{"enable-blink-features", "disable-blink-features"}, Feature: "X"},
+		{Flags: []flags.Flag{flags.EnableFeatures, flags.DisableFeatures}, Feature: "B"},
+	})
+	g.Eq(l.Conflicts()[1].String(), "feature B is in both --enable-features and --disable-features")
+
+	// the latest call wins
+	l.DisableFeatures("A").EnableFeatures("D:k/v")
+	list, _ = l.GetFlags(flags.EnableFeatures)
+	g.Eq(list, []string{"B", "C", "D:k/v"})
+	list, _ = l.GetFlags(flags.DisableFeatures)
+	g.Eq(list, []string{"B<Trial", "A"})
+
+	l = launcher.New().Headless(true).Set(flags.App, "http://test.com")
+	g.Eq(l.Conflicts()[0].String(), "--headless and --app are mutually exclusive")
+
+	// the comma separated values are split
+	l = launcher.New().Delete(flags.DisableFeatures).Set(flags.EnableFeatures, "A,B, C,").
+		EnableFeatures("B,D", "A").Append(flags.DisableFeatures, "E,C")
+	list, _ = l.GetFlags(flags.EnableFeatures)
+	g.Eq(list, []string{"A", "B", "C", "D"})
+	g.Has(l.FormatArgs(), "--enable-features=A,B,C,D")
+	g.Eq(l.Conflicts()[0].Feature, "C")
+
+	l.DisableFeatures("A,D")
+	list, _ = l.GetFlags(flags.EnableFeatures)
+	g.Eq(list, []string{"B", "C"})
+	list, _ = l.GetFlags(flags.DisableFeatures)
+	g.Eq(list, []string{"E", "C", "A", "D"})
+}
+
+func TestFlagsConcurrent(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().Delete(flags.EnableFeatures)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v := fmt.Sprint(i)
+			l.Set(flags.Flag("test-"+v), v).
+				Append(flags.Arguments, v).
+				Append(flags.EnableFeatures, "F"+v).
+				Delete("test-none")
+			_ = l.FormatArgs()
+			_, _ = l.Validate()
+			_ = l.JSON()
+		}()
+	}
+	wg.Wait()
+
+	list, _ := l.GetFlags(flags.Arguments)
+	g.Len(list, 10)
+	list, _ = l.GetFlags(flags.EnableFeatures)
+	g.Len(list, 10)
+	g.Eq(l.Get("test-3"), "3")
+
+	// the returned list is a copy
+	list[0] = "x"
+	g.Neq(l.Get(flags.EnableFeatures), "x")
+}
+
+func TestStartURL(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().StartURL("http://b.com", "http://a.com")
+	g.Eq(l.StartURLs(), []string{"http://b.com", "http://a.com"})
+
+	args := l.FormatArgs()
+	g.Eq(args[len(args)-2:], []string{"http://b.com", "http://a.com"})
+}
+
+func TestClone(t *testing.T) {
+	g := setup(t)
+
+	base := launcher.New().Revision(1234).
+		Env("TZ=Asia/Tokyo").
+		PreferencesMerge(map[string]any{"a": 1}).
+		Set("test", "a")
+
+	c := base.Clone()
+	g.Eq(c.Get("test"), "a")
+	g.Eq(c.Get(flags.Env), "TZ=Asia/Tokyo")
+	g.Has(string(c.JSON()), `"revision":1234`)
+
+	// the random user data dir isn't shared
+	g.Neq(c.Get(flags.UserDataDir), base.Get(flags.UserDataDir))
+	g.Has(c.Get(flags.UserDataDir), launcher.DefaultUserDataDirPrefix)
+
+	c.Append("test", "b").Append(flags.Env, "A=B").PreferencesMerge(map[string]any{"b": 2}).Revision(1)
+	g.Eq(base.Get("test"), "a")
+	list, _ := base.GetFlags("test")
+	g.Eq(list, []string{"a"})
+	list, _ = base.GetFlags(flags.Env)
+	g.Eq(list, []string{"TZ=Asia/Tokyo"})
+	g.Eq(base.Get(flags.Preferences), `{"a":1}`)
+	g.Has(string(base.JSON()), `"revision":1234`)
+
+	// the fixed user data dir is kept
+	g.Eq(launcher.New().UserDataDir("tmp/a").Clone().Get(flags.UserDataDir), "tmp/a")
+
+	// the random volumes aren't shared
+	docker := launcher.NewDocker("")
+	c1, c2 := docker.Clone(), docker.Clone()
+	g.Has(c1.Get(flags.DockerVolume), "rod-user-data-")
+	g.Neq(c1.Get(flags.DockerVolume), docker.Get(flags.DockerVolume))
+	g.Neq(c1.Get(flags.DockerVolume), c2.Get(flags.DockerVolume))
+	g.Eq(docker.DockerVolume("shared").Clone().Get(flags.DockerVolume), "shared")
+
+	// the fixed display isn't shared
+	list, _ = launcher.New().ManagedXVFB(99, 800, 600).Clone().GetFlags(flags.ManagedXVFB)
+	g.Eq(list, []string{"", "800x600x24"})
+}
+
+func TestExtension(t *testing.T) {
+	g := setup(t)
+
+	abs, err := filepath.Abs("a")
+	g.E(err)
+
+	l := launcher.New().Set("disable-extensions").Extension("a", "a")
+	list, _ := l.GetFlags(flags.LoadExtension)
+	g.Eq(list, []string{abs})
+	list, _ = l.GetFlags(flags.DisableExtensionsExcept)
+	g.Eq(list, []string{abs})
+	list, _ = l.GetFlags(flags.DisableFeatures)
+	g.Has(list, "DisableLoadExtensionCommandLineSwitch")
+	g.False(l.Has("disable-extensions"))
+	g.Eq(l.Get(flags.Headless), "new")
+	g.Len(l.Conflicts(), 0)
+
+	l.Headless(true).HeadlessShell(true)
+	g.Eq(l.Conflicts(), []*launcher.FlagConflict{
+		{Flags: []flags.Flag{flags.Headless, flags.LoadExtension}},
+		{Flags: []flags.Flag{flags.HeadlessShell, flags.LoadExtension}},
+	})
+}
+
+func TestPreset(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().Preset(launcher.PresetCI(), launcher.PresetStealth())
+	g.Eq(l.Get(flags.Headless), "new")
+	g.True(l.Has(flags.NoSandbox))
+	g.True(l.Has(flags.Leakless))
+	g.False(l.Has("enable-automation"))
+	g.Eq(l.Get("disable-blink-features"), "AutomationControlled")
+	g.Eq(l.Get(flags.WindowSize), "1920,1080")
+
+	l = launcher.New().Headless(true).Preset(launcher.PresetStealth())
+	g.Eq(l.Get(flags.Headless), "new")
+
+	l = launcher.New().Preset(launcher.PresetKiosk()).StartURL("http://test.com")
+	g.False(l.Has(flags.Headless))
+	g.False(l.Has("no-startup-window"))
+	g.True(l.Has("kiosk"))
+	g.Len(l.Conflicts(), 0)
+
+	custom := func(l *launcher.Launcher) { l.Set("lang", "en-US") }
+	g.Eq(launcher.New().Preset(custom).Get("lang"), "en-US")
+}
+
 func TestBrowserValid(t *testing.T) {
 	g := setup(t)
 
@@ -325,3 +748,289 @@ func TestLaunchMultiTimes(t *testing.T) {
 	_, e = l.Launch()
 	g.Eq(e, launcher.ErrAlreadyLaunched)
 }
+
+func TestValidate(t *testing.T) {
+	g := setup(t)
+
+	bin, err := os.Executable()
+	g.E(err)
+	out := bytes.NewBuffer(nil)
+
+	l := launcher.New().Bin(bin).Logger(out).
+		Set("no-proxy-server").Proxy("a.com").
+		WorkingDir(g.RandStr(8)).
+		Env("A=1 2")
+	l.Flags["bad=flag"] = nil
+
+	preview, err := l.Validate()
+	g.Is(err, launcher.ErrInvalidLaunch)
+	g.Has(err.Error(), `malformed flag name: "bad=flag"`)
+	g.Has(err.Error(), "working dir")
+	g.Eq(preview.Bin, bin)
+	g.Eq(preview.Env, []string{"A=1 2"})
+	g.Len(preview.Conflicts, 1)
+	g.Has(preview.String(), "export 'A=1 2'")
+	g.Has(preview.String(), "--proxy-server=a.com")
+	g.Has(out.String(), "--proxy-server and --no-proxy-server are mutually exclusive")
+
+	l = launcher.New().Bin(bin)
+	preview, err = l.Validate()
+	g.E(err)
+	g.Nil(preview.Env)
+	g.Eq(preview.Args[0], bin)
+
+	_, err = launcher.New().Bin(g.RandStr(8)).Validate()
+	g.Has(err.Error(), "browser bin")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	g.E(err)
+	defer func() { _ = ln.Close() }()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	_, err = launcher.New().Bin(bin).RemoteDebuggingPort(port).Validate()
+	g.Has(err.Error(), "is not available")
+
+	_, err = launcher.New().Bin(bin).Leakless(false).RemoteDebuggingPort(port).Validate()
+	g.E(err)
+}
+
+func TestLauncherJSON(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().Revision(1234).
+		Env("TZ=Asia/Tokyo").
+		Preferences(`{"a":1}`).
+		Leakless(false).
+		XVFB("--server-num=5")
+
+	data := l.JSON()
+	g.Has(string(data), `"version":1`)
+	g.Has(string(data), `"revision":1234`)
+
+	l2 := launcher.New()
+	g.E(json.Unmarshal(data, l2))
+	g.Eq(l2.Flags, l.Flags)
+	g.Eq(l2.JSON(), data)
+
+	// the json without version is version 0
+	g.E(json.Unmarshal([]byte(`{"flags":{"headless":null}}`), l2))
+	g.Eq(l2.Flags, map[flags.Flag][]string{"headless": nil})
+
+	g.E(json.Unmarshal([]byte(`{}`), l2))
+	g.Eq(l2.Set("a").Flags, map[flags.Flag][]string{"a": nil})
+
+	err := json.Unmarshal([]byte(`{"flags":{},"unknown":1}`), l2)
+	g.Is(err, launcher.ErrLauncherJSON)
+	g.Has(err.Error(), `unknown field "unknown"`)
+
+	err = json.Unmarshal([]byte(`{"version":2}`), l2)
+	g.Eq(err.Error(), "invalid launcher json: version 2 is newer than the supported version 1")
+
+	err = json.Unmarshal([]byte(`{"flags":{"--a=b":null}}`), l2)
+	g.Eq(err.Error(), `invalid launcher json: malformed flag name: "--a=b"`)
+}
+
+func TestFirefox(t *testing.T) {
+	g := setup(t)
+
+	bin, err := os.Executable()
+	g.E(err)
+
+	dir := filepath.Join(g.RandStr(8), "profile")
+	l := launcher.NewFirefox().Bin(bin).UserDataDir(dir).StartURL("http://a.com")
+	g.False(l.Has(flags.UserDataDir))
+	g.Eq(l.Get(flags.FirefoxProfile), dir)
+
+	args := l.FormatArgs()
+	abs, err := filepath.Abs(dir)
+	g.E(err)
+	g.Eq(args[len(args)-2:], []string{"--profile", abs})
+	g.Has(args, "--headless")
+	g.Has(args, "--remote-debugging-port=0")
+	g.Has(args, "http://a.com")
+
+	preview, err := l.Validate()
+	g.E(err)
+	g.Eq(preview.Bin, bin)
+}
+
+func TestFirefoxConnect(t *testing.T) {
+	g := setup(t)
+
+	if _, has := launcher.LookPathFirefox(); !has {
+		t.Skip("firefox is not installed")
+	}
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>ok</body></html>`)
+
+	l := launcher.NewFirefox()
+	defer l.Cleanup()
+
+	browser := rod.New().ControlURL(l.MustLaunch()).MustConnect()
+	defer browser.MustClose()
+
+	page := browser.MustPage(s.URL()).MustWaitLoad()
+	g.Eq(page.MustElement("body").MustText(), "ok")
+	g.Eq(page.MustEval(`() => navigator.userAgent.includes("Firefox")`).Bool(), true)
+}
+
+func TestFirefoxDownload(t *testing.T) {
+	g := setup(t)
+
+	// the go std lib can't compress bzip2
+	bz, err := exec.LookPath("bzip2")
+	if err != nil {
+		t.Skip("bzip2 is not installed")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+	content := "#!/bin/sh\necho firefox\n"
+	g.E(tw.WriteHeader(&tar.Header{Name: "firefox/firefox", Mode: 0o755, Size: int64(len(content))}))
+	_, err = tw.Write([]byte(content))
+	g.E(err)
+	g.E(tw.Close())
+
+	cmd := exec.Command(bz, "-c")
+	cmd.Stdin = buf
+	archive, err := cmd.Output()
+	g.E(err)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(archive)
+	})
+
+	b := launcher.NewFirefoxBrowser()
+	b.Host = s.URL()
+	b.RootDir = filepath.Join(os.TempDir(), "rod", g.RandStr(8))
+	b.Logger = utils.LoggerQuiet
+	defer func() { _ = os.RemoveAll(b.RootDir) }()
+
+	u, err := b.URL()
+	if err != nil {
+		g.Has(err.Error(), "downloading firefox is not supported")
+		return
+	}
+	g.Has(u, "/128.0esr/")
+
+	p, err := b.Get()
+	g.E(err)
+	g.Eq(p, b.BinPath())
+	g.Eq(g.Read(p).String(), content)
+
+	// the second time won't download
+	p, err = b.Get()
+	g.E(err)
+	g.Eq(p, b.BinPath())
+}
+
+func TestChannel(t *testing.T) {
+	g := setup(t)
+
+	c, ok := launcher.Channel("chrome-beta").Normalize()
+	g.True(ok)
+	g.Eq(c, launcher.ChannelBeta)
+	g.True(launcher.ChannelMSEdgeDev.IsEdge())
+
+	_, ok = launcher.Channel("nightly").Normalize()
+	g.False(ok)
+	_, has := launcher.LookPathChannel("nightly")
+	g.False(has)
+
+	_, err := launcher.New().Logger(io.Discard).Channel("nightly").Validate()
+	g.Is(err, launcher.ErrInvalidLaunch)
+	g.Has(err.Error(), "unknown browser channel: nightly")
+
+	p, err := launcher.New().Logger(io.Discard).Channel(launcher.ChannelCanary).Validate()
+	g.E(err)
+	if _, has := launcher.LookPathChannel(launcher.ChannelCanary); !has {
+		g.True(p.Download)
+		g.Eq(p.Bin, launcher.NewChannelBrowser(launcher.ChannelCanary).BinPath())
+	}
+}
+
+func TestChannelDownload(t *testing.T) {
+	g := setup(t)
+
+	b := launcher.NewChannelBrowser("chrome-dev")
+	b.RootDir = filepath.Join(os.TempDir(), "rod", g.RandStr(8))
+	b.Logger = utils.LoggerQuiet
+	defer func() { _ = os.RemoveAll(b.RootDir) }()
+
+	rel, err := filepath.Rel(b.Dir(), b.BinPath())
+	g.E(err)
+
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("chrome-test/" + filepath.ToSlash(rel))
+	g.E(err)
+	_, err = w.Write([]byte("chrome"))
+	g.E(err)
+	g.E(zw.Close())
+
+	version := "130.0.1"
+	s := g.Serve()
+	s.Mux.HandleFunc("/chrome.zip", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(buf.Bytes())
+	})
+	s.Mux.HandleFunc("/versions.json", func(w http.ResponseWriter, _ *http.Request) {
+		downloads := []map[string]string{}
+		for _, p := range []string{"linux64", "mac-x64", "mac-arm64", "win32", "win64"} {
+			downloads = append(downloads, map[string]string{"platform": p, "url": s.URL("/chrome.zip")})
+		}
+		g.E(json.NewEncoder(w).Encode(map[string]interface{}{
+			"channels": map[string]interface{}{
+				"Dev": map[string]interface{}{"version": version, "downloads": map[string]interface{}{"chrome": downloads}},
+			},
+		}))
+	})
+	b.VersionsURL = s.URL("/versions.json")
+
+	v, _, err := b.Latest()
+	if err != nil {
+		g.Has(err.Error(), "downloading chrome is not supported")
+		return
+	}
+	g.Eq(v, version)
+
+	p, err := b.Get()
+	g.E(err)
+	g.Eq(p, b.BinPath())
+	g.Eq(g.Read(p).String(), "chrome")
+
+	// the latest version is cached within the ttl
+	b.VersionsURL = s.URL("/404")
+	version = "131.0.0"
+	g.Eq(b.MustGet(), p)
+
+	// the new version is downloaded beside the old one that may be in use
+	b.VersionsURL = s.URL("/versions.json")
+	b.TTL = 0
+	g.E(os.WriteFile(p, []byte("old"), 0o600))
+	p2, err := b.Get()
+	g.E(err)
+	g.Neq(p2, p)
+	g.Eq(p2, b.BinPath())
+	g.Eq(g.Read(p2).String(), "chrome")
+	g.Eq(g.Read(p).String(), "old")
+
+	// the downloaded one is used when the versions can't be resolved
+	b.VersionsURL = s.URL("/404")
+	p, err = b.Get()
+	g.E(err)
+	g.Eq(p, p2)
+
+	report, err := b.Prune()
+	g.E(err)
+	g.Eq(report.Removed, []string{filepath.Join(b.Dir(), "130.0.1")})
+	g.E(b.Get())
+
+	report, err = b.Prune()
+	g.E(err)
+	g.Len(report.Removed, 0)
+
+	_, err = launcher.NewChannelBrowser(launcher.ChannelMSEdge).Get()
+	g.Has(err.Error(), "downloading msedge is not supported")
+}
@@ -21,7 +21,7 @@ func BenchmarkManager(b *testing.B) {
 
 	s := got.New(b).Serve()
 
-	// docker run --rm -p 7317:7317 ghcr.io/go-rod/rod
+	// docker run --rm -p 7317:7317 ghcr.io/xyjwsj/grod
 	s.HostURL.Host = "host.docker.internal"
 
 	s.Route("/", ".html", `<html><body>
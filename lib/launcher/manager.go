@@ -1,13 +1,21 @@
 package launcher
 
 import (
+	"bytes"
+	"crypto/subtle"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/xyjwsj/grod/lib/cdp"
 	"github.com/xyjwsj/grod/lib/launcher/flags"
@@ -19,6 +27,16 @@ const (
 	HeaderName = "Rod-Launcher"
 )
 
+// ManagedOptions to connect to a secured [Manager].
+type ManagedOptions struct {
+	// Token for the bearer token authentication of the [Manager.Tokens]
+	Token string
+
+	// TLSConfig for the "https" or "wss" service url, such as to trust the self-signed certificate of the manager.
+	// Nil means the default config.
+	TLSConfig *tls.Config
+}
+
 // MustNewManaged is similar to NewManaged.
 func MustNewManaged(serviceURL string) *Launcher {
 	l, err := NewManaged(serviceURL)
@@ -37,6 +55,12 @@ func MustNewManaged(serviceURL string) *Launcher {
 // Linux machine will return different default settings from the one on Mac.
 // If Launcher.Leakless is enabled, the remote browser will be killed after the websocket is closed.
 func NewManaged(serviceURL string) (*Launcher, error) {
+	return NewManagedWithOptions(serviceURL, ManagedOptions{})
+}
+
+// NewManagedWithOptions is similar to [NewManaged], it connects to a [Manager] that requires the token
+// or serves TLS with the opts.
+func NewManagedWithOptions(serviceURL string, opts ManagedOptions) (*Launcher, error) {
 	if serviceURL == "" {
 		serviceURL = "ws://127.0.0.1:7317"
 	}
@@ -48,15 +72,34 @@ func NewManaged(serviceURL string) (*Launcher, error) {
 
 	l := New()
 	l.managed = true
+	l.managedOpts = opts
 	l.serviceURL = toWS(*u).String()
 	l.Flags = nil
 
-	res, err := http.Get(toHTTP(*u).String()) //nolint: noctx
+	req, err := http.NewRequestWithContext(l.ctx, http.MethodGet, toHTTP(*u).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.Token)
+	}
+
+	client := http.DefaultClient
+	if opts.TLSConfig != nil {
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: opts.TLSConfig}}
+	}
+
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = res.Body.Close() }()
 
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("%s: %s", res.Status, b)
+	}
+
 	return l, json.NewDecoder(res.Body).Decode(l)
 }
 
@@ -67,28 +110,104 @@ func (l *Launcher) KeepUserDataDir() *Launcher {
 	return l
 }
 
+// JSONVersion is the version of the JSON format of the [Launcher].
+// The JSON without the version field is treated as version 0, it only has the flags.
+const JSONVersion = 1
+
+// launcherJSON is the JSON format of the [Launcher].
+// All the settings, such as the env, preferences, leakless, and xvfb, are stored as flags.
+type launcherJSON struct {
+	Version  int                     `json:"version"`
+	Flags    map[flags.Flag][]string `json:"flags"`
+	Revision int                     `json:"revision,omitempty"`
+}
+
 // JSON serialization.
 func (l *Launcher) JSON() []byte {
 	return utils.MustToJSONBytes(l)
 }
 
+// MarshalJSON interface.
+func (l *Launcher) MarshalJSON() ([]byte, error) {
+	data := launcherJSON{Version: JSONVersion, Flags: l.flagsSnapshot()}
+	if l.browser != nil {
+		data.Revision = l.browser.Revision
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON interface. The flags will be replaced, not merged.
+// It returns [ErrLauncherJSON] if the JSON has unknown fields, malformed flags, or a newer version.
+func (l *Launcher) UnmarshalJSON(b []byte) error {
+	data := launcherJSON{}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLauncherJSON, err)
+	}
+
+	if data.Version > JSONVersion {
+		return fmt.Errorf("%w: version %d is newer than the supported version %d", ErrLauncherJSON, data.Version, JSONVersion)
+	}
+
+	for name := range data.Flags {
+		if strings.Contains(string(name), "=") || name != name.NormalizeFlag() {
+			return fmt.Errorf("%w: malformed flag name: %q", ErrLauncherJSON, name)
+		}
+	}
+
+	if data.Flags == nil {
+		data.Flags = map[flags.Flag][]string{}
+	}
+	l.flagsLock.Lock()
+	l.Flags = data.Flags
+	l.flagsLock.Unlock()
+
+	if data.Revision != 0 && l.browser != nil {
+		l.browser.Revision = data.Revision
+	}
+
+	return nil
+}
+
 // MustClient similar to Launcher.Client.
 func (l *Launcher) MustClient() *cdp.Client {
-	u, h := l.ClientHeader()
-	return cdp.MustStartWithURL(l.ctx, u, h)
+	c, err := l.Client()
+	utils.E(err)
+	return c
 }
 
 // Client for launching browser remotely via the launcher.Manager.
 func (l *Launcher) Client() (*cdp.Client, error) {
 	u, h := l.ClientHeader()
-	return cdp.StartWithURL(l.ctx, u, h)
+
+	ws := &cdp.WebSocket{}
+	if cfg := l.managedOpts.TLSConfig; cfg != nil {
+		ws.Dialer = &tls.Dialer{Config: cfg}
+		if parsed, err := url.Parse(u); err == nil && parsed.Port() == "" {
+			parsed.Host += ":443"
+			u = parsed.String()
+		}
+	}
+
+	err := ws.Connect(l.ctx, u, h)
+	if err != nil {
+		return nil, err
+	}
+	return cdp.New().Start(ws), nil
 }
 
 // ClientHeader for launching browser remotely via the launcher.Manager.
+// It includes the bearer token of the [ManagedOptions].
 func (l *Launcher) ClientHeader() (string, http.Header) {
 	l.mustManaged()
 	header := http.Header{}
 	header.Add(string(HeaderName), utils.MustToJSON(l))
+	if l.managedOpts.Token != "" {
+		header.Set("Authorization", "Bearer "+l.managedOpts.Token)
+	}
 	return l.serviceURL, header
 }
 
@@ -113,17 +232,60 @@ var _ http.Handler = &Manager{}
 //	2. X start a websocket connect to Y with the Launcher settings
 //	3. Y launches a browser with the Launcher settings X
 //	4. Y transparently proxy the websocket connect between X and the launched browser
+//
+// To expose it to untrusted networks, set the [Manager.Tokens] and serve it with TLS, such as:
+//
+//	m := launcher.NewManager()
+//	m.Tokens = map[string]*launcher.ManagerClient{"secret": {Name: "ci", MaxBrowsers: 4}}
+//	http.ListenAndServeTLS(":7317", "cert.pem", "key.pem", m)
+//
+// Then connect to it via [NewManagedWithOptions].
 type Manager struct {
 	// Logger for key events
 	Logger utils.Logger
 
+	// Tokens for the bearer token authentication, the key is the token.
+	// If it's empty, the authentication is disabled.
+	Tokens map[string]*ManagerClient
+
+	// MaxBrowsers is the max number of the browsers that run at the same time for all the clients,
+	// 0 means unlimited.
+	MaxBrowsers int
+
 	// Defaults should return the default Launcher settings
 	Defaults func(http.ResponseWriter, *http.Request) *Launcher
 
 	// BeforeLaunch hook is called right before the launching with the Launcher instance that will be used
 	// to launch the browser.
 	// Such as use it to filter malicious values of Launcher.UserDataDir, Launcher.Bin, or Launcher.WorkingDir.
+	// The default one of [NewManager] also refuses the [ManagerDeniedFlags].
 	BeforeLaunch func(*Launcher, http.ResponseWriter, *http.Request)
+
+	lock    sync.Mutex
+	running map[*ManagerClient]int
+	total   int
+}
+
+// ManagerClient is the access policy of a token of the [Manager.Tokens].
+type ManagerClient struct {
+	// Name of the client for the logs
+	Name string
+
+	// AllowedFlags that the client can add, remove, or change from the [Manager.Defaults].
+	// Nil means all the flags are allowed. The [flags.UserDataDir] is always allowed,
+	// because its default value is random, use the [Manager.BeforeLaunch] to check its path.
+	AllowedFlags []flags.Flag
+
+	// MaxBrowsers is the max number of the browsers that run at the same time for the client, 0 means unlimited.
+	MaxBrowsers int
+}
+
+// ManagerDeniedFlags are the flags that the default [Manager.BeforeLaunch] refuses to accept from the clients,
+// because they can access the files of the host or the other clients, such as the [flags.UserDataDirTemplate]
+// can copy the live profile of another client, the [flags.Policies] can change the policies of the host,
+// and the [flags.Docker] can run any image with a host path of the [flags.DockerVolume] mounted.
+var ManagerDeniedFlags = []flags.Flag{
+	flags.UserDataDirTemplate, flags.Policies, flags.ManagedPolicies, flags.Docker, flags.DockerVolume,
 }
 
 // NewManager instance.
@@ -134,31 +296,59 @@ func NewManager() *Manager {
 			p, _ := os.Getwd()
 			return p
 		}(),
-		flags.UserDataDir: DefaultUserDataDirPrefix,
+		flags.UserDataDir:    DefaultUserDataDirPrefix,
+		flags.FirefoxProfile: DefaultUserDataDirPrefix,
 	}
 
 	return &Manager{
 		Logger:   utils.LoggerQuiet,
 		Defaults: func(_ http.ResponseWriter, _ *http.Request) *Launcher { return New() },
 		BeforeLaunch: func(l *Launcher, w http.ResponseWriter, _ *http.Request) {
+			for _, f := range ManagerDeniedFlags {
+				if l.Has(f) {
+					abort(w, fmt.Sprintf("not allowed flag: %s (use --allow-all to disable the protection)", f))
+				}
+			}
+
 			for f, allowed := range allowedPath {
 				p := l.Get(f)
-				if p != "" && !strings.HasPrefix(p, allowed) {
-					b := []byte(fmt.Sprintf("[rod-manager] not allowed %s path: %s (use --allow-all to disable the protection)", f, p))
-					w.Header().Add("Content-Length", fmt.Sprintf("%d", len(b)))
-					w.WriteHeader(http.StatusBadRequest)
-					utils.E(w.Write(b))
-					w.(http.Flusher).Flush() //nolint: forcetypeassert
-					panic(http.ErrAbortHandler)
+				if p != "" && !inDir(p, allowed) {
+					abort(w, fmt.Sprintf("not allowed %s path: %s (use --allow-all to disable the protection)", f, p))
 				}
 			}
 		},
 	}
 }
 
+// inDir returns true if the path is the dir or inside the dir, the paths are compared after they are cleaned
+// and converted to the absolute paths, so the ".." can't escape the dir.
+func inDir(path, dir string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
 func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client, ok := m.auth(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "[rod-manager] unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	if r.Header.Get("Upgrade") == "websocket" {
-		m.launch(w, r)
+		m.launch(w, r, client)
 		return
 	}
 
@@ -166,17 +356,112 @@ func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	utils.E(w.Write(l.JSON()))
 }
 
-func (m *Manager) launch(w http.ResponseWriter, r *http.Request) {
+// auth returns the client of the bearer token, it's nil if the authentication is disabled.
+func (m *Manager) auth(r *http.Request) (*ManagerClient, bool) {
+	if len(m.Tokens) == 0 {
+		return nil, true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, false
+	}
+
+	var found *ManagerClient
+	for t, c := range m.Tokens {
+		// compare all the tokens in constant time to prevent the timing attack
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			found = c
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	return found, true
+}
+
+// checkFlags returns the first flag that the client isn't allowed to add, remove, or change.
+func (m *Manager) checkFlags(client *ManagerClient, l *Launcher, w http.ResponseWriter, r *http.Request) flags.Flag {
+	if client == nil || client.AllowedFlags == nil {
+		return ""
+	}
+
+	allowed := map[flags.Flag]bool{flags.UserDataDir: true}
+	for _, f := range client.AllowedFlags {
+		allowed[f.NormalizeFlag()] = true
+	}
+
+	defaults := m.Defaults(w, r).Flags
+	snapshot := l.flagsSnapshot()
+	names := []string{}
+	for name := range snapshot {
+		names = append(names, string(name))
+	}
+	for name := range defaults {
+		if _, has := snapshot[name]; !has {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := flags.Flag(name)
+		if !allowed[f] && !slices.Equal(snapshot[f], defaults[f]) {
+			return f
+		}
+	}
+	return ""
+}
+
+// acquire a slot to launch a browser for the client, it returns false if the limits are reached.
+func (m *Manager) acquire(client *ManagerClient) (release func(), ok bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.MaxBrowsers > 0 && m.total >= m.MaxBrowsers {
+		return nil, false
+	}
+	if client != nil && client.MaxBrowsers > 0 && m.running[client] >= client.MaxBrowsers {
+		return nil, false
+	}
+
+	if m.running == nil {
+		m.running = map[*ManagerClient]int{}
+	}
+	m.total++
+	m.running[client]++
+
+	return func() {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		m.total--
+		m.running[client]--
+	}, true
+}
+
+func (m *Manager) launch(w http.ResponseWriter, r *http.Request, client *ManagerClient) {
 	l := New()
 
 	options := r.Header.Get(string(HeaderName))
 	if options != "" {
-		l.Flags = nil
-		utils.E(json.Unmarshal([]byte(options), l))
+		err := json.Unmarshal([]byte(options), l)
+		if err != nil {
+			abort(w, err.Error())
+		}
 	}
 
 	m.BeforeLaunch(l, w, r)
 
+	if f := m.checkFlags(client, l, w, r); f != "" {
+		abort(w, fmt.Sprintf("not allowed flag for %s: %s", client.Name, f))
+	}
+
+	release, ok := m.acquire(client)
+	if !ok {
+		abortWith(w, http.StatusTooManyRequests, "too many browsers")
+	}
+	defer release()
+
 	kill := l.Has(flags.Leakless)
 
 	// Always enable leakless so that if the Manager process crashes
@@ -197,6 +482,21 @@ func (m *Manager) launch(w http.ResponseWriter, r *http.Request) {
 	httputil.NewSingleHostReverseProxy(toHTTP(*parsedURL)).ServeHTTP(w, r)
 }
 
+// abort the request with a bad request response.
+func abort(w http.ResponseWriter, msg string) {
+	abortWith(w, http.StatusBadRequest, msg)
+}
+
+// abortWith aborts the request with the status code.
+func abortWith(w http.ResponseWriter, code int, msg string) {
+	b := []byte("[rod-manager] " + msg)
+	w.Header().Add("Content-Length", fmt.Sprintf("%d", len(b)))
+	w.WriteHeader(code)
+	utils.E(w.Write(b))
+	w.(http.Flusher).Flush() //nolint: forcetypeassert
+	panic(http.ErrAbortHandler)
+}
+
 func (m *Manager) cleanup(l *Launcher, kill bool) {
 	if kill {
 		l.Kill()
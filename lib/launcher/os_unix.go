@@ -3,14 +3,73 @@
 package launcher
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"runtime"
 	"syscall"
 
 	"github.com/xyjwsj/grod/lib/launcher/flags"
 )
 
-func killGroup(pid int) {
-	_ = syscall.Kill(-pid, syscall.SIGKILL)
+// killGroup kills the process group of the pid, so the children of the browser, such as the gpu and renderer
+// processes, will also be killed. When the browser is started by a guard process, such as leakless,
+// the group is led by the guard, not the browser. After the browser is reaped the group has been killed
+// by the [osWait], and the group id may be reused by the OS, so it does nothing.
+func (l *Launcher) killGroup(pid int) {
+	select {
+	case <-l.attempt:
+		return
+	default:
+	}
+
+	pgid := int(l.group)
+	if pgid == 0 {
+		pgid = pid
+	}
+
+	// avoid killing the group of the current process
+	if pgid == syscall.Getpgrp() {
+		return
+	}
+
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+}
+
+// osTrack records the process group of the browser, the group is created by the [Launcher.osSetupCmd].
+func (l *Launcher) osTrack(pid int) {
+	pgid, err := syscall.Getpgid(pid)
+	if err == nil {
+		l.group = uintptr(pgid)
+	}
+}
+
+// osWait waits for the cmd to exit, if the cmd leads a process group, the group is killed before the cmd is
+// reaped, because the group id may be reused by the OS after all the processes of the group are reaped.
+func osWait(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		_ = cmd.Wait()
+		return
+	}
+
+	pgid := cmd.Process.Pid
+
+	if waitExited(pgid) {
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+		_ = cmd.Wait()
+		return
+	}
+
+	_ = cmd.Wait()
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+}
+
+// exitSignal returns the signal that terminated the process, such as "segmentation fault".
+func exitSignal(state *os.ProcessState) string {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return status.Signal().String()
+	}
+	return ""
 }
 
 func (l *Launcher) osSetupCmd(cmd *exec.Cmd) {
@@ -24,3 +83,36 @@ func (l *Launcher) osSetupCmd(cmd *exec.Cmd) {
 	}
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
+
+// alwaysOnTop uses the wmctrl on Linux, other unix systems are not supported.
+func alwaysOnTop(title string, enable bool) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("%w on %s", ErrAlwaysOnTopNotSupported, runtime.GOOS)
+	}
+
+	bin, err := exec.LookPath("wmctrl")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAlwaysOnTopNotSupported, err)
+	}
+
+	action := "remove"
+	if enable {
+		action = "add"
+	}
+
+	out, err := exec.Command(bin, "-F", "-r", title, "-b", action+",above").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("wmctrl: %w %s", err, out)
+	}
+	return nil
+}
+
+// osTerminate sends the SIGTERM to the browser, the browser handles it like closing all the windows.
+func (l *Launcher) osTerminate(pid int) {
+	_ = syscall.Kill(pid, syscall.SIGTERM)
+}
+
+func isOwnedByRoot(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Uid == 0
+}
@@ -0,0 +1,751 @@
+//go:build !windows
+
+package launcher
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/got"
+	"github.com/ysmood/gson"
+)
+
+func TestKillGroup(t *testing.T) {
+	g := setup(t)
+
+	l := New()
+	cmd := exec.Command("sh", "-c", "sleep 30 & echo $!; wait")
+	l.osSetupCmd(cmd)
+	out, err := cmd.StdoutPipe()
+	g.E(err)
+	g.E(cmd.Start())
+	l.pid = cmd.Process.Pid
+	l.osTrack(l.pid)
+	g.Eq(int(l.group), l.pid)
+
+	var child int
+	_, err = fmt.Fscan(out, &child)
+	g.E(err)
+
+	l.killGroup(l.pid)
+	<-wait(cmd)
+
+	// the child should be killed with the group, wait for it to be reaped
+	for i := 0; i < 50 && syscall.Kill(child, 0) == nil; i++ {
+		utils.Sleep(0.1)
+	}
+	g.Eq(syscall.Kill(child, 0), syscall.ESRCH)
+
+	// the leftover children are killed before the leader is reaped
+	cmd = exec.Command("sh", "-c", "sleep 30 & echo $!")
+	l.osSetupCmd(cmd)
+	out, err = cmd.StdoutPipe()
+	g.E(err)
+	g.E(cmd.Start())
+	_, err = fmt.Fscan(out, &child)
+	g.E(err)
+
+	<-wait(cmd)
+	for i := 0; i < 50 && syscall.Kill(child, 0) == nil; i++ {
+		utils.Sleep(0.1)
+	}
+	g.Eq(syscall.Kill(child, 0), syscall.ESRCH)
+}
+
+func TestDocker(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/json/version", ".json", `{"webSocketDebuggerUrl": "ws://test.com"}`)
+	host := strings.Trim(strings.TrimPrefix(s.URL(), "http://"), "/")
+
+	// a fake docker cli that records the calls
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	bin := filepath.Join(dir, "docker")
+	g.E(os.WriteFile(bin, []byte(`#!/bin/sh
+echo "$@" >> `+log+`
+case "$1" in
+image) exit 1 ;;
+run) echo "DevTools listening on ws://0.0.0.0:9222/devtools/browser/id" >&2; exec sleep 30 ;;
+port) echo "`+host+`" ;;
+esac
+if [ "$2" = ls ]; then echo "$1-a $1-b"; fi
+`), 0o755))
+
+	old := dockerBin
+	dockerBin = bin
+	defer func() { dockerBin = old }()
+
+	l := NewDocker("").Leakless(false).Env("A=1")
+	g.Eq(l.Get(flags.Docker), DockerImageDefault)
+	g.False(l.Has(flags.UserDataDir))
+
+	preview, err := l.Validate()
+	g.E(err)
+	g.Eq(preview.Bin, bin)
+	g.Has(strings.Join(preview.Args, " "), "-e A=1 "+DockerImageDefault+" chrome")
+
+	g.Eq(l.MustLaunch(), "ws://"+host)
+	l.Kill()
+	l.Cleanup()
+
+	calls := strings.Split(strings.TrimSpace(g.Read(log).String()), "\n")
+	g.Len(calls, 7)
+	g.Eq(calls[0], "image inspect "+DockerImageDefault)
+	g.Eq(calls[1], "pull "+DockerImageDefault)
+	g.Eq(calls[2], "volume create --label managed-by=rod "+l.Get(flags.DockerVolume))
+	g.Has(calls[3], "run --rm --name "+l.container+" --label managed-by=rod -p 127.0.0.1::9222 -v "+
+		l.Get(flags.DockerVolume)+":/rod/user-data")
+	g.Has(calls[3], "--remote-debugging-address=0.0.0.0")
+	g.Has(calls[3], "--remote-debugging-port=9222")
+	g.Has(calls[3], "--user-data-dir=/rod/user-data")
+	g.Eq(calls[4], "port "+l.container+" 9222/tcp")
+	g.Eq(calls[5], "rm -f "+l.container)
+	g.Eq(calls[6], "volume rm -f "+l.Get(flags.DockerVolume))
+
+	// the volume named by the user isn't labeled
+	g.E(os.Remove(log))
+	l = NewDocker("img").Leakless(false).DockerVolume("data")
+	l.MustLaunch()
+	l.Kill()
+	g.Has(g.Read(log).String(), "\nrun ")
+	g.False(strings.Contains(g.Read(log).String(), "volume create"))
+
+	g.E(os.Remove(log))
+	g.E(CleanupDocker())
+	g.Eq(strings.Split(strings.TrimSpace(g.Read(log).String()), "\n"), []string{
+		"container ls -aq --filter label=managed-by=rod",
+		"container rm -f container-a container-b",
+		"volume ls -q --filter label=managed-by=rod",
+		"volume rm -f volume-a volume-b",
+	})
+
+	dockerBin = filepath.Join(dir, "none")
+	g.Has(CleanupDocker().Error(), "failed to list the docker containers")
+}
+
+func TestPipe(t *testing.T) {
+	g := setup(t)
+
+	// a fake browser that echoes the messages from the fd 3 to the fd 4
+	bin := filepath.Join(t.TempDir(), "browser")
+	g.E(os.WriteFile(bin, []byte("#!/bin/sh\nexec cat <&3 >&4\n"), 0o755))
+
+	l := New().Bin(bin).Pipe(true)
+	g.True(l.Has(flags.RemoteDebuggingPipe))
+	g.False(l.Has(flags.RemoteDebuggingPort))
+
+	u := l.MustLaunch()
+	g.True(IsPipeURL(u))
+	g.Neq(l.Guard(), GuardLeakless)
+	g.Is(l.GuardErr(), ErrLeaklessBlocked)
+
+	resolved, err := ResolveURL(u)
+	g.E(err)
+	g.Eq(resolved, u)
+
+	c, err := PipeClient(u)
+	g.E(err)
+	_, err = c.Call(g.Context(), "", "Browser.getVersion", nil)
+	g.E(err)
+
+	_, err = PipeClient(u)
+	g.Has(err.Error(), "the pipe is not found or already connected")
+
+	l.Kill()
+	l.Cleanup()
+
+	g.False(New().Pipe(true).Pipe(false).Has(flags.RemoteDebuggingPipe))
+}
+
+func TestPipeNotConnected(t *testing.T) {
+	g := setup(t)
+
+	// a fake browser that exits before the pipe is connected
+	bin := filepath.Join(t.TempDir(), "browser")
+	g.E(os.WriteFile(bin, []byte("#!/bin/sh\nexit 0\n"), 0o755))
+
+	l := New().Bin(bin).Pipe(true)
+	u := l.MustLaunch()
+	attempt := l.attempt
+	<-attempt
+
+	_, err := PipeClient(u)
+	g.Has(err.Error(), "the pipe is not found or already connected")
+	g.Is(l.pipe.Send(nil), os.ErrClosed)
+
+	l.Cleanup()
+}
+
+// fakeDevtools serves the "/devtools/browser/id" of s as a fake devtools endpoint, it answers each call with
+// an empty result, after the client disconnects the calls of the connection are sent to the returned channel.
+func fakeDevtools(g got.G, s *got.Router) chan []gson.JSON {
+	calls := make(chan []gson.JSON, 10)
+
+	s.Mux.HandleFunc("/devtools/browser/id", func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		g.E(err)
+		defer func() { _ = conn.Close() }()
+
+		accept := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		_, _ = fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+			"Sec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(accept[:]))
+		g.E(rw.Flush())
+
+		list := []gson.JSON{}
+		defer func() { calls <- list }()
+
+		for {
+			header := make([]byte, 2)
+			if _, err := io.ReadFull(rw, header); err != nil {
+				return
+			}
+
+			size := int(header[1] & 0x7f)
+			if size == 126 {
+				ext := make([]byte, 2)
+				_, err = io.ReadFull(rw, ext)
+				g.E(err)
+				size = int(binary.BigEndian.Uint16(ext))
+			}
+
+			mask := make([]byte, 4)
+			_, err = io.ReadFull(rw, mask)
+			g.E(err)
+			msg := make([]byte, size)
+			_, err = io.ReadFull(rw, msg)
+			g.E(err)
+			for i := range msg {
+				msg[i] ^= mask[i%4]
+			}
+
+			call := gson.New(msg)
+			list = append(list, call)
+
+			res := fmt.Sprintf(`{"id":%d,"result":{}}`, call.Get("id").Int())
+			_, _ = rw.Write(append([]byte{0b1000_0001, byte(len(res))}, res...))
+			g.E(rw.Flush())
+		}
+	})
+
+	return calls
+}
+
+func TestLauncherClose(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/json/version", ".json", `{"webSocketDebuggerUrl": "ws://test.com/devtools/browser/id"}`)
+	host := strings.Trim(strings.TrimPrefix(s.URL(), "http://"), "/")
+
+	closed := fakeDevtools(g, s)
+
+	old := CloseTerminateTimeout
+	CloseTerminateTimeout = 100 * time.Millisecond
+	defer func() { CloseTerminateTimeout = old }()
+
+	launch := func(trap string) *Launcher {
+		bin := filepath.Join(t.TempDir(), "browser")
+		g.E(os.WriteFile(bin, []byte(fmt.Sprintf(`#!/bin/sh
+trap '%s' TERM
+echo "DevTools listening on ws://%s/devtools/browser/id" >&2
+while true; do sleep 0.1; done
+`, trap, host)), 0o755))
+
+		l := New().Bin(bin).Leakless(false)
+		l.MustLaunch()
+		return l
+	}
+
+	{
+		l := launch("echo terminated >&2; exit 0")
+		ctx, cancel := context.WithTimeout(g.Context(), 100*time.Millisecond)
+		defer cancel()
+		g.Is(l.Close(ctx), context.DeadlineExceeded)
+		g.Has(l.stderr.String(), "terminated")
+		g.Eq(atomic.LoadInt32(&l.killed), int32(0))
+		l.Cleanup()
+
+		// the client is closed after the call
+		g.Eq((<-closed)[0].Get("method").Str(), "Browser.close")
+	}
+
+	{
+		l := launch("")
+		ctx, cancel := context.WithTimeout(g.Context(), 100*time.Millisecond)
+		defer cancel()
+		g.Is(l.Close(ctx), context.DeadlineExceeded)
+		g.Eq(atomic.LoadInt32(&l.killed), int32(1))
+		l.Cleanup()
+
+		g.E(l.Close(g.Context()))
+	}
+
+	g.E(New().Close(g.Context()))
+}
+
+func TestBrowserUpdate(t *testing.T) {
+	g := setup(t)
+
+	root := t.TempDir()
+
+	b := NewBrowser()
+	b.RootDir = root
+	b.Revision = 1
+	b.Logger = utils.LoggerQuiet
+	bin, err := filepath.Rel(b.Dir(), b.BinPath())
+	g.E(err)
+
+	buf := bytes.NewBuffer(nil)
+	z := zip.NewWriter(buf)
+	h := &zip.FileHeader{Name: filepath.ToSlash(filepath.Join("chrome", bin))}
+	h.SetMode(0o755)
+	f, _ := z.CreateHeader(h)
+	_, _ = f.Write([]byte("#!/bin/sh\necho '<html><head></head><body></body></html>'\n"))
+	_ = z.Close()
+
+	latest := "1"
+	s := g.Serve()
+	s.Mux.HandleFunc("/versions", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"channels": {
+			"Stable": {"version": "1.0.0.%[1]s", "revision": %[1]q},
+			"Beta": {"version": "2.0.0.0", "revision": "100"}
+		}}`, latest)
+	})
+	s.Mux.HandleFunc("/chrome.zip", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(buf.Bytes())
+	})
+
+	b.LatestURL = s.URL("/versions")
+	b.Hosts = []Host{func(_ int) string { return s.URL("/chrome.zip") }}
+
+	rev, has, err := b.CheckUpdate()
+	g.E(err)
+	g.Eq(rev, 1)
+	g.False(has)
+	g.False(b.MustUpdate())
+
+	latest = "3"
+	rev, has, err = b.CheckUpdate()
+	g.E(err)
+	g.Eq(rev, 3)
+	g.True(has)
+
+	g.True(b.MustUpdate())
+	g.Eq(b.Revision, 3)
+	g.Nil(b.Validate())
+
+	target, err := os.Readlink(b.CurrentDir())
+	g.E(err)
+	g.Eq(target, b.Dir())
+
+	list, err := filepath.Glob(filepath.Join(root, ".tmp-*"))
+	g.E(err)
+	g.Len(list, 0)
+
+	// it follows the channel
+	b.UpdateChannel = ChannelBeta
+	rev, _, err = b.CheckUpdate()
+	g.E(err)
+	g.Eq(rev, 100)
+
+	b.UpdateChannel = ChannelCanary
+	_, _, err = b.CheckUpdate()
+	g.Has(err.Error(), "chrome channel not found: canary")
+
+	b.UpdateChannel = ChannelStable
+	latest = "x"
+	_, _, err = b.CheckUpdate()
+	g.Has(err.Error(), "invalid revision of chrome stable 1.0.0.x")
+}
+
+func TestSupervise(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/json/version", ".json", `{"webSocketDebuggerUrl": "ws://test.com"}`)
+	host := strings.Trim(strings.TrimPrefix(s.URL(), "http://"), "/")
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "browser")
+
+	// the first launch crashes, the second one keeps running
+	g.E(os.WriteFile(bin, []byte(fmt.Sprintf(`#!/bin/sh
+echo "DevTools listening on ws://%s/devtools/browser/id" >&2
+if [ ! -f %s/launched ]; then
+	touch %s/launched
+	sleep 0.3
+	kill -SEGV $$
+fi
+while true; do sleep 0.1; done
+`, host, dir, dir)), 0o755))
+
+	l := New().Bin(bin).Leakless(false)
+	l.MustLaunch()
+
+	_, err := l.Relaunch(g.Context())
+	g.Is(err, ErrBrowserRunning)
+
+	// the states of the crashed launcher are readable while it's relaunching
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-l.Exit():
+				_ = l.CrashReport()
+				_ = l.PID()
+				utils.Sleep(0.01)
+			}
+		}
+	}()
+
+	recovered := make(chan *Recovery, 1)
+	stop := l.Supervise(func(r *Recovery) { recovered <- r })
+	defer stop()
+
+	r := <-recovered
+	g.E(r.Err)
+	g.Has(r.Crash.Error(), "the browser crashed")
+	g.Has(r.URL, "ws://")
+	g.Eq(r.URL, r.Launcher.ControlURL())
+	g.Nil(r.Launcher.CrashReport())
+	g.Neq(r.Launcher.PID(), l.PID())
+	g.Eq(r.Launcher.Get(flags.UserDataDir), l.Get(flags.UserDataDir))
+
+	// the crashed launcher is untouched
+	g.NotNil(l.CrashReport())
+
+	r.Launcher.Kill()
+	r.Launcher.Cleanup()
+}
+
+func TestRetry(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/json/version", ".json", `{"webSocketDebuggerUrl": "ws://test.com/devtools/browser/id"}`)
+	host := strings.Trim(strings.TrimPrefix(s.URL(), "http://"), "/")
+	calls := fakeDevtools(g, s)
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "browser")
+
+	// the first launch fails because of the port, the second one keeps running
+	g.E(os.WriteFile(bin, []byte(fmt.Sprintf(`#!/bin/sh
+if [ ! -f %s/launched ]; then
+	touch %s/launched
+	echo "bind() returned an error, errno=98: Address already in use (98)" >&2
+	echo "Cannot start http server for devtools." >&2
+	exit 1
+fi
+echo "DevTools listening on ws://%s/devtools/browser/id" >&2
+while true; do sleep 0.1; done
+`, dir, dir, host)), 0o755))
+
+	l := New().Bin(bin).Leakless(false).Set(flags.RemoteDebuggingPort, "9999")
+	userDataDir := l.Get(flags.UserDataDir)
+
+	retried := 0
+	l.Retry(2, func(context.Context) error {
+		retried++
+		return nil
+	})
+
+	g.Has(l.MustLaunch(), "ws://")
+	g.Eq(retried, 1)
+	g.Eq(l.Get(flags.RemoteDebuggingPort), "0")
+	g.Neq(l.Get(flags.UserDataDir), userDataDir)
+	g.Has(l.Get(flags.UserDataDir), DefaultUserDataDirPrefix)
+
+	l.Kill()
+	l.Cleanup()
+
+	// the server of the app mode stays up across the retries, it closes after the cleanup
+	g.E(os.Remove(filepath.Join(dir, "launched")))
+	l = MustNewAppModeFS(fstest.MapFS{"index.html": {Data: []byte("app")}}).Bin(bin).Leakless(false).Retry(2, nil)
+	g.Has(l.MustLaunch(), "ws://")
+
+	// the token is set via the cookie instead of the command line
+	cookie := (<-calls)[0]
+	g.Eq(cookie.Get("method").Str(), "Storage.setCookies")
+	g.Eq(cookie.Get("params.cookies.0.url").Str(), l.Get(flags.App))
+	token := cookie.Get("params.cookies.0.value").Str()
+	g.Len(token, 32)
+	for _, arg := range l.FormatArgs() {
+		g.False(strings.Contains(arg, token))
+	}
+
+	get := func() int {
+		req, err := http.NewRequest(http.MethodGet, l.Get(flags.App), nil)
+		g.E(err)
+		req.AddCookie(&http.Cookie{Name: appModeTokenName, Value: token})
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0
+		}
+		_ = res.Body.Close()
+		return res.StatusCode
+	}
+	g.Eq(get(), http.StatusOK)
+
+	l.Kill()
+	<-l.Exit()
+	g.Eq(get(), http.StatusOK)
+
+	// the relaunched browser takes over the server
+	n, err := l.Relaunch(g.Context())
+	g.E(err)
+	g.Eq((<-calls)[0].Get("params.cookies.0.value").Str(), token)
+	l.Cleanup()
+	g.Eq(get(), http.StatusOK)
+
+	n.Kill()
+	n.Cleanup()
+	g.Eq(get(), 0)
+
+	// the server closes if the launch fails
+	l = MustNewAppModeFS(fstest.MapFS{}).Bin(filepath.Join(dir, "not-exists")).Leakless(false)
+	g.Err(l.Launch())
+	_, err = http.Get(l.Get(flags.App))
+	g.Err(err)
+
+	// the other errors won't be retried
+	g.E(os.WriteFile(bin, []byte("#!/bin/sh\necho 'error while loading shared libraries' >&2\nexit 1\n"), 0o755))
+
+	retried = 0
+	l = New().Bin(bin).Leakless(false).Retry(3, func(context.Context) error {
+		retried++
+		return nil
+	})
+	_, err = l.Launch()
+	g.Has(err.Error(), "shared libraries")
+	g.False(IsTransientLaunchErr(err))
+	g.Eq(retried, 0)
+	l.Cleanup()
+}
+
+func TestHeadlessShell(t *testing.T) {
+	g := setup(t)
+
+	if cftPlatform == "" {
+		t.Skip("chrome-headless-shell is not available for the os")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	z := zip.NewWriter(buf)
+	h := &zip.FileHeader{Name: "chrome-headless-shell-" + cftPlatform + "/chrome-headless-shell"}
+	h.SetMode(0o755)
+	f, _ := z.CreateHeader(h)
+	_, _ = f.Write([]byte("#!/bin/sh\necho '<html><head></head><body></body></html>'\n"))
+	_ = z.Close()
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/shell.zip", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(buf.Bytes())
+	})
+	s.Route("/versions", ".json", fmt.Sprintf(`{"versions": [
+		{"version": "1.0", "revision": "10", "downloads": {"chrome-headless-shell": [
+			{"platform": %[1]q, "url": %[2]q}]}},
+		{"version": "2.0", "revision": "20", "downloads": {"chrome-headless-shell": [
+			{"platform": %[1]q, "url": %[3]q}]}},
+		{"version": "3.0", "revision": "30", "downloads": {"chrome-headless-shell": [
+			{"platform": %[1]q, "url": "http://not-exists"}]}}
+	]}`, cftPlatform, s.URL("/wrong.zip"), s.URL("/shell.zip")))
+
+	b := NewBrowser()
+	b.RootDir = t.TempDir()
+	b.Revision = 25
+	b.Logger = utils.LoggerQuiet
+	b.HeadlessShell = true
+	b.HeadlessShellVersionsURL = s.URL("/versions")
+
+	g.Has(b.BinPath(), "chrome-headless-shell-25")
+	g.Eq(b.MustGet(), b.BinPath())
+	g.Nil(b.Validate())
+
+	b.Revision = 5
+	g.Has(b.Download().Error(), "no chrome-headless-shell is available for revision 5")
+
+	l := New().HeadlessNew(true).HeadlessShell(true)
+	headless, has := l.GetFlags(flags.Headless)
+	g.True(has)
+	g.Len(headless, 0)
+	preview, _ := l.Validate()
+	g.Has(preview.Bin, "chrome-headless-shell")
+
+	g.False(l.HeadlessShell(false).Has(flags.HeadlessShell))
+}
+
+func TestManagedXVFB(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/json/version", ".json", `{"webSocketDebuggerUrl": "ws://test.com"}`)
+	host := strings.Trim(strings.TrimPrefix(s.URL(), "http://"), "/")
+
+	dir := t.TempDir()
+
+	oldBin := xvfbBin
+	defer func() { xvfbBin = oldBin }()
+	xvfbBin = filepath.Join(dir, "Xvfb")
+	g.E(os.WriteFile(xvfbBin, []byte(fmt.Sprintf(`#!/bin/sh
+echo "$@" > %s/xvfb-args
+echo 99 >&3
+while true; do sleep 0.1; done
+`, dir)), 0o755))
+
+	bin := filepath.Join(dir, "browser")
+	g.E(os.WriteFile(bin, []byte(fmt.Sprintf(`#!/bin/sh
+echo "$DISPLAY" > %s/display
+echo "DevTools listening on ws://%s/devtools/browser/id" >&2
+while true; do sleep 0.1; done
+`, dir, host)), 0o755))
+
+	l := New().Bin(bin).Leakless(false).Headless(false).ManagedXVFB(99, 800, 600)
+	g.Eq(l.Flags[flags.ManagedXVFB], []string{"99", "800x600x24"})
+	l.MustLaunch()
+
+	g.Eq(g.Read(filepath.Join(dir, "xvfb-args")).String(), ":99 -screen 0 800x600x24 -nolisten tcp -displayfd 3\n")
+	g.Eq(g.Read(filepath.Join(dir, "display")).String(), ":99\n")
+
+	l.Kill()
+	l.Cleanup()
+	g.NotNil(l.xvfb.cmd.ProcessState)
+
+	// Xvfb failed to start
+	g.E(os.WriteFile(xvfbBin, []byte("#!/bin/sh\necho 'no screens found' >&2\nexit 1\n"), 0o755))
+	_, err := New().Bin(bin).Leakless(false).Headless(false).ManagedXVFB(0, 0, 0).Launch()
+	g.Is(err, ErrXVFB)
+	g.Has(err.Error(), "no screens found")
+}
+
+func TestStats(t *testing.T) {
+	g := setup(t)
+
+	if runtime.GOOS != "linux" {
+		_, err := New().Stats()
+		g.Is(err, ErrStatsNotSupported)
+		return
+	}
+
+	s := g.Serve()
+	s.Route("/json/version", ".json", `{"webSocketDebuggerUrl": "ws://test.com"}`)
+	host := strings.Trim(strings.TrimPrefix(s.URL(), "http://"), "/")
+
+	bin := filepath.Join(t.TempDir(), "browser")
+	g.E(os.WriteFile(bin, []byte(fmt.Sprintf(`#!/bin/sh
+sleep 60 &
+echo "DevTools listening on ws://%s/devtools/browser/id" >&2
+while true; do sleep 0.1; done
+`, host)), 0o755))
+
+	_, err := New().Stats()
+	g.Err(err)
+
+	l := New().Bin(bin).Leakless(false)
+	l.MustLaunch()
+	defer l.Cleanup()
+	defer l.Kill()
+
+	stats, err := l.Stats()
+	g.E(err)
+	g.Eq(stats.Process.PID, l.PID())
+	g.Eq(stats.Process.Name, "browser")
+	g.Gt(stats.RSS, uint64(0))
+	g.Gt(stats.FDs, 0)
+	g.Gte(stats.Count, 2)
+	g.Has(stats.Process.Children[0].Name, "sleep")
+
+	ch := make(chan *Stats, 1)
+	stop := l.WatchStats(10*time.Millisecond, func(s *Stats) {
+		select {
+		case ch <- s:
+		default:
+		}
+	})
+	defer stop()
+	g.Eq((<-ch).Process.PID, l.PID())
+}
+
+func TestBrowserVersion(t *testing.T) {
+	g := setup(t)
+
+	if runtime.GOOS != "linux" || cftPlatform == "" {
+		t.Skip("the chrome for testing bin path of the test is linux only")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	z := zip.NewWriter(buf)
+	h := &zip.FileHeader{Name: "chrome-" + cftPlatform + "/chrome"}
+	h.SetMode(0o755)
+	f, _ := z.CreateHeader(h)
+	_, _ = f.Write([]byte("#!/bin/sh\necho '<html><head></head><body></body></html>'\n"))
+	_ = z.Close()
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/chrome.zip", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(buf.Bytes())
+	})
+	s.Route("/versions", ".json", fmt.Sprintf(`{"versions": [
+		{"version": "120.0.6099.70", "revision": "10", "downloads": {"chrome": [
+			{"platform": %[1]q, "url": "http://not-exists"}]}},
+		{"version": "120.0.6099.71", "revision": "11", "downloads": {"chrome": [
+			{"platform": %[1]q, "url": %[2]q}]}},
+		{"version": "121.0.6167.85", "revision": "12", "downloads": {"chrome": [
+			{"platform": "other", "url": "http://not-exists"}]}}
+	]}`, cftPlatform, s.URL("/chrome.zip")))
+
+	b := NewBrowser()
+	b.RootDir = t.TempDir()
+	b.Logger = utils.LoggerQuiet
+	b.Version = "120.0.6099.71"
+	b.HeadlessShellVersionsURL = s.URL("/versions")
+
+	g.Eq(b.BinPath(), filepath.Join(b.RootDir, "chrome-120.0.6099.71", "chrome"))
+	g.Eq(b.MustGet(), b.BinPath())
+	g.Nil(b.Validate())
+
+	b.Version = "121.0.6167.85"
+	g.Has(b.Download().Error(), "chrome 121.0.6167.85 is not available for")
+
+	b.Version = "122.0.6261.57"
+	g.Has(b.Download().Error(), "chrome 122.0.6261.57 is not found")
+
+	b.Version = "120"
+	g.Has(b.Download().Error(), `invalid chrome for testing version "120"`)
+
+	b.Version = "120.0.6099.71"
+	b.HeadlessShell = true
+	g.Has(b.Download().Error(), "chrome-headless-shell 120.0.6099.71 is not available for")
+	g.Has(b.BinPath(), "chrome-headless-shell-120.0.6099.71")
+
+	l := New().Version("120.0.6099.71")
+	g.Eq(l.Get(flags.Version), "120.0.6099.71")
+	g.Eq(l.Clone().Get(flags.Version), "120.0.6099.71")
+	preview, _ := l.Validate()
+	g.Has(preview.Bin, filepath.Join("chrome-120.0.6099.71", "chrome"))
+	g.False(l.Version("").Has(flags.Version))
+}
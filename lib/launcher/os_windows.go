@@ -3,14 +3,64 @@
 package launcher
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"syscall"
+	"unsafe"
 )
 
-func killGroup(pid int) {
+var (
+	procTerminateJobObject = kernel32.NewProc("TerminateJobObject")
+
+	user32           = syscall.NewLazyDLL("user32.dll")
+	procFindWindowW  = user32.NewProc("FindWindowW")
+	procSetWindowPos = user32.NewProc("SetWindowPos")
+)
+
+// killGroup terminates the job object of the browser, then kills the process tree of the pid for the
+// processes that escaped the job, such as the ones spawned before the browser was assigned to the job.
+func (l *Launcher) killGroup(pid int) {
+	if l.group != 0 {
+		_, _, _ = procTerminateJobObject.Call(l.group, 1)
+	}
+
+	_ = exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+
 	terminateProcess(pid)
 }
 
+// osTrack assigns the browser to a job object, so that all the children of the browser can be killed together.
+// If the guard has created a job object, it will be reused.
+func (l *Launcher) osTrack(pid int) {
+	if l.group != 0 {
+		return
+	}
+
+	job, err := newJobObject(false)
+	if err != nil {
+		return
+	}
+
+	if assignJobObject(job, pid) != nil {
+		_ = syscall.CloseHandle(job)
+		return
+	}
+
+	l.group = uintptr(job)
+}
+
+// osWait waits for the cmd to exit, the job object of the browser is killed by the [Launcher.killGroup].
+func osWait(cmd *exec.Cmd) {
+	_ = cmd.Wait()
+}
+
+// exitSignal is always empty on Windows.
+func exitSignal(_ *os.ProcessState) string {
+	return ""
+}
+
 func (l *Launcher) osSetupCmd(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
@@ -26,3 +76,40 @@ func terminateProcess(pid int) {
 	_ = syscall.TerminateProcess(handle, 0)
 	_ = syscall.CloseHandle(handle)
 }
+
+// osTerminate asks the browser to close its windows, Windows has no terminate signal.
+func (l *Launcher) osTerminate(pid int) {
+	_ = exec.Command("taskkill", "/PID", strconv.Itoa(pid)).Run()
+}
+
+// alwaysOnTop finds the top-level window by the exact title, then sets its topmost state.
+func alwaysOnTop(title string, enable bool) error {
+	t, err := syscall.UTF16PtrFromString(title)
+	if err != nil {
+		return err
+	}
+
+	hwnd, _, err := procFindWindowW.Call(0, uintptr(unsafe.Pointer(t)))
+	if hwnd == 0 {
+		return fmt.Errorf("window not found: %s: %w", title, err)
+	}
+
+	const (
+		swpNoSize = 0x0001
+		swpNoMove = 0x0002
+	)
+	insertAfter := ^uintptr(1) // HWND_NOTOPMOST is -2
+	if enable {
+		insertAfter = ^uintptr(0) // HWND_TOPMOST is -1
+	}
+
+	ok, _, err := procSetWindowPos.Call(hwnd, insertAfter, 0, 0, 0, 0, swpNoSize|swpNoMove)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+func isOwnedByRoot(_ os.FileInfo) bool {
+	return true
+}
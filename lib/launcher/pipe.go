@@ -0,0 +1,122 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/cdp"
+	"github.com/xyjwsj/grod/lib/defaults"
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// PipeScheme is the scheme of the control url returned by [Launcher.Launch] when the [Launcher.Pipe] is enabled.
+const PipeScheme = "pipe://"
+
+// ErrPipeNotSupported is an error that indicates the [Launcher.Pipe] can't work on the current platform.
+var ErrPipeNotSupported = errors.New("the remote debugging pipe is not supported on " + runtime.GOOS)
+
+// pipes of the launched browsers that are not connected yet, the key is the control url.
+var pipes sync.Map
+
+// Pipe switch to control the browser via the pipes of the [flags.RemoteDebuggingPipe] instead of a TCP port,
+// so other processes or tenants on the same host can't connect to the browser.
+// The returned control url of [Launcher.Launch] can only be connected once via [PipeClient],
+// rod.Browser.ControlURL supports it directly. The leakless guard process can't pass the pipes to the browser,
+// so the native guard of the OS will be used, check [Launcher.Guard]. Windows is not supported.
+func (l *Launcher) Pipe(enable bool) *Launcher {
+	if enable {
+		return l.Set(flags.RemoteDebuggingPipe).Delete(flags.RemoteDebuggingPort)
+	}
+	return l.Delete(flags.RemoteDebuggingPipe).Set(flags.RemoteDebuggingPort, defaults.Port)
+}
+
+// IsPipeURL returns true if the u is returned by [Launcher.Launch] with the [Launcher.Pipe].
+func IsPipeURL(u string) bool {
+	return strings.HasPrefix(u, PipeScheme)
+}
+
+// PipeClient returns the client of the control url that is returned by [Launcher.Launch] with the [Launcher.Pipe].
+// Each control url can only be used once, because the pipes can't be shared.
+func PipeClient(u string) (*cdp.Client, error) {
+	p, has := pipes.LoadAndDelete(u)
+	if !has {
+		return nil, fmt.Errorf("the pipe is not found or already connected: %s", u)
+	}
+	return cdp.New().Start(p.(*cdp.Pipe)), nil //nolint: forcetypeassert
+}
+
+// setupPipe creates the pipes for the fd 3 and 4 of the browser.
+func (l *Launcher) setupPipe() error {
+	if runtime.GOOS == "windows" {
+		return ErrPipeNotSupported
+	}
+
+	browserIn, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	r, browserOut, err := os.Pipe()
+	if err != nil {
+		_ = browserIn.Close()
+		_ = w.Close()
+		return err
+	}
+
+	l.pipeFiles = []*os.File{browserIn, browserOut}
+	l.pipe = cdp.NewPipe(r, w)
+	return nil
+}
+
+// launchPipe starts the browser with the pipes and returns the control url of them.
+func (l *Launcher) launchPipe(bin string, args []string) (string, error) {
+	err := l.setupPipe()
+	if err != nil {
+		l.closeProxy()
+		l.closeXVFB()
+		l.closePolicies()
+		return "", err
+	}
+
+	l.startedAt = time.Now()
+
+	done, err := l.start(bin, args)
+
+	// the browser has its own copies of the fds
+	for _, f := range l.pipeFiles {
+		_ = f.Close()
+	}
+
+	if err != nil {
+		l.closeProxy()
+		l.closeXVFB()
+		l.closePolicies()
+		_ = l.pipe.Close()
+		return "", err
+	}
+
+	l.osTrack(l.pid)
+
+	u := PipeScheme + utils.RandString(8)
+	pipes.Store(u, l.pipe)
+
+	attempt := l.attempt
+	go func() {
+		<-done
+		// the pipe is still in the map if the browser exits before it's connected, nobody else will close it
+		if p, has := pipes.LoadAndDelete(u); has {
+			_ = p.(*cdp.Pipe).Close() //nolint: forcetypeassert
+		}
+		l.closeProxy()
+		l.closeXVFB()
+		l.closePolicies()
+		close(attempt)
+	}()
+
+	return u, nil
+}
@@ -0,0 +1,101 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// PolicyDirs are the dirs of the managed policy files that the Chromium based browsers read on Linux,
+// check [Launcher.ManagedPolicies].
+var PolicyDirs = []string{
+	"/etc/chromium/policies/managed",
+	"/etc/opt/chrome/policies/managed",
+	"/etc/opt/chrome_for_testing/policies/managed",
+	"/etc/opt/edge/policies/managed",
+}
+
+// Policies to control the enterprise policies of the browser, such as:
+//
+//	l.Policies(`{"URLBlocklist": ["example.com"], "DownloadRestrictions": 3}`)
+//
+// The policies is a json object, the list of policies: https://chromeenterprise.google/policies
+// The policies are passed via the "--policy" flag, it works for the Chromium builds that enable the policy testing,
+// such as the Chromium snapshots downloaded by the launcher, the branded stable browsers ignore it,
+// check [Launcher.ManagedPolicies] for them.
+func (l *Launcher) Policies(policies string) *Launcher {
+	return l.Set(flags.Policies, policies)
+}
+
+// ManagedPolicies writes the [Launcher.Policies] as a managed policy file into each of the [PolicyDirs] that
+// the current user has the permission to write when launching on Linux, so the branded stable browsers
+// apply them too. The files are removed after the browser exits. It's disabled by default, because the
+// managed policy files are system-wide: they apply to all the browsers on the host while they exist,
+// the policies of the concurrent launchers are merged, and the files are left behind if the process crashes.
+// Use the OS tools to provision the policies of the branded browsers on Windows and macOS.
+func (l *Launcher) ManagedPolicies(enable bool) *Launcher {
+	if enable {
+		return l.Set(flags.ManagedPolicies)
+	}
+	return l.Delete(flags.ManagedPolicies)
+}
+
+// setupPolicies provisions the [flags.Policies] for the browser, it returns [ErrInvalidLaunch] if the json is invalid.
+func (l *Launcher) setupPolicies() error {
+	policies := l.Get(flags.Policies)
+	if policies == "" || l.Has(flags.Firefox) {
+		return nil
+	}
+
+	compact, err := compactPolicies(policies)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidLaunch, err)
+	}
+
+	l.Set("policy", compact)
+
+	if runtime.GOOS != "linux" || !l.Has(flags.ManagedPolicies) {
+		return nil
+	}
+
+	name := "rod-" + utils.RandString(8) + ".json"
+	for _, dir := range PolicyDirs {
+		if os.MkdirAll(dir, 0o755) != nil {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if os.WriteFile(path, []byte(compact), 0o644) != nil { //nolint: gosec
+			continue
+		}
+		l.policyFiles = append(l.policyFiles, path)
+	}
+
+	return nil
+}
+
+// closePolicies removes the managed policy files of the [Launcher.Policies].
+func (l *Launcher) closePolicies() {
+	for _, path := range l.policyFiles {
+		_ = os.Remove(path)
+	}
+	l.policyFiles = nil
+}
+
+// compactPolicies checks the policies is a json object and returns the compacted json of it.
+func compactPolicies(policies string) (string, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(policies), &obj); err != nil || obj == nil {
+		return "", fmt.Errorf("the policies should be a json object: %s", policies)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	utils.E(json.Compact(buf, []byte(policies)))
+	return buf.String(), nil
+}
@@ -0,0 +1,118 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// PreferencesMerge deep-merges the prefs into the [Launcher.Preferences], so multiple preference tweaks compose,
+// such as:
+//
+//	l.PreferencesMerge(map[string]any{"download": map[string]any{"prompt_for_download": false}}).
+//		PreferencesMerge(map[string]any{"download": map[string]any{"default_directory": "/tmp"}})
+//
+// The nested objects are merged key by key, the other values, such as the arrays, are replaced.
+// If the current [flags.Preferences] isn't a json object it will be replaced.
+// For Firefox the nested keys are joined with dots and appended as the user_pref lines of the user.js.
+func (l *Launcher) PreferencesMerge(prefs map[string]any) *Launcher {
+	firefox := l.Has(flags.Firefox)
+
+	return l.updateFlag(flags.Preferences, func(list []string, _ bool) ([]string, bool) {
+		pref := ""
+		if len(list) > 0 {
+			pref = list[0]
+		}
+
+		if firefox {
+			if pref != "" && !strings.HasSuffix(pref, "\n") {
+				pref += "\n"
+			}
+			return []string{pref + firefoxUserPrefs(prefs)}, true
+		}
+
+		base, _ := decodePreferences([]byte(pref))
+		return []string{utils.MustToJSON(mergePreferences(base, prefs))}, true
+	})
+}
+
+// decodePreferences decodes the json object, the numbers are kept as [json.Number] to avoid the precision loss.
+func decodePreferences(b []byte) (map[string]any, error) {
+	m := map[string]any{}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err := dec.Decode(&m)
+	return m, err
+}
+
+// mergePreferences deep-merges the src into the dst, and returns the dst.
+func mergePreferences(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = map[string]any{}
+	}
+
+	for k, v := range src {
+		from, ok := v.(map[string]any)
+		if !ok {
+			dst[k] = v
+			continue
+		}
+
+		to, _ := dst[k].(map[string]any)
+		dst[k] = mergePreferences(to, from)
+	}
+
+	return dst
+}
+
+// mergePreferencesFile deep-merges the pref json into the Preferences file of the profile, so the existing
+// settings of the profile are kept. If the pref or the file isn't a json object, the file will be overwritten
+// with the pref.
+func mergePreferencesFile(path, pref string) error {
+	src, err := decodePreferences([]byte(pref))
+	if err != nil {
+		return utils.OutputFile(path, pref)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return utils.OutputFile(path, pref)
+		}
+		return err
+	}
+
+	dst, err := decodePreferences(b)
+	if err != nil {
+		return utils.OutputFile(path, pref)
+	}
+
+	return utils.OutputFile(path, mergePreferences(dst, src))
+}
+
+// firefoxUserPrefs returns the user_pref lines of the prefs, the nested keys are joined with dots.
+func firefoxUserPrefs(prefs map[string]any) string {
+	lines := []string{}
+
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]any); ok {
+				walk(prefix+k+".", sub)
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("user_pref(%q, %s);\n", prefix+k, utils.MustToJSON(v)))
+		}
+	}
+	walk("", prefs)
+
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,67 @@
+package launcher
+
+import (
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// Preset is a curated set of flags, use [Launcher.Preset] to apply it. The presets are composable,
+// the later ones override the flags of the earlier ones, such as:
+//
+//	launcher.New().Preset(launcher.PresetCI(), launcher.PresetStealth())
+//
+// To create your own preset, just return a function that sets the flags.
+type Preset func(l *Launcher)
+
+// Preset applies the presets to the launcher in order.
+func (l *Launcher) Preset(presets ...Preset) *Launcher {
+	for _, p := range presets {
+		p(l)
+	}
+	return l
+}
+
+// PresetStealth reduces the signs of automation that websites can detect, such as the navigator.webdriver
+// and the "controlled by automated test software" infobar. It uses the new headless mode if the headless is
+// enabled, the old one is easier to detect. For the js side, check the github.com/go-rod/stealth .
+func PresetStealth() Preset {
+	return func(l *Launcher) {
+		l.Delete("enable-automation").
+			mergeFeatures("disable-blink-features", []string{"AutomationControlled"}).
+			WindowSize(1920, 1080)
+
+		if values, has := l.GetFlags(flags.Headless); has && len(values) == 0 {
+			l.HeadlessNew(true)
+		}
+	}
+}
+
+// PresetCI is for the CI environments and containers, which usually run as root, have no gpu,
+// and have a small /dev/shm.
+func PresetCI() Preset {
+	return func(l *Launcher) {
+		l.HeadlessNew(true).
+			NoSandbox(true).
+			Leakless(true).
+			Set("disable-dev-shm-usage").
+			Set("disable-gpu").
+			Set("disable-extensions").
+			Set("mute-audio").
+			Set("hide-scrollbars")
+	}
+}
+
+// PresetKiosk shows the [Launcher.StartURL] in fullscreen without the browser UI, the error dialogs,
+// the crash bubble, and the infobar, such as for a digital signage or a self-service terminal.
+func PresetKiosk() Preset {
+	return func(l *Launcher) {
+		l.Headless(false).
+			Delete("no-startup-window").
+			Delete("enable-automation").
+			Set("kiosk").
+			Set("noerrdialogs").
+			Set("disable-session-crashed-bubble").
+			Set("disable-pinch").
+			Set("overscroll-history-navigation", "0").
+			SuppressNativeUI(true)
+	}
+}
@@ -0,0 +1,335 @@
+package launcher
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// forwardProxy is a local http proxy that forwards all the traffic to an upstream proxy.
+// Browsers can't use proxies that require authentication via the command line flags,
+// the forwardProxy handles the authentication for them.
+type forwardProxy struct {
+	upstream *url.URL
+	listener net.Listener
+	server   *http.Server
+}
+
+// proxyWithCredentials returns the upstream url for the [forwardProxy] if the proxy host has credentials.
+// The scheme defaults to http like the [flags.ProxyServer]. The proxy rules like "http=a.com;https=b.com" are ignored.
+// The credentials can contain the "=" and ";", they are escaped in the returned url,
+// but the "=" in the username without a scheme should be escaped as "%3D", or the host is treated as a proxy rule.
+func proxyWithCredentials(host string) (string, bool) {
+	at := strings.LastIndex(host, "@")
+	if at < 0 {
+		return "", false
+	}
+
+	scheme, userinfo, addr := "", host[:at], host[at+1:]
+	if s, rest, ok := strings.Cut(userinfo, "://"); ok {
+		scheme, userinfo = s, rest
+	}
+
+	user, pass, _ := strings.Cut(userinfo, ":")
+	if strings.ContainsAny(scheme+addr, ";=") || (scheme == "" && strings.Contains(user, "=")) {
+		return "", false
+	}
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	user, err := url.PathUnescape(user)
+	if err != nil || user == "" {
+		return "", false
+	}
+	pass, err = url.PathUnescape(pass)
+	if err != nil {
+		return "", false
+	}
+
+	u := &url.URL{Scheme: scheme, User: url.UserPassword(user, pass), Host: addr}
+	if _, err := url.Parse(u.String()); err != nil {
+		return "", false
+	}
+
+	// the url escaping keeps the "=" and ";" of the userinfo
+	cred := strings.NewReplacer("=", "%3D", ";", "%3B").Replace(u.User.String())
+	return scheme + "://" + cred + "@" + addr, true
+}
+
+func newForwardProxy(upstream string) (*forwardProxy, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported upstream proxy scheme: %s", u.Scheme)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &forwardProxy{upstream: u, listener: l}
+	f.server = &http.Server{Handler: f, ReadHeaderTimeout: 30 * time.Second}
+
+	go func() { _ = f.server.Serve(l) }()
+
+	return f, nil
+}
+
+// Addr of the local proxy, such as "127.0.0.1:1234".
+func (f *forwardProxy) Addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *forwardProxy) Close() error {
+	return f.server.Close()
+}
+
+func (f *forwardProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		f.tunnel(w, r)
+		return
+	}
+
+	r.RequestURI = ""
+	r.Header.Del("Proxy-Connection")
+
+	transport := &http.Transport{Proxy: http.ProxyURL(f.upstream)}
+	defer transport.CloseIdleConnections()
+
+	res, err := transport.RoundTrip(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	for k, vs := range res.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
+
+func (f *forwardProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	dst, err := f.dial(r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = dst.Close()
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+
+	src, buf, err := hijacker.Hijack()
+	if err != nil {
+		_ = dst.Close()
+		return
+	}
+
+	_, _ = src.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+
+	go func() {
+		_, _ = io.Copy(dst, buf)
+		_ = dst.Close()
+	}()
+	_, _ = io.Copy(src, dst)
+	_ = src.Close()
+}
+
+// dial the addr via the upstream proxy.
+func (f *forwardProxy) dial(addr string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", f.upstream.Host, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.upstream.Scheme == "http" {
+		var tunnel net.Conn
+		tunnel, err = f.connectHTTP(conn, addr)
+		if err == nil {
+			return tunnel, nil
+		}
+	} else {
+		err = f.connectSOCKS5(conn, addr)
+		if err == nil {
+			return conn, nil
+		}
+	}
+
+	_ = conn.Close()
+	return nil, err
+}
+
+// bufferedConn reads the bytes that are already buffered by the reader before the ones of the Conn.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// connectHTTP returns the tunnel, the upstream may send the data of the tunnel right after the response header.
+func (f *forwardProxy) connectHTTP(conn net.Conn, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+
+	if user := f.upstream.User; user != nil {
+		password, _ := user.Password()
+		token := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+token)
+	}
+
+	err := req.Write(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, err
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrProxyUpstream, res.Status)
+	}
+	return &bufferedConn{conn, r}, nil
+}
+
+// connectSOCKS5 implements the client side of RFC 1928 and the username/password auth of RFC 1929.
+func (f *forwardProxy) connectSOCKS5(conn net.Conn, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return err
+	}
+
+	// the lengths are encoded in a single byte
+	if len(host) > 255 {
+		return fmt.Errorf("socks5 host is longer than 255 bytes: %s", host)
+	}
+
+	method := byte(0x00) // no auth
+	if f.upstream.User != nil {
+		method = 0x02 // username/password
+
+		password, _ := f.upstream.User.Password()
+		if len(f.upstream.User.Username()) > 255 || len(password) > 255 {
+			return errors.New("socks5 username or password is longer than 255 bytes")
+		}
+	}
+
+	_, err = conn.Write([]byte{0x05, 0x01, method})
+	if err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(conn, reply)
+	if err != nil {
+		return err
+	}
+	if reply[0] != 0x05 || reply[1] != method {
+		return fmt.Errorf("%w: socks5 auth method not accepted", ErrProxyUpstream)
+	}
+
+	if method == 0x02 {
+		user := f.upstream.User.Username()
+		password, _ := f.upstream.User.Password()
+
+		req := []byte{0x01, byte(len(user))}
+		req = append(req, user...)
+		req = append(req, byte(len(password)))
+		req = append(req, password...)
+
+		_, err = conn.Write(req)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.ReadFull(conn, reply)
+		if err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return fmt.Errorf("%w: socks5 authentication failed", ErrProxyUpstream)
+		}
+	}
+
+	req := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		req = append(req, 0x03, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, 0x01)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, 0x04)
+		req = append(req, ip.To16()...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+
+	_, err = conn.Write(req)
+	if err != nil {
+		return err
+	}
+
+	head := make([]byte, 4)
+	_, err = io.ReadFull(conn, head)
+	if err != nil {
+		return err
+	}
+	if head[1] != 0x00 {
+		return fmt.Errorf("%w: socks5 reply code %d", ErrProxyUpstream, head[1])
+	}
+
+	// skip the bound address
+	var l int
+	switch head[3] {
+	case 0x01:
+		l = net.IPv4len
+	case 0x04:
+		l = net.IPv6len
+	case 0x03:
+		n := make([]byte, 1)
+		_, err = io.ReadFull(conn, n)
+		if err != nil {
+			return err
+		}
+		l = int(n[0])
+	}
+	_, err = io.ReadFull(conn, make([]byte, l+2))
+	return err
+}
@@ -0,0 +1,105 @@
+package launcher
+
+import (
+	"fmt"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// retryPolicy of the [Launcher.Retry].
+type retryPolicy struct {
+	times   int
+	backoff utils.Sleeper
+}
+
+var (
+	// regTransientLaunch matches the outputs of the browser that fails because of a transient problem.
+	regTransientLaunch = regexp.MustCompile(`(?i)address already in use|bind\(\) (returned an error|failed)|` +
+		`cannot start http server|text file busy|resource temporarily unavailable|` +
+		`profile appears to be in use|singletonlock`)
+
+	// regPortInUse matches the outputs of the browser that fails because the debugging port is taken.
+	regPortInUse = regexp.MustCompile(`(?i)address already in use|bind\(\) (returned an error|failed)|` +
+		`cannot start http server`)
+)
+
+// Retry the [Launcher.Launch] up to the times when it fails with a known transient error, such as the debugging
+// port is taken by another process during the launch, the browser binary is still being written, or the
+// user data dir is locked by a browser that is exiting. The backoff is called before each retry, such as
+// utils.BackoffSleeper(time.Second, 5*time.Second, nil), if it returns an error the last launch error is returned.
+// Before each retry the failed browser is killed, a fresh dir will be used if the user data dir is a random one
+// under the [DefaultUserDataDirPrefix], and a random debugging port will be used if the port is taken.
+// The backoff can be nil to retry right away.
+func (l *Launcher) Retry(times int, backoff utils.Sleeper) *Launcher {
+	l.retry = retryPolicy{times, backoff}
+	return l
+}
+
+// IsTransientLaunchErr returns true if the err of the [Launcher.Launch] is known to be transient,
+// check [Launcher.Retry].
+func IsTransientLaunchErr(err error) bool {
+	return err != nil && regTransientLaunch.MatchString(err.Error())
+}
+
+// launchWithRetry runs the launch, and relaunches it according to the [Launcher.Retry].
+func (l *Launcher) launchWithRetry() (string, error) {
+	u, err := l.launch()
+
+	for i := 0; i < l.retry.times && IsTransientLaunchErr(err) && l.ctx.Err() == nil; i++ {
+		if l.retry.backoff != nil && l.retry.backoff(l.ctx) != nil {
+			break
+		}
+
+		_, _ = fmt.Fprintf(l.logger, "[launcher] retry the launch %d/%d: %v\n", i+1, l.retry.times, err)
+
+		l.prepareRetry(regPortInUse.MatchString(err.Error()))
+
+		u, err = l.launch()
+	}
+
+	// the exit of the launcher follows the browser of the last attempt
+	if l.pid != 0 {
+		attempt := l.attempt
+		go func() {
+			<-attempt
+			close(l.exit)
+		}()
+	}
+
+	return u, err
+}
+
+// prepareRetry waits for the failed browser to exit and resets the states of the previous launch.
+func (l *Launcher) prepareRetry(portInUse bool) {
+	// the launch has killed the browser if it failed after the browser started
+	if l.pid != 0 {
+		select {
+		case <-l.ctx.Done():
+		case <-l.attempt:
+		}
+	}
+
+	l.freshUserDataDir(true)
+
+	if portInUse && l.Get(flags.RemoteDebuggingPort) != "0" {
+		l.Set(flags.RemoteDebuggingPort, "0")
+	}
+
+	l.reset()
+}
+
+// reset the states of the previous launch attempt, so the launcher can launch again.
+// The [Launcher.Exit] isn't changed, because it's for the lifetime of the launcher.
+func (l *Launcher) reset() {
+	l.attempt = make(chan struct{})
+	l.parser = NewURLParser().Context(l.ctx)
+
+	atomic.StoreInt32(&l.killed, 0)
+	l.pid = 0
+	l.cmd = nil
+	l.stderr = nil
+	l.guardErr = nil
+}
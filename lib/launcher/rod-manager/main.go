@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/xyjwsj/grod/lib/launcher"
 	"github.com/xyjwsj/grod/lib/utils"
@@ -17,6 +18,10 @@ var (
 	addr         = flag.String("address", ":7317", "the address to listen to")
 	quiet        = flag.Bool("quiet", false, "silence the log")
 	allowAllPath = flag.Bool("allow-all", false, "allow all path set by the client")
+	cert         = flag.String("cert", "", "the TLS certificate file, the key flag is required too")
+	key          = flag.String("key", "", "the TLS private key file")
+	tokens       = flag.String("tokens", "", "comma separated bearer tokens that are required to launch browsers")
+	maxBrowsers  = flag.Int("max-browsers", 0, "the max number of the browsers that run at the same time, 0 means unlimited")
 )
 
 func main() {
@@ -32,6 +37,15 @@ func main() {
 		m.BeforeLaunch = func(_ *launcher.Launcher, _ http.ResponseWriter, _ *http.Request) {}
 	}
 
+	if *tokens != "" {
+		m.Tokens = map[string]*launcher.ManagerClient{}
+		for i, t := range strings.Split(*tokens, ",") {
+			m.Tokens[strings.TrimSpace(t)] = &launcher.ManagerClient{Name: fmt.Sprintf("token-%d", i)}
+		}
+	}
+
+	m.MaxBrowsers = *maxBrowsers
+
 	l, err := net.Listen("tcp", *addr)
 	if err != nil {
 		utils.E(err)
@@ -42,5 +56,10 @@ func main() {
 	}
 
 	srv := &http.Server{Handler: m}
+
+	if *cert != "" {
+		utils.E(srv.ServeTLS(l, *cert, *key))
+		return
+	}
 	utils.E(srv.Serve(l))
 }
@@ -0,0 +1,258 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stats of the browser process and its descendant processes, such as the gpu and renderer processes.
+type Stats struct {
+	// Time when the stats are collected
+	Time time.Time
+
+	// Process is the browser process, the descendants are in its Children
+	Process *ProcessStats
+
+	// RSS is the total resident memory of the process tree in bytes
+	RSS uint64
+
+	// CPU is the total CPU usage of the process tree in percent of a core, it can be greater than 100
+	CPU float64
+
+	// FDs is the total number of the open file descriptors of the process tree
+	FDs int
+
+	// Count is the number of the processes in the tree
+	Count int
+}
+
+// ProcessStats of a process.
+type ProcessStats struct {
+	PID  int
+	PPID int
+
+	// Name of the executable
+	Name string
+
+	// RSS is the resident memory in bytes
+	RSS uint64
+
+	// CPUTime is the total CPU time the process has used
+	CPUTime time.Duration
+
+	// CPU usage in percent of a core since the previous [Launcher.Stats], or since the process started
+	// for the first one
+	CPU float64
+
+	// FDs is the number of the open file descriptors
+	FDs int
+
+	Children []*ProcessStats
+
+	// started is the time the process started after the system booted
+	started time.Duration
+}
+
+// interfaces for testing.
+var (
+	statsProcDir = "/proc"
+	statsClockHz = 100.0
+)
+
+// statsState is the previous CPU time of each process to calculate the CPU usage.
+type statsState struct {
+	lock sync.Mutex
+	at   time.Time
+	cpu  map[int]time.Duration
+}
+
+// Stats returns the resource usage of the launched browser and its descendant processes, operators can use it to
+// enforce limits and detect leaks in long-running automation, check [Launcher.WatchStats] for polling.
+// It only works on Linux, otherwise [ErrStatsNotSupported] is returned.
+func (l *Launcher) Stats() (*Stats, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("%w on %s", ErrStatsNotSupported, runtime.GOOS)
+	}
+
+	pid := l.PID()
+	if pid == 0 {
+		return nil, errors.New("the browser is not launched")
+	}
+
+	all, err := readProcs()
+	if err != nil {
+		return nil, err
+	}
+
+	root, has := all[pid]
+	if !has {
+		return nil, fmt.Errorf("the browser process %d is not found", pid)
+	}
+
+	for _, p := range all {
+		if parent, has := all[p.PPID]; has && p.PID != pid {
+			parent.Children = append(parent.Children, p)
+		}
+	}
+
+	now := time.Now()
+	s := &Stats{Time: now, Process: root}
+
+	l.stats.lock.Lock()
+	defer l.stats.lock.Unlock()
+
+	prev, prevAt := l.stats.cpu, l.stats.at
+	l.stats.cpu = map[int]time.Duration{}
+	l.stats.at = now
+
+	uptime := readUptime()
+
+	var walk func(p *ProcessStats)
+	walk = func(p *ProcessStats) {
+		sort.Slice(p.Children, func(i, j int) bool { return p.Children[i].PID < p.Children[j].PID })
+
+		p.FDs = countFDs(p.PID)
+		l.stats.cpu[p.PID] = p.CPUTime
+
+		if last, has := prev[p.PID]; has && now.After(prevAt) {
+			p.CPU = percent(p.CPUTime-last, now.Sub(prevAt))
+		} else if p.started > 0 && uptime > p.started {
+			p.CPU = percent(p.CPUTime, uptime-p.started)
+		}
+
+		s.RSS += p.RSS
+		s.CPU += p.CPU
+		s.FDs += p.FDs
+		s.Count++
+
+		for _, c := range p.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	return s, nil
+}
+
+// WatchStats calls the handler with the [Launcher.Stats] every interval until the browser exits,
+// the stats fail, or the stop is called.
+func (l *Launcher) WatchStats(interval time.Duration, handler func(*Stats)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	exit := l.Exit()
+
+	go func() {
+		for {
+			s, err := l.Stats()
+			if err != nil {
+				_, _ = fmt.Fprintln(l.logger, "[launcher] stop watching stats:", err)
+				return
+			}
+			handler(s)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-exit:
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+
+	return cancel
+}
+
+func percent(used, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(used) / float64(elapsed) * 100
+}
+
+// readProcs reads the stats of all the processes, the fds are not counted to save time.
+func readProcs() (map[int]*ProcessStats, error) {
+	entries, err := os.ReadDir(statsProcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	all := map[int]*ProcessStats{}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+
+		// the process may exit at any time
+		if p := readProc(pid); p != nil {
+			all[pid] = p
+		}
+	}
+	return all, nil
+}
+
+// readProc parses the /proc/[pid]/stat, the doc is https://man7.org/linux/man-pages/man5/proc.5.html
+func readProc(pid int) *ProcessStats {
+	b, err := os.ReadFile(filepath.Join(statsProcDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil
+	}
+	stat := string(b)
+
+	// the name may contain spaces and parentheses
+	start, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return nil
+	}
+
+	// the fields after the name, the first one is the 3rd field "state"
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return nil
+	}
+	field := func(n int) int64 {
+		v, _ := strconv.ParseInt(fields[n-3], 10, 64)
+		return v
+	}
+
+	return &ProcessStats{
+		PID:     pid,
+		PPID:    int(field(4)),
+		Name:    stat[start+1 : end],
+		CPUTime: ticks(field(14) + field(15)),
+		RSS:     uint64(field(24)) * uint64(os.Getpagesize()),
+		started: ticks(field(22)),
+	}
+}
+
+func ticks(n int64) time.Duration {
+	return time.Duration(float64(n) / statsClockHz * float64(time.Second))
+}
+
+// readUptime returns the time since the system booted.
+func readUptime() time.Duration {
+	b, err := os.ReadFile(filepath.Join(statsProcDir, "uptime"))
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return 0
+	}
+	sec, _ := strconv.ParseFloat(fields[0], 64)
+	return time.Duration(sec * float64(time.Second))
+}
+
+func countFDs(pid int) int {
+	list, _ := os.ReadDir(filepath.Join(statsProcDir, strconv.Itoa(pid), "fd"))
+	return len(list)
+}
@@ -0,0 +1,110 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// Recovery is reported by [Launcher.Supervise] after the browser crashed and relaunched.
+type Recovery struct {
+	// Crash report of the crashed browser
+	Crash *CrashReport
+
+	// Launcher of the relaunched browser, it's nil if the Err isn't nil
+	Launcher *Launcher
+
+	// URL is the control url of the relaunched browser, it's empty if the Err isn't nil
+	URL string
+
+	// Err of the relaunch
+	Err error
+}
+
+// ControlURL returns the control url of the launched browser, it's empty if the browser isn't launched.
+func (l *Launcher) ControlURL() string {
+	return l.controlURL
+}
+
+// Relaunch returns a new launcher that launches the browser with the same flags after the browser of l exits,
+// such as after a crash. The states of l are never changed, so it's safe to keep using l, such as the
+// [Launcher.Exit] and [Launcher.CrashReport] of it, while relaunching. The new launcher uses the same
+// user data dir as l, so only call the [Launcher.Cleanup] of the last one. The ctx is used by the new launch
+// like the [Launcher.Context]. It returns [ErrBrowserRunning] if the browser hasn't exited.
+// It follows the [Launcher.Retry] like the [Launcher.Launch], use [Launcher.ControlURL] to get the new control url.
+func (l *Launcher) Relaunch(ctx context.Context) (*Launcher, error) {
+	select {
+	case <-l.exit:
+	default:
+		return nil, ErrBrowserRunning
+	}
+
+	n := l.Clone().Context(ctx)
+
+	// the resources of the presets, such as the server of the NewAppModeFS, are still needed by the new browser
+	n.launched = slices.Clone(l.launched)
+	n.cleanups = l.takeCleanups()
+
+	// keep the profile of the crashed browser
+	for _, f := range []flags.Flag{flags.UserDataDir, flags.FirefoxProfile, flags.DockerVolume} {
+		if list, has := l.GetFlags(f); has {
+			n.Set(f, list...)
+		}
+	}
+
+	_, err := n.Launch()
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Supervise relaunches the browser via [Launcher.Relaunch] every time it crashes, the handler is called after
+// each relaunch with the new launcher, the next crash is detected via the new launcher.
+// It stops when the browser exits without a [Launcher.CrashReport], such as by [Launcher.Kill]
+// or the Browser.close of CDP, or when the relaunch fails. Call stop to stop it.
+// The browser must be launched before supervising.
+func (l *Launcher) Supervise(handler func(*Recovery)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		for cur := l; ; {
+			select {
+			case <-ctx.Done():
+				return
+			case <-cur.Exit():
+			}
+
+			crash := cur.CrashReport()
+			if crash == nil {
+				return
+			}
+
+			_, _ = fmt.Fprintln(cur.logger, crash.Error())
+			_, _ = fmt.Fprintln(cur.logger, "[launcher] relaunch the browser")
+
+			next, err := cur.Relaunch(ctx)
+			if ctx.Err() != nil {
+				if next != nil {
+					next.Kill()
+				}
+				return
+			}
+
+			r := &Recovery{Crash: crash, Err: err}
+			if err == nil {
+				r.Launcher, r.URL = next, next.ControlURL()
+			}
+			handler(r)
+
+			if err != nil {
+				return
+			}
+			cur = next
+		}
+	}()
+
+	return cancel
+}
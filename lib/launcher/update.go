@@ -0,0 +1,114 @@
+package launcher
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/leakless"
+)
+
+// CurrentDir is the symlink to the dir of the revision that [Browser.Update] swapped to,
+// the path is stable across the updates, so other processes can use it to find the latest browser.
+func (lc *Browser) CurrentDir() string {
+	return filepath.Join(lc.RootDir, "chromium-current")
+}
+
+// CheckUpdate fetches the latest revision of the [Browser.UpdateChannel] from the [Browser.LatestURL],
+// has is true if it's newer than the [Browser.Revision].
+func (lc *Browser) CheckUpdate() (latest int, has bool, err error) {
+	client, err := lc.httpClient()
+	if err != nil {
+		return
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ch, err := cftLatest(lc.Context, client, lc.LatestURL, lc.UpdateChannel)
+	if err != nil {
+		return
+	}
+
+	latest, err = strconv.Atoi(ch.Revision)
+	if err != nil {
+		err = fmt.Errorf("invalid revision of chrome %s %s: %w", lc.UpdateChannel, ch.Version, err)
+		return
+	}
+
+	return latest, latest > lc.Revision, nil
+}
+
+// Update the browser to the latest revision if [Browser.CheckUpdate] finds one.
+// The new revision is downloaded and validated in a temp dir before it's moved beside the current one,
+// then the [Browser.CurrentDir] is swapped to it atomically and the [Browser.Revision] is set to it.
+// The dir of the old revision is kept, so the running browsers won't be affected, the next launch will use
+// the new [Browser.BinPath]. It returns false if the browser is already up to date.
+func (lc *Browser) Update() (bool, error) {
+	latest, has, err := lc.CheckUpdate()
+	if err != nil || !has {
+		return false, err
+	}
+
+	defer leakless.LockPort(lc.LockPort)()
+
+	next := *lc
+	next.Revision = latest
+
+	if next.Validate() != nil {
+		tmp := next
+		tmp.RootDir = filepath.Join(lc.RootDir, ".tmp-"+utils.RandString(8))
+		defer func() { _ = os.RemoveAll(tmp.RootDir) }()
+
+		err = tmp.Download()
+		if err != nil {
+			return false, err
+		}
+
+		err = tmp.Validate()
+		if err != nil {
+			return false, err
+		}
+
+		_ = os.RemoveAll(next.Dir())
+		err = os.Rename(tmp.Dir(), next.Dir())
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = swapSymlink(next.Dir(), lc.CurrentDir())
+	if err != nil {
+		return false, err
+	}
+
+	lc.Revision = latest
+	return true, nil
+}
+
+// MustUpdate is similar with [Browser.Update].
+func (lc *Browser) MustUpdate() bool {
+	updated, err := lc.Update()
+	utils.E(err)
+	return updated
+}
+
+// swapSymlink points the link to the target atomically by renaming a new symlink over it.
+func swapSymlink(target, link string) error {
+	tmp := link + ".tmp"
+	_ = os.Remove(tmp)
+
+	err := os.Symlink(target, tmp)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp, link)
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
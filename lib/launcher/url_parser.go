@@ -104,6 +104,10 @@ func ResolveURL(u string) (string, error) {
 		u = "9222"
 	}
 
+	if IsPipeURL(u) {
+		return u, nil
+	}
+
 	u = strings.TrimSpace(u)
 	u = regPort.ReplaceAllString(u, "127.0.0.1:$1")
 
@@ -0,0 +1,107 @@
+package launcher
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// the files that lock the profile to the running browser, they must not be copied.
+var profileLocks = map[string]bool{
+	"SingletonLock":   true,
+	"SingletonCookie": true,
+	"SingletonSocket": true,
+	"lockfile":        true,
+	"lock":            true,
+	".parentlock":     true,
+	"parent.lock":     true,
+}
+
+// UserDataDirTemplate sets the prepared profile dir, such as the one with the logged-in cookies, extensions, and
+// preferences, it will be copied into the [flags.UserDataDir] before launch, so that the parallel temp browsers
+// can reuse it without sharing the same user data dir. For [NewFirefox] it's copied into the [flags.FirefoxProfile].
+// It's skipped if the user data dir isn't empty, such as the one kept by the previous launch.
+// The lock files of the running browser in the src are ignored, but it's better to close the browser of it first,
+// so that the profile is flushed to the disk. Set it to empty to disable it.
+// The [Manager] refuses it from the remote clients by default, check [ManagerDeniedFlags].
+func (l *Launcher) UserDataDirTemplate(src string) *Launcher {
+	if src == "" {
+		return l.Delete(flags.UserDataDirTemplate)
+	}
+	return l.Set(flags.UserDataDirTemplate, src)
+}
+
+// setupUserDataDirTemplate copies the [flags.UserDataDirTemplate] into the user data dir.
+func (l *Launcher) setupUserDataDirTemplate() error {
+	src := l.Get(flags.UserDataDirTemplate)
+	if src == "" {
+		return nil
+	}
+
+	dst := l.Get(flags.UserDataDir)
+	if l.Has(flags.Firefox) {
+		dst = l.Get(flags.FirefoxProfile)
+	}
+	if dst == "" {
+		return nil
+	}
+
+	if list, err := os.ReadDir(dst); err == nil && len(list) > 0 {
+		return nil
+	}
+
+	return copyProfile(src, dst)
+}
+
+// copyProfile copies the regular files of the src dir to the dst dir, the profile locks are skipped.
+func copyProfile(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		if !d.Type().IsRegular() || profileLocks[d.Name()] {
+			return nil
+		}
+
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	from, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = from.Close() }()
+
+	to, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(to, from)
+	if err != nil {
+		_ = to.Close()
+		return err
+	}
+	return to.Close()
+}
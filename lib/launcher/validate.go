@@ -0,0 +1,200 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// LaunchPreview is the command that [Launcher.Launch] would execute, it's returned by [Launcher.Validate].
+type LaunchPreview struct {
+	// Path of the executable, it's different from the Bin when the [flags.XVFB] is used.
+	Path string
+
+	// Bin of the browser
+	Bin string
+
+	// Download is true if the Bin doesn't exist and it will be downloaded before launching.
+	Download bool
+
+	// Args of the command, the first one is the Path.
+	Args []string
+
+	// Env of the command, nil means the env of the current process will be used.
+	Env []string
+
+	// Dir is the working dir of the command.
+	Dir string
+
+	// Leakless is true if the command will be wrapped by the leakless guard process.
+	Leakless bool
+
+	// Conflicts of the flags, they won't fail the validation.
+	Conflicts []*FlagConflict
+}
+
+// String returns the shell form of the command, each env is on its own line before the command line.
+func (p *LaunchPreview) String() string {
+	lines := []string{}
+	if p.Dir != "" {
+		lines = append(lines, "cd "+shellQuote(p.Dir))
+	}
+	for _, e := range p.Env {
+		lines = append(lines, "export "+shellQuote(e))
+	}
+
+	args := make([]string, 0, len(p.Args))
+	for _, a := range p.Args {
+		args = append(args, shellQuote(a))
+	}
+	lines = append(lines, strings.Join(args, " "))
+
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the launch settings without launching the browser, it's useful to debug launch issues on CI.
+// It checks the flag syntax, the bin path, the [flags.UserDataDir], the [flags.WorkingDir], the [flags.Policies],
+// and whether the [flags.RemoteDebuggingPort] is available.
+// The returned preview is the exact command that [Launcher.Launch] would execute, it's also printed to the [Launcher.Logger].
+// The error wraps [ErrInvalidLaunch] and all the problems found.
+//
+// The browser won't be downloaded, and the local proxy of [Launcher.ProxyUpstream] won't be started,
+// so the "proxy-server" flag of it is not in the preview.
+func (l *Launcher) Validate() (*LaunchPreview, error) {
+	problems := []error{}
+
+	snapshot := l.flagsSnapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.ContainsAny(name, "= \t\n") || strings.HasPrefix(name, "-") {
+			problems = append(problems, fmt.Errorf("malformed flag name: %q", name))
+		}
+	}
+
+	preview := &LaunchPreview{
+		Bin:       l.Get(flags.Bin),
+		Leakless:  l.Has(flags.Leakless),
+		Conflicts: l.Conflicts(),
+	}
+
+	args := l.FormatArgs()
+
+	if l.Has(flags.Docker) {
+		preview.Bin = dockerBin
+		args = l.dockerArgs("rod-preview")
+		if _, err := exec.LookPath(dockerBin); err != nil {
+			problems = append(problems, fmt.Errorf("docker: %w", err))
+		}
+	} else if preview.Bin == "" && l.Has(flags.Firefox) {
+		bin, has := LookPathFirefox()
+		if !has {
+			bin = NewFirefoxBrowser().BinPath()
+		}
+		preview.Bin = bin
+		_, err := os.Stat(preview.Bin)
+		preview.Download = err != nil
+	} else if preview.Bin == "" && l.Has(flags.Channel) {
+		c, ok := Channel(l.Get(flags.Channel)).Normalize()
+		bin, has := LookPathChannel(c)
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Errorf("unknown browser channel: %s", c))
+		case has:
+			preview.Bin = bin
+		case c.IsEdge():
+			problems = append(problems, fmt.Errorf("the %s channel is not installed", c))
+		default:
+			preview.Bin = NewChannelBrowser(c).BinPath()
+			preview.Download = true
+		}
+	} else if preview.Bin == "" {
+		l.browser.HeadlessShell = l.Has(flags.HeadlessShell)
+		l.browser.Version = l.Get(flags.Version)
+		preview.Bin = l.browser.BinPath()
+		_, err := os.Stat(preview.Bin)
+		preview.Download = err != nil
+	} else if _, err := exec.LookPath(preview.Bin); err != nil {
+		problems = append(problems, fmt.Errorf("browser bin: %w", err))
+	}
+
+	if dir := l.Get(flags.UserDataDir); dir != "" {
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			problems = append(problems, fmt.Errorf("user data dir is not a directory: %s", dir))
+		} else if err != nil && !os.IsNotExist(err) {
+			problems = append(problems, fmt.Errorf("user data dir: %w", err))
+		}
+	}
+
+	if dir := l.Get(flags.UserDataDirTemplate); dir != "" {
+		if info, err := os.Stat(dir); err != nil {
+			problems = append(problems, fmt.Errorf("user data dir template: %w", err))
+		} else if !info.IsDir() {
+			problems = append(problems, fmt.Errorf("user data dir template is not a directory: %s", dir))
+		}
+	}
+
+	if dir := l.Get(flags.WorkingDir); dir != "" {
+		if info, err := os.Stat(dir); err != nil {
+			problems = append(problems, fmt.Errorf("working dir: %w", err))
+		} else if !info.IsDir() {
+			problems = append(problems, fmt.Errorf("working dir is not a directory: %s", dir))
+		}
+	}
+
+	if policies := l.Get(flags.Policies); policies != "" {
+		if _, err := compactPolicies(policies); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if l.Has(flags.ManagedXVFB) {
+		if _, err := exec.LookPath(xvfbBin); err != nil {
+			problems = append(problems, fmt.Errorf("xvfb: %w", err))
+		}
+	}
+
+	// When leakless is disabled, a busy port means the launcher will reuse the running browser.
+	if port := l.Get(flags.RemoteDebuggingPort); port != "" && port != "0" && preview.Leakless {
+		ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", port))
+		if err != nil {
+			problems = append(problems, fmt.Errorf("remote debugging port %s is not available: %w", port, err))
+		} else {
+			_ = ln.Close()
+		}
+	}
+
+	cmd := exec.Command(preview.Bin, args...)
+	l.setupCmd(cmd)
+	preview.Path = cmd.Path
+	preview.Args = cmd.Args
+	preview.Env = cmd.Env
+	preview.Dir = cmd.Dir
+
+	_, _ = fmt.Fprintln(l.logger, "[launcher] preview:")
+	_, _ = fmt.Fprintln(l.logger, preview)
+	for _, c := range preview.Conflicts {
+		_, _ = fmt.Fprintln(l.logger, "[launcher] warning:", c)
+	}
+
+	if len(problems) > 0 {
+		return preview, fmt.Errorf("%w: %w", ErrInvalidLaunch, errors.Join(problems...))
+	}
+	return preview, nil
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?&;|<>(){}[]#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,114 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// the products of the Chrome for Testing downloads.
+const (
+	cftChrome        = "chrome"
+	cftHeadlessShell = "chrome-headless-shell"
+)
+
+var regVersion = regexp.MustCompile(`^\d+\.\d+\.\d+\.\d+$`)
+
+// Version of the Chrome for Testing to download and launch, such as "120.0.6099.71", it's more readable than the
+// [Launcher.Revision] and matches the version the team tests against. It resolves the download url of the
+// current platform via the [Browser.HeadlessShellVersionsURL]. Set it to empty to use the [Launcher.Revision].
+// It's not used if the [Launcher.Bin] or the [Launcher.Channel] is set.
+func (l *Launcher) Version(v string) *Launcher {
+	if v == "" {
+		return l.Delete(flags.Version)
+	}
+	return l.Set(flags.Version, v)
+}
+
+// cftVersion is an item of the versions of the Chrome for Testing.
+type cftVersion struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Downloads map[string][]struct {
+		Platform string `json:"platform"`
+		URL      string `json:"url"`
+	} `json:"downloads"`
+}
+
+// download returns the url of the product for the current platform, empty if it's not available.
+func (v *cftVersion) download(product string) string {
+	for _, d := range v.Downloads[product] {
+		if d.Platform == cftPlatform {
+			return d.URL
+		}
+	}
+	return ""
+}
+
+// cftVersions lists the versions of the Chrome for Testing via the [Browser.HeadlessShellVersionsURL].
+func (lc *Browser) cftVersions() ([]*cftVersion, error) {
+	req, err := http.NewRequestWithContext(lc.Context, http.MethodGet, lc.HeadlessShellVersionsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := lc.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the chrome for testing versions: %s", res.Status)
+	}
+
+	var list struct {
+		Versions []*cftVersion `json:"versions"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&list)
+	return list.Versions, err
+}
+
+// versionURL resolves the download url of the [Browser.Version].
+func (lc *Browser) versionURL() (string, error) {
+	if !regVersion.MatchString(lc.Version) {
+		return "", fmt.Errorf("invalid chrome for testing version %q, it should be like 120.0.6099.71", lc.Version)
+	}
+
+	if cftPlatform == "" {
+		return "", fmt.Errorf("chrome for testing is not available for your OS")
+	}
+
+	product := cftChrome
+	if lc.HeadlessShell {
+		product = cftHeadlessShell
+	}
+
+	list, err := lc.cftVersions()
+	if err != nil {
+		return "", err
+	}
+
+	for _, v := range list {
+		if v.Version != lc.Version {
+			continue
+		}
+		if u := v.download(product); u != "" {
+			return u, nil
+		}
+		return "", fmt.Errorf("%s %s is not available for %s", product, lc.Version, cftPlatform)
+	}
+
+	return "", fmt.Errorf("%s %s is not found in %s", product, lc.Version, lc.HeadlessShellVersionsURL)
+}
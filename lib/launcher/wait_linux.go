@@ -0,0 +1,20 @@
+package launcher
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// waitExited blocks until the process exits without reaping it, the zombie keeps the process group alive.
+// It returns false if it fails to wait.
+func waitExited(pid int) bool {
+	// the siginfo_t is 128 bytes on linux
+	var info [128]byte
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, 1 /* P_PID */, uintptr(pid),
+			uintptr(unsafe.Pointer(&info)), syscall.WEXITED|syscall.WNOWAIT, 0, 0)
+		if errno != syscall.EINTR {
+			return errno == 0
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !linux
+
+package launcher
+
+// waitExited isn't supported, the process group is killed right after the process is reaped,
+// there's a small window that the group id is reused by the OS.
+func waitExited(_ int) bool {
+	return false
+}
@@ -0,0 +1,137 @@
+package launcher
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/launcher/flags"
+)
+
+// XVFBTimeout is the time to wait for the Xvfb of the [Launcher.ManagedXVFB] to be ready.
+var XVFBTimeout = 10 * time.Second
+
+// xvfbBin for testing.
+var xvfbBin = "Xvfb"
+
+// ManagedXVFB starts a dedicated Xvfb process before launching the browser, waits for it to be ready,
+// and sets the DISPLAY env of the browser to it, the Xvfb is stopped after the browser exits or on [Launcher.Cleanup].
+// It's useful to run the headful mode on linux without the xvfb-run of the [Launcher.XVFB], such as:
+//
+//	launcher.New().Headless(false).ManagedXVFB(0, 1280, 720)
+//
+// The display is the number of the X display, such as 99, 0 means to choose a free one.
+// The width and height are the screen size, 0 means 1920x1080. The Xvfb must be installed.
+// It's not supported on Windows.
+func (l *Launcher) ManagedXVFB(display, width, height int) *Launcher {
+	if width == 0 || height == 0 {
+		width, height = 1920, 1080
+	}
+
+	d := ""
+	if display != 0 {
+		d = strconv.Itoa(display)
+	}
+
+	return l.Set(flags.ManagedXVFB, d, fmt.Sprintf("%dx%dx24", width, height))
+}
+
+type xvfb struct {
+	cmd     *exec.Cmd
+	display string
+	once    sync.Once
+}
+
+func (x *xvfb) close() {
+	x.once.Do(func() {
+		_ = x.cmd.Process.Kill()
+		_ = x.cmd.Wait()
+	})
+}
+
+// startXVFB starts the Xvfb of the [flags.ManagedXVFB], the Xvfb writes the display number to the
+// displayfd once it's ready to accept connections.
+func (l *Launcher) startXVFB() error {
+	args, has := l.GetFlags(flags.ManagedXVFB)
+	if !has {
+		return nil
+	}
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("%w: not supported on windows", ErrXVFB)
+	}
+
+	display, screen := "", "1920x1080x24"
+	if len(args) > 0 {
+		display = args[0]
+	}
+	if len(args) > 1 {
+		screen = args[1]
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	command := []string{}
+	if display != "" {
+		command = append(command, ":"+display)
+	}
+	// the fd 3 is the first of the ExtraFiles
+	command = append(command, "-screen", "0", screen, "-nolisten", "tcp", "-displayfd", "3")
+
+	stderr := newTailBuffer(stderrTailSize)
+	cmd := exec.Command(xvfbBin, command...)
+	cmd.ExtraFiles = []*os.File{w}
+	cmd.Stdout = l.logger
+	cmd.Stderr = stderr
+
+	err = cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		_ = r.Close()
+		return fmt.Errorf("%w: %w", ErrXVFB, err)
+	}
+
+	x := &xvfb{cmd: cmd}
+
+	ready := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(r).ReadString('\n')
+		_ = r.Close()
+		ready <- strings.TrimSpace(line)
+	}()
+
+	select {
+	case n := <-ready:
+		if n != "" {
+			x.display = ":" + n
+			l.xvfb = x
+			_, _ = fmt.Fprintln(l.logger, "[launcher] Xvfb is ready on display", x.display)
+			return nil
+		}
+		// wait for the stderr to be flushed
+		x.close()
+		return fmt.Errorf("%w: %s", ErrXVFB, strings.TrimSpace(stderr.String()))
+	case <-time.After(XVFBTimeout):
+		err = fmt.Errorf("%w: not ready in %s", ErrXVFB, XVFBTimeout)
+	case <-l.ctx.Done():
+		err = l.ctx.Err()
+	}
+
+	x.close()
+	return err
+}
+
+// closeXVFB stops the Xvfb of the [Launcher.ManagedXVFB].
+func (l *Launcher) closeXVFB() {
+	if l.xvfb != nil {
+		l.xvfb.close()
+	}
+}
@@ -0,0 +1,114 @@
+// Package netfault provides the http handlers that emulate the faulty servers, such as the ones that respond slowly,
+// stall in the middle of the body, send the malformed chunked encoding, or reset the connection.
+// They are useful to test how the code that loads the pages or hijacks the requests handles the network errors,
+// such as:
+//
+//	s := httptest.NewServer(netfault.Reset())
+//	err := page.Navigate(s.URL) // the rod.NavigationError
+package netfault
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Status responds the status code with the body.
+func Status(code int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+// Slow responds the body with the chunked encoding, it sends the size bytes of the body every interval.
+func Slow(contentType, body string, size int, interval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+
+		for i := 0; i < len(body); i += size {
+			_, _ = w.Write([]byte(body[i:min(i+size, len(body))]))
+			flush(w)
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}
+}
+
+// Stall responds the headers with the Content-Length of the whole body, but only sends the first n bytes of it,
+// then it stalls until the client gives up.
+func Stall(contentType, body string, n int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(body[:min(n, len(body))]))
+		flush(w)
+
+		<-r.Context().Done()
+	}
+}
+
+// MalformedChunked responds the first half of the body as a valid chunk, then sends a chunk with an invalid size
+// and closes the connection.
+func MalformedChunked(contentType, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		conn, buf := hijack(w)
+		if conn == nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		half := len(body) / 2
+		_, _ = fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: %s\r\nTransfer-Encoding: chunked\r\n\r\n", contentType)
+		_, _ = fmt.Fprintf(buf, "%x\r\n%s\r\n", half, body[:half])
+		_, _ = fmt.Fprintf(buf, "zz\r\n%s\r\n", body[half:])
+		_ = buf.Flush()
+	}
+}
+
+// Reset resets the connection before responding anything, the client will get the connection reset error.
+func Reset() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		conn, _ := hijack(w)
+		if conn == nil {
+			return
+		}
+
+		// the RST is sent instead of the FIN when the linger is 0
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			_ = tcp.SetLinger(0)
+		}
+		_ = conn.Close()
+	}
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// hijack takes over the connection, it returns nil if the w doesn't support it, such as the HTTP/2 response.
+func hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter) {
+	h, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "netfault: the connection can't be hijacked", http.StatusInternalServerError)
+		return nil, nil
+	}
+
+	conn, buf, err := h.Hijack()
+	if err != nil {
+		http.Error(w, "netfault: "+err.Error(), http.StatusInternalServerError)
+		return nil, nil
+	}
+	return conn, buf
+}
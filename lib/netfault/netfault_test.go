@@ -0,0 +1,79 @@
+package netfault_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/netfault"
+	"github.com/ysmood/got"
+)
+
+func get(g got.G, h http.HandlerFunc) (string, error) {
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(g.Context(), time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	g.E(err)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	b, err := io.ReadAll(res.Body)
+	return string(b), err
+}
+
+func TestStatus(t *testing.T) {
+	g := got.T(t)
+
+	s := httptest.NewServer(netfault.Status(http.StatusServiceUnavailable, "busy"))
+	defer s.Close()
+
+	res, err := http.Get(s.URL)
+	g.E(err)
+	defer func() { _ = res.Body.Close() }()
+	g.Eq(res.StatusCode, http.StatusServiceUnavailable)
+	g.Eq(g.Read(res.Body).String(), "busy")
+}
+
+func TestSlow(t *testing.T) {
+	g := got.T(t)
+
+	start := time.Now()
+	body, err := get(g, netfault.Slow("text/plain", "abcdef", 2, 50*time.Millisecond))
+	g.E(err)
+	g.Eq(body, "abcdef")
+	g.Gte(time.Since(start), 100*time.Millisecond)
+}
+
+func TestStall(t *testing.T) {
+	g := got.T(t)
+
+	body, err := get(g, netfault.Stall("text/plain", "abcdef", 3))
+	g.Is(err, context.DeadlineExceeded)
+	g.Eq(body, "abc")
+}
+
+func TestMalformedChunked(t *testing.T) {
+	g := got.T(t)
+
+	body, err := get(g, netfault.MalformedChunked("text/plain", "abcdef"))
+	g.Has(err.Error(), "chunk")
+	g.Eq(body, "abc")
+}
+
+func TestReset(t *testing.T) {
+	g := got.T(t)
+
+	_, err := get(g, netfault.Reset())
+	g.Has(err.Error(), "connection reset")
+}
@@ -138,6 +138,26 @@ func (t T) CookiesToParams() {
 
 	t.Eq(list[0].Name, "name")
 	t.Eq(list[0].Value, "val")
+	t.Nil(list[0].SourcePort)
+	t.Nil(list[0].PartitionKey)
+
+	key := &proto.NetworkCookiePartitionKey{TopLevelSite: "https://a.com", HasCrossSiteAncestor: true}
+	list = proto.CookiesToParams([]*proto.NetworkCookie{{
+		Name:         "name",
+		SourceScheme: proto.NetworkCookieSourceSchemeSecure,
+		SourcePort:   443,
+		PartitionKey: key,
+	}})
+
+	t.Eq(list[0].SourceScheme, proto.NetworkCookieSourceSchemeSecure)
+	t.Eq(*list[0].SourcePort, 443)
+	t.Eq(list[0].PartitionKey, key)
+}
+
+func (t T) NetworkCookiePartitioned() {
+	t.False((&proto.NetworkCookie{}).Partitioned())
+	t.True((&proto.NetworkCookie{PartitionKey: &proto.NetworkCookiePartitionKey{TopLevelSite: "https://a.com"}}).Partitioned())
+	t.True((&proto.NetworkCookie{PartitionKeyOpaque: true}).Partitioned())
 }
 
 func (t T) GeneratorOptimize() {
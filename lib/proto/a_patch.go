@@ -164,20 +164,34 @@ func (p *InputTouchPoint) MoveTo(x, y float64) {
 }
 
 // CookiesToParams converts Cookies list to NetworkCookieParam list.
+// The partition key of the partitioned cookies is kept, so they are set back into the same partition.
 func CookiesToParams(cookies []*NetworkCookie) []*NetworkCookieParam {
 	list := []*NetworkCookieParam{}
 	for _, c := range cookies {
-		list = append(list, &NetworkCookieParam{
-			Name:     c.Name,
-			Value:    c.Value,
-			Domain:   c.Domain,
-			Path:     c.Path,
-			Secure:   c.Secure,
-			HTTPOnly: c.HTTPOnly,
-			SameSite: c.SameSite,
-			Expires:  c.Expires,
-			Priority: c.Priority,
-		})
+		param := &NetworkCookieParam{
+			Name:         c.Name,
+			Value:        c.Value,
+			Domain:       c.Domain,
+			Path:         c.Path,
+			Secure:       c.Secure,
+			HTTPOnly:     c.HTTPOnly,
+			SameSite:     c.SameSite,
+			Expires:      c.Expires,
+			Priority:     c.Priority,
+			SourceScheme: c.SourceScheme,
+			PartitionKey: c.PartitionKey,
+		}
+		if c.SourcePort != 0 {
+			port := c.SourcePort
+			param.SourcePort = &port
+		}
+		list = append(list, param)
 	}
 	return list
 }
+
+// Partitioned returns true if the cookie is a partitioned cookie (CHIPS), it's only readable under the
+// top-level site of its partition key.
+func (c *NetworkCookie) Partitioned() bool {
+	return c.PartitionKey != nil || c.PartitionKeyOpaque
+}
@@ -0,0 +1,515 @@
+// Package puppeteer is an experimental converter that translates simple Puppeteer and Playwright scripts
+// to rod Go code, to speed up the migrations. Only the straight-line scripts of the common page actions,
+// such as goto, click, type, and waitForSelector, are supported. The statements that can't be converted are
+// kept as the TODO comments and reported in the [Result.Warnings]. The rod-import is the command line tool of it.
+package puppeteer
+
+import (
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Result of the [Convert].
+type Result struct {
+	// Code of the Go program
+	Code string
+
+	// Warnings of the statements that can't be converted
+	Warnings []string
+}
+
+// Convert the js script to a Go program that uses rod.
+func Convert(script string) (*Result, error) {
+	c := &converter{
+		browsers: map[string]bool{},
+		pages:    map[string]bool{},
+		imports:  map[string]bool{"github.com/xyjwsj/grod": true},
+	}
+
+	if m := regIIFE.FindStringSubmatch(script); m != nil {
+		script = m[1] + m[2] + m[3]
+	}
+
+	for _, stmt := range splitStatements(script) {
+		c.statement(stmt)
+	}
+
+	std, others := []string{}, []string{}
+	for imp := range c.imports {
+		if strings.Contains(imp, ".") {
+			others = append(others, strconv.Quote(imp))
+		} else {
+			std = append(std, strconv.Quote(imp))
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+	imports := strings.Join(others, "\n")
+	if len(std) > 0 {
+		imports = strings.Join(std, "\n") + "\n\n" + imports
+	}
+
+	src := fmt.Sprintf("package main\n\nimport (\n%s\n)\n\nfunc main() {\n%s}\n", imports, strings.Join(c.lines, ""))
+
+	code, err := format.Source([]byte(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the generated code: %w\n%s", err, src)
+	}
+
+	return &Result{Code: string(code), Warnings: c.warnings}, nil
+}
+
+type converter struct {
+	browsers map[string]bool
+	pages    map[string]bool
+	imports  map[string]bool
+	lines    []string
+	warnings []string
+}
+
+// segment of a member chain, such as the "click('a')" of "page.click('a')".
+type segment struct {
+	name string
+	args []string
+	call bool
+}
+
+var (
+	regAssign  = regexp.MustCompile(`^(?:(?:const|let|var)\s+)?([\w$]+)\s*=\s*(.+)$`)
+	regIIFE    = regexp.MustCompile(`(?s)^(.*?)\(\s*async\s*(?:function\s*)?\(\)\s*(?:=>\s*)?\{(.*)\}\s*\)\s*\(\s*\)\s*;?(.*)$`)
+	regIgnored = regexp.MustCompile(`^(?:import\s|(?:const|let|var)\s+.*\brequire\()`)
+	regNumber  = regexp.MustCompile(`^-?\d+(?:\.\d+)?$`)
+	regLetter  = regexp.MustCompile(`^[a-zA-Z]$`)
+)
+
+// keys of the input package that have the same names as the keys of Puppeteer.
+var keys = map[string]bool{
+	"Enter": true, "Tab": true, "Escape": true, "Backspace": true, "Delete": true, "Space": true,
+	"Home": true, "End": true, "PageUp": true, "PageDown": true,
+	"ArrowUp": true, "ArrowDown": true, "ArrowLeft": true, "ArrowRight": true,
+}
+
+func (c *converter) emit(format string, args ...interface{}) {
+	c.lines = append(c.lines, fmt.Sprintf(format, args...)+"\n")
+}
+
+func (c *converter) todo(stmt string) {
+	c.warnings = append(c.warnings, stmt)
+	c.emit("// TODO: %s", strings.ReplaceAll(stmt, "\n", " "))
+}
+
+func (c *converter) statement(stmt string) {
+	if strings.HasPrefix(stmt, "//") {
+		c.emit("%s", stmt)
+		return
+	}
+	if regIgnored.MatchString(stmt) {
+		return
+	}
+
+	name := ""
+	expr := stmt
+	if m := regAssign.FindStringSubmatch(stmt); m != nil {
+		name, expr = m[1], m[2]
+	}
+	expr = strings.TrimSpace(strings.TrimPrefix(expr, "await "))
+
+	chain, ok := parseChain(expr)
+	if !ok || !c.convert(name, chain) {
+		c.todo(stmt)
+	}
+}
+
+// convert the chain, it returns false if the chain is not supported.
+func (c *converter) convert(name string, chain []segment) bool {
+	if len(chain) < 2 {
+		return false
+	}
+
+	// the playwright locator, such as page.locator('a').click() is the same as page.click('a')
+	if len(chain) == 3 && chain[1].name == "locator" && len(chain[1].args) == 1 {
+		chain = []segment{chain[0], {
+			name: chain[2].name,
+			args: append([]string{chain[1].args[0]}, chain[2].args...),
+			call: true,
+		}}
+	}
+
+	obj := chain[0].name
+	method := chain[len(chain)-1]
+	if !method.call {
+		return false
+	}
+
+	switch {
+	case obj == "puppeteer" || obj == "chromium" || obj == "firefox" || obj == "webkit":
+		if method.name != "launch" || len(chain) != 2 || name == "" {
+			return false
+		}
+		c.browsers[name] = true
+		c.emit("%s := rod.New().MustConnect()", name)
+		return true
+
+	case c.browsers[obj] && len(chain) == 2:
+		return c.browser(name, obj, method)
+
+	case c.pages[obj] && len(chain) == 3 && chain[1].name == "keyboard":
+		return c.keyboard(obj, method)
+
+	case c.pages[obj] && len(chain) == 2:
+		return c.page(name, obj, method)
+	}
+
+	return false
+}
+
+func (c *converter) browser(name, obj string, method segment) bool {
+	switch method.name {
+	case "newPage":
+		if name == "" {
+			return false
+		}
+		c.pages[name] = true
+		c.emit("%s := %s.MustPage()", name, obj)
+	case "close":
+		c.emit("%s.MustClose()", obj)
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *converter) keyboard(page string, method segment) bool {
+	if len(method.args) == 0 {
+		return false
+	}
+
+	switch method.name {
+	case "press":
+		key, ok := jsString(method.args[0])
+		if !ok {
+			return false
+		}
+		switch {
+		case keys[key]:
+			key = "input." + key
+		case regLetter.MatchString(key):
+			key = "input.Key" + strings.ToUpper(key)
+		default:
+			return false
+		}
+		c.imports["github.com/xyjwsj/grod/lib/input"] = true
+		c.emit("%s.Keyboard.MustType(%s)", page, key)
+	case "type":
+		text, ok := jsString(method.args[0])
+		if !ok {
+			return false
+		}
+		c.emit("%s.MustInsertText(%q)", page, text)
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *converter) page(name, page string, method segment) bool { //nolint: cyclop
+	args := method.args
+	sel, str := "", false
+	if len(args) > 0 {
+		sel, str = jsString(args[0])
+	}
+	opts := map[string]string{}
+	if len(args) > 1 {
+		opts = jsObject(args[len(args)-1])
+	}
+
+	is := func(key, val string) bool {
+		v, ok := jsString(opts[key])
+		return opts[key] == val || ok && v == val
+	}
+
+	el := fmt.Sprintf("%s.MustElement(%q)", page, sel)
+
+	switch {
+	case method.name == "goto" && str:
+		c.emit("%s.MustNavigate(%q).MustWaitLoad()", page, sel)
+
+	case method.name == "click" && str:
+		c.emit("%s.MustClick()", el)
+
+	case method.name == "dblclick" && str:
+		c.emit("%s.MustDoubleClick()", el)
+
+	case method.name == "hover" && str:
+		c.emit("%s.MustHover()", el)
+
+	case method.name == "focus" && str:
+		c.emit("%s.MustFocus()", el)
+
+	case (method.name == "type" || method.name == "fill") && str && len(args) > 1:
+		text, ok := jsString(args[1])
+		if !ok {
+			return false
+		}
+		c.emit("%s.MustInput(%q)", el, text)
+
+	case (method.name == "select" || method.name == "selectOption") && str && len(args) > 1:
+		list := []string{}
+		for _, a := range args[1:] {
+			v, ok := jsString(a)
+			if !ok {
+				return false
+			}
+			list = append(list, strconv.Quote(v))
+		}
+		c.emit("%s.MustSelect(%s)", el, strings.Join(list, ", "))
+
+	case (method.name == "waitForSelector" || method.name == "waitFor") && str:
+		switch {
+		case is("visible", "true") || is("state", "visible"):
+			c.emit("%s.MustWaitVisible()", el)
+		case is("hidden", "true") || is("state", "hidden"):
+			c.emit("%s.MustWaitInvisible()", el)
+		default:
+			c.emit("%s", el)
+		}
+
+	case (method.name == "textContent" || method.name == "innerText") && str && name != "":
+		c.emit("%s := %s.MustText()", name, el)
+
+	case method.name == "waitForTimeout" && len(args) == 1 && regNumber.MatchString(args[0]):
+		c.imports["time"] = true
+		c.emit("time.Sleep(%s * time.Millisecond)", args[0])
+
+	case (method.name == "waitForNavigation" || method.name == "waitForLoadState"):
+		c.emit("%s.MustWaitLoad()", page)
+
+	case method.name == "title" && len(args) == 0 && name != "":
+		c.emit("%s := %s.MustInfo().Title", name, page)
+
+	case method.name == "url" && len(args) == 0 && name != "":
+		c.emit("%s := %s.MustInfo().URL", name, page)
+
+	case method.name == "screenshot" && len(args) == 1:
+		opts = jsObject(args[0])
+		path, ok := jsString(opts["path"])
+		if !ok {
+			return false
+		}
+		if opts["fullPage"] == "true" {
+			c.emit("%s.MustScreenshotFullPage(%q)", page, path)
+		} else {
+			c.emit("%s.MustScreenshot(%q)", page, path)
+		}
+
+	case method.name == "close" && len(args) == 0:
+		c.emit("%s.MustClose()", page)
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// splitStatements splits the script by the semicolons and the newlines outside of the brackets and the strings.
+func splitStatements(script string) []string {
+	list := []string{}
+	buf := strings.Builder{}
+	depth := 0
+	var quote rune
+
+	flush := func() {
+		s := strings.TrimSpace(buf.String())
+		if s != "" {
+			list = append(list, s)
+		}
+		buf.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if quote != 0 {
+			buf.WriteRune(r)
+			if r == '\\' && i+1 < len(runes) {
+				i++
+				buf.WriteRune(runes[i])
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/' && depth == 0:
+			flush()
+			end := i
+			for end < len(runes) && runes[end] != '\n' {
+				end++
+			}
+			list = append(list, strings.TrimSpace(string(runes[i:end])))
+			i = end
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case (r == ';' || r == '\n') && depth <= 0:
+			flush()
+			continue
+		}
+
+		buf.WriteRune(r)
+	}
+	flush()
+
+	return list
+}
+
+// parseChain parses the member chain, such as "page.keyboard.press('Enter')".
+func parseChain(expr string) ([]segment, bool) {
+	chain := []segment{}
+	s := []rune(expr)
+	i := 0
+
+	for i < len(s) {
+		start := i
+		for i < len(s) && (s[i] == '_' || s[i] == '$' || s[i] >= 'a' && s[i] <= 'z' ||
+			s[i] >= 'A' && s[i] <= 'Z' || s[i] >= '0' && s[i] <= '9') {
+			i++
+		}
+		if start == i {
+			return nil, false
+		}
+		seg := segment{name: string(s[start:i])}
+
+		if i < len(s) && s[i] == '(' {
+			end := matchBracket(s, i)
+			if end < 0 {
+				return nil, false
+			}
+			seg.call = true
+			seg.args = splitArgs(string(s[i+1 : end]))
+			i = end + 1
+		}
+		chain = append(chain, seg)
+
+		if i == len(s) {
+			break
+		}
+		if s[i] != '.' {
+			return nil, false
+		}
+		i++
+	}
+
+	return chain, len(chain) > 0
+}
+
+// matchBracket returns the index of the bracket that closes the one at the start.
+func matchBracket(s []rune, start int) int {
+	depth := 0
+	var quote rune
+	for i := start; i < len(s); i++ {
+		r := s[i]
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitArgs splits the args by the commas outside of the brackets and the strings.
+func splitArgs(s string) []string {
+	list := []string{}
+	runes := []rune(s)
+	start := 0
+	depth := 0
+	var quote rune
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			list = append(list, strings.TrimSpace(string(runes[start:i])))
+			start = i + 1
+		}
+	}
+
+	if last := strings.TrimSpace(string(runes[start:])); last != "" {
+		list = append(list, last)
+	}
+	return list
+}
+
+// jsString returns the value of the js string literal, the template literals with placeholders are not supported.
+func jsString(s string) (string, bool) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || !strings.ContainsRune("'\"`", rune(s[0])) {
+		return "", false
+	}
+	if s[0] == '`' && strings.Contains(s, "${") {
+		return "", false
+	}
+
+	body := s[1 : len(s)-1]
+	if s[0] != '"' {
+		body = strings.ReplaceAll(body, `\`+string(s[0]), string(s[0]))
+		body = strings.ReplaceAll(body, `"`, `\"`)
+	}
+
+	v, err := strconv.Unquote(`"` + body + `"`)
+	return v, err == nil
+}
+
+// jsObject returns the top level properties of the simple js object literal, such as "{ visible: true }".
+func jsObject(s string) map[string]string {
+	obj := map[string]string{}
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return obj
+	}
+
+	for _, prop := range splitArgs(s[1 : len(s)-1]) {
+		kv := strings.SplitN(prop, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.Trim(strings.TrimSpace(kv[0]), `'"`)
+		obj[key] = strings.TrimSpace(kv[1])
+	}
+	return obj
+}
@@ -0,0 +1,84 @@
+package puppeteer_test
+
+import (
+	"testing"
+
+	"github.com/xyjwsj/grod/lib/puppeteer"
+	"github.com/ysmood/got"
+)
+
+func TestConvert(t *testing.T) {
+	g := got.T(t)
+
+	res, err := puppeteer.Convert(`
+const puppeteer = require('puppeteer');
+
+(async () => {
+  const browser = await puppeteer.launch();
+  const page = await browser.newPage();
+  // open the page
+  await page.goto('https://example.com');
+  await page.waitForSelector('#search', { visible: true });
+  await page.type('#search', "it's rod");
+  await page.keyboard.press('Enter');
+  await page.click(
+    'a.result'
+  );
+  await page.waitForTimeout(500);
+  const title = await page.title();
+  await page.screenshot({ path: 'a.png', fullPage: true });
+  await page.$$eval('a', as => as.map(a => a.href));
+  await browser.close();
+})();
+`)
+	g.E(err)
+
+	g.Eq(res.Code, `package main
+
+import (
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/input"
+)
+
+func main() {
+	browser := rod.New().MustConnect()
+	page := browser.MustPage()
+	// open the page
+	page.MustNavigate("https://example.com").MustWaitLoad()
+	page.MustElement("#search").MustWaitVisible()
+	page.MustElement("#search").MustInput("it's rod")
+	page.Keyboard.MustType(input.Enter)
+	page.MustElement("a.result").MustClick()
+	time.Sleep(500 * time.Millisecond)
+	title := page.MustInfo().Title
+	page.MustScreenshotFullPage("a.png")
+	// TODO: await page.$$eval('a', as => as.map(a => a.href))
+	browser.MustClose()
+}
+`)
+	g.Eq(res.Warnings, []string{"await page.$$eval('a', as => as.map(a => a.href))"})
+}
+
+func TestConvertPlaywright(t *testing.T) {
+	g := got.T(t)
+
+	res, err := puppeteer.Convert(`
+import { chromium } from 'playwright';
+const browser = await chromium.launch();
+const page = await browser.newPage();
+await page.goto("https://example.com");
+await page.locator('input[name="q"]').fill('rod');
+await page.selectOption('select', 'a', 'b');
+await page.waitForSelector('.loading', { state: 'hidden' });
+const text = await page.textContent('h1');
+`)
+	g.E(err)
+
+	g.Has(res.Code, `page.MustElement("input[name=\"q\"]").MustInput("rod")`)
+	g.Has(res.Code, `page.MustElement("select").MustSelect("a", "b")`)
+	g.Has(res.Code, `page.MustElement(".loading").MustWaitInvisible()`)
+	g.Has(res.Code, `text := page.MustElement("h1").MustText()`)
+	g.Len(res.Warnings, 0)
+}
@@ -0,0 +1,39 @@
+// Package main converts a simple Puppeteer or Playwright script to rod Go code, such as:
+//
+//	go run github.com/xyjwsj/grod/lib/puppeteer/rod-import script.js > main.go
+//
+// The script is read from the stdin if no file is specified.
+// The statements that can't be converted are printed to the stderr.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xyjwsj/grod/lib/puppeteer"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+func main() {
+	flag.Parse()
+
+	var src []byte
+	var err error
+	if flag.NArg() > 0 {
+		src, err = os.ReadFile(flag.Arg(0))
+	} else {
+		src, err = io.ReadAll(os.Stdin)
+	}
+	utils.E(err)
+
+	res, err := puppeteer.Convert(string(src))
+	utils.E(err)
+
+	fmt.Print(res.Code)
+
+	for _, w := range res.Warnings {
+		fmt.Fprintln(os.Stderr, "not converted:", w)
+	}
+}
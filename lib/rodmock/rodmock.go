@@ -0,0 +1,535 @@
+// Package rodmock provides an in-memory fake browser that speaks enough of the CDP for rod to drive it.
+// Code that depends on rod can use it to be unit tested hermetically, without launching a real browser.
+//
+//	m := rodmock.New()
+//	m.Route("https://example.com", &rodmock.Document{
+//		Title: "Example",
+//		Nodes: map[string][]*rodmock.Node{"h1": {{Text: "Hello"}}},
+//	})
+//	page := rod.New().Client(m).MustConnect().MustPage("https://example.com")
+//	page.MustElement("h1").MustText() // "Hello"
+package rodmock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/xyjwsj/grod/lib/cdp"
+	"github.com/xyjwsj/grod/lib/js"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// Document is the DOM fixture of a page.
+type Document struct {
+	Title string
+
+	// HTML is returned as the outer HTML of the "html" element when Nodes doesn't define it.
+	HTML string
+
+	// Nodes maps a css selector to the nodes it matches, the selector must be used verbatim in the queries.
+	Nodes map[string][]*Node
+}
+
+// Node is the DOM fixture of an element.
+type Node struct {
+	// Tag name of the node, such as "div"
+	Tag string
+
+	Text       string
+	HTML       string
+	Attributes map[string]string
+	Hidden     bool
+
+	// Children maps a css selector to the nodes it matches under this node.
+	Children map[string][]*Node
+}
+
+// Handler of a CDP method. The returned value will be encoded as the JSON result of the call.
+type Handler func(sessionID string, params gson.JSON) (interface{}, error)
+
+// Call is a record of a CDP call received by the [Mock].
+type Call struct {
+	SessionID string
+	Method    string
+	Params    gson.JSON
+}
+
+// Mock is a fake CDP client, it implements the [rod.CDPClient] interface.
+type Mock struct {
+	lock sync.Mutex
+
+	event  chan *cdp.Event
+	closed bool
+
+	routes   map[string]*Document
+	handlers map[string]Handler
+	calls    []Call
+
+	count   int
+	targets []*target
+	objects map[string]interface{}
+	nodeIDs map[*Node]string
+}
+
+type target struct {
+	id      string
+	session string
+	url     string
+	doc     *Document
+}
+
+// the object id of the helper functions holder.
+const functionsID = "functions"
+
+var (
+	regHelperName   = regexp.MustCompile(`/\* (\w+) \*/`)
+	regHelperDefine = regexp.MustCompile(`^functions => \{ const f = functions\.(\w+) =`)
+)
+
+// New creates a fake CDP client with an empty "about:blank" route.
+func New() *Mock {
+	return &Mock{
+		event:    make(chan *cdp.Event, 100),
+		routes:   map[string]*Document{"about:blank": {}},
+		handlers: map[string]Handler{},
+		objects:  map[string]interface{}{},
+		nodeIDs:  map[*Node]string{},
+	}
+}
+
+// Route sets the document served for the url. Navigating to a url without route fails with
+// "net::ERR_NAME_NOT_RESOLVED".
+func (m *Mock) Route(url string, doc *Document) *Mock {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.routes[url] = doc
+	return m
+}
+
+// Handle overrides the builtin behavior of the CDP method, such as "Runtime.callFunctionOn".
+func (m *Mock) Handle(method string, h Handler) *Mock {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.handlers[method] = h
+	return m
+}
+
+// Emit an event to rod. If the sessionID is empty the event belongs to the browser.
+func (m *Mock) Emit(sessionID string, e proto.Event) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.emit(sessionID, e)
+}
+
+// Calls returns the history of the calls that match the method, if the method is empty all calls are returned.
+func (m *Mock) Calls(method string) []Call {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	list := []Call{}
+	for _, c := range m.calls {
+		if method == "" || c.Method == method {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// Close the event stream, rod will treat it as a browser disconnection.
+func (m *Mock) Close() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.closed {
+		m.closed = true
+		close(m.event)
+	}
+}
+
+// Event interface.
+func (m *Mock) Event() <-chan *cdp.Event {
+	return m.event
+}
+
+// Call interface.
+func (m *Mock) Call(ctx context.Context, sessionID, method string, params interface{}) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p := gson.New(params)
+	p = gson.NewFrom(p.JSON("", ""))
+
+	m.lock.Lock()
+	m.calls = append(m.calls, Call{sessionID, method, p})
+	h, has := m.handlers[method]
+	m.lock.Unlock()
+
+	var res interface{}
+	var err error
+	if has {
+		res, err = h(sessionID, p)
+	} else {
+		m.lock.Lock()
+		res, err = m.builtin(sessionID, method, p)
+		m.lock.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		res = map[string]interface{}{}
+	}
+	return json.Marshal(res)
+}
+
+func (m *Mock) builtin(sessionID, method string, p gson.JSON) (interface{}, error) {
+	switch method {
+	case "Browser.getVersion":
+		return proto.BrowserGetVersionResult{Product: "rodmock", ProtocolVersion: "1.3"}, nil
+
+	case "Target.createTarget":
+		m.count++
+		t := &target{id: fmt.Sprintf("target-%d", m.count), url: "about:blank", doc: m.routes["about:blank"]}
+		m.targets = append(m.targets, t)
+		return proto.TargetCreateTargetResult{TargetID: proto.TargetTargetID(t.id)}, nil
+
+	case "Target.attachToTarget":
+		t := m.target(p.Get("targetId").Str(), "")
+		if t == nil {
+			return nil, cdp.ErrSessionNotFound
+		}
+		m.count++
+		t.session = fmt.Sprintf("session-%d", m.count)
+		return proto.TargetAttachToTargetResult{SessionID: proto.TargetSessionID(t.session)}, nil
+
+	case "Target.getTargets":
+		list := []*proto.TargetTargetInfo{}
+		for _, t := range m.targets {
+			list = append(list, m.info(t))
+		}
+		return proto.TargetGetTargetsResult{TargetInfos: list}, nil
+
+	case "Target.getTargetInfo":
+		t := m.target(p.Get("targetId").Str(), sessionID)
+		if t == nil {
+			return nil, cdp.ErrSessionNotFound
+		}
+		return proto.TargetGetTargetInfoResult{TargetInfo: m.info(t)}, nil
+
+	case "Target.closeTarget":
+		m.closeTarget(m.target(p.Get("targetId").Str(), ""))
+		return proto.TargetCloseTargetResult{Success: true}, nil
+
+	case "Page.close":
+		m.closeTarget(m.target("", sessionID))
+		return nil, nil
+
+	case "Page.navigate":
+		return m.navigate(sessionID, p.Get("url").Str())
+
+	case "Runtime.evaluate":
+		if p.Get("expression").Str() == "window" {
+			return callResult(&proto.RuntimeRemoteObject{
+				Type:      proto.RuntimeRemoteObjectTypeObject,
+				ClassName: "Window",
+				ObjectID:  proto.RuntimeRemoteObjectID("window:" + sessionID),
+			}), nil
+		}
+		return callResult(undefined()), nil
+
+	case "Runtime.callFunctionOn":
+		return m.callFunctionOn(sessionID, p)
+
+	case "Runtime.getProperties":
+		return m.getProperties(p.Get("objectId").Str())
+
+	case "DOM.getOuterHTML":
+		n, ok := m.objects[p.Get("objectId").Str()].(*Node)
+		if !ok {
+			return nil, cdp.ErrObjNotFound
+		}
+		return proto.DOMGetOuterHTMLResult{OuterHTML: n.HTML}, nil
+
+	case "DOM.describeNode":
+		n, ok := m.objects[p.Get("objectId").Str()].(*Node)
+		if !ok {
+			return nil, cdp.ErrObjNotFound
+		}
+		return proto.DOMDescribeNodeResult{Node: &proto.DOMNode{
+			NodeType:  1,
+			NodeName:  strings.ToUpper(n.Tag),
+			LocalName: n.Tag,
+			NodeValue: "",
+		}}, nil
+	}
+
+	return nil, nil
+}
+
+func (m *Mock) target(id, session string) *target {
+	for _, t := range m.targets {
+		if (id != "" && t.id == id) || (session != "" && t.session == session) {
+			return t
+		}
+	}
+	return nil
+}
+
+func (m *Mock) info(t *target) *proto.TargetTargetInfo {
+	title := ""
+	if t.doc != nil {
+		title = t.doc.Title
+	}
+	return &proto.TargetTargetInfo{
+		TargetID: proto.TargetTargetID(t.id),
+		Type:     proto.TargetTargetInfoTypePage,
+		Title:    title,
+		URL:      t.url,
+		Attached: t.session != "",
+	}
+}
+
+func (m *Mock) closeTarget(t *target) {
+	if t == nil {
+		return
+	}
+
+	list := []*target{}
+	for _, item := range m.targets {
+		if item != t {
+			list = append(list, item)
+		}
+	}
+	m.targets = list
+
+	m.emit("", &proto.TargetTargetDestroyed{TargetID: proto.TargetTargetID(t.id)})
+}
+
+func (m *Mock) navigate(sessionID, url string) (interface{}, error) {
+	t := m.target("", sessionID)
+	if t == nil {
+		return nil, cdp.ErrSessionNotFound
+	}
+
+	doc, has := m.routes[url]
+	if !has {
+		return proto.PageNavigateResult{ErrorText: "net::ERR_NAME_NOT_RESOLVED"}, nil
+	}
+
+	t.url = url
+	t.doc = doc
+
+	frame := proto.PageFrameID(t.id)
+	m.emit(sessionID, &proto.PageFrameStartedLoading{FrameID: frame})
+	m.emit(sessionID, &proto.PageFrameNavigated{Frame: &proto.PageFrame{ID: frame, URL: url}})
+	m.emit(sessionID, &proto.PageDomContentEventFired{})
+	m.emit(sessionID, &proto.PageLoadEventFired{})
+	m.emit(sessionID, &proto.PageFrameStoppedLoading{FrameID: frame})
+
+	return proto.PageNavigateResult{FrameID: frame}, nil
+}
+
+func (m *Mock) callFunctionOn(sessionID string, p gson.JSON) (interface{}, error) {
+	decl := p.Get("functionDeclaration").Str()
+	this := p.Get("objectId").Str()
+	args := p.Get("arguments").Arr()
+	byValue := p.Get("returnByValue").Bool()
+
+	switch {
+	case decl == js.Functions.Definition:
+		return callResult(&proto.RuntimeRemoteObject{
+			Type:     proto.RuntimeRemoteObjectTypeObject,
+			ObjectID: functionsID,
+		}), nil
+
+	case regHelperDefine.MatchString(decl):
+		name := regHelperDefine.FindStringSubmatch(decl)[1]
+		return callResult(&proto.RuntimeRemoteObject{
+			Type:     proto.RuntimeRemoteObjectTypeFunction,
+			ObjectID: proto.RuntimeRemoteObjectID("helper:" + name),
+		}), nil
+
+	case decl == `() => window`:
+		return callResult(&proto.RuntimeRemoteObject{
+			Type:     proto.RuntimeRemoteObjectTypeObject,
+			ObjectID: proto.RuntimeRemoteObjectID("window:" + sessionID),
+		}), nil
+
+	case strings.Contains(decl, "this.getAttribute(n)"):
+		n, ok := m.objects[this].(*Node)
+		if !ok {
+			return nil, cdp.ErrObjNotFound
+		}
+		v, has := n.Attributes[argValue(args, 0).Str()]
+		if !has {
+			return callResult(value(nil)), nil
+		}
+		return callResult(value(v)), nil
+	}
+
+	if regHelperName.MatchString(decl) && len(args) > 0 {
+		name := regHelperName.FindStringSubmatch(decl)[1]
+		res, err := m.helper(sessionID, name, this, args[1:], byValue)
+		if err != nil {
+			return nil, err
+		}
+		return callResult(res), nil
+	}
+
+	return callResult(undefined()), nil
+}
+
+func (m *Mock) helper(sessionID, name, this string, args []gson.JSON, byValue bool) (*proto.RuntimeRemoteObject, error) {
+	children, err := m.children(sessionID, this)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case js.Element.Name:
+		list := children[argValue(args, 0).Str()]
+		if len(list) == 0 {
+			return value(nil), nil
+		}
+		return m.node(list[0]), nil
+
+	case js.Elements.Name:
+		list := children[argValue(args, 0).Str()]
+		id := fmt.Sprintf("array:%d", len(m.objects))
+		m.objects[id] = list
+		return &proto.RuntimeRemoteObject{
+			Type:     proto.RuntimeRemoteObjectTypeObject,
+			Subtype:  proto.RuntimeRemoteObjectSubtypeArray,
+			ObjectID: proto.RuntimeRemoteObjectID(id),
+		}, nil
+
+	case js.Text.Name, js.Visible.Name:
+		n, ok := m.objects[this].(*Node)
+		if !ok {
+			return nil, cdp.ErrObjNotFound
+		}
+		if name == js.Text.Name {
+			return value(n.Text), nil
+		}
+		return value(!n.Hidden), nil
+	}
+
+	if byValue {
+		return undefined(), nil
+	}
+	return value(nil), nil
+}
+
+// children returns the selector map of the window or node.
+func (m *Mock) children(sessionID, this string) (map[string][]*Node, error) {
+	if strings.HasPrefix(this, "window:") {
+		t := m.target("", sessionID)
+		if t == nil || t.doc == nil {
+			return map[string][]*Node{}, nil
+		}
+
+		nodes := map[string][]*Node{"html": {{Tag: "html", HTML: t.doc.HTML}}}
+		for k, v := range t.doc.Nodes {
+			nodes[k] = v
+		}
+		return nodes, nil
+	}
+
+	n, ok := m.objects[this].(*Node)
+	if !ok {
+		return nil, cdp.ErrObjNotFound
+	}
+	return n.Children, nil
+}
+
+func (m *Mock) getProperties(id string) (interface{}, error) {
+	list, ok := m.objects[id].([]*Node)
+	if !ok {
+		return nil, cdp.ErrObjNotFound
+	}
+
+	props := []*proto.RuntimePropertyDescriptor{}
+	for i, n := range list {
+		props = append(props, &proto.RuntimePropertyDescriptor{
+			Name:  fmt.Sprint(i),
+			Value: m.node(n),
+		})
+	}
+	props = append(props, &proto.RuntimePropertyDescriptor{Name: "length", Value: value(len(list))})
+
+	return proto.RuntimeGetPropertiesResult{Result: props}, nil
+}
+
+// node returns the remote object of the node, the same node always has the same object id.
+func (m *Mock) node(n *Node) *proto.RuntimeRemoteObject {
+	id, has := m.nodeIDs[n]
+	if !has {
+		id = fmt.Sprintf("node:%d", len(m.nodeIDs))
+		m.nodeIDs[n] = id
+		m.objects[id] = n
+	}
+
+	return &proto.RuntimeRemoteObject{
+		Type:        proto.RuntimeRemoteObjectTypeObject,
+		Subtype:     proto.RuntimeRemoteObjectSubtypeNode,
+		ClassName:   "HTMLElement",
+		Description: n.Tag,
+		ObjectID:    proto.RuntimeRemoteObjectID(id),
+	}
+}
+
+func (m *Mock) emit(sessionID string, e proto.Event) {
+	if m.closed {
+		return
+	}
+
+	m.event <- &cdp.Event{
+		SessionID: sessionID,
+		Method:    e.ProtoEvent(),
+		Params:    json.RawMessage(gson.New(e).JSON("", "")),
+	}
+}
+
+func callResult(obj *proto.RuntimeRemoteObject) *proto.RuntimeCallFunctionOnResult {
+	return &proto.RuntimeCallFunctionOnResult{Result: obj}
+}
+
+func value(v interface{}) *proto.RuntimeRemoteObject {
+	if v == nil {
+		return &proto.RuntimeRemoteObject{
+			Type:    proto.RuntimeRemoteObjectTypeObject,
+			Subtype: proto.RuntimeRemoteObjectSubtypeNull,
+			Value:   gson.New(nil),
+		}
+	}
+
+	t := proto.RuntimeRemoteObjectTypeObject
+	switch v.(type) {
+	case string:
+		t = proto.RuntimeRemoteObjectTypeString
+	case bool:
+		t = proto.RuntimeRemoteObjectTypeBoolean
+	case int, float64:
+		t = proto.RuntimeRemoteObjectTypeNumber
+	}
+
+	return &proto.RuntimeRemoteObject{Type: t, Value: gson.New(v)}
+}
+
+func undefined() *proto.RuntimeRemoteObject {
+	return &proto.RuntimeRemoteObject{Type: proto.RuntimeRemoteObjectTypeUndefined}
+}
+
+func argValue(args []gson.JSON, i int) gson.JSON {
+	if i >= len(args) {
+		return gson.New(nil)
+	}
+	return args[i].Get("value")
+}
@@ -0,0 +1,64 @@
+package rodmock_test
+
+import (
+	"testing"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/rodmock"
+	"github.com/ysmood/got"
+	"github.com/ysmood/gson"
+)
+
+func TestMock(t *testing.T) {
+	g := got.T(t)
+
+	m := rodmock.New()
+	m.Route("https://example.com", &rodmock.Document{
+		Title: "Example",
+		HTML:  "<html><h1>Hello</h1></html>",
+		Nodes: map[string][]*rodmock.Node{
+			"h1": {{Tag: "h1", Text: "Hello", Attributes: map[string]string{"id": "title"}}},
+			"li": {{Tag: "li", Text: "a"}, {Tag: "li", Text: "b", Hidden: true}},
+			"form": {{Tag: "form", Children: map[string][]*rodmock.Node{
+				"input": {{Tag: "input", Text: "value"}},
+			}}},
+		},
+	})
+
+	b := rod.New().Client(m).MustConnect()
+	p := b.MustPage("https://example.com")
+
+	g.Eq(p.MustInfo().Title, "Example")
+	g.Eq(p.MustHTML(), "<html><h1>Hello</h1></html>")
+
+	h1 := p.MustElement("h1")
+	g.Eq(h1.MustText(), "Hello")
+	g.Eq(*h1.MustAttribute("id"), "title")
+	g.Nil(h1.MustAttribute("class"))
+
+	list := p.MustElements("li")
+	g.Len(list, 2)
+	g.True(list[0].MustVisible())
+	g.False(list[1].MustVisible())
+
+	g.Eq(p.MustElement("form").MustElement("input").MustText(), "value")
+
+	g.False(p.Sleeper(rod.NotFoundSleeper).MustHas("table"))
+
+	g.Is(p.Navigate("https://not-exists.com"), &rod.NavigationError{})
+
+	m.Handle("Runtime.callFunctionOn", func(_ string, _ gson.JSON) (interface{}, error) {
+		return proto.RuntimeCallFunctionOnResult{Result: &proto.RuntimeRemoteObject{
+			Type:  proto.RuntimeRemoteObjectTypeNumber,
+			Value: gson.New(3),
+		}}, nil
+	})
+	g.Eq(p.MustEval(`() => 1 + 2`).Int(), 3)
+
+	g.Len(m.Calls("Page.navigate"), 2)
+	g.Eq(m.Calls("Page.navigate")[0].Params.Get("url").Str(), "https://example.com")
+
+	p.MustClose()
+	g.Len(b.MustPages(), 0)
+}
@@ -0,0 +1,158 @@
+// Package main builds a minimal OCI image that only contains the browser of the pinned revision,
+// the fonts, and the certificates, so that the CI can launch the same browser everywhere.
+// Run it from the root of the repo like this:
+//
+//	go run ./lib/utils/build-image -tag ghcr.io/me/browser -push
+//
+// The image is built via docker buildx for each platform, and the multi-arch manifest list is created for them.
+// The timestamps of the image are set to the time of the last git commit to make the build reproducible,
+// the base images are pinned by their digests, and the apt packages are installed from the snapshot.debian.org
+// of the same time. The linux/arm64 image contains the chromium of Playwright, check [launcher.HostPlaywright].
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/xyjwsj/grod/lib/launcher"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+var (
+	flagTag       = flag.String("tag", fmt.Sprintf("rod-browser:r%d", launcher.RevisionDefault), "the tag of the image")
+	flagPlatforms = flag.String("platforms", "linux/amd64,linux/arm64", "the comma separated platforms of the image")
+	flagBase      = flag.String("base", "debian:bookworm-slim",
+		"the base image, it's pinned by the digest it currently resolves to if no digest is specified")
+	flagGolang = flag.String("golang", "golang:1.24-bookworm",
+		"the image to download the browser, it's pinned the same way as the -base")
+	flagFonts = flag.String("fonts", "fonts-liberation fonts-noto-color-emoji fonts-noto-cjk",
+		"the space separated font packages to install")
+	flagPush   = flag.Bool("push", false, "push the image and its multi-arch manifest list to the registry")
+	flagOutput = flag.String("output", "", "save the image as an OCI tarball to the path")
+	flagPrint  = flag.Bool("print", false, "only print the generated Dockerfile")
+)
+
+func main() {
+	flag.Parse()
+
+	dockerfile := genDockerfile(pin(*flagBase), pin(*flagGolang))
+
+	if *flagPrint {
+		fmt.Print(dockerfile)
+		return
+	}
+
+	f, err := os.CreateTemp("", "rod-build-image-*.Dockerfile")
+	utils.E(err)
+	defer func() { _ = os.Remove(f.Name()) }()
+	utils.E(f.WriteString(dockerfile))
+	utils.E(f.Close())
+
+	epoch := strings.TrimSpace(utils.ExecLine(false, "git log -1 --format=%ct"))
+
+	args := []string{
+		"-f", f.Name(),
+		"--platform", *flagPlatforms,
+		"--build-arg", "SOURCE_DATE_EPOCH=" + epoch,
+		"-t", *flagTag,
+	}
+
+	switch {
+	case *flagPush:
+		args = append(args, "--output", "type=registry,rewrite-timestamp=true")
+	case *flagOutput != "":
+		args = append(args, "--output", "type=oci,rewrite-timestamp=true,dest="+*flagOutput)
+	case strings.Contains(*flagPlatforms, ","):
+		log.Fatalln("the docker image store can't load a multi-arch image, use the -push or -output flag")
+	default:
+		args = append(args, "--load")
+	}
+
+	utils.E(os.Setenv("SOURCE_DATE_EPOCH", epoch))
+	utils.Exec("docker buildx build", append(args, ".")...)
+}
+
+const dockerfileTpl = `# generated by lib/utils/build-image for the revision {{.Revision}}
+
+FROM {{.Golang}} AS go
+
+COPY . /rod
+WORKDIR /rod
+RUN go run ./lib/utils/get-browser
+
+FROM {{.Base}} AS base
+
+ARG SOURCE_DATE_EPOCH
+
+# install the packages of the snapshot at the SOURCE_DATE_EPOCH, so the rebuild gets the same versions
+RUN . /etc/os-release && \
+    snapshot=$(date -u -d "@$SOURCE_DATE_EPOCH" +%Y%m%dT%H%M%SZ) && \
+    rm -f /etc/apt/sources.list.d/* && \
+    echo "deb http://snapshot.debian.org/archive/debian/$snapshot $VERSION_CODENAME main" > /etc/apt/sources.list && \
+    echo "deb http://snapshot.debian.org/archive/debian/$snapshot $VERSION_CODENAME-updates main" >> /etc/apt/sources.list && \
+    echo "deb http://snapshot.debian.org/archive/debian-security/$snapshot $VERSION_CODENAME-security main" >> /etc/apt/sources.list && \
+    apt-get -o Acquire::Check-Valid-Until=false update > /dev/null && \
+    apt-get install --no-install-recommends -y \
+    libnss3 libxss1 libxtst6 libgbm1 libgtk-3-0 libasound2 \
+    ca-certificates \
+    {{.Fonts}} \
+    > /dev/null && \
+    rm -rf /var/lib/apt/lists/* /var/log/* /var/cache/ldconfig/aux-cache
+
+COPY --from=go /root/.cache/rod/browser/chromium-{{.Revision}} /opt/rod/chromium
+RUN ln -s /opt/rod/chromium/chrome /usr/bin/chrome && chrome --version
+
+LABEL org.opencontainers.image.base.name="{{.Base}}"
+
+FROM base AS base-amd64
+LABEL org.opencontainers.image.description="The chromium {{.Revision}} for rod"
+
+# the launcher downloads the chromium of Playwright for linux/arm64
+FROM base AS base-arm64
+LABEL org.opencontainers.image.description="The chromium {{.PlaywrightRevision}} of Playwright for rod"
+
+FROM base-${TARGETARCH}
+
+ENTRYPOINT ["chrome", "--headless", "--no-sandbox", "--remote-debugging-address=0.0.0.0", "--remote-debugging-port=9222"]
+`
+
+func genDockerfile(base, golang string) string {
+	buf := bytes.NewBuffer(nil)
+	utils.E(template.Must(template.New("").Parse(dockerfileTpl)).Execute(buf, map[string]interface{}{
+		"Revision":           launcher.RevisionDefault,
+		"PlaywrightRevision": launcher.RevisionPlaywright,
+		"Base":               base,
+		"Golang":             golang,
+		"Fonts":              *flagFonts,
+	}))
+	return buf.String()
+}
+
+// pin returns the image ref with the digest of the manifest list it resolves to,
+// so that the images of all the platforms are built from the same base.
+func pin(ref string) string {
+	if pinned(ref) {
+		return ref
+	}
+
+	out := utils.ExecLine(false, "docker buildx imagetools inspect", ref, "--format", "{{json .Manifest}}")
+
+	var manifest struct {
+		Digest string `json:"digest"`
+	}
+	utils.E(json.Unmarshal([]byte(out), &manifest))
+
+	pinned := ref + "@" + manifest.Digest
+	log.Println("pinned:", pinned)
+	return pinned
+}
+
+func pinned(ref string) bool {
+	return strings.Contains(ref, "@sha256:")
+}
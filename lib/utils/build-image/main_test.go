@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xyjwsj/grod/lib/launcher"
+	"github.com/ysmood/got"
+)
+
+func TestDockerfile(t *testing.T) {
+	g := got.T(t)
+
+	f := genDockerfile("debian@sha256:1", "golang@sha256:2")
+
+	g.Has(f, "FROM debian@sha256:1 AS base\n")
+	g.Has(f, "FROM golang@sha256:2 AS go\n")
+	g.Has(f, "snapshot.debian.org/archive/debian/$snapshot")
+
+	// each platform has the description of the browser it really contains
+	for _, p := range strings.Split(*flagPlatforms, ",") {
+		_, arch, _ := strings.Cut(p, "/")
+		g.Has(f, "FROM base AS base-"+arch+"\n")
+	}
+	g.Has(f, fmt.Sprintf(`"The chromium %d for rod"`, launcher.RevisionDefault))
+	g.Has(f, fmt.Sprintf(`"The chromium %d of Playwright for rod"`, launcher.RevisionPlaywright))
+}
+
+func TestPin(t *testing.T) {
+	g := got.T(t)
+
+	g.True(pinned("debian:bookworm-slim@sha256:1"))
+	g.False(pinned("debian:bookworm-slim"))
+	g.Eq(pin("debian@sha256:1"), "debian@sha256:1")
+}
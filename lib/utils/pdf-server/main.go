@@ -0,0 +1,264 @@
+// Package main is a PDF rendering service.
+//
+//	go run ./lib/utils/pdf-server -addr :7318 -pool 4
+//	curl -d '{"html": "<h1>Hi {{.name}}</h1>", "data": {"name": "Joy"}}' http://127.0.0.1:7318/pdf > out.pdf
+//
+// Each rendering runs in its own incognito browser context, so the cookies and the storage of a client
+// never leak to the others. Only the http and https urls are rendered, the hosts that resolve to the private
+// ips are refused unless the -allow-private is set, check the [urlpolicy.Policy]. The policy also applies to
+// each request of the page, such as the redirects, and the iframes, images, or stylesheets of the html,
+// the refused ones fail as blocked.
+//
+// The /pdf endpoint accepts a JSON body:
+//
+//	url      the page to render, either url or html is required
+//	html     the html to render, it's executed as a Go html/template with the data
+//	data     the JSON data for the template, it's also available as window.pdfData in the page
+//	options  the options of proto.PagePrintToPDF, such as {"landscape": true, "printBackground": true}
+//	timeout  max duration of the rendering, such as "30s", defaults to 30s
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils/urlpolicy"
+)
+
+func main() {
+	addr := flag.String("addr", ":7318", "the address to listen to")
+	size := flag.Int("pool", 4, "the max number of the renderings at the same time")
+	u := flag.String("control-url", "", "connect to an existing browser instead of launching a new one")
+	policy := urlpolicy.Flags(flag.CommandLine)
+	flag.Parse()
+
+	browser := rod.New().ControlURL(*u).MustConnect()
+	defer browser.MustClose()
+
+	s := newServer(browser, *size, policy)
+
+	log.Println("pdf server listening on", *addr)
+	srv := &http.Server{Addr: *addr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	log.Fatal(srv.ListenAndServe())
+}
+
+type server struct {
+	browser *rod.Browser
+	policy  *urlpolicy.Policy
+	limit   chan struct{}
+}
+
+func newServer(browser *rod.Browser, size int, policy *urlpolicy.Policy) *server {
+	return &server{browser: browser, policy: policy, limit: make(chan struct{}, size)}
+}
+
+type request struct {
+	URL     string                `json:"url"`
+	HTML    string                `json:"html"`
+	Data    json.RawMessage       `json:"data"`
+	Options *proto.PagePrintToPDF `json:"options"`
+	Timeout string                `json:"timeout"`
+
+	timeout time.Duration
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/pdf" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := parseRequest(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.URL != "" {
+		err = s.policy.Check(r.Context(), req.URL)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	select {
+	case s.limit <- struct{}{}:
+		defer func() { <-s.limit }()
+	case <-r.Context().Done():
+		return
+	}
+
+	bin, err := s.render(r, req)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, &rod.NavigationError{}) {
+			code = http.StatusBadGateway
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	_, _ = w.Write(bin)
+}
+
+func parseRequest(body io.Reader) (*request, error) {
+	req := &request{timeout: 30 * time.Second}
+
+	err := json.NewDecoder(body).Decode(req)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json body: %w", err)
+	}
+
+	if (req.URL == "") == (req.HTML == "") {
+		return nil, errors.New("one of url and html is required")
+	}
+
+	if req.Timeout != "" {
+		req.timeout, err = time.ParseDuration(req.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	if len(req.Data) == 0 {
+		req.Data = json.RawMessage("null")
+	}
+
+	if req.Options == nil {
+		req.Options = &proto.PagePrintToPDF{}
+	}
+
+	if req.HTML != "" {
+		var data interface{}
+		err = json.Unmarshal(req.Data, &data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+
+		tpl, err := template.New("pdf").Parse(req.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("invalid html template: %w", err)
+		}
+
+		buf := bytes.NewBuffer(nil)
+		err = tpl.Execute(buf, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute html template: %w", err)
+		}
+		req.HTML = buf.String()
+	}
+
+	return req, nil
+}
+
+// waitAssets waits for the fonts and images to be ready.
+const waitAssets = `() => Promise.all([
+	document.fonts.ready,
+	...Array.from(document.images)
+		.filter(img => !img.complete)
+		.map(img => new Promise(resolve => { img.onload = img.onerror = resolve }))
+])`
+
+// render the page in a new incognito browser context, the context is disposed after the rendering.
+func (s *server) render(r *http.Request, req *request) ([]byte, error) {
+	incognito, err := s.browser.Incognito()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = incognito.Close() }()
+
+	page, err := incognito.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, err
+	}
+	page = page.Context(r.Context()).Timeout(req.timeout)
+
+	err = s.guard(r, page)
+	if err != nil {
+		return nil, err
+	}
+
+	inject := fmt.Sprintf(`window.pdfData = %s`, req.Data)
+
+	if req.URL != "" {
+		remove, err := page.EvalOnNewDocument(inject)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = remove() }()
+
+		err = page.Navigate(req.URL)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := page.Navigate("about:blank")
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = page.Eval(`data => { window.pdfData = data }`, req.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		err = page.SetDocumentContent(req.HTML)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = page.WaitLoad()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = page.Eval(waitAssets)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := page.PDF(req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(stream)
+}
+
+// guard checks each request of the page with the policy, the page is closed with its browser context,
+// so the router doesn't need to be stopped.
+func (s *server) guard(r *http.Request, page *rod.Page) error {
+	router := page.HijackRequests()
+
+	err := router.Add("*", "", func(ctx *rod.Hijack) {
+		if s.policy.Check(r.Context(), ctx.Request.URL().String()) != nil {
+			ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+			return
+		}
+		ctx.ContinueRequest(&proto.FetchContinueRequest{})
+	})
+	if err != nil {
+		return err
+	}
+
+	go router.Run()
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/utils/urlpolicy"
+	"github.com/ysmood/got"
+)
+
+var setup = got.Setup(nil)
+
+func TestServer(t *testing.T) {
+	g := setup(t)
+
+	browser := rod.New().MustConnect()
+	defer browser.MustClose()
+
+	cookies := make(chan int, 10)
+
+	site := g.Serve()
+	site.Mux.HandleFunc("/set", func(w http.ResponseWriter, r *http.Request) {
+		cookies <- len(r.Cookies())
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "1"})
+		_, _ = w.Write([]byte(`<html><body>ok</body></html>`))
+	})
+
+	post := func(s *server, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pdf", strings.NewReader(body)))
+		return w
+	}
+
+	s := newServer(browser, 2, &urlpolicy.Policy{Private: true})
+
+	res := post(s, `{"html": "<h1>Hi {{.name}}</h1>", "data": {"name": "Joy"}}`)
+	g.Eq(res.Code, http.StatusOK)
+	g.Eq(res.Header().Get("Content-Type"), "application/pdf")
+	g.True(bytes.HasPrefix(res.Body.Bytes(), []byte("%PDF")))
+
+	// each rendering has its own browser context
+	g.Eq(post(s, `{"url": "`+site.URL("/set")+`"}`).Code, http.StatusOK)
+	g.Eq(post(s, `{"url": "`+site.URL("/set")+`"}`).Code, http.StatusOK)
+	g.Eq(<-cookies, 0)
+	g.Eq(<-cookies, 0)
+
+	for _, u := range []string{"file:///etc/passwd", "chrome://settings", "javascript:alert(1)"} {
+		res = post(s, `{"url": "`+u+`"}`)
+		g.Eq(res.Code, http.StatusForbidden)
+		g.Has(res.Body.String(), urlpolicy.ErrNotAllowed.Error())
+	}
+
+	// the private hosts are refused by default
+	s = newServer(browser, 2, &urlpolicy.Policy{})
+	g.Eq(post(s, `{"url": "`+site.URL("/set")+`"}`).Code, http.StatusForbidden)
+
+	// the subresources of the html are checked too
+	images := make(chan struct{}, 10)
+	site.Mux.HandleFunc("/img", func(_ http.ResponseWriter, _ *http.Request) {
+		images <- struct{}{}
+	})
+	html := `{"html": "<img src='` + site.URL("/img") + `'>"}`
+
+	g.Eq(post(s, html).Code, http.StatusOK)
+	g.Len(images, 0)
+
+	g.Eq(post(newServer(browser, 2, &urlpolicy.Policy{Private: true}), html).Code, http.StatusOK)
+	g.Len(images, 1)
+
+	g.Eq(post(s, `{}`).Code, http.StatusBadRequest)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pdf", nil))
+	g.Eq(w.Code, http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,216 @@
+// Package main is a screenshot service, so that rod can be deployed as a screenshot microservice.
+//
+//	go run ./lib/utils/screenshot-server -addr :7317 -pool 4 -allow-hosts example.com
+//	curl 'http://127.0.0.1:7317/screenshot?url=https://example.com&format=webp&full=true' > shot.webp
+//
+// Each capture runs in its own incognito browser context, so the cookies and the storage of a client
+// never leak to the others. Only the http and https urls are captured, the hosts that resolve to the private
+// ips are refused unless the -allow-private is set, check the [urlpolicy.Policy].
+//
+// Query parameters of the /screenshot endpoint:
+//
+//	url       the page to capture, required
+//	width     viewport width, defaults to 1280
+//	height    viewport height, defaults to 800
+//	full      capture the full page instead of the viewport
+//	selector  capture only the first element that matches the css selector
+//	format    png, jpeg, or webp, defaults to png
+//	quality   compression quality for jpeg and webp, 0-100
+//	timeout   max duration of the capture, such as "30s", defaults to 30s
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils/urlpolicy"
+	"github.com/ysmood/gson"
+)
+
+func main() {
+	addr := flag.String("addr", ":7317", "the address to listen to")
+	size := flag.Int("pool", 4, "the max number of the captures at the same time")
+	u := flag.String("control-url", "", "connect to an existing browser instead of launching a new one")
+	policy := urlpolicy.Flags(flag.CommandLine)
+	flag.Parse()
+
+	browser := rod.New().ControlURL(*u).MustConnect()
+	defer browser.MustClose()
+
+	s := newServer(browser, *size, policy)
+
+	log.Println("screenshot server listening on", *addr)
+	srv := &http.Server{Addr: *addr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	log.Fatal(srv.ListenAndServe())
+}
+
+type server struct {
+	browser *rod.Browser
+	policy  *urlpolicy.Policy
+	limit   chan struct{}
+}
+
+func newServer(browser *rod.Browser, size int, policy *urlpolicy.Policy) *server {
+	return &server{browser: browser, policy: policy, limit: make(chan struct{}, size)}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/screenshot" {
+		http.NotFound(w, r)
+		return
+	}
+
+	opts, err := parseOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.policy.Check(r.Context(), opts.url)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	select {
+	case s.limit <- struct{}{}:
+		defer func() { <-s.limit }()
+	case <-r.Context().Done():
+		return
+	}
+
+	bin, err := s.capture(r, opts)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, &rod.NavigationError{}) {
+			code = http.StatusBadGateway
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/"+string(opts.format))
+	_, _ = w.Write(bin)
+}
+
+type options struct {
+	url      string
+	width    int
+	height   int
+	full     bool
+	selector string
+	format   proto.PageCaptureScreenshotFormat
+	quality  int
+	timeout  time.Duration
+}
+
+func parseOptions(r *http.Request) (*options, error) {
+	q := r.URL.Query()
+
+	opts := &options{
+		url:      q.Get("url"),
+		width:    1280,
+		height:   800,
+		selector: q.Get("selector"),
+		format:   proto.PageCaptureScreenshotFormatPng,
+		timeout:  30 * time.Second,
+	}
+
+	if opts.url == "" {
+		return nil, errors.New("the url parameter is required")
+	}
+
+	var err error
+	parseInt := func(name string, dst *int) {
+		if v := q.Get(name); v != "" && err == nil {
+			*dst, err = strconv.Atoi(v)
+			if err != nil {
+				err = fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+	}
+	parseInt("width", &opts.width)
+	parseInt("height", &opts.height)
+	parseInt("quality", &opts.quality)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := q.Get("full"); v != "" {
+		opts.full, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid full: %w", err)
+		}
+	}
+
+	if v := q.Get("timeout"); v != "" {
+		opts.timeout, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	switch f := proto.PageCaptureScreenshotFormat(q.Get("format")); f {
+	case "":
+	case proto.PageCaptureScreenshotFormatPng, proto.PageCaptureScreenshotFormatJpeg, proto.PageCaptureScreenshotFormatWebp:
+		opts.format = f
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", f)
+	}
+
+	return opts, nil
+}
+
+// capture the page in a new incognito browser context, the context is disposed after the capture.
+func (s *server) capture(r *http.Request, opts *options) ([]byte, error) {
+	incognito, err := s.browser.Incognito()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = incognito.Close() }()
+
+	page, err := incognito.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, err
+	}
+	page = page.Context(r.Context()).Timeout(opts.timeout)
+
+	err = page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+		Width:  opts.width,
+		Height: opts.height,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = page.Navigate(opts.url)
+	if err != nil {
+		return nil, err
+	}
+
+	err = page.WaitLoad()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.selector != "" {
+		el, err := page.Element(opts.selector)
+		if err != nil {
+			return nil, err
+		}
+		return el.Screenshot(opts.format, opts.quality)
+	}
+
+	req := &proto.PageCaptureScreenshot{Format: opts.format}
+	if opts.quality > 0 {
+		req.Quality = gson.Int(opts.quality)
+	}
+	return page.Screenshot(opts.full, req)
+}
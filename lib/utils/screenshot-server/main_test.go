@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/utils/urlpolicy"
+	"github.com/ysmood/got"
+)
+
+var setup = got.Setup(nil)
+
+func TestServer(t *testing.T) {
+	g := setup(t)
+
+	browser := rod.New().MustConnect()
+	defer browser.MustClose()
+
+	cookies := make(chan int, 10)
+
+	site := g.Serve()
+	site.Route("/", ".html", `<html><body>ok</body></html>`)
+	site.Mux.HandleFunc("/set", func(w http.ResponseWriter, r *http.Request) {
+		cookies <- len(r.Cookies())
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "1"})
+	})
+
+	get := func(s *server, u string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/screenshot?url="+url.QueryEscape(u), nil))
+		return w
+	}
+
+	s := newServer(browser, 2, &urlpolicy.Policy{Private: true})
+
+	res := get(s, site.URL())
+	g.Eq(res.Code, http.StatusOK)
+	g.Eq(res.Header().Get("Content-Type"), "image/png")
+	g.Gt(res.Body.Len(), 0)
+
+	// each capture has its own browser context
+	g.Eq(get(s, site.URL("/set")).Code, http.StatusOK)
+	g.Eq(get(s, site.URL("/set")).Code, http.StatusOK)
+	g.Eq(<-cookies, 0)
+	g.Eq(<-cookies, 0)
+
+	for _, u := range []string{"file:///etc/passwd", "chrome://settings", "javascript:alert(1)"} {
+		res = get(s, u)
+		g.Eq(res.Code, http.StatusForbidden)
+		g.Has(res.Body.String(), urlpolicy.ErrNotAllowed.Error())
+	}
+
+	// the private hosts are refused by default
+	s = newServer(browser, 2, &urlpolicy.Policy{})
+	g.Eq(get(s, site.URL()).Code, http.StatusForbidden)
+
+	s = newServer(browser, 2, &urlpolicy.Policy{Private: true, Hosts: []string{"example.com"}})
+	g.Eq(get(s, site.URL()).Code, http.StatusForbidden)
+
+	g.Eq(get(s, "").Code, http.StatusBadRequest)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	g.Eq(w.Code, http.StatusNotFound)
+}
@@ -0,0 +1,128 @@
+// Package urlpolicy checks the urls that the clients of a service ask the browser to open,
+// such as the lib/utils/screenshot-server, so that the clients can't make the browser read the local files
+// or the internal network of the service.
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ErrNotAllowed is returned by [Policy.Check] if the url isn't allowed.
+var ErrNotAllowed = errors.New("the url is not allowed")
+
+// Policy of the urls. Only the http and https urls are allowed.
+// It only checks the url itself, the redirects and the subresources of the page aren't checked,
+// use the network policy of the deployment to fully isolate the browser from the internal network.
+type Policy struct {
+	// Hosts that are allowed, such as "example.com", or "*.example.com" for its subdomains.
+	// Empty means all the hosts.
+	Hosts []string
+
+	// Private allows the hosts that resolve to the loopback, private, link-local, or unspecified ips.
+	Private bool
+
+	// Resolver to look up the ips of the hosts, nil means the [net.DefaultResolver]
+	Resolver *net.Resolver
+}
+
+// Flags registers the flags of the policy to the fs, the policy is ready after the fs is parsed:
+//
+//	-allow-hosts  comma-separated hosts that are allowed, such as "example.com,*.example.com"
+//	-allow-private  allow the hosts that resolve to the private ips
+func Flags(fs *flag.FlagSet) *Policy {
+	p := &Policy{}
+	fs.Func("allow-hosts", `comma-separated hosts that are allowed, such as "example.com,*.example.com", `+
+		"empty means all the hosts", func(s string) error {
+		for _, h := range strings.Split(s, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				p.Hosts = append(p.Hosts, h)
+			}
+		}
+		return nil
+	})
+	fs.BoolVar(&p.Private, "allow-private", false, "allow the hosts that resolve to the loopback or private ips")
+	return p
+}
+
+// Check returns an error that wraps the [ErrNotAllowed] if the raw url isn't allowed by the policy.
+func (p *Policy) Check(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotAllowed, err)
+	}
+
+	if s := strings.ToLower(u.Scheme); s != "http" && s != "https" {
+		return fmt.Errorf("%w: only http and https are supported: %s", ErrNotAllowed, raw)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: no host: %s", ErrNotAllowed, raw)
+	}
+
+	if !p.allowHost(host) {
+		return fmt.Errorf("%w: the host isn't allowed: %s", ErrNotAllowed, host)
+	}
+
+	if p.Private {
+		return nil
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		r := p.Resolver
+		if r == nil {
+			r = net.DefaultResolver
+		}
+
+		addrs, err := r.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrNotAllowed, err)
+		}
+
+		ips = ips[:0]
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if Private(ip) {
+			return fmt.Errorf("%w: the host resolves to a private ip: %s %s", ErrNotAllowed, host, ip)
+		}
+	}
+
+	return nil
+}
+
+func (p *Policy) allowHost(host string) bool {
+	if len(p.Hosts) == 0 {
+		return true
+	}
+
+	for _, h := range p.Hosts {
+		h = strings.ToLower(h)
+		if sub, ok := strings.CutPrefix(h, "*."); ok {
+			if strings.HasSuffix(host, "."+sub) {
+				return true
+			}
+			continue
+		}
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+// Private returns true if the ip is a loopback, private, link-local, or unspecified one.
+func Private(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
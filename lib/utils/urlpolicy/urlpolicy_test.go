@@ -0,0 +1,60 @@
+package urlpolicy_test
+
+import (
+	"context"
+	"flag"
+	"net"
+	"testing"
+
+	"github.com/xyjwsj/grod/lib/utils/urlpolicy"
+	"github.com/ysmood/got"
+)
+
+func TestCheck(t *testing.T) {
+	g := got.T(t)
+
+	p := &urlpolicy.Policy{}
+
+	for _, u := range []string{
+		"file:///etc/passwd",
+		"FILE:///C:/Windows/win.ini",
+		"javascript:alert(1)",
+		"chrome://settings",
+		"http:///no-host",
+		"/relative",
+		"http://127.0.0.1:8080",
+		"http://[::1]/",
+		"http://10.0.0.1",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0",
+		"http://localhost",
+		"://",
+	} {
+		g.Is(p.Check(g.Context(), u), urlpolicy.ErrNotAllowed)
+	}
+
+	g.E(p.Check(g.Context(), "https://1.1.1.1/a"))
+	g.E((&urlpolicy.Policy{Private: true}).Check(g.Context(), "http://127.0.0.1"))
+
+	// the resolver failed
+	p.Resolver = &net.Resolver{PreferGo: true, Dial: func(context.Context, string, string) (net.Conn, error) {
+		return nil, net.UnknownNetworkError("test")
+	}}
+	g.Is(p.Check(g.Context(), "http://example.com"), urlpolicy.ErrNotAllowed)
+}
+
+func TestFlags(t *testing.T) {
+	g := got.T(t)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := urlpolicy.Flags(fs)
+	g.E(fs.Parse([]string{"-allow-hosts", "Example.com, *.test.com,", "-allow-private"}))
+
+	g.Eq(p.Hosts, []string{"Example.com", "*.test.com"})
+	g.True(p.Private)
+
+	g.E(p.Check(g.Context(), "http://example.com./a"))
+	g.E(p.Check(g.Context(), "http://a.b.test.com"))
+	g.Is(p.Check(g.Context(), "http://test.com"), urlpolicy.ErrNotAllowed)
+	g.Is(p.Check(g.Context(), "http://example.com.evil.com"), urlpolicy.ErrNotAllowed)
+}
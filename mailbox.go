@@ -0,0 +1,203 @@
+// This file contains the helpers to automate the email verification of the sign-up flows.
+
+package rod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// Email received by a [MailboxProvider].
+type Email struct {
+	ID      string
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+	Date    time.Time
+}
+
+// MailboxProvider is a pluggable mailbox, such as a wrapper of an IMAP client or the api of a testing mail service.
+// Use [Browser.Mailbox] to set it, [MaildevMailbox] is a built-in one.
+type MailboxProvider interface {
+	// Emails returns all the emails in the mailbox, the order doesn't matter.
+	Emails(ctx context.Context) ([]*Email, error)
+}
+
+// Mailbox sets the provider for the helpers like [Browser.WaitEmailLink].
+func (b *Browser) Mailbox(provider MailboxProvider) *Browser {
+	b.mailbox = provider
+	return b
+}
+
+// EmailMatcher selects the email and the link in it for [Browser.WaitEmailLink].
+// The empty fields match everything.
+type EmailMatcher struct {
+	// To is the recipient address of the email, case-insensitive
+	To string
+
+	// Subject of the email
+	Subject *regexp.Regexp
+
+	// Since ignores the emails that are sent before it, such as the ones from the previous test runs
+	Since time.Time
+
+	// Link to extract from the email, the first link that matches will be used
+	Link *regexp.Regexp
+}
+
+// Match returns the first link of the email that matches, it's empty if not matched.
+func (m EmailMatcher) Match(e *Email) string {
+	if m.To != "" && !slices.ContainsFunc(e.To, func(to string) bool { return strings.EqualFold(to, m.To) }) {
+		return ""
+	}
+	if m.Subject != nil && !m.Subject.MatchString(e.Subject) {
+		return ""
+	}
+	if !m.Since.IsZero() && e.Date.Before(m.Since) {
+		return ""
+	}
+
+	for _, link := range EmailLinks(e) {
+		if m.Link == nil || m.Link.MatchString(link) {
+			return link
+		}
+	}
+	return ""
+}
+
+var (
+	regEmailHref = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+	regEmailURL  = regexp.MustCompile(`https?://[^\s<>"']+`)
+)
+
+// EmailLinks returns the http links of the email, the href of the anchors of the HTML body come first,
+// then the urls in the text body.
+func EmailLinks(e *Email) []string {
+	list := []string{}
+	has := map[string]bool{}
+	add := func(link string) {
+		link = strings.TrimRight(html.UnescapeString(link), ".,;:!?)]")
+		if !has[link] && regEmailURL.MatchString(link) {
+			has[link] = true
+			list = append(list, link)
+		}
+	}
+
+	for _, m := range regEmailHref.FindAllStringSubmatch(e.HTML, -1) {
+		add(m[1])
+	}
+	for _, link := range regEmailURL.FindAllString(e.Text, -1) {
+		add(link)
+	}
+	return list
+}
+
+// WaitEmailLink polls the mailbox until an email matches, then opens the link in a new page.
+// It's useful to complete the email verification step of a sign-up flow.
+// Use [Browser.Context] or [Browser.Timeout] to limit the polling.
+func (b *Browser) WaitEmailLink(m EmailMatcher) (*Page, error) {
+	if b.mailbox == nil {
+		return nil, &MailboxNotSetError{}
+	}
+
+	var link string
+	err := utils.Retry(b.ctx, b.sleeper(), func() (bool, error) {
+		list, err := b.mailbox.Emails(b.ctx)
+		if err != nil {
+			return true, err
+		}
+		for _, e := range list {
+			if link = m.Match(e); link != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := b.Page(proto.TargetCreateTarget{URL: link})
+	if err != nil {
+		return nil, err
+	}
+	return p, p.WaitLoad()
+}
+
+// MaildevMailbox is a [MailboxProvider] for the REST api of maildev, it also works for the compatible
+// services. Such as:
+//
+//	docker run -p 1080:1080 -p 1025:1025 maildev/maildev
+//	browser.Mailbox(&rod.MaildevMailbox{URL: "http://127.0.0.1:1080"})
+type MaildevMailbox struct {
+	// URL of the web interface of maildev
+	URL string
+
+	// HTTPClient to call the api, the default is http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// Emails interface.
+func (m *MaildevMailbox) Emails(ctx context.Context) ([]*Email, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(m.URL, "/")+"/email", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := m.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list the emails of maildev: %s", res.Status)
+	}
+
+	type address struct {
+		Address string `json:"address"`
+	}
+	var raw []struct {
+		ID      string    `json:"id"`
+		From    []address `json:"from"`
+		To      []address `json:"to"`
+		Subject string    `json:"subject"`
+		Text    string    `json:"text"`
+		HTML    string    `json:"html"`
+		Date    time.Time `json:"date"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Email, 0, len(raw))
+	for _, r := range raw {
+		e := &Email{ID: r.ID, Subject: r.Subject, Text: r.Text, HTML: r.HTML, Date: r.Date}
+		if len(r.From) > 0 {
+			e.From = r.From[0].Address
+		}
+		for _, to := range r.To {
+			e.To = append(e.To, to.Address)
+		}
+		list = append(list, e)
+	}
+	return list, nil
+}
@@ -0,0 +1,110 @@
+package rod_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+)
+
+// fakeMailbox receives the emails after the mailbox is polled for a few times.
+type fakeMailbox struct {
+	polled int32
+	after  int32
+	emails []*rod.Email
+	err    error
+}
+
+func (m *fakeMailbox) Emails(_ context.Context) ([]*rod.Email, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if atomic.AddInt32(&m.polled, 1) <= m.after {
+		return nil, nil
+	}
+	return m.emails, nil
+}
+
+func TestEmailLinks(t *testing.T) {
+	g := setup(t)
+
+	e := &rod.Email{
+		HTML: `<a href="https://a.com/verify?token=1&amp;u=2">verify</a> <a href='mailto:a@b.com'>mail</a>`,
+		Text: "Open https://a.com/verify?token=1&u=2 or https://b.com/help.",
+	}
+	g.Eq(rod.EmailLinks(e), []string{"https://a.com/verify?token=1&u=2", "https://b.com/help"})
+
+	m := rod.EmailMatcher{To: "JOY@a.com", Link: regexp.MustCompile(`/help`)}
+	g.Eq(m.Match(e), "")
+	e.To = []string{"joy@a.com"}
+	g.Eq(m.Match(e), "https://b.com/help")
+
+	e.Date = time.Now().Add(-time.Hour)
+	m.Since = time.Now()
+	g.Eq(m.Match(e), "")
+
+	g.Eq(rod.EmailMatcher{Subject: regexp.MustCompile(`Welcome`)}.Match(e), "")
+}
+
+func TestWaitEmailLink(t *testing.T) {
+	g := setup(t)
+
+	_, err := g.browser.WaitEmailLink(rod.EmailMatcher{})
+	g.Is(err, &rod.MailboxNotSetError{})
+	g.Eq(err.Error(), "the mailbox is not set, use Browser.Mailbox to set it")
+
+	s := g.Serve().Route("/verify", ".html", `<html><body>verified</body></html>`)
+
+	mailbox := &fakeMailbox{after: 2, emails: []*rod.Email{
+		{Subject: "Newsletter", Text: "see " + s.URL("/news")},
+		{Subject: "Verify your account", HTML: `<a href="` + s.URL("/verify") + `">verify</a>`},
+	}}
+	b := g.browser.Mailbox(mailbox)
+	defer g.browser.Mailbox(nil)
+
+	p := b.MustWaitEmailLink(rod.EmailMatcher{Subject: regexp.MustCompile(`Verify`)})
+	defer p.MustClose()
+	g.Eq(p.MustElement("body").MustText(), "verified")
+	g.Eq(atomic.LoadInt32(&mailbox.polled), 3)
+
+	_, err = b.Timeout(100 * time.Millisecond).WaitEmailLink(rod.EmailMatcher{To: "nobody@a.com"})
+	g.Is(err, context.DeadlineExceeded)
+
+	mailbox.err = errors.New("imap error")
+	_, err = b.WaitEmailLink(rod.EmailMatcher{})
+	g.Eq(err.Error(), "imap error")
+}
+
+func TestMaildevMailbox(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/email", func(w http.ResponseWriter, _ *http.Request) {
+		g.E(json.NewEncoder(w).Encode([]map[string]interface{}{{
+			"id":      "1",
+			"from":    []map[string]string{{"address": "no-reply@a.com", "name": "A"}},
+			"to":      []map[string]string{{"address": "joy@a.com"}},
+			"subject": "Verify",
+			"text":    "https://a.com/verify",
+			"html":    `<a href="https://a.com/verify">verify</a>`,
+			"date":    "2024-01-02T03:04:05.000Z",
+		}}))
+	})
+
+	list, err := (&rod.MaildevMailbox{URL: s.URL() + "/"}).Emails(g.Context())
+	g.E(err)
+	g.Len(list, 1)
+	g.Eq(list[0].From, "no-reply@a.com")
+	g.Eq(list[0].To, []string{"joy@a.com"})
+	g.Eq(list[0].Date.Year(), 2024)
+	g.Eq(rod.EmailMatcher{To: "joy@a.com"}.Match(list[0]), "https://a.com/verify")
+
+	_, err = (&rod.MaildevMailbox{URL: s.URL("/404")}).Emails(g.Context())
+	g.Has(err.Error(), "404")
+}
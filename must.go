@@ -9,11 +9,13 @@
 package rod
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -57,6 +59,20 @@ func (b *Browser) MustClose() {
 	_ = b.Close()
 }
 
+// MustSupervise is similar to [Browser.Supervise].
+func (b *Browser) MustSupervise(handler func(*BrowserRecovery)) (stop func()) {
+	stop, err := b.Supervise(handler)
+	b.e(err)
+	return stop
+}
+
+// MustExtensionTarget is similar to [Browser.ExtensionTarget].
+func (b *Browser) MustExtensionTarget(id string) *proto.TargetTargetInfo {
+	t, err := b.ExtensionTarget(id)
+	b.e(err)
+	return t
+}
+
 // MustIncognito is similar to [Browser.Incognito].
 func (b *Browser) MustIncognito() *Browser {
 	p, err := b.Incognito()
@@ -79,6 +95,19 @@ func (b *Browser) MustPages() Pages {
 	return list
 }
 
+// MustStartPages is similar to [Browser.StartPages].
+func (b *Browser) MustStartPages() Pages {
+	list, err := b.StartPages()
+	b.e(err)
+	return list
+}
+
+// MustTileWindows is similar to [Browser.TileWindows].
+func (b *Browser) MustTileWindows(rows, cols int, display *proto.BrowserBounds) *Browser {
+	b.e(b.TileWindows(rows, cols, display))
+	return b
+}
+
 // MustPageFromTargetID is similar to [Browser.PageFromTargetID].
 func (b *Browser) MustPageFromTargetID(targetID proto.TargetTargetID) *Page {
 	p, err := b.PageFromTarget(targetID)
@@ -92,6 +121,20 @@ func (b *Browser) MustHandleAuth(username, password string) (wait func()) {
 	return func() { b.e(w()) }
 }
 
+// MustHandleAuthFor is similar to [Browser.HandleAuthFor].
+func (b *Browser) MustHandleAuthFor(pattern, username, password string) (stop func()) {
+	s, err := b.HandleAuthFor(pattern, username, password)
+	b.e(err)
+	return func() { b.e(s()) }
+}
+
+// MustMapHosts is similar to [Browser.MapHosts].
+func (b *Browser) MustMapHosts(hosts map[string]string) (stop func()) {
+	s, err := b.MapHosts(hosts)
+	b.e(err)
+	return func() { b.e(s()) }
+}
+
 // MustIgnoreCertErrors is similar to [Browser.IgnoreCertErrors].
 func (b *Browser) MustIgnoreCertErrors(enable bool) *Browser {
 	b.e(b.IgnoreCertErrors(enable))
@@ -105,6 +148,13 @@ func (b *Browser) MustGetCookies() []*proto.NetworkCookie {
 	return nc
 }
 
+// MustPartitionedCookies is similar to [Browser.PartitionedCookies].
+func (b *Browser) MustPartitionedCookies(topLevelSite string) []*proto.NetworkCookie {
+	cookies, err := b.PartitionedCookies(topLevelSite)
+	b.e(err)
+	return cookies
+}
+
 // MustSetCookies is similar to [Browser.SetCookies].
 // If the len(cookies) is 0 it will clear all the cookies.
 func (b *Browser) MustSetCookies(cookies ...*proto.NetworkCookie) *Browser {
@@ -139,6 +189,13 @@ func (b *Browser) MustVersion() *proto.BrowserGetVersionResult {
 	return v
 }
 
+// MustWaitEmailLink is similar to [Browser.WaitEmailLink].
+func (b *Browser) MustWaitEmailLink(m EmailMatcher) *Page {
+	p, err := b.WaitEmailLink(m)
+	b.e(err)
+	return p
+}
+
 // MustFind is similar to [Browser.Find].
 func (ps Pages) MustFind(selector string) *Page {
 	p, err := ps.Find(selector)
@@ -208,6 +265,12 @@ func (p *Page) MustSetCookies(cookies ...*proto.NetworkCookieParam) *Page {
 	return p
 }
 
+// MustDeleteCookies is similar to [Page.DeleteCookies].
+func (p *Page) MustDeleteCookies(cookies ...*proto.NetworkCookie) *Page {
+	p.e(p.DeleteCookies(cookies))
+	return p
+}
+
 // MustSetExtraHeaders is similar to [Page.SetExtraHeaders].
 func (p *Page) MustSetExtraHeaders(dict ...string) (cleanup func()) {
 	cleanup, err := p.SetExtraHeaders(dict)
@@ -227,6 +290,13 @@ func (p *Page) MustSetBlockedURLs(urls ...string) *Page {
 	return p
 }
 
+// MustSetOriginTrialTokens is similar to [Page.SetOriginTrialTokens].
+func (p *Page) MustSetOriginTrialTokens(tokens ...string) (remove func()) {
+	r, err := p.SetOriginTrialTokens(tokens...)
+	p.e(err)
+	return func() { p.e(r()) }
+}
+
 // MustNavigate is similar to [Page.Navigate].
 func (p *Page) MustNavigate(url string) *Page {
 	p.e(p.Navigate(url))
@@ -362,6 +432,29 @@ func (p *Page) MustHandleFileDialog() func(...string) {
 	}
 }
 
+// MustWaitFileChooser is similar to [Page.WaitFileChooser].
+func (p *Page) MustWaitFileChooser() func() *FileChooser {
+	wait, err := p.WaitFileChooser()
+	p.e(err)
+	return func() *FileChooser {
+		fc, err := wait()
+		p.e(err)
+		return fc
+	}
+}
+
+// MustElement is similar to [FileChooser.Element].
+func (fc *FileChooser) MustElement() *Element {
+	el, err := fc.Element()
+	fc.page.e(err)
+	return el
+}
+
+// MustSetFiles is similar to [FileChooser.SetFiles].
+func (fc *FileChooser) MustSetFiles(paths ...string) {
+	fc.page.e(fc.SetFiles(paths))
+}
+
 // MustScreenshot is similar to [Page.Screenshot].
 // If the toFile is "", it Page.will save output to "tmp/screenshots" folder, time as the file name.
 func (p *Page) MustScreenshot(toFile ...string) []byte {
@@ -378,6 +471,13 @@ func (p *Page) MustCaptureDOMSnapshot() (domSnapshot *proto.DOMSnapshotCaptureSn
 	return domSnapshot
 }
 
+// MustDOMSnapshot is similar to [Page.DOMSnapshot].
+func (p *Page) MustDOMSnapshot() *DOMSnapshot {
+	s, err := p.DOMSnapshot()
+	p.e(err)
+	return s
+}
+
 // MustTriggerFavicon is similar to [PageTriggerFavicon].
 func (p *Page) MustTriggerFavicon() *Page {
 	p.e(p.TriggerFavicon())
@@ -440,6 +540,44 @@ func (p *Page) MustWaitIdle() *Page {
 	return p
 }
 
+// MustWaitResponse is similar to [Page.WaitResponse].
+func (p *Page) MustWaitResponse(m *ResponseMatcher) (wait func() *NetworkRequest) {
+	w := p.WaitResponse(m)
+	return func() *NetworkRequest {
+		r, err := w()
+		p.e(err)
+		return r
+	}
+}
+
+// MustRequests is similar to [Page.Requests].
+func (p *Page) MustRequests(filter *RequestFilter) []*NetworkRequest {
+	list, err := p.Requests(filter)
+	p.e(err)
+	return list
+}
+
+// MustResponseBody is similar to [Page.ResponseBody].
+func (p *Page) MustResponseBody(id proto.NetworkRequestID) []byte {
+	body, err := p.ResponseBody(id)
+	p.e(err)
+	return body
+}
+
+// MustNavigationTiming is similar to [Page.NavigationTiming].
+func (p *Page) MustNavigationTiming() *NavigationTiming {
+	t, err := p.NavigationTiming()
+	p.e(err)
+	return t
+}
+
+// MustWebVitals is similar to [Page.WebVitals].
+func (p *Page) MustWebVitals() *WebVitals {
+	v, err := p.WebVitals(p.ctx)
+	p.e(err)
+	return v
+}
+
 // MustWaitDOMStable is similar to [Page.WaitDOMStable].
 func (p *Page) MustWaitDOMStable() *Page {
 	p.e(p.WaitDOMStable(time.Second, 0))
@@ -476,6 +614,41 @@ func (p *Page) MustEvalOnNewDocument(js string) {
 	p.e(err)
 }
 
+// MustSuppressNativeUI is similar to [Page.SuppressNativeUI].
+func (p *Page) MustSuppressNativeUI() (restore func()) {
+	r, err := p.SuppressNativeUI()
+	p.e(err)
+	return func() { p.e(r()) }
+}
+
+// MustOnExternalProtocol is similar to [Page.OnExternalProtocol].
+func (p *Page) MustOnExternalProtocol(handler func(u string)) (stop func()) {
+	s, err := p.OnExternalProtocol(handler)
+	p.e(err)
+	return func() { p.e(s()) }
+}
+
+// MustOpenLinksExternally is similar to [Page.OpenLinksExternally].
+func (p *Page) MustOpenLinksExternally(handler func(u string)) (stop func()) {
+	s, err := p.OpenLinksExternally(handler)
+	p.e(err)
+	return func() { p.e(s()) }
+}
+
+// MustAppWindow is similar to [Page.AppWindow].
+func (p *Page) MustAppWindow(opts AppWindowOptions) (stop func()) {
+	s, err := p.AppWindow(opts)
+	p.e(err)
+	return func() { p.e(s()) }
+}
+
+// MustCall is similar to [RPCServer.Call].
+func (r *RPCServer) MustCall(ctx context.Context, method string, params interface{}) gson.JSON {
+	res, err := r.Call(ctx, method, params)
+	r.page.e(err)
+	return res
+}
+
 // MustExpose is similar to [Page.Expose].
 func (p *Page) MustExpose(name string, fn func(gson.JSON) (interface{}, error)) (stop func()) {
 	s, err := p.Expose(name, fn)
@@ -483,6 +656,87 @@ func (p *Page) MustExpose(name string, fn func(gson.JSON) (interface{}, error))
 	return func() { p.e(s()) }
 }
 
+// MustObserveMutations is similar to [Page.ObserveMutations].
+func (p *Page) MustObserveMutations(selector string, opts *MutationOptions) (
+	records <-chan *MutationRecord, stop func(),
+) {
+	records, s, err := p.ObserveMutations(selector, opts)
+	p.e(err)
+	return records, func() { p.e(s()) }
+}
+
+// MustLongTasks is similar to [Page.LongTasks].
+func (p *Page) MustLongTasks() (tasks <-chan *LongTask, stop func()) {
+	tasks, s, err := p.LongTasks()
+	p.e(err)
+	return tasks, func() { p.e(s()) }
+}
+
+// MustWaitCustomElementsDefined is similar to [Page.WaitCustomElementsDefined].
+func (p *Page) MustWaitCustomElementsDefined(names ...string) *Page {
+	p.e(p.WaitCustomElementsDefined(names...))
+	return p
+}
+
+// MustWaitHydrated is similar to [Page.WaitHydrated].
+func (p *Page) MustWaitHydrated(framework Framework) *Page {
+	p.e(p.WaitHydrated(framework))
+	return p
+}
+
+// MustWaitFrameworkIdle is similar to [Page.WaitFrameworkIdle].
+func (p *Page) MustWaitFrameworkIdle() *Page {
+	p.e(p.WaitFrameworkIdle())
+	return p
+}
+
+// MustOverrideExperiments is similar to [Page.OverrideExperiments].
+func (p *Page) MustOverrideExperiments(overrides ExperimentOverrides) (remove func()) {
+	r, err := p.OverrideExperiments(overrides)
+	p.e(err)
+	return func() { p.e(r()) }
+}
+
+// MustFillSecondFactor is similar to [Page.FillSecondFactor].
+func (p *Page) MustFillSecondFactor(provider SecondFactorProvider) bool {
+	filled, err := p.FillSecondFactor(provider)
+	p.e(err)
+	return filled
+}
+
+// MustRecordCanvas is similar to [Page.RecordCanvas].
+func (p *Page) MustRecordCanvas() (remove func()) {
+	r, err := p.RecordCanvas()
+	p.e(err)
+	return func() { p.e(r()) }
+}
+
+// MustFindTextOnScreen is similar to [Page.FindTextOnScreen].
+func (p *Page) MustFindTextOnScreen(re *regexp.Regexp) []*OCRText {
+	list, err := p.FindTextOnScreen(re)
+	p.e(err)
+	return list
+}
+
+// MustHostedField is similar to [Page.HostedField].
+func (p *Page) MustHostedField(f HostedField) *Element {
+	el, err := p.HostedField(f)
+	p.e(err)
+	return el
+}
+
+// MustFillHostedField is similar to [Page.FillHostedField].
+func (p *Page) MustFillHostedField(f HostedField, value string) *Page {
+	p.e(p.FillHostedField(f, value))
+	return p
+}
+
+// MustFillCard is similar to [Page.FillCard].
+func (p *Page) MustFillCard(fields CardFields, card Card) *Page {
+	p.e(p.FillCard(fields, card))
+	return p
+}
+
 // MustEval is similar to [Page.Eval].
 func (p *Page) MustEval(js string, params ...interface{}) gson.JSON {
 	res, err := p.Eval(js, params...)
@@ -577,6 +831,13 @@ func (p *Page) MustSearch(query string) *Element {
 	return res.First
 }
 
+// MustElementDeep is similar to [Page.ElementDeep].
+func (p *Page) MustElementDeep(query string) *Element {
+	el, err := p.ElementDeep(query)
+	p.e(err)
+	return el
+}
+
 // MustElement is similar to [Page.Element].
 func (p *Page) MustElement(selector string) *Element {
 	el, err := p.Element(selector)
@@ -906,6 +1167,12 @@ func (p *Page) MustSetDocumentContent(html string) *Page {
 	return p
 }
 
+// MustSetContent is similar to [Page.SetContent].
+func (p *Page) MustSetContent(html string, opts *SetContentOptions) *Page {
+	p.e(p.SetContent(html, opts))
+	return p
+}
+
 // MustText is similar to [Element.Text].
 func (el *Element) MustText() string {
 	s, err := el.Text()
@@ -933,6 +1200,12 @@ func (el *Element) MustWaitLoad() *Element {
 	return el
 }
 
+// MustWaitAnimations is similar to [Element.WaitAnimations].
+func (el *Element) MustWaitAnimations() *Element {
+	el.e(el.WaitAnimations())
+	return el
+}
+
 // MustWaitStable is similar to [Element.WaitStable].
 func (el *Element) MustWaitStable() *Element {
 	el.e(el.WaitStable(300 * time.Millisecond))
@@ -997,6 +1270,15 @@ func (el *Element) MustBackgroundImage() []byte {
 	return bin
 }
 
+// MustScreenshotScrolled is similar to [Element.ScreenshotScrolled].
+// If the toFile is "", it will save output to "tmp/screenshots" folder, time as the file name.
+func (el *Element) MustScreenshotScrolled(toFile ...string) []byte {
+	bin, err := el.ScreenshotScrolled(nil)
+	el.e(err)
+	el.e(saveFile(saveFileTypeScreenshot, bin, toFile))
+	return bin
+}
+
 // MustScreenshot is similar to [Element.Screenshot].
 func (el *Element) MustScreenshot(toFile ...string) []byte {
 	bin, err := el.Screenshot(proto.PageCaptureScreenshotFormatPng, 0)
@@ -1162,6 +1444,58 @@ func (el *Element) MustGetXPath(optimized bool) string {
 	return xpath
 }
 
+// MustWaitUpgrade is similar to [Element.WaitUpgrade].
+func (el *Element) MustWaitUpgrade() *Element {
+	el.e(el.WaitUpgrade())
+	return el
+}
+
+// MustCanvasCommandsLog is similar to [Element.CanvasCommandsLog].
+func (el *Element) MustCanvasCommandsLog() []*CanvasCommand {
+	list, err := el.CanvasCommandsLog()
+	el.e(err)
+	return list
+}
+
+// MustClearCanvasCommandsLog is similar to [Element.ClearCanvasCommandsLog].
+func (el *Element) MustClearCanvasCommandsLog() *Element {
+	el.e(el.ClearCanvasCommandsLog())
+	return el
+}
+
+// MustTextViaOCR is similar to [Element.TextViaOCR].
+func (el *Element) MustTextViaOCR() string {
+	s, err := el.TextViaOCR()
+	el.e(err)
+	return s
+}
+
+// MustAttach is similar to [Bus.Attach].
+func (bus *Bus) MustAttach(p *Page) (detach func()) {
+	d, err := bus.Attach(p)
+	p.e(err)
+	return func() { p.e(d()) }
+}
+
+// MustRun is similar to [OAuthFlow.Run].
+func (f *OAuthFlow) MustRun(p *Page) *OAuthResult {
+	res, err := f.Run(p)
+	p.e(err)
+	return res
+}
+
+// MustSync is similar to [Scenario.Sync].
+func (s *Scenario) MustSync(name string) {
+	s.browser.e(s.Sync(name))
+}
+
+// MustCheckout is similar to [BrowserManager.Checkout].
+func (m *BrowserManager) MustCheckout(ctx context.Context) *Browser {
+	b, err := m.Checkout(ctx)
+	utils.E(err)
+	return b
+}
+
 // MustGet an elem from the pool. Use the [Pool[T].Put] to make it reusable later.
 func (p Pool[T]) MustGet(create func() *T) *T {
 	elem := <-p
@@ -0,0 +1,159 @@
+// This file contains the helpers to avoid the native UI of the browser that blocks the automation.
+
+package rod
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// webSchemes are the url schemes that the browser handles itself, the others are external protocols.
+var webSchemes = map[string]bool{
+	"http": true, "https": true, "about": true, "blob": true, "data": true, "file": true,
+	"javascript": true, "chrome": true, "devtools": true, "ws": true, "wss": true,
+}
+
+// IsExternalProtocol returns true if the url is handled by an external application of the os, such as "mailto:".
+func IsExternalProtocol(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme == "" {
+		return false
+	}
+	return !webSchemes[strings.ToLower(parsed.Scheme)]
+}
+
+// onExternalProtocolJS reports the clicks on the links of external protocols and the window.open of them
+// to the binding instead of navigating.
+const onExternalProtocolJS = `(bind) => {
+	const web = ['http:', 'https:', 'about:', 'blob:', 'data:', 'file:', 'javascript:']
+	const report = (u) => window[bind](String(u))
+
+	document.addEventListener('click', (e) => {
+		const a = e.target && e.target.closest && e.target.closest('a[href]')
+		if (a && !web.includes(a.protocol)) {
+			e.preventDefault()
+			report(a.href)
+		}
+	}, true)
+
+	const open = window.open
+	window.open = function (u, ...args) {
+		try {
+			if (!web.includes(new URL(u, location.href).protocol)) {
+				report(new URL(u, location.href))
+				return null
+			}
+		} catch {}
+		return open.call(this, u, ...args)
+	}
+}`
+
+// suppressNativeUIJS disables the print dialog, and cancels the clicks on the links of external protocols,
+// such as "mailto:" or "zoommtg:", that would open the prompt to launch an external application.
+const suppressNativeUIJS = `() => {
+	window.print = () => {}
+
+	const web = ['http:', 'https:', 'about:', 'blob:', 'data:', 'file:', 'javascript:']
+	document.addEventListener('click', (e) => {
+		const a = e.target && e.target.closest && e.target.closest('a[href]')
+		if (a && !web.includes(a.protocol)) e.preventDefault()
+	}, true)
+}`
+
+// SuppressNativeUI is a single switch to avoid the native UI of the browser that CDP can't interact with,
+// once it pops up the page may hang until a human closes it:
+//
+//   - The print dialog of window.print is disabled. To print silently to the default printer instead,
+//     check launcher.Launcher.SuppressNativeUI .
+//   - The beforeunload dialogs are accepted, so that navigations and [Page.Close] won't be blocked.
+//   - The clicks on the links of external protocols, such as "mailto:", are canceled to avoid the prompt
+//     to launch an external application. The navigations to them via js are not covered.
+//
+// The alert, confirm, and prompt dialogs are not affected, use [Page.HandleDialog] for them.
+// Call restore to turn it off, the current document keeps the overrides until it's reloaded.
+func (p *Page) SuppressNativeUI() (restore func() error, err error) {
+	js := "(" + suppressNativeUIJS + ")()"
+
+	_, err = p.Evaluate(Eval(suppressNativeUIJS))
+	if err != nil {
+		return
+	}
+
+	remove, err := p.EvalOnNewDocument(js)
+	if err != nil {
+		return
+	}
+
+	restoreDomain := p.EnableDomain(&proto.PageEnable{})
+
+	p, cancel := p.WithCancel()
+	go p.EachEvent(func(e *proto.PageJavascriptDialogOpening) {
+		if e.Type == proto.PageDialogTypeBeforeunload {
+			_ = proto.PageHandleJavaScriptDialog{Accept: true}.Call(p)
+		}
+	})()
+
+	restore = func() error {
+		cancel()
+		restoreDomain()
+		return remove()
+	}
+
+	return
+}
+
+// OnExternalProtocol intercepts the navigations to the external protocols, such as "mailto:", "tel:", or custom
+// schemes like "zoommtg:", and reports the url to the handler, instead of opening the prompt of the os to launch
+// an external application, or failing silently in headless mode. Check [IsExternalProtocol] for the schemes.
+// The clicks on the links and window.open are canceled, the navigations via js, such as assigning the
+// location.href, are reported but can't be canceled. The handler is called sequentially in a background goroutine.
+// Call stop to turn it off.
+func (p *Page) OnExternalProtocol(handler func(u string)) (stop func() error, err error) {
+	bind := "_" + utils.RandString(8)
+
+	err = proto.RuntimeAddBinding{Name: bind}.Call(p)
+	if err != nil {
+		return
+	}
+
+	js := fmt.Sprintf(`(%s)("%s")`, onExternalProtocolJS, bind)
+
+	_, err = p.Evaluate(Eval(onExternalProtocolJS, bind))
+	if err != nil {
+		return
+	}
+
+	remove, err := p.EvalOnNewDocument(js)
+	if err != nil {
+		return
+	}
+
+	restoreDomain := p.EnableDomain(&proto.PageEnable{})
+
+	p, cancel := p.WithCancel()
+	go p.EachEvent(func(e *proto.RuntimeBindingCalled) {
+		if e.Name == bind && IsExternalProtocol(e.Payload) {
+			handler(e.Payload)
+		}
+	}, func(e *proto.PageFrameRequestedNavigation) {
+		if IsExternalProtocol(e.URL) {
+			handler(e.URL)
+		}
+	})()
+
+	stop = func() error {
+		cancel()
+		restoreDomain()
+		err := remove()
+		if err != nil {
+			return err
+		}
+		return proto.RuntimeRemoveBinding{Name: bind}.Call(p)
+	}
+
+	return
+}
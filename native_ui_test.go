@@ -0,0 +1,75 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/xyjwsj/grod"
+)
+
+func TestSuppressNativeUI(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.blank())
+	restore := p.MustSuppressNativeUI()
+
+	p.MustNavigate(g.blank()).MustWaitLoad()
+
+	// it should not open the print dialog and hang
+	p.MustEval(`() => window.print()`)
+
+	p.MustEval(`() => {
+		const a = document.createElement('a')
+		a.href = 'mailto:a@b.com'
+		a.textContent = 'mail'
+		document.body.appendChild(a)
+	}`)
+	p.MustElement("a").MustClick()
+	g.Eq(p.MustInfo().URL, g.blank())
+
+	// the beforeunload dialog should be accepted
+	p.MustEval(`() => window.onbeforeunload = (e) => { e.preventDefault(); return e.returnValue = 'leave?' }`)
+	p.MustElement("body").MustClick()
+	p.MustNavigate(g.srcFile("fixtures/click.html")).MustWaitLoad()
+	g.Has(p.MustInfo().URL, "click.html")
+
+	restore()
+}
+
+func TestIsExternalProtocol(t *testing.T) {
+	g := setup(t)
+
+	g.True(rod.IsExternalProtocol("mailto:a@b.com"))
+	g.True(rod.IsExternalProtocol("tel:+100"))
+	g.True(rod.IsExternalProtocol("zoommtg://zoom.us/join"))
+	g.False(rod.IsExternalProtocol("HTTPS://a.com"))
+	g.False(rod.IsExternalProtocol("about:blank"))
+	g.False(rod.IsExternalProtocol("/relative"))
+}
+
+func TestOnExternalProtocol(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.blank())
+
+	urls := make(chan string, 3)
+	stop := p.MustOnExternalProtocol(func(u string) { urls <- u })
+
+	p.MustNavigate(g.blank()).MustWaitLoad()
+	p.MustEval(`() => {
+		const a = document.createElement('a')
+		a.href = 'mailto:a@b.com'
+		a.textContent = 'mail'
+		document.body.appendChild(a)
+	}`)
+	p.MustElement("a").MustClick()
+	g.Eq(<-urls, "mailto:a@b.com")
+	g.Eq(p.MustInfo().URL, g.blank())
+
+	g.Nil(p.MustEval(`() => window.open('tel:+100')`).Val())
+	g.Eq(<-urls, "tel:+100")
+
+	p.MustEval(`() => location.href = 'zoommtg://zoom.us/join'`)
+	g.Eq(<-urls, "zoommtg://zoom.us/join")
+
+	stop()
+}
@@ -0,0 +1,788 @@
+// This file contains the helpers to observe the network activities of a page.
+
+package rod
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// NetworkRequest is a request observed on the page and its response.
+type NetworkRequest struct {
+	ID       proto.NetworkRequestID
+	Request  *proto.NetworkRequest
+	Response *proto.NetworkResponse
+
+	// Initiator of the request
+	Initiator *proto.NetworkInitiator
+
+	// Action is the last input action, such as "left click <button#submit>", performed on the page before the request is sent.
+	// It's only set for the requests recorded by [Page.TrackRequests].
+	Action string
+
+	// Type of the resource, such as "XHR"
+	Type proto.NetworkResourceType
+
+	// Start is the wall time when the request is sent. It's only set for the requests recorded by [Page.TrackRequests].
+	Start time.Time
+
+	// Duration from the request is sent to the loading is finished or failed, 0 if it's still loading.
+	// It's only set for the requests recorded by [Page.TrackRequests].
+	Duration time.Duration
+
+	// ErrorText is set if the loading failed. It's only set for the requests recorded by [Page.TrackRequests].
+	ErrorText string
+
+	// Body of the response
+	Body []byte
+}
+
+// JSONBody of the response.
+func (r *NetworkRequest) JSONBody() gson.JSON {
+	return gson.New(r.Body)
+}
+
+// ResponseMatcher for [Page.WaitResponse]. The zero value of a field matches everything.
+type ResponseMatcher struct {
+	// URL is a regexp to match the url of the request
+	URL string
+
+	// Method of the request, such as "POST"
+	Method string
+
+	// Status code of the response
+	Status int
+
+	// Body is a predicate of the response body
+	Body func(body []byte) bool
+}
+
+// WaitResponse returns a wait function that waits until a response that matches the m is loaded.
+// The wait function returns the matched request with the response body.
+// Call it before the action that triggers the request, such as:
+//
+//	wait := page.WaitResponse(&rod.ResponseMatcher{URL: `/api/items$`, Method: "POST"})
+//	page.MustElement("button").MustClick()
+//	res, err := wait()
+func (p *Page) WaitResponse(m *ResponseMatcher) func() (*NetworkRequest, error) {
+	var reg *regexp.Regexp
+	if m.URL != "" {
+		reg = regexp.MustCompile(m.URL)
+	}
+
+	p, cancel := p.WithCancel()
+	requests := map[proto.NetworkRequestID]*NetworkRequest{}
+	var matched *NetworkRequest
+
+	wait := p.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		if (reg == nil || reg.MatchString(e.Request.URL)) &&
+			(m.Method == "" || strings.EqualFold(m.Method, e.Request.Method)) {
+			requests[e.RequestID] = &NetworkRequest{ID: e.RequestID, Request: e.Request, Initiator: e.Initiator, Type: e.Type}
+		} else {
+			// the redirected url may not match any more
+			delete(requests, e.RequestID)
+		}
+	}, func(e *proto.NetworkResponseReceived) {
+		r, has := requests[e.RequestID]
+		if !has {
+			return
+		}
+
+		if m.Status != 0 && e.Response.Status != m.Status {
+			delete(requests, e.RequestID)
+			return
+		}
+
+		r.Response = e.Response
+	}, func(e *proto.NetworkLoadingFinished) bool {
+		r, has := requests[e.RequestID]
+		if !has || r.Response == nil {
+			return false
+		}
+		delete(requests, e.RequestID)
+
+		body, err := p.responseBody(e.RequestID)
+		if err != nil && m.Body != nil {
+			return false
+		}
+		r.Body = body
+
+		if m.Body != nil && !m.Body(body) {
+			return false
+		}
+
+		matched = r
+		return true
+	}, func(e *proto.NetworkLoadingFailed) {
+		delete(requests, e.RequestID)
+	})
+
+	return func() (*NetworkRequest, error) {
+		defer p.tryTrace(TraceTypeWait, "response", m.URL)()
+		defer cancel()
+
+		wait()
+
+		if matched == nil {
+			return nil, p.ctx.Err()
+		}
+		return matched, nil
+	}
+}
+
+func (p *Page) responseBody(id proto.NetworkRequestID) ([]byte, error) {
+	res, err := proto.NetworkGetResponseBody{RequestID: id}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Base64Encoded {
+		return base64.StdEncoding.DecodeString(res.Body)
+	}
+	return []byte(res.Body), nil
+}
+
+// ResponseBodyCacheOptions for [Page.CacheResponseBodies].
+type ResponseBodyCacheOptions struct {
+	// MaxTotalSize of the cached bodies in bytes, the oldest bodies are evicted when it's exceeded.
+	// It's also used as the browser side buffer size of the network payloads. Defaults to 64MB.
+	MaxTotalSize int
+
+	// MaxBodySize in bytes, a bigger body won't be cached. Defaults to 8MB.
+	MaxBodySize int
+
+	// TTL of a cached body, 0 means the body never expires.
+	TTL time.Duration
+}
+
+// ResponseBodyCacheStats is the memory accounting of [Page.CacheResponseBodies].
+type ResponseBodyCacheStats struct {
+	// Count of the cached bodies
+	Count int
+
+	// Size of the cached bodies in bytes
+	Size int
+
+	// Evicted is the total number of the bodies evicted by the limits or the TTL
+	Evicted int
+
+	// Skipped is the total number of the bodies that are bigger than the MaxBodySize
+	Skipped int
+}
+
+// CacheResponseBodies caches the response bodies of the page on the rod side until stop is called,
+// so that [Page.ResponseBody] still works after the browser discards them.
+// The cache is bounded by the opts, the opts can be nil. Calling it again before stop replaces the options.
+func (p *Page) CacheResponseBodies(opts *ResponseBodyCacheOptions) (stop func()) {
+	if opts == nil {
+		opts = &ResponseBodyCacheOptions{}
+	}
+	o := *opts
+	if o.MaxTotalSize <= 0 {
+		o.MaxTotalSize = 64 * 1024 * 1024
+	}
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = 8 * 1024 * 1024
+	}
+	p.bodies.setOptions(o)
+
+	prev := proto.NetworkEnable{}
+	enabled := p.LoadState(&prev)
+	_ = proto.NetworkEnable{
+		MaxTotalBufferSize:    gson.Int(o.MaxTotalSize),
+		MaxResourceBufferSize: gson.Int(o.MaxBodySize),
+	}.Call(p)
+
+	page, cancel := p.WithCancel()
+
+	wait := page.EachEvent(func(e *proto.NetworkLoadingFinished) {
+		if int(e.EncodedDataLength) > o.MaxBodySize {
+			page.bodies.skip()
+			return
+		}
+
+		body, err := page.responseBody(e.RequestID)
+		if err == nil {
+			page.bodies.put(e.RequestID, body)
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait()
+	}()
+
+	return func() {
+		cancel()
+		<-done
+
+		// restore the previous state via the uncanceled page
+		if enabled {
+			_ = prev.Call(p)
+		} else {
+			_ = proto.NetworkDisable{}.Call(p)
+		}
+	}
+}
+
+// ResponseBody returns the response body of the request, it's read from the cache of [Page.CacheResponseBodies]
+// first, if it's not cached it will be fetched from the browser.
+func (p *Page) ResponseBody(id proto.NetworkRequestID) ([]byte, error) {
+	if body, has := p.bodies.get(id); has {
+		return body, nil
+	}
+	return p.responseBody(id)
+}
+
+// EvictResponseBodies removes all the bodies cached by [Page.CacheResponseBodies].
+func (p *Page) EvictResponseBodies() {
+	p.bodies.evictAll()
+}
+
+// ResponseBodyCacheStats returns the memory accounting of [Page.CacheResponseBodies].
+func (p *Page) ResponseBodyCacheStats() ResponseBodyCacheStats {
+	p.bodies.lock.Lock()
+	defer p.bodies.lock.Unlock()
+	return p.bodies.stats
+}
+
+type cachedBody struct {
+	id   proto.NetworkRequestID
+	body []byte
+	at   time.Time
+}
+
+type bodyCache struct {
+	lock  sync.Mutex
+	opts  ResponseBodyCacheOptions
+	list  []*cachedBody // the oldest first
+	index map[proto.NetworkRequestID]*cachedBody
+	stats ResponseBodyCacheStats
+}
+
+func (c *bodyCache) setOptions(opts ResponseBodyCacheOptions) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.opts = opts
+	if c.index == nil {
+		c.index = map[proto.NetworkRequestID]*cachedBody{}
+	}
+	c.evict(0)
+}
+
+func (c *bodyCache) put(id proto.NetworkRequestID, body []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(body) > c.opts.MaxBodySize {
+		c.stats.Skipped++
+		return
+	}
+
+	c.remove(id)
+	c.evict(len(body))
+
+	item := &cachedBody{id: id, body: body, at: time.Now()}
+	c.list = append(c.list, item)
+	c.index[id] = item
+	c.stats.Count++
+	c.stats.Size += len(body)
+}
+
+func (c *bodyCache) get(id proto.NetworkRequestID) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.evict(0)
+
+	item, has := c.index[id]
+	if !has {
+		return nil, false
+	}
+	return item.body, true
+}
+
+func (c *bodyCache) skip() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Skipped++
+}
+
+func (c *bodyCache) evictAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.stats.Evicted += len(c.list)
+	c.stats.Count = 0
+	c.stats.Size = 0
+	c.list = nil
+	c.index = map[proto.NetworkRequestID]*cachedBody{}
+}
+
+// evict the expired bodies and the oldest bodies until there's room for the incoming size.
+func (c *bodyCache) evict(incoming int) {
+	now := time.Now()
+	for len(c.list) > 0 {
+		oldest := c.list[0]
+		expired := c.opts.TTL > 0 && now.Sub(oldest.at) > c.opts.TTL
+		if !expired && c.stats.Size+incoming <= c.opts.MaxTotalSize {
+			return
+		}
+		c.remove(oldest.id)
+		c.stats.Evicted++
+	}
+}
+
+func (c *bodyCache) remove(id proto.NetworkRequestID) {
+	item, has := c.index[id]
+	if !has {
+		return
+	}
+	delete(c.index, id)
+
+	for i, it := range c.list {
+		if it == item {
+			c.list = append(c.list[:i], c.list[i+1:]...)
+			break
+		}
+	}
+	c.stats.Count--
+	c.stats.Size -= len(item.body)
+}
+
+// RequestCheckpoint is a position in the requests recorded by [Page.TrackRequests].
+type RequestCheckpoint int
+
+// TrackRequests records the requests of the page until stop is called.
+// Each request is tagged with the last input action performed on the page before the request is sent,
+// so that we can tell which requests are caused by an action, such as:
+//
+//	stop := page.TrackRequests()
+//	defer stop()
+//	checkpoint := page.Checkpoint()
+//	page.MustElement("button").MustClick()
+//	page.MustWaitRequestIdle()()
+//	list := page.RequestsSince(checkpoint)
+//
+// Calling it again before the stop is called is a no-op.
+// Only the latest 1000 requests are kept, the older requests are evicted once they are finished.
+func (p *Page) TrackRequests() (stop func()) {
+	t := p.requests
+	if !t.start() {
+		return func() {}
+	}
+
+	p, cancel := p.WithCancel()
+
+	wait := p.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		t.add(e)
+	}, func(e *proto.NetworkResponseReceived) {
+		t.respond(e)
+	}, func(e *proto.NetworkLoadingFinished) {
+		t.finish(e.RequestID, e.Timestamp, "")
+	}, func(e *proto.NetworkLoadingFailed) {
+		t.finish(e.RequestID, e.Timestamp, e.ErrorText)
+	})
+
+	go wait()
+
+	return func() {
+		cancel()
+		t.stop()
+	}
+}
+
+// Checkpoint returns the current position of the requests recorded by [Page.TrackRequests].
+func (p *Page) Checkpoint() RequestCheckpoint {
+	p.requests.lock.Lock()
+	defer p.requests.lock.Unlock()
+	return RequestCheckpoint(p.requests.next)
+}
+
+// RequestsSince returns the copies of the requests recorded by [Page.TrackRequests] after the checkpoint.
+func (p *Page) RequestsSince(c RequestCheckpoint) []*NetworkRequest {
+	return p.requests.snapshot(int(c))
+}
+
+// Request returns a copy of the request recorded by [Page.TrackRequests] by its id, nil if it's not found.
+// The id is stable across redirects, the request is updated to the last redirect.
+func (p *Page) Request(id proto.NetworkRequestID) *NetworkRequest {
+	p.requests.lock.Lock()
+	defer p.requests.lock.Unlock()
+
+	r, has := p.requests.index[id]
+	if !has {
+		return nil
+	}
+	c := *r
+	return &c
+}
+
+// RequestFilter for [Page.Requests]. The zero value of a field matches everything.
+type RequestFilter struct {
+	// URL is a regexp to match the url of the request
+	URL string
+
+	// Method of the request, such as "POST"
+	Method string
+
+	// Type of the resource, such as "XHR"
+	Type proto.NetworkResourceType
+
+	// Status code of the response
+	Status int
+}
+
+// Requests returns the copies of the requests recorded by [Page.TrackRequests] that match the filter
+// in the order they are sent. The filter can be nil. It returns an error if the URL of the filter isn't a valid regexp.
+func (p *Page) Requests(filter *RequestFilter) ([]*NetworkRequest, error) {
+	if filter == nil {
+		filter = &RequestFilter{}
+	}
+
+	var reg *regexp.Regexp
+	if filter.URL != "" {
+		var err error
+		reg, err = regexp.Compile(filter.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	list := []*NetworkRequest{}
+	for _, r := range p.requests.snapshot(0) {
+		if (reg == nil || reg.MatchString(r.Request.URL)) &&
+			(filter.Method == "" || strings.EqualFold(filter.Method, r.Request.Method)) &&
+			(filter.Type == "" || filter.Type == r.Type) &&
+			(filter.Status == 0 || (r.Response != nil && r.Response.Status == filter.Status)) {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+// requestTrackerLimit is the max number of the requests the requestTracker keeps,
+// the unfinished requests are not evicted.
+const requestTrackerLimit = 1000
+
+type requestTracker struct {
+	lock     sync.Mutex
+	tracking bool
+	action   string
+	next     int // the sequence number of the next request
+	list     []*trackedRequest
+	index    map[proto.NetworkRequestID]*NetworkRequest
+	sent     map[proto.NetworkRequestID]proto.MonotonicTime
+}
+
+// trackedRequest is a request with its sequence number, the [RequestCheckpoint] is a sequence number.
+type trackedRequest struct {
+	seq int
+	req *NetworkRequest
+}
+
+func (t *requestTracker) start() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.tracking {
+		return false
+	}
+	t.tracking = true
+	if t.index == nil {
+		t.index = map[proto.NetworkRequestID]*NetworkRequest{}
+		t.sent = map[proto.NetworkRequestID]proto.MonotonicTime{}
+	}
+	return true
+}
+
+// snapshot returns the copies of the requests from the index, so the callers won't race with the tracker.
+func (t *requestTracker) snapshot(from int) []*NetworkRequest {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	list := []*NetworkRequest{}
+	for _, r := range t.list {
+		if r.seq >= from {
+			c := *r.req
+			list = append(list, &c)
+		}
+	}
+	return list
+}
+
+func (t *requestTracker) stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.tracking = false
+	t.action = ""
+}
+
+// breadcrumb records the action so that the following requests can be tagged with it.
+func (t *requestTracker) breadcrumb(action string) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.tracking {
+		t.action = action
+	}
+}
+
+func (t *requestTracker) add(e *proto.NetworkRequestWillBeSent) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// a redirect reuses the request id
+	if r, has := t.index[e.RequestID]; has {
+		r.Request = e.Request
+		return
+	}
+
+	r := &NetworkRequest{
+		ID:        e.RequestID,
+		Request:   e.Request,
+		Initiator: e.Initiator,
+		Action:    t.action,
+		Type:      e.Type,
+		Start:     e.WallTime.Time(),
+	}
+	t.index[e.RequestID] = r
+	t.sent[e.RequestID] = e.Timestamp
+	t.list = append(t.list, &trackedRequest{t.next, r})
+	t.next++
+}
+
+func (t *requestTracker) finish(id proto.NetworkRequestID, at proto.MonotonicTime, errText string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if r, has := t.index[id]; has {
+		r.Duration = (at - t.sent[id]).Duration()
+		r.ErrorText = errText
+		delete(t.sent, id)
+		t.evict()
+	}
+}
+
+// evict removes the oldest finished requests until there are at most requestTrackerLimit requests.
+func (t *requestTracker) evict() {
+	over := len(t.list) - requestTrackerLimit
+	if over <= 0 {
+		return
+	}
+
+	list := make([]*trackedRequest, 0, len(t.list)-over)
+	for _, r := range t.list {
+		if _, pending := t.sent[r.req.ID]; over > 0 && !pending {
+			delete(t.index, r.req.ID)
+			over--
+			continue
+		}
+		list = append(list, r)
+	}
+	t.list = list
+}
+
+func (t *requestTracker) respond(e *proto.NetworkResponseReceived) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if r, has := t.index[e.RequestID]; has {
+		r.Response = e.Response
+	}
+}
+
+// TrafficRecordType of [TrafficRecord].
+type TrafficRecordType string
+
+const (
+	// TrafficRecordTypeRequest type.
+	TrafficRecordTypeRequest TrafficRecordType = "request"
+	// TrafficRecordTypeResponse type.
+	TrafficRecordTypeResponse TrafficRecordType = "response"
+	// TrafficRecordTypeFailed type.
+	TrafficRecordTypeFailed TrafficRecordType = "failed"
+)
+
+// TrafficRecord is a line of the NDJSON stream of [Browser.MirrorTraffic].
+type TrafficRecord struct {
+	Type      TrafficRecordType      `json:"type"`
+	Time      time.Time              `json:"time"`
+	SessionID proto.TargetSessionID  `json:"sessionId"`
+	RequestID proto.NetworkRequestID `json:"requestId"`
+
+	URL          string                    `json:"url,omitempty"`
+	Method       string                    `json:"method,omitempty"`
+	ResourceType proto.NetworkResourceType `json:"resourceType,omitempty"`
+	Headers      proto.NetworkHeaders      `json:"headers,omitempty"`
+	PostData     string                    `json:"postData,omitempty"`
+
+	Status        int    `json:"status,omitempty"`
+	MIMEType      string `json:"mimeType,omitempty"`
+	RemoteAddress string `json:"remoteAddress,omitempty"`
+
+	// Protocol negotiated for the response, such as "h2" or "h3"
+	Protocol         string `json:"protocol,omitempty"`
+	ConnectionReused bool   `json:"connectionReused,omitempty"`
+
+	// Body of the response, it's base64 encoded in the json
+	Body []byte `json:"body,omitempty"`
+
+	ErrorText string `json:"errorText,omitempty"`
+}
+
+// MirrorTraffic streams the network traffic of the pages of the browser to w in NDJSON format,
+// each line is a [TrafficRecord]. If bodies is true, the response bodies will be included.
+// Only the pages that are controlled by rod will be mirrored. Call stop to end the mirroring.
+func (b *Browser) MirrorTraffic(w io.Writer, bodies bool) (stop func()) {
+	b, cancel := b.WithCancel()
+	enc := json.NewEncoder(w)
+
+	restores := []func(){}
+	enable := func(id proto.TargetSessionID) {
+		restores = append(restores, b.EnableDomain(id, &proto.NetworkEnable{}))
+	}
+
+	b.states.Range(func(_, v interface{}) bool {
+		if p, ok := v.(*Page); ok {
+			enable(p.SessionID)
+		}
+		return true
+	})
+
+	responses := map[proto.NetworkRequestID]*TrafficRecord{}
+
+	// to get the response body of a session
+	sessions := map[proto.TargetSessionID]*Page{}
+	session := func(id proto.TargetSessionID) *Page {
+		if _, has := sessions[id]; !has {
+			sessions[id] = b.PageFromSession(id)
+		}
+		return sessions[id]
+	}
+
+	write := func(r *TrafficRecord) {
+		r.Time = time.Now()
+		_ = enc.Encode(r)
+	}
+
+	wait := b.eachEvent("", func(e *proto.TargetAttachedToTarget) {
+		if e.TargetInfo.Type == proto.TargetTargetInfoTypePage {
+			enable(e.SessionID)
+		}
+	}, func(e *proto.NetworkRequestWillBeSent, id proto.TargetSessionID) {
+		write(&TrafficRecord{
+			Type:         TrafficRecordTypeRequest,
+			SessionID:    id,
+			RequestID:    e.RequestID,
+			URL:          e.Request.URL,
+			Method:       e.Request.Method,
+			ResourceType: e.Type,
+			Headers:      e.Request.Headers,
+			PostData:     e.Request.PostData,
+		})
+	}, func(e *proto.NetworkResponseReceived, id proto.TargetSessionID) {
+		r := &TrafficRecord{
+			Type:          TrafficRecordTypeResponse,
+			SessionID:     id,
+			RequestID:     e.RequestID,
+			URL:           e.Response.URL,
+			ResourceType:  e.Type,
+			Headers:       e.Response.Headers,
+			Status:        e.Response.Status,
+			MIMEType:      e.Response.MIMEType,
+			RemoteAddress: e.Response.RemoteIPAddress,
+
+			Protocol:         e.Response.Protocol,
+			ConnectionReused: e.Response.ConnectionReused,
+		}
+
+		if !bodies {
+			write(r)
+			return
+		}
+
+		// the body is only available after the loading is finished
+		responses[e.RequestID] = r
+	}, func(e *proto.NetworkLoadingFinished, id proto.TargetSessionID) {
+		r, has := responses[e.RequestID]
+		if !has {
+			return
+		}
+		delete(responses, e.RequestID)
+
+		r.Body, _ = session(id).responseBody(e.RequestID)
+		write(r)
+	}, func(e *proto.NetworkLoadingFailed, id proto.TargetSessionID) {
+		if r, has := responses[e.RequestID]; has {
+			delete(responses, e.RequestID)
+			write(r)
+		}
+
+		write(&TrafficRecord{
+			Type:         TrafficRecordTypeFailed,
+			SessionID:    id,
+			RequestID:    e.RequestID,
+			ResourceType: e.Type,
+			ErrorText:    e.ErrorText,
+		})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait()
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
+
+// MirrorTrafficTo is similar to [Browser.MirrorTraffic], but it streams the records to the collectorURL
+// via a single chunked HTTP POST request with the "application/x-ndjson" content type.
+// The stop returns the error of the request.
+func (b *Browser) MirrorTrafficTo(collectorURL string, bodies bool) (stop func() error) {
+	r, w := io.Pipe()
+
+	errCh := make(chan error, 1)
+	go func() {
+		res, err := http.Post(collectorURL, "application/x-ndjson", r) //nolint: noctx
+		if err == nil {
+			_ = res.Body.Close()
+			if res.StatusCode >= http.StatusBadRequest {
+				err = fmt.Errorf("collector responded with: %s", res.Status)
+			}
+		}
+		_ = r.CloseWithError(err)
+		errCh <- err
+	}()
+
+	stopMirror := b.MirrorTraffic(w, bodies)
+
+	return func() error {
+		stopMirror()
+		_ = w.Close()
+		return <-errCh
+	}
+}
@@ -0,0 +1,383 @@
+package rod_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/got"
+	"github.com/ysmood/gson"
+)
+
+func TestPageWaitResponse(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><button onclick="
+		fetch('/a', {method: 'POST'}).then(() => fetch('/b', {method: 'POST'}))
+	">ok</button></html>`)
+	s.Route("/a", ".json", `{"id": 1}`)
+	s.Mux.HandleFunc("/b", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 2}`))
+	})
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+
+	{
+		wait := p.MustWaitResponse(&rod.ResponseMatcher{URL: `/(a|b)$`, Method: "post", Status: http.StatusCreated})
+		p.MustElement("button").MustClick()
+		res := wait()
+		g.Has(res.Request.URL, "/b")
+		g.Eq(res.JSONBody().Get("id").Int(), 2)
+	}
+
+	{
+		wait := p.MustWaitResponse(&rod.ResponseMatcher{Body: func(body []byte) bool {
+			return string(body) == `{"id": 1}`
+		}})
+		p.MustElement("button").MustClick()
+		g.Has(wait().Request.URL, "/a")
+	}
+
+	{
+		wait := p.Timeout(time.Second).WaitResponse(&rod.ResponseMatcher{URL: `not-exists`})
+		p.MustElement("button").MustClick()
+		_, err := wait()
+		g.Is(err, context.DeadlineExceeded)
+	}
+
+	{
+		wait := p.Timeout(time.Second).WaitResponse(&rod.ResponseMatcher{URL: `/a$`, Body: func([]byte) bool {
+			return true
+		}})
+		g.mc.stubErr(1, proto.NetworkGetResponseBody{})
+		p.MustElement("button").MustClick()
+		_, err := wait()
+		g.Is(err, context.DeadlineExceeded)
+	}
+}
+
+func TestPageTrackRequests(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html>
+		<button id="a" onclick="fetch('/a')">a</button>
+		<button id="b" onclick="fetch('/b')">b</button>
+	</html>`)
+	s.Route("/a", ".txt", "a")
+	s.Route("/b", ".txt", "b")
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+
+	stop := p.TrackRequests()
+	defer stop()
+
+	// no-op when it is already tracking
+	p.TrackRequests()()
+
+	a := p.Checkpoint()
+
+	wait := p.MustWaitResponse(&rod.ResponseMatcher{URL: `/a$`})
+	p.MustElement("#a").MustClick()
+	wait()
+
+	b := p.Checkpoint()
+
+	wait = p.MustWaitResponse(&rod.ResponseMatcher{URL: `/b$`})
+	p.MustElement("#b").MustClick()
+	wait()
+
+	list := p.RequestsSince(a)
+	g.Len(list, 2)
+	g.Has(list[0].Request.URL, "/a")
+	g.Has(list[0].Action, "left click")
+	g.Has(list[0].Action, "button#a")
+	g.Eq(list[0].Initiator.Type, proto.NetworkInitiatorTypeScript)
+	g.Eq(list[0].Response.Status, http.StatusOK)
+
+	list = p.RequestsSince(b)
+	g.Len(list, 1)
+	g.Has(list[0].Action, "button#b")
+
+	g.Len(p.RequestsSince(p.Checkpoint()), 0)
+
+	a1 := p.Request(list[0].ID)
+	g.Has(a1.Request.URL, "/b")
+	g.Eq(a1.Type, proto.NetworkResourceTypeFetch)
+	g.False(a1.Start.IsZero())
+	g.Nil(p.Request("not-exists"))
+
+	// the results are copies, changing them won't affect the tracker
+	a1.Action = "changed"
+	list[0].Action = "changed"
+	g.Has(p.Request(a1.ID).Action, "button#b")
+	g.Has(p.RequestsSince(b)[0].Action, "button#b")
+
+	g.Len(p.MustRequests(nil), 2)
+	g.Len(p.MustRequests(&rod.RequestFilter{URL: `/a$`, Method: "get", Status: http.StatusOK}), 1)
+	g.Len(p.MustRequests(&rod.RequestFilter{Type: proto.NetworkResourceTypeDocument}), 0)
+	g.Len(p.MustRequests(&rod.RequestFilter{Status: http.StatusNotFound}), 0)
+
+	_, err := p.Requests(&rod.RequestFilter{URL: `(`})
+	g.Err(err)
+
+	// the oldest finished requests are evicted
+	c := p.Checkpoint()
+	p.MustEval(`async () => { for (let i = 0; i < 1000; i++) await (await fetch('/b')).text() }`)
+	for list := p.RequestsSince(c); len(list) < 1000 || list[999].Duration == 0; list = p.RequestsSince(c) {
+		utils.Sleep(0.1)
+	}
+	g.Len(p.RequestsSince(c), 1000)
+	g.Len(p.MustRequests(nil), 1000)
+	g.Len(p.MustRequests(&rod.RequestFilter{URL: `/a$`}), 0)
+}
+
+func TestNewConnectionInfo(t *testing.T) {
+	g := got.T(t)
+
+	port := 443
+	alg := int(tls.ECDSAWithP256AndSHA256)
+	c := rod.NewConnectionInfo(&proto.NetworkResponse{
+		Protocol:         "h3-29",
+		ConnectionID:     7,
+		ConnectionReused: true,
+		RemoteIPAddress:  "::1",
+		RemotePort:       &port,
+		SecurityDetails: &proto.NetworkSecurityDetails{
+			Protocol:                 "QUIC",
+			Cipher:                   "AES_128_GCM",
+			ServerSignatureAlgorithm: &alg,
+		},
+	})
+
+	g.Eq(c.Protocol, rod.NetworkProtocolH3)
+	g.Eq(c.RawProtocol, "h3-29")
+	g.True(c.QUIC())
+	g.True(c.Reused)
+	g.Eq(c.ConnectionID, 7.0)
+	g.Eq(c.RemoteAddress, "[::1]:443")
+	g.Eq(c.TLS, "QUIC")
+	g.Eq(c.CertKeyType, rod.CertKeyTypeECDSA)
+
+	for raw, p := range map[string]rod.NetworkProtocol{
+		"http/1.0":      rod.NetworkProtocolHTTP1,
+		"http/1.1":      rod.NetworkProtocolHTTP1,
+		"h2":            rod.NetworkProtocolH2,
+		"quic/1+spdy/3": rod.NetworkProtocolQUIC,
+		"data":          rod.NetworkProtocolOther,
+	} {
+		g.Eq(rod.NewConnectionInfo(&proto.NetworkResponse{Protocol: raw}).Protocol, p)
+	}
+
+	g.Nil((&rod.NetworkRequest{}).Connection())
+}
+
+func TestPageConnectionStats(t *testing.T) {
+	g := setup(t)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = fmt.Fprint(w, `<html><script>fetch('/a').then(() => fetch('/b'))</script></html>`)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	}))
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	defer s.Close()
+
+	g.browser.MustIgnoreCertErrors(true)
+	defer g.browser.MustIgnoreCertErrors(false)
+
+	p := g.newPage()
+	stop := p.TrackRequests()
+	defer stop()
+
+	// it's safe to read the stats while the requests are being tracked, run it with -race to check it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = p.ConnectionStats()
+		}
+	}()
+
+	wait := p.MustWaitResponse(&rod.ResponseMatcher{URL: `/b$`})
+	p.MustNavigate(s.URL)
+	wait()
+	<-done
+
+	c := p.MustRequests(&rod.RequestFilter{URL: `/a$`})[0].Connection()
+	g.Eq(c.Protocol, rod.NetworkProtocolH2)
+	g.False(c.QUIC())
+	g.Has(c.TLS, "TLS")
+	g.Eq(c.CertKeyType, rod.CertKeyTypeRSA)
+
+	stats := p.ConnectionStats()
+	g.Len(stats, 1)
+	g.Eq(stats[0].Host, s.Listener.Addr().String())
+	g.Gte(stats[0].Requests, 3)
+	g.Eq(stats[0].Protocols[rod.NetworkProtocolH2], stats[0].Requests)
+	g.Gte(stats[0].Reused, 1)
+	g.Lt(stats[0].Connections, stats[0].Requests)
+}
+
+func TestPageCacheResponseBodies(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html>
+		<button id="a" onclick="fetch('/a')">a</button>
+		<button id="b" onclick="fetch('/b')">b</button>
+		<button id="big" onclick="fetch('/big')">big</button>
+	</html>`)
+	s.Route("/a", ".txt", strings.Repeat("a", 10))
+	s.Route("/b", ".txt", strings.Repeat("b", 10))
+	s.Route("/big", ".txt", strings.Repeat("c", 100))
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+
+	stop := p.CacheResponseBodies(&rod.ResponseBodyCacheOptions{MaxTotalSize: 15, MaxBodySize: 50})
+
+	processed := func() int {
+		stats := p.ResponseBodyCacheStats()
+		return stats.Count + stats.Evicted + stats.Skipped
+	}
+
+	// click the button and wait for the response to be processed by the cache
+	click := func(id string) *rod.NetworkRequest {
+		n := processed()
+		wait := p.MustWaitResponse(&rod.ResponseMatcher{URL: "/" + id + "$"})
+		p.MustElement("#" + id).MustClick()
+		r := wait()
+		for processed() == n {
+			utils.Sleep(0.05)
+		}
+		return r
+	}
+
+	a := click("a")
+	g.Eq(string(p.MustResponseBody(a.ID)), strings.Repeat("a", 10))
+	g.Eq(p.ResponseBodyCacheStats().Size, 10)
+
+	click("b")
+	stats := p.ResponseBodyCacheStats()
+	g.Eq(stats.Count, 1)
+	g.Eq(stats.Size, 10)
+	g.Eq(stats.Evicted, 1)
+
+	click("big")
+	g.Eq(p.ResponseBodyCacheStats().Skipped, 1)
+
+	p.EvictResponseBodies()
+	g.Eq(p.ResponseBodyCacheStats().Count, 0)
+	g.Eq(p.ResponseBodyCacheStats().Evicted, 2)
+
+	g.mc.stubErr(1, proto.NetworkGetResponseBody{})
+	_, err := p.ResponseBody(a.ID)
+	g.Err(err)
+	// the network domain is disabled after the stop
+	stop()
+	g.False(p.LoadState(&proto.NetworkEnable{}))
+
+	// the buffer sizes of the previous enable are restored
+	g.E(proto.NetworkEnable{MaxTotalBufferSize: gson.Int(1000)}.Call(p))
+	p.CacheResponseBodies(nil)()
+	state := proto.NetworkEnable{}
+	g.True(p.LoadState(&state))
+	g.Eq(*state.MaxTotalBufferSize, 1000)
+	g.Nil(state.MaxResourceBufferSize)
+}
+
+func TestBrowserMirrorTraffic(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><script>fetch('/a').then(() => fetch('http://not-exists.invalid/'))</script></html>`)
+	s.Route("/a", ".txt", "body")
+
+	p := g.newPage()
+
+	buf := bytes.NewBuffer(nil)
+	stop := g.browser.MirrorTraffic(buf, true)
+
+	wait := p.MustWaitResponse(&rod.ResponseMatcher{URL: `/a$`})
+	p.MustNavigate(s.URL())
+	wait()
+	p.MustWaitIdle()
+	stop()
+
+	records := []*rod.TrafficRecord{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		r := &rod.TrafficRecord{}
+		g.E(json.Unmarshal([]byte(line), r))
+		records = append(records, r)
+	}
+
+	find := func(typ rod.TrafficRecordType, url string) *rod.TrafficRecord {
+		for _, r := range records {
+			if r.Type == typ && strings.HasSuffix(r.URL, url) {
+				return r
+			}
+		}
+		return nil
+	}
+
+	g.Eq(find(rod.TrafficRecordTypeRequest, "/a").Method, "GET")
+	g.Eq(find(rod.TrafficRecordTypeResponse, "/a").Status, http.StatusOK)
+	g.Eq(string(find(rod.TrafficRecordTypeResponse, "/a").Body), "body")
+	g.Eq(find(rod.TrafficRecordTypeRequest, "/a").SessionID, p.SessionID)
+
+	failed := false
+	for _, r := range records {
+		if r.Type == rod.TrafficRecordTypeFailed {
+			failed = true
+			g.NotZero(r.ErrorText)
+		}
+	}
+	g.True(failed)
+}
+
+func TestBrowserMirrorTrafficTo(t *testing.T) {
+	g := setup(t)
+
+	received := make(chan string, 1)
+	collector := g.Serve()
+	collector.Mux.HandleFunc("/collect", func(_ http.ResponseWriter, r *http.Request) {
+		g.Eq(r.Header.Get("Content-Type"), "application/x-ndjson")
+		b, err := io.ReadAll(r.Body)
+		g.E(err)
+		received <- string(b)
+	})
+
+	s := g.Serve().Route("/", ".html", `<html>ok</html>`)
+
+	p := g.newPage()
+
+	stop := g.browser.MirrorTrafficTo(collector.URL("/collect"), false)
+	p.MustNavigate(s.URL()).MustWaitLoad()
+	g.E(stop())
+
+	g.Has(<-received, `"type":"response"`)
+
+	collector.Mux.HandleFunc("/bad", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	stop = g.browser.MirrorTrafficTo(collector.URL("/bad"), false)
+	g.Eq(stop().Error(), "collector responded with: 400 Bad Request")
+}
@@ -0,0 +1,244 @@
+// This file contains the helper to automate the OAuth login flows.
+
+package rod
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+)
+
+// OAuthFlow automates the login via an OAuth provider. The provider can be opened in a popup or in the
+// current page, the flow handles both of them.
+//
+//	res, err := (&rod.OAuthFlow{
+//		LoginButton: "#login-with-github",
+//		Credentials: func(provider *rod.Page) error {
+//			provider.MustElement("#login_field").MustInput("user")
+//			provider.MustElement("#password").MustInput("pass")
+//			return provider.MustElement("[type=submit]").Click(proto.InputMouseButtonLeft, 1)
+//		},
+//		RedirectURL: regexp.MustCompile(`^https://app\.example\.com/callback`),
+//	}).Run(page)
+type OAuthFlow struct {
+	// LoginButton is the css selector of the button that starts the login.
+	LoginButton string
+
+	// Credentials fills the credentials on the page of the provider and submits them.
+	// It's skipped if the provider redirects back immediately, such as the user has already logged in.
+	// It's optional, if it's nil the flow only waits for the redirect, such as the provider logs in via the SSO.
+	Credentials func(provider *Page) error
+
+	// RedirectURL matches the url that the provider redirects back to.
+	RedirectURL *regexp.Regexp
+
+	// SecondFactor is optional, it's used to fill the OTP field if the provider asks for it
+	// after the credentials are submitted, check [Page.FillSecondFactor].
+	SecondFactor SecondFactorProvider
+}
+
+// OAuthResult of [OAuthFlow.Run].
+type OAuthResult struct {
+	// URL that the provider redirects back to, it includes the fragment.
+	URL string
+
+	// Params of the query and the fragment of the URL, such as "code", "state", "access_token", and "id_token".
+	Params map[string]string
+
+	// Cookies of the URL when the redirect is captured.
+	Cookies []*proto.NetworkCookie
+
+	// Popup is true if the provider was opened in a popup.
+	Popup bool
+}
+
+// Run the flow on the page: it clicks the login button, adopts the provider page, fills the credentials,
+// then waits for the redirect back and extracts the tokens and cookies.
+// If the provider redirects back with the "error" param, the result and an [OAuthError] will be returned.
+// Use [Page.Timeout] to limit the whole flow.
+func (f *OAuthFlow) Run(p *Page) (*OAuthResult, error) {
+	p, cancel := p.WithCancel()
+	defer cancel()
+
+	redirected := make(chan string, 1)
+	match := func(u string) {
+		if f.RedirectURL.MatchString(u) {
+			select {
+			case redirected <- u:
+			default:
+			}
+		}
+	}
+
+	// watch the redirect in both the response headers and the url fragment
+	watch := func(page *Page) {
+		go page.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+			if e.RedirectResponse != nil {
+				match(e.RedirectResponse.URL)
+			}
+		}, func(e *proto.NetworkResponseReceived) {
+			if e.Type == proto.NetworkResourceTypeDocument {
+				match(e.Response.URL)
+			}
+		}, func(e *proto.PageFrameNavigated) {
+			if e.Frame.ParentID == "" {
+				match(e.Frame.URL + e.Frame.URLFragment)
+			}
+		}, func(e *proto.PageNavigatedWithinDocument) {
+			match(e.URL)
+		})()
+	}
+
+	popup := make(chan proto.TargetTargetID, 1)
+	go p.browser.Context(p.ctx).EachEvent(func(e *proto.TargetTargetCreated) bool {
+		if e.TargetInfo.OpenerID != p.TargetID {
+			return false
+		}
+		popup <- e.TargetInfo.TargetID
+		return true
+	})()
+
+	watch(p)
+
+	info, err := p.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	btn, err := p.Element(f.LoginButton)
+	if err != nil {
+		return nil, err
+	}
+	err = btn.Click(proto.InputMouseButtonLeft, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &OAuthResult{}
+
+	var provider *Page
+	err = utils.Retry(p.ctx, p.sleeper(), func() (bool, error) {
+		select {
+		case id := <-popup:
+			res.Popup = true
+			provider, err = p.browser.Context(p.ctx).PageFromTarget(id)
+			return true, err
+		case u := <-redirected:
+			redirected <- u
+			return true, nil
+		default:
+		}
+
+		current, err := p.Info()
+		if err != nil {
+			return true, err
+		}
+		if current.URL != info.URL {
+			provider = p
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if provider != nil {
+		if res.Popup {
+			watch(provider)
+		}
+
+		select {
+		case u := <-redirected:
+			redirected <- u
+		default:
+			err = provider.WaitLoad()
+			if err != nil {
+				return nil, err
+			}
+
+			if f.Credentials != nil {
+				err = f.Credentials(provider)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			err = f.fillSecondFactor(p, provider, redirected)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	select {
+	case <-p.ctx.Done():
+		return nil, p.ctx.Err()
+	case res.URL = <-redirected:
+	}
+
+	res.Params = oauthParams(res.URL)
+
+	cookies, err := proto.NetworkGetCookies{Urls: []string{res.URL}}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+	res.Cookies = cookies.Cookies
+
+	if res.Params["error"] != "" {
+		return res, &OAuthError{Code: res.Params["error"], Description: res.Params["error_description"]}
+	}
+
+	return res, nil
+}
+
+// fillSecondFactor waits until the provider redirects back or asks for the second factor.
+func (f *OAuthFlow) fillSecondFactor(p, provider *Page, redirected chan string) error {
+	if f.SecondFactor == nil {
+		return nil
+	}
+
+	isRedirected := func() bool {
+		select {
+		case u := <-redirected:
+			redirected <- u
+			return true
+		default:
+			return false
+		}
+	}
+
+	return utils.Retry(p.ctx, p.sleeper(), func() (bool, error) {
+		if isRedirected() {
+			return true, nil
+		}
+
+		filled, err := provider.FillSecondFactor(f.SecondFactor)
+		if err != nil && isRedirected() {
+			// the popup may be closed after the redirect
+			return true, nil
+		}
+		return filled, err
+	})
+}
+
+// oauthParams returns the params of the query and the fragment, the fragment ones take precedence.
+func oauthParams(u string) map[string]string {
+	params := map[string]string{}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return params
+	}
+
+	fragment, _ := url.ParseQuery(parsed.Fragment)
+	for _, values := range []url.Values{parsed.Query(), fragment} {
+		for k, v := range values {
+			params[k] = v[0]
+		}
+	}
+
+	return params
+}
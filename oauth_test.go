@@ -0,0 +1,81 @@
+package rod_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestOAuthFlow(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/popup", ".html", `<html><body>
+		<button onclick="window.open('/provider')">login</button>
+	</body></html>`)
+	s.Route("/redirect", ".html", `<html><body>
+		<button onclick="location.href = '/provider'">login</button>
+	</body></html>`)
+	s.Route("/provider", ".html", `<html><body>
+		<input id="user"><button id="submit" onclick="
+			const user = document.querySelector('#user').value
+			location.href = user === 'deny' ?
+				'/callback?error=access_denied&error_description=denied' :
+				'/callback?code=abc&state=s1#access_token=t1'
+		">submit</button>
+	</body></html>`)
+	s.Route("/sso", ".html", `<html><body>
+		<button onclick="location.href = '/sso-provider'">login</button>
+	</body></html>`)
+	s.Route("/sso-provider", ".html", `<html><body><script>
+		setTimeout(() => { location.href = '/callback?code=sso' }, 500)
+	</script></body></html>`)
+	s.Mux.HandleFunc("/callback", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s2", Path: "/"})
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	login := func(user string) func(provider *rod.Page) error {
+		return func(provider *rod.Page) error {
+			provider.MustElement("#user").MustInput(user)
+			return provider.MustElement("#submit").Click(proto.InputMouseButtonLeft, 1)
+		}
+	}
+
+	flow := &rod.OAuthFlow{
+		LoginButton: "button",
+		Credentials: login("joy"),
+		RedirectURL: regexp.MustCompile(`/callback`),
+	}
+
+	res := flow.MustRun(g.newPage(s.URL("/popup")).MustWaitLoad())
+	g.True(res.Popup)
+	g.Eq(res.Params, map[string]string{"code": "abc", "state": "s1", "access_token": "t1"})
+	g.Has(res.URL, "#access_token=t1")
+
+	res = flow.MustRun(g.newPage(s.URL("/redirect")).MustWaitLoad())
+	g.False(res.Popup)
+	g.Eq(res.Params["code"], "abc")
+	g.Eq(res.Cookies[0].Value, "s2")
+
+	flow.Credentials = login("deny")
+	res, err := flow.Run(g.newPage(s.URL("/redirect")).MustWaitLoad())
+	g.Is(err, &rod.OAuthError{})
+	g.Eq(err.Error(), "oauth error: access_denied: denied")
+	g.Eq(res.Params["error"], "access_denied")
+	g.Eq((&rod.OAuthError{Code: "a"}).Error(), "oauth error: a")
+
+	// the credentials are optional
+	res = (&rod.OAuthFlow{LoginButton: "button", RedirectURL: regexp.MustCompile(`/callback`)}).
+		MustRun(g.newPage(s.URL("/sso")).MustWaitLoad())
+	g.Eq(res.Params["code"], "sso")
+
+	g.Panic(func() {
+		p := g.newPage(s.URL("/redirect")).MustWaitLoad()
+		g.mc.stubErr(1, proto.TargetGetTargetInfo{})
+		flow.MustRun(p)
+	})
+}
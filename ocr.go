@@ -0,0 +1,106 @@
+// This file contains the hooks to recognize the text on the screen with an OCR engine.
+
+package rod
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// OCR is a pluggable engine to recognize the text in an image, such as a wrapper of tesseract
+// or a cloud vision api. Use [Browser.OCR] to set it.
+type OCR interface {
+	// Recognize the text in the png image. The boxes of the returned texts are in the pixels of the image.
+	Recognize(img []byte) ([]*OCRText, error)
+}
+
+// OCRText is a piece of text recognized by the [OCR] engine.
+type OCRText struct {
+	Text string
+
+	// Box of the text
+	Box *proto.DOMRect
+
+	// Confidence of the recognition, from 0 to 1, it's optional for the engine
+	Confidence float64
+}
+
+// OCR sets the engine for the helpers like [Element.TextViaOCR] and [Page.FindTextOnScreen].
+func (b *Browser) OCR(engine OCR) *Browser {
+	b.ocr = engine
+	return b
+}
+
+// TextViaOCR recognizes the text of the element from its screenshot, useful for canvases and images
+// where the DOM text isn't available. The lines are joined with "\n".
+func (el *Element) TextViaOCR() (string, error) {
+	engine := el.page.browser.ocr
+	if engine == nil {
+		return "", &OCRNotSetError{}
+	}
+
+	img, err := el.Screenshot(proto.PageCaptureScreenshotFormatPng, 0)
+	if err != nil {
+		return "", err
+	}
+
+	list, err := engine.Recognize(img)
+	if err != nil {
+		return "", err
+	}
+
+	lines := make([]string, 0, len(list))
+	for _, t := range list {
+		lines = append(lines, t.Text)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// FindTextOnScreen recognizes the text in the current viewport and returns the pieces that match the regex.
+// The boxes of the returned texts are in the css pixels of the viewport, so they can be used with [Page.Mouse] directly.
+func (p *Page) FindTextOnScreen(re *regexp.Regexp) ([]*OCRText, error) {
+	engine := p.browser.ocr
+	if engine == nil {
+		return nil, &OCRNotSetError{}
+	}
+
+	metrics, err := proto.PageGetLayoutMetrics{}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+	if metrics.CSSVisualViewport == nil {
+		return nil, errors.New("failed to get css visual viewport")
+	}
+	view := metrics.CSSVisualViewport
+
+	// capture with the scale of 1 so that a pixel of the image is a css pixel
+	shot, err := proto.PageCaptureScreenshot{
+		Format: proto.PageCaptureScreenshotFormatPng,
+		Clip: &proto.PageViewport{
+			X:      view.PageX,
+			Y:      view.PageY,
+			Width:  view.ClientWidth,
+			Height: view.ClientHeight,
+			Scale:  1,
+		},
+	}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := engine.Recognize(shot.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []*OCRText{}
+	for _, t := range list {
+		if re.MatchString(t.Text) {
+			matched = append(matched, t)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,80 @@
+package rod_test
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"regexp"
+	"testing"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// fakeOCR recognizes every image as a single text that covers the whole image.
+type fakeOCR struct {
+	text string
+	err  error
+}
+
+func (o *fakeOCR) Recognize(img []byte) ([]*rod.OCRText, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	cfg, err := png.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return nil, err
+	}
+
+	return []*rod.OCRText{
+		{Text: "ignored", Box: &proto.DOMRect{}},
+		{Text: o.text, Box: &proto.DOMRect{Width: float64(cfg.Width), Height: float64(cfg.Height)}, Confidence: 1},
+	}, nil
+}
+
+func TestOCR(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.blank())
+	el := p.MustElement("body")
+
+	_, err := el.TextViaOCR()
+	g.Is(err, &rod.OCRNotSetError{})
+	g.Eq(err.Error(), "the ocr engine is not set, use Browser.OCR to set it")
+	_, err = p.FindTextOnScreen(regexp.MustCompile(``))
+	g.Is(err, &rod.OCRNotSetError{})
+
+	engine := &fakeOCR{text: "total: 42"}
+	g.browser.OCR(engine)
+	defer g.browser.OCR(nil)
+
+	g.Eq(el.MustTextViaOCR(), "ignored\ntotal: 42")
+
+	list := p.MustFindTextOnScreen(regexp.MustCompile(`total: \d+`))
+	g.Len(list, 1)
+	g.Eq(list[0].Text, "total: 42")
+	g.Eq(list[0].Box.Width, p.MustEval(`() => innerWidth`).Num())
+
+	g.Len(p.MustFindTextOnScreen(regexp.MustCompile(`none`)), 0)
+
+	engine.err = errors.New("engine error")
+	_, err = el.TextViaOCR()
+	g.Eq(err, engine.err)
+	_, err = p.FindTextOnScreen(regexp.MustCompile(``))
+	g.Eq(err, engine.err)
+	engine.err = nil
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.PageGetLayoutMetrics{})
+		p.MustFindTextOnScreen(regexp.MustCompile(``))
+	})
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.PageCaptureScreenshot{})
+		p.MustFindTextOnScreen(regexp.MustCompile(``))
+	})
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.PageCaptureScreenshot{})
+		el.MustTextViaOCR()
+	})
+}
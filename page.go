@@ -1,12 +1,18 @@
 package rod
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	stdhtml "html"
+	"io"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -70,6 +76,13 @@ type Page struct {
 	jsCtxID     *proto.RuntimeRemoteObjectID // use pointer so that page clones can share the change
 	helpersLock *sync.Mutex
 	helpers     map[proto.RuntimeRemoteObjectID]map[string]proto.RuntimeRemoteObjectID
+
+	queries  *queryCache     // shared by page clones, each frame has its own
+	requests *requestTracker // shared by page clones and frames in the same session
+	bodies   *bodyCache      // shared by page clones and frames in the same session
+	hooks    *pageHooks      // shared by page clones, each frame has its own
+
+	waitUpgrade bool // see Page.WithWaitUpgrade
 }
 
 // String interface.
@@ -86,6 +99,59 @@ func (p *Page) IsIframe() bool {
 	return p.element != nil
 }
 
+// isOOPIF tells if it's an out-of-process iframe that has its own session.
+func (p *Page) isOOPIF() bool {
+	return p.IsIframe() && p.element.page.SessionID != p.SessionID
+}
+
+// outerOOPIF returns the nearest out-of-process iframe that contains the page, nil if there's none.
+func (p *Page) outerOOPIF() *Page {
+	for f := p; f.IsIframe(); f = f.element.page {
+		if f.isOOPIF() {
+			return f
+		}
+	}
+	return nil
+}
+
+// rootTransform returns a function to convert a point relative to the viewport of the page's session
+// to the viewport of the root page. Only pages inside an OOPIF need the conversion, because the coordinates
+// of an OOPIF session are relative to the OOPIF itself. The content box of the iframe element is used
+// so that the scroll, border, padding, and css transform of the iframe are all counted.
+func (p *Page) rootTransform() (func(proto.Point) proto.Point, error) {
+	oopif := p.outerOOPIF()
+	if oopif == nil {
+		return func(pt proto.Point) proto.Point { return pt }, nil
+	}
+
+	box, err := proto.DOMGetBoxModel{ObjectID: oopif.element.id()}.Call(oopif.element)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := proto.PageGetLayoutMetrics{}.Call(oopif)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := oopif.element.page.Context(p.ctx).rootTransform()
+	if err != nil {
+		return nil, err
+	}
+
+	c := box.Model.Content
+	w := float64(metrics.CSSLayoutViewport.ClientWidth)
+	h := float64(metrics.CSSLayoutViewport.ClientHeight)
+
+	return func(pt proto.Point) proto.Point {
+		x, y := pt.X/w, pt.Y/h
+		return parent(proto.Point{
+			X: c[0] + x*(c[2]-c[0]) + y*(c[6]-c[0]),
+			Y: c[1] + x*(c[3]-c[1]) + y*(c[7]-c[1]),
+		})
+	}, nil
+}
+
 // GetSessionID interface.
 func (p *Page) GetSessionID() proto.TargetSessionID {
 	return p.SessionID
@@ -167,6 +233,59 @@ func (p *Page) SetBlockedURLs(urls []string) error {
 	return proto.NetworkSetBlockedURLs{Urls: urls}.Call(p)
 }
 
+// SetOriginTrialTokens injects the origin trial tokens into the current and new documents of the page,
+// so that the experimental web platform features of the trials can be tested without changing the server.
+// Call remove to stop injecting the tokens into new documents.
+func (p *Page) SetOriginTrialTokens(tokens ...string) (remove func() error, err error) {
+	_, err = p.Evaluate(Eval(js.SetOriginTrialTokens.Definition, tokens))
+	if err != nil {
+		return
+	}
+
+	code := fmt.Sprintf(`(%s)(%s)`, js.SetOriginTrialTokens.Definition, utils.MustToJSON(tokens))
+	return p.EvalOnNewDocument(code)
+}
+
+// ExperimentOverrides is a declarative map of the overrides for [Page.OverrideExperiments].
+// The key is prefixed with where to inject the value, the non-string values of the cookies and storages
+// are encoded as json, then the cookies are percent-encoded like the js-cookie library,
+// so the chars such as ";" and "," are safe in them:
+//
+//	rod.ExperimentOverrides{
+//		"cookie:ab_bucket":            "B",
+//		"localStorage:flags":          map[string]bool{"newCheckout": true},
+//		"sessionStorage:variant":      "control",
+//		"window:__FLAGS__.newCheckout": true, // the dots are the path of the nested properties
+//	}
+type ExperimentOverrides map[string]interface{}
+
+// OverrideExperiments injects the overrides into every new document of the page before its first script runs,
+// so that tests always land in a known experiment bucket. The window overrides are kept even if the scripts of
+// the page assign the globals later. The cookies are set via document.cookie, so the server won't see them
+// in the request of the document, use [Page.SetCookies] for the server-side bucketing.
+// Call remove to stop the injection.
+func (p *Page) OverrideExperiments(overrides ExperimentOverrides) (remove func() error, err error) {
+	for key := range overrides {
+		kind, name, _ := strings.Cut(key, ":")
+		switch kind {
+		case "cookie", "localStorage", "sessionStorage", "window":
+		default:
+			return nil, fmt.Errorf("unknown kind of the experiment override: %s", key)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("empty name of the experiment override: %s", key)
+		}
+	}
+
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the experiment overrides: %w", err)
+	}
+
+	code := fmt.Sprintf(`(%s)(%s)`, js.OverrideExperiments.Definition, data)
+	return p.EvalOnNewDocument(code)
+}
+
 // Navigate to the url. If the url is empty, "about:blank" will be used.
 // It will return immediately after the server responds the http header.
 func (p *Page) Navigate(url string) error {
@@ -293,6 +412,74 @@ func (p *Page) SetDocumentContent(html string) error {
 	}.Call(p)
 }
 
+// SetContentOptions for [Page.SetContent].
+type SetContentOptions struct {
+	// BaseURL (optional) is used to resolve the relative urls of the assets in the html,
+	// it's injected as a <base> tag.
+	BaseURL string
+
+	// WaitRequestIdle (optional) waits until there's no request for the duration after the load event.
+	WaitRequestIdle time.Duration
+}
+
+// SetContent sets the document html content of the page, then waits for the load event.
+// Unlike [Page.SetDocumentContent] it supports a base url for the relative assets and
+// waiting for the network to be idle. The opts can be nil.
+func (p *Page) SetContent(html string, opts *SetContentOptions) error {
+	if opts == nil {
+		opts = &SetContentOptions{}
+	}
+
+	if opts.BaseURL != "" {
+		html = injectBaseURL(html, opts.BaseURL)
+	}
+
+	wait := func() {}
+	if opts.WaitRequestIdle > 0 {
+		wait = p.WaitRequestIdle(opts.WaitRequestIdle, nil, nil, nil)
+	}
+
+	err := p.SetDocumentContent(html)
+	if err != nil {
+		return err
+	}
+
+	err = p.WaitLoad()
+	if err != nil {
+		return err
+	}
+
+	wait()
+
+	return p.ctx.Err()
+}
+
+var (
+	regHead    = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	regHTMLTag = regexp.MustCompile(`(?i)<html(\s[^>]*)?>`)
+	regDoctype = regexp.MustCompile(`(?i)^\s*<!doctype[^>]*>`)
+)
+
+// injectBaseURL inserts the base tag as the first child of the head. If there's no head, a head with the tag
+// is inserted after the html tag or the doctype, so the doctype stays first and the page won't be in quirks mode.
+func injectBaseURL(html, u string) string {
+	tag := `<base href="` + stdhtml.EscapeString(u) + `">`
+
+	if loc := regHead.FindStringIndex(html); loc != nil {
+		return html[:loc[1]] + tag + html[loc[1]:]
+	}
+
+	head := "<head>" + tag + "</head>"
+
+	for _, reg := range []*regexp.Regexp{regHTMLTag, regDoctype} {
+		if loc := reg.FindStringIndex(html); loc != nil {
+			return html[:loc[1]] + head + html[loc[1]:]
+		}
+	}
+
+	return head + html
+}
+
 // Emulate the device, such as iPhone9. If device is devices.Clear, it will clear the override.
 func (p *Page) Emulate(device devices.Device) error {
 	err := p.SetViewport(device.MetricsEmulation())
@@ -433,8 +620,124 @@ func (p *Page) HandleFileDialog() (func([]string) error, error) {
 	}, nil
 }
 
+// FileChooser is the file chooser dialog intercepted by [Page.WaitFileChooser].
+type FileChooser struct {
+	page *Page
+
+	// Mode is either single or multiple selection
+	Mode proto.PageFileChooserOpenedMode
+
+	// FrameID of the frame that opens the chooser
+	FrameID proto.PageFrameID
+
+	// BackendNodeID of the file input, it's empty if the chooser isn't opened by an input element
+	BackendNodeID proto.DOMBackendNodeID
+}
+
+// WaitFileChooser intercepts the next file chooser dialog, the OS dialog won't show up.
+// It works for the choosers opened via js, such as the click on a detached or hidden file input.
+// The returned wait function blocks until the chooser is opened, then the interception will be disabled.
+//
+//	wait, _ := page.WaitFileChooser()
+//	page.MustElement("button.upload").MustClick()
+//	chooser, _ := wait()
+//	_ = chooser.SetFiles([]string{"a.png"})
+func (p *Page) WaitFileChooser() (func() (*FileChooser, error), error) {
+	err := proto.PageSetInterceptFileChooserDialog{Enabled: true}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var e proto.PageFileChooserOpened
+	w := p.WaitEvent(&e)
+
+	return func() (*FileChooser, error) {
+		w()
+
+		if err := p.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		err := proto.PageSetInterceptFileChooserDialog{Enabled: false}.Call(p)
+		if err != nil {
+			return nil, err
+		}
+
+		return &FileChooser{
+			page:          p,
+			Mode:          e.Mode,
+			FrameID:       e.FrameID,
+			BackendNodeID: e.BackendNodeID,
+		}, nil
+	}, nil
+}
+
+// Multiple returns true if the chooser accepts multiple files.
+func (fc *FileChooser) Multiple() bool {
+	return fc.Mode == proto.PageFileChooserOpenedModeSelectMultiple
+}
+
+// Element returns the file input that opens the chooser.
+func (fc *FileChooser) Element() (*Element, error) {
+	if fc.BackendNodeID == 0 {
+		return nil, &NoFileInputError{}
+	}
+	return fc.page.ElementFromNode(&proto.DOMNode{BackendNodeID: fc.BackendNodeID})
+}
+
+// SetFiles of the file input, the change and input events will be fired like the user selects the files.
+func (fc *FileChooser) SetFiles(paths []string) error {
+	if fc.BackendNodeID == 0 {
+		return &NoFileInputError{}
+	}
+	return proto.DOMSetFileInputFiles{
+		Files:         utils.AbsolutePaths(paths),
+		BackendNodeID: fc.BackendNodeID,
+	}.Call(fc.page)
+}
+
+// Cancel the chooser, the cancel event will be fired on the file input like the user closes the dialog.
+func (fc *FileChooser) Cancel() error {
+	el, err := fc.Element()
+	if err != nil {
+		return err
+	}
+	_, err = el.Eval(`() => this.dispatchEvent(new Event('cancel', { bubbles: true }))`)
+	return err
+}
+
 // Screenshot captures the screenshot of current page.
 func (p *Page) Screenshot(fullPage bool, req *proto.PageCaptureScreenshot) ([]byte, error) {
+	raw, err := p.captureScreenshot(fullPage, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var shot proto.PageCaptureScreenshotResult
+	err = json.Unmarshal(raw, &shot)
+	if err != nil {
+		return nil, err
+	}
+	return shot.Data, nil
+}
+
+// ScreenshotTo is similar to [Page.Screenshot], but decodes the image into w.
+// The browser sends the whole image as a base64 string in a single message, the message is still held in memory,
+// but the decoded image is streamed into w instead of being buffered.
+// Use the req.Format to choose png, jpeg, or webp, the req.Quality only works for jpeg and webp.
+// The browser can't encode avif, convert the png if you need it.
+// Set the req.OptimizeForSpeed to trade the size of the image for the encoding speed of the bulk captures.
+func (p *Page) ScreenshotTo(w io.Writer, fullPage bool, req *proto.PageCaptureScreenshot) error {
+	raw, err := p.captureScreenshot(fullPage, req)
+	if err != nil {
+		return err
+	}
+
+	return writeScreenshotData(w, raw)
+}
+
+// captureScreenshot returns the raw json result of the [proto.PageCaptureScreenshot].
+func (p *Page) captureScreenshot(fullPage bool, req *proto.PageCaptureScreenshot) ([]byte, error) {
 	if req == nil {
 		req = &proto.PageCaptureScreenshot{}
 	}
@@ -469,11 +772,30 @@ func (p *Page) Screenshot(fullPage bool, req *proto.PageCaptureScreenshot) ([]by
 		}()
 	}
 
-	shot, err := req.Call(p)
+	return p.Call(p.ctx, string(p.SessionID), req.ProtoReq(), req)
+}
+
+// writeScreenshotData decodes the base64 data field of the raw screenshot result into w.
+// It falls back to the json decoding when the field isn't in the compact form the browser sends.
+func writeScreenshotData(w io.Writer, raw []byte) error {
+	key := []byte(`"data":"`)
+	i := bytes.Index(raw, key)
+	if i >= 0 {
+		data := raw[i+len(key):]
+		end := bytes.IndexByte(data, '"')
+		if end >= 0 && bytes.IndexByte(data[:end], '\\') < 0 {
+			_, err := io.Copy(w, base64.NewDecoder(base64.StdEncoding, bytes.NewReader(data[:end])))
+			return err
+		}
+	}
+
+	var shot proto.PageCaptureScreenshotResult
+	err := json.Unmarshal(raw, &shot)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return shot.Data, nil
+	_, err = w.Write(shot.Data)
+	return err
 }
 
 // ScrollScreenshotOptions is the options for the ScrollScreenshot.
@@ -852,6 +1174,11 @@ func (p *Page) WaitRepaint() error {
 	return err
 }
 
+// waitDocumentReady waits until the document is no longer loading.
+func (p *Page) waitDocumentReady() error {
+	return p.Wait(Eval(`() => document.readyState !== 'loading'`))
+}
+
 // WaitLoad waits for the `window.onload` event, it returns immediately if the event is already fired.
 func (p *Page) WaitLoad() error {
 	defer p.tryTrace(TraceTypeWait, "load")()
@@ -859,6 +1186,19 @@ func (p *Page) WaitLoad() error {
 	return err
 }
 
+// WaitCustomElementsDefined waits until the custom elements of the names are defined via customElements.define.
+// If no name is specified, it waits for all the custom elements that are in the document but not defined yet.
+func (p *Page) WaitCustomElementsDefined(names ...string) error {
+	defer p.tryTrace(TraceTypeWait, "custom elements")()
+	_, err := p.Evaluate(Eval(`names => {
+		if (!names.length) {
+			names = Array.from(document.querySelectorAll(':not(:defined)'), el => el.localName)
+		}
+		return Promise.all(names.map(n => customElements.whenDefined(n)))
+	}`, names).ByPromise())
+	return err
+}
+
 // AddScriptTag to page. If url is empty, content will be used.
 func (p *Page) AddScriptTag(url, content string) error {
 	hash := md5.Sum([]byte(url + content))
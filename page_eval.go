@@ -3,6 +3,7 @@
 package rod
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -225,6 +226,129 @@ func (p *Page) Expose(name string, fn func(gson.JSON) (interface{}, error)) (sto
 	return
 }
 
+// MutationType of a [MutationRecord].
+type MutationType string
+
+const (
+	// MutationTypeChildList type.
+	MutationTypeChildList MutationType = "childList"
+	// MutationTypeAttributes type.
+	MutationTypeAttributes MutationType = "attributes"
+	// MutationTypeCharacterData type.
+	MutationTypeCharacterData MutationType = "characterData"
+)
+
+// MutationOptions is the same as the options of the MutationObserver.observe in javascript.
+type MutationOptions struct {
+	ChildList             bool     `json:"childList,omitempty"`
+	Attributes            bool     `json:"attributes,omitempty"`
+	CharacterData         bool     `json:"characterData,omitempty"`
+	Subtree               bool     `json:"subtree,omitempty"`
+	AttributeOldValue     bool     `json:"attributeOldValue,omitempty"`
+	CharacterDataOldValue bool     `json:"characterDataOldValue,omitempty"`
+	AttributeFilter       []string `json:"attributeFilter,omitempty"`
+}
+
+// MutationRecord is the same as the MutationRecord in javascript,
+// the nodes are serialized as [MutationNode].
+type MutationRecord struct {
+	Type          MutationType    `json:"type"`
+	Target        *MutationNode   `json:"target"`
+	AddedNodes    []*MutationNode `json:"addedNodes"`
+	RemovedNodes  []*MutationNode `json:"removedNodes"`
+	AttributeName string          `json:"attributeName"`
+	OldValue      string          `json:"oldValue"`
+}
+
+// MutationNode is a brief description of a DOM node.
+type MutationNode struct {
+	NodeName string `json:"nodeName"`
+	ID       string `json:"id"`
+
+	// Text is the textContent of the node, it's truncated to 1000 characters.
+	Text string `json:"text"`
+}
+
+// ObserveMutations of the element that matches the selector, if the selector is empty the whole document will be observed.
+// If opts is nil, all types of mutations of the element and its descendants will be observed.
+// The observation survives reloads. The records channel will be closed after stop is called or the page is closed.
+func (p *Page) ObserveMutations(selector string, opts *MutationOptions) (
+	records <-chan *MutationRecord, stop func() error, err error,
+) {
+	if opts == nil {
+		opts = &MutationOptions{ChildList: true, Attributes: true, CharacterData: true, Subtree: true}
+	}
+
+	bind := "_" + utils.RandString(8)
+
+	err = proto.RuntimeAddBinding{Name: bind}.Call(p)
+	if err != nil {
+		return
+	}
+
+	page, cancel := p.WithCancel()
+	defer func() {
+		if err != nil {
+			cancel()
+			_ = proto.RuntimeRemoveBinding{Name: bind}.Call(p)
+		}
+	}()
+
+	ch := make(chan *MutationRecord)
+	records = ch
+
+	// subscribe before the observer is installed so that no record will be missed
+	wait := page.EachEvent(func(e *proto.RuntimeBindingCalled) {
+		if e.Name != bind {
+			return
+		}
+
+		var list []*MutationRecord
+		if json.Unmarshal([]byte(e.Payload), &list) != nil {
+			return
+		}
+
+		for _, r := range list {
+			select {
+			case <-page.ctx.Done():
+				return
+			case ch <- r:
+			}
+		}
+	})
+
+	go func() {
+		defer close(ch)
+		wait()
+	}()
+
+	_, err = page.Evaluate(Eval(js.ObserveMutations.Definition, selector, bind, opts))
+	if err != nil {
+		return
+	}
+
+	code := fmt.Sprintf(`(%s)(%s, "%s", %s)`,
+		js.ObserveMutations.Definition, utils.MustToJSON(selector), bind, utils.MustToJSON(opts))
+	remove, err := page.EvalOnNewDocument(code)
+	if err != nil {
+		return
+	}
+
+	stop = func() error {
+		defer cancel()
+		defer func() { _ = proto.RuntimeRemoveBinding{Name: bind}.Call(page) }()
+
+		err := remove()
+		if err != nil {
+			return err
+		}
+		_, _ = page.Eval(`name => window[name] && window[name].disconnect()`, bind+"_observer")
+		return nil
+	}
+
+	return
+}
+
 func (p *Page) formatArgs(opts *EvalOptions) ([]*proto.RuntimeCallArgument, error) {
 	formatted := []*proto.RuntimeCallArgument{}
 	for _, arg := range opts.JSArgs {
@@ -329,7 +453,7 @@ func (p *Page) getJSCtxID() (proto.RuntimeRemoteObjectID, error) {
 		return *p.jsCtxID, nil
 	}
 
-	if !p.IsIframe() {
+	if !p.IsIframe() || p.isOOPIF() {
 		obj, err := proto.RuntimeEvaluate{Expression: "window"}.Call(p)
 		if err != nil {
 			return "", err
@@ -347,6 +471,11 @@ func (p *Page) getJSCtxID() (proto.RuntimeRemoteObjectID, error) {
 		return "", err
 	}
 
+	// the iframe hasn't created its document yet
+	if node.ContentDocument == nil {
+		return "", cdp.ErrCtxNotFound
+	}
+
 	obj, err := proto.DOMResolveNode{BackendNodeID: node.ContentDocument.BackendNodeID}.Call(p)
 	if err != nil {
 		return "", err
@@ -378,3 +507,38 @@ func (p *Page) jsCtxIDByObjectID(id proto.RuntimeRemoteObjectID) (proto.RuntimeR
 
 	return res.Result.ObjectID, nil
 }
+
+// EvalAs is a shortcut for [Page.Eval] that decodes the result into type T.
+//
+//	n, err := rod.EvalAs[int](page, `() => document.links.length`)
+func EvalAs[T any](p *Page, js string, args ...interface{}) (T, error) {
+	var v T
+
+	res, err := p.Eval(js, args...)
+	if err != nil {
+		return v, err
+	}
+
+	err = res.Value.Unmarshal(&v)
+	return v, err
+}
+
+// Attr returns the attribute of the element decoded into type T.
+// If T is string the raw attribute value is returned, otherwise the value is decoded as JSON,
+// such as "10" to int or "true" to bool. If the attribute doesn't exist the zero value of T is returned.
+func Attr[T any](el *Element, name string) (T, error) {
+	var v T
+
+	attr, err := el.Attribute(name)
+	if err != nil || attr == nil {
+		return v, err
+	}
+
+	if s, ok := interface{}(&v).(*string); ok {
+		*s = *attr
+		return v, nil
+	}
+
+	err = json.Unmarshal([]byte(*attr), &v)
+	return v, err
+}
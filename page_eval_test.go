@@ -58,6 +58,37 @@ func TestPageEval(t *testing.T) {
 	g.Has(err.Error(), `eval js error: ReferenceError: notExist is not defined`)
 }
 
+func TestEvalAs(t *testing.T) {
+	g := setup(t)
+
+	page := g.page.MustNavigate(g.srcFile("fixtures/input.html"))
+
+	n, err := rod.EvalAs[int](page, `(a, b) => a + b`, 1, 2)
+	g.E(err)
+	g.Eq(n, 3)
+
+	list, err := rod.EvalAs[[]string](page, `() => ['a', 'b']`)
+	g.E(err)
+	g.Eq(list, []string{"a", "b"})
+
+	_, err = rod.EvalAs[int](page, `() => 'x'`)
+	g.Err(err)
+
+	el := page.MustElement("textarea")
+
+	cols, err := rod.Attr[int](el, "cols")
+	g.E(err)
+	g.Eq(cols, 30)
+
+	s, err := rod.Attr[string](el, "cols")
+	g.E(err)
+	g.Eq(s, "30")
+
+	none, err := rod.Attr[int](el, "not-exists")
+	g.E(err)
+	g.Zero(none)
+}
+
 func TestPageEvaluateRetry(t *testing.T) {
 	g := setup(t)
 
@@ -138,6 +169,56 @@ func TestPageExpose(t *testing.T) {
 	})
 }
 
+func TestPageObserveMutations(t *testing.T) {
+	g := setup(t)
+
+	page := g.newPage(g.srcFile("fixtures/click.html")).MustWaitLoad()
+
+	records, stop := page.MustObserveMutations("body", nil)
+
+	page.MustEval(`() => {
+		const p = document.createElement('p')
+		p.id = 'a'
+		p.innerText = 'ok'
+		document.body.appendChild(p)
+	}`)
+
+	r := <-records
+	g.Eq(r.Type, rod.MutationTypeChildList)
+	g.Eq(r.Target.NodeName, "BODY")
+	g.Eq(r.AddedNodes[0].ID, "a")
+	g.Eq(r.AddedNodes[0].Text, "ok")
+
+	page.MustElement("button").MustEval(`() => this.setAttribute('a', 'b')`)
+	r = <-records
+	g.Eq(r.Type, rod.MutationTypeAttributes)
+	g.Eq(r.AttributeName, "a")
+
+	// survive the reload
+	page.MustReload().MustWaitLoad()
+	page.MustElement("button").MustEval(`() => this.setAttribute('a', 'c')`)
+	r = <-records
+	g.Eq(r.AttributeName, "a")
+
+	stop()
+
+	_, ok := <-records
+	g.False(ok)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeAddBinding{})
+		page.MustObserveMutations("", nil)
+	})
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		page.MustObserveMutations("", nil)
+	})
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.PageAddScriptToEvaluateOnNewDocument{})
+		page.MustObserveMutations("", nil)
+	})
+}
+
 func TestObjectRelease(t *testing.T) {
 	g := setup(t)
 
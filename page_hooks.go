@@ -0,0 +1,143 @@
+// This file contains the lifecycle hooks of a page.
+
+package rod
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// pageHooks are shared by the clones of a page, the event loop starts when the first hook is registered.
+type pageHooks struct {
+	ctx  context.Context
+	lock sync.Mutex
+	once sync.Once
+	id   int
+
+	close           map[int]func()
+	crash           map[int]func()
+	navigationStart map[int]func()
+	navigationEnd   map[int]func(*proto.PageFrame)
+}
+
+func newPageHooks(ctx context.Context) *pageHooks {
+	return &pageHooks{
+		ctx:             ctx,
+		close:           map[int]func(){},
+		crash:           map[int]func(){},
+		navigationStart: map[int]func(){},
+		navigationEnd:   map[int]func(*proto.PageFrame){},
+	}
+}
+
+// OnClose registers the fn to call after the page is closed. It's useful to release the resources bound to the page.
+// All the hooks are called in the event loop of the page, the fn shouldn't block.
+func (p *Page) OnClose(fn func()) (remove func()) {
+	return p.addHook(func(h *pageHooks, id int) { h.close[id] = fn }, func(h *pageHooks, id int) { delete(h.close, id) })
+}
+
+// OnCrash registers the fn to call after the page crashes, such as the renderer process runs out of memory.
+func (p *Page) OnCrash(fn func()) (remove func()) {
+	return p.addHook(func(h *pageHooks, id int) { h.crash[id] = fn }, func(h *pageHooks, id int) { delete(h.crash, id) })
+}
+
+// OnNavigationStart registers the fn to call when the frame of the page starts to load a new document.
+func (p *Page) OnNavigationStart(fn func()) (remove func()) {
+	return p.addHook(func(h *pageHooks, id int) { h.navigationStart[id] = fn },
+		func(h *pageHooks, id int) { delete(h.navigationStart, id) })
+}
+
+// OnNavigationEnd registers the fn to call when the frame of the page finishes loading a new document,
+// the frame is the navigated frame.
+func (p *Page) OnNavigationEnd(fn func(frame *proto.PageFrame)) (remove func()) {
+	return p.addHook(func(h *pageHooks, id int) { h.navigationEnd[id] = fn },
+		func(h *pageHooks, id int) { delete(h.navigationEnd, id) })
+}
+
+func (p *Page) addHook(add, del func(h *pageHooks, id int)) (remove func()) {
+	h := p.hooks
+
+	h.lock.Lock()
+	h.id++
+	id := h.id
+	add(h, id)
+	h.lock.Unlock()
+
+	h.once.Do(p.startHooks)
+
+	return func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		del(h, id)
+	}
+}
+
+func (p *Page) startHooks() {
+	h := p.hooks
+	ctx, cancel := context.WithCancel(h.ctx)
+	page := p.Context(ctx)
+
+	call := func(list func() []func()) {
+		h.lock.Lock()
+		fns := list()
+		h.lock.Unlock()
+		for _, fn := range fns {
+			fn()
+		}
+	}
+
+	waitTarget := page.browser.Context(ctx).EachEvent(func(e *proto.TargetTargetDestroyed) bool {
+		if e.TargetID != page.TargetID {
+			return false
+		}
+		call(func() []func() { return values(h.close) })
+		cancel()
+		return true
+	}, func(e *proto.TargetTargetCrashed) {
+		if e.TargetID == page.TargetID {
+			call(func() []func() { return values(h.crash) })
+		}
+	})
+
+	var navigated *proto.PageFrame
+
+	waitPage := page.EachEvent(func(e *proto.PageFrameStartedLoading) {
+		if e.FrameID == page.FrameID {
+			call(func() []func() { return values(h.navigationStart) })
+		}
+	}, func(e *proto.PageFrameNavigated) {
+		if e.Frame.ID == page.FrameID {
+			navigated = e.Frame
+		}
+	}, func(e *proto.PageFrameStoppedLoading) {
+		if e.FrameID != page.FrameID || navigated == nil {
+			return
+		}
+		frame := navigated
+		navigated = nil
+
+		h.lock.Lock()
+		fns := make([]func(*proto.PageFrame), 0, len(h.navigationEnd))
+		for _, fn := range h.navigationEnd {
+			fns = append(fns, fn)
+		}
+		h.lock.Unlock()
+
+		for _, fn := range fns {
+			fn(frame)
+		}
+	})
+
+	go waitTarget()
+	go waitPage()
+}
+
+func values(m map[int]func()) []func() {
+	list := make([]func(), 0, len(m))
+	for _, fn := range m {
+		list = append(list, fn)
+	}
+	return list
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/xyjwsj/grod/lib/cdp"
 	"github.com/xyjwsj/grod/lib/defaults"
 	"github.com/xyjwsj/grod/lib/devices"
+	"github.com/xyjwsj/grod/lib/netfault"
 	"github.com/xyjwsj/grod/lib/proto"
 	"github.com/xyjwsj/grod/lib/utils"
 	"github.com/ysmood/gson"
@@ -77,6 +78,81 @@ func TestSetCookies(t *testing.T) {
 	})
 }
 
+func TestSetOriginTrialTokens(t *testing.T) {
+	g := setup(t)
+
+	page := g.newPage(g.blank()).MustWaitLoad()
+
+	remove := page.MustSetOriginTrialTokens("a", "b")
+
+	tokens := `() => Array.from(document.querySelectorAll('meta[http-equiv=origin-trial]'), m => m.content).join()`
+	g.Eq(page.MustEval(tokens).Str(), "a,b")
+
+	page.MustReload().MustWaitLoad()
+	g.Eq(page.MustEval(tokens).Str(), "a,b")
+
+	remove()
+	page.MustReload().MustWaitLoad()
+	g.Eq(page.MustEval(tokens).Str(), "")
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		page.MustSetOriginTrialTokens("a")
+	})
+}
+
+func TestOverrideExperiments(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body><script>
+		window.seen = {
+			cookie: document.cookie,
+			local: localStorage.getItem('flags'),
+			session: sessionStorage.getItem('variant'),
+		}
+		window.__FLAGS__ = { newCheckout: false, darkMode: true }
+		window.BUCKET = 'A'
+	</script></body></html>`)
+
+	p := g.newPage()
+	remove := p.MustOverrideExperiments(rod.ExperimentOverrides{
+		"cookie:ab_bucket":             "B",
+		"cookie:list":                  "a;b c",
+		"localStorage:flags":           map[string]bool{"newCheckout": true},
+		"sessionStorage:variant":       "control",
+		"window:__FLAGS__.newCheckout": true,
+		"window:BUCKET":                "B",
+	})
+	p.MustNavigate(s.URL()).MustWaitLoad()
+
+	g.Eq(p.MustEval(`() => window.seen`).Map(), map[string]gson.JSON{
+		"cookie":  gson.New("ab_bucket=B; list=a%3Bb%20c"),
+		"local":   gson.New(`{"newCheckout":true}`),
+		"session": gson.New("control"),
+	})
+	g.True(p.MustEval(`() => __FLAGS__.newCheckout && __FLAGS__.darkMode`).Bool())
+	g.Eq(p.MustEval(`() => BUCKET`).Str(), "B")
+
+	remove()
+	p.MustEval(`() => { sessionStorage.clear() }`)
+	p.MustReload().MustWaitLoad()
+	g.Eq(p.MustEval(`() => window.seen.session`).Nil(), true)
+	g.Eq(p.MustEval(`() => BUCKET`).Str(), "A")
+
+	_, err := p.OverrideExperiments(rod.ExperimentOverrides{"header:x": 1})
+	g.Eq(err.Error(), "unknown kind of the experiment override: header:x")
+	_, err = p.OverrideExperiments(rod.ExperimentOverrides{"window:": 1})
+	g.Eq(err.Error(), "empty name of the experiment override: window:")
+	_, err = p.OverrideExperiments(rod.ExperimentOverrides{"window:x": func() {}})
+	g.Has(err.Error(), "failed to encode the experiment overrides")
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.PageAddScriptToEvaluateOnNewDocument{})
+		p.MustOverrideExperiments(rod.ExperimentOverrides{})
+	})
+}
+
 func TestSetBlockedURLs(t *testing.T) {
 	g := setup(t)
 	page := g.newPage()
@@ -320,6 +396,42 @@ func TestSetDocumentContent(t *testing.T) {
 	g.Eq(page.MustElement("div").MustText(), "💪")
 }
 
+func TestSetContent(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/a.js", ".js", `window.loaded = true`)
+
+	page := g.newPage(g.blank())
+
+	page.MustSetContent(`<html><head><script src="a.js"></script></head><body>ok</body></html>`, &rod.SetContentOptions{
+		BaseURL:         s.URL("/"),
+		WaitRequestIdle: 100 * time.Millisecond,
+	})
+	g.True(page.MustEval(`() => window.loaded`).Bool())
+	g.Eq(page.MustElement("base").MustProperty("href").Str(), s.URL("/"))
+
+	page.MustSetContent(`<div>no head</div>`, &rod.SetContentOptions{BaseURL: s.URL("/")})
+	g.Eq(page.MustElement("div").MustText(), "no head")
+	g.Has(page.MustHTML(), `<base href="`+s.URL("/")+`">`)
+
+	// the doctype stays first, so the page isn't in quirks mode
+	for _, html := range []string{
+		`<!DOCTYPE html><div>doctype</div>`,
+		`<!DOCTYPE html><html lang="en"><body><div>doctype</div></body></html>`,
+	} {
+		page.MustSetContent(html, &rod.SetContentOptions{BaseURL: s.URL("/")})
+		g.Eq(page.MustEval(`() => document.compatMode`).Str(), "CSS1Compat")
+		g.Eq(page.MustElement("head > base").MustProperty("href").Str(), s.URL("/"))
+	}
+
+	page.MustSetContent(`<p>plain</p>`, nil)
+	g.Eq(page.MustElement("p").MustText(), "plain")
+
+	g.mc.stubErr(1, proto.PageSetDocumentContent{})
+	g.Err(page.SetContent(`<p></p>`, nil))
+}
+
 func TestEmulateDevice(t *testing.T) {
 	g := setup(t)
 
@@ -435,6 +547,22 @@ func TestPageNavigateBlank(t *testing.T) {
 	g.page.MustNavigate("")
 }
 
+func TestPageWaitCustomElementsDefined(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/custom-elements.html"))
+	p.MustWaitCustomElementsDefined("x-card")
+	g.True(p.MustEval(`() => !!customElements.get('x-card')`).Bool())
+
+	p.MustReload().MustWaitCustomElementsDefined()
+	g.True(p.MustEval(`() => !!customElements.get('x-card') && !!customElements.get('x-label')`).Bool())
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		p.MustWaitCustomElementsDefined()
+	})
+}
+
 func TestPageWaitNavigation(t *testing.T) {
 	g := setup(t)
 
@@ -522,6 +650,40 @@ func TestPageCaptureDOMSnapshot(t *testing.T) {
 	g.Nil(snapshot)
 }
 
+func TestPageDOMSnapshotDiff(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html")).MustWaitLoad()
+
+	a := p.MustDOMSnapshot()
+	g.Has(a.URL, "click.html")
+	g.Eq(a.Get("/#document[0]/HTML[0]/BODY[0]/BUTTON[0]").Name, "BUTTON")
+	g.Nil(a.Get("not-exists"))
+
+	g.True(rod.DiffDOM(a, p.MustDOMSnapshot()).Empty())
+
+	p.MustEval(`() => {
+		document.querySelector('button').setAttribute('a', 'b')
+		document.body.appendChild(document.createElement('p'))
+	}`)
+
+	b := p.MustDOMSnapshot()
+
+	d := rod.DiffDOM(a, b)
+	g.False(d.Empty())
+	g.Len(d.Changed, 1)
+	g.Eq(d.Changed[0].After.Attributes["a"], "b")
+	g.Eq(d.Added[len(d.Added)-1].Name, "P")
+	g.Len(d.Removed, 0)
+
+	g.Len(rod.DiffDOM(b, a).Removed, len(d.Added))
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.DOMSnapshotCaptureSnapshot{})
+		p.MustDOMSnapshot()
+	})
+}
+
 func TestPageWaitDOMStable(t *testing.T) {
 	g := setup(t)
 
@@ -635,6 +797,22 @@ func TestPageWaitEventParseEventOnlyOnce(t *testing.T) {
 	nav2()
 }
 
+func TestEventsSeq(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage()
+
+	go func() {
+		utils.Sleep(0.3)
+		p.MustNavigate(g.blank())
+	}()
+
+	for e := range rod.EventsSeq[proto.PageFrameNavigated](g.Context(), p) {
+		g.Has(e.Frame.URL, "http")
+		break
+	}
+}
+
 func TestPageEvent(t *testing.T) {
 	g := setup(t)
 
@@ -712,6 +890,52 @@ func TestPageHandleFileDialog(t *testing.T) {
 	}
 }
 
+func TestPageWaitFileChooser(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.blank())
+	p.MustEval(`() => {
+		const input = document.createElement('input')
+		input.type = 'file'
+		input.multiple = true
+		input.onchange = () => { window.picked = Array.from(input.files).map(f => f.name) }
+		input.addEventListener('cancel', () => { window.picked = 'canceled' })
+		window.pick = () => input.click()
+	}`)
+	pick := func() {
+		_, err := p.Evaluate(rod.Eval(`() => pick()`).ByUser())
+		g.E(err)
+	}
+
+	wait := p.MustWaitFileChooser()
+	pick()
+	fc := wait()
+	g.True(fc.Multiple())
+	g.Eq(fc.MustElement().MustProperty("type").Str(), "file")
+	fc.MustSetFiles(slash("fixtures/click.html"), slash("fixtures/alert.html"))
+	g.Eq(p.MustEval(`() => window.picked`).Arr()[1].Str(), "alert.html")
+
+	wait = p.MustWaitFileChooser()
+	pick()
+	g.E(wait().Cancel())
+	g.Eq(p.MustEval(`() => window.picked`).Str(), "canceled")
+
+	g.Is((&rod.FileChooser{}).SetFiles(nil), &rod.NoFileInputError{})
+	g.Is((&rod.FileChooser{}).Cancel(), &rod.NoFileInputError{})
+
+	{
+		g.mc.stubErr(1, proto.PageSetInterceptFileChooserDialog{})
+		g.Err(p.WaitFileChooser())
+	}
+	{
+		wait, err := p.Timeout(100 * time.Millisecond).WaitFileChooser()
+		g.E(err)
+		_, err = wait()
+		g.Is(err, context.DeadlineExceeded)
+		g.E(proto.PageSetInterceptFileChooserDialog{Enabled: false}.Call(p))
+	}
+}
+
 func TestPageScreenshot(t *testing.T) {
 	g := setup(t)
 
@@ -734,6 +958,40 @@ func TestPageScreenshot(t *testing.T) {
 	})
 }
 
+func TestPageScreenshotTo(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+	p.MustElement("button")
+
+	buf := bytes.NewBuffer(nil)
+	g.E(p.ScreenshotTo(buf, false, &proto.PageCaptureScreenshot{
+		Format:  proto.PageCaptureScreenshotFormatWebp,
+		Quality: gson.Int(50),
+	}))
+	g.Eq(string(buf.Bytes()[8:12]), "WEBP")
+
+	buf.Reset()
+	g.E(p.MustElement("h4").ScreenshotTo(buf, proto.PageCaptureScreenshotFormatWebp, 50))
+	g.Eq(string(buf.Bytes()[8:12]), "WEBP")
+
+	buf.Reset()
+	g.E(p.MustElement("h4").ScreenshotTo(buf, proto.PageCaptureScreenshotFormatPng, 0))
+	img, err := png.Decode(buf)
+	g.E(err)
+	g.Eq(200, img.Bounds().Dx())
+
+	buf.Reset()
+	g.mc.stub(1, proto.PageCaptureScreenshot{}, func(_ StubSend) (gson.JSON, error) {
+		return gson.New(proto.PageCaptureScreenshotResult{Data: []byte("image")}), nil
+	})
+	g.E(p.ScreenshotTo(buf, false, nil))
+	g.Eq(buf.String(), "image")
+
+	g.mc.stubErr(1, proto.PageCaptureScreenshot{})
+	g.Err(p.ScreenshotTo(buf, false, nil))
+}
+
 func TestScreenshotFullPage(t *testing.T) {
 	g := setup(t)
 
@@ -937,6 +1195,23 @@ func TestPageNavigateErr(t *testing.T) {
 	}), &rod.NavigationError{})
 }
 
+func TestPageNavigateNetworkFaults(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/reset", netfault.Reset())
+	s.Mux.HandleFunc("/stall", netfault.Stall("text/html", "<html><body>stalled</body></html>", 6))
+
+	p := g.newPage()
+
+	g.Is(p.Navigate(s.URL("/reset")), &rod.NavigationError{})
+
+	p.MustNavigate(s.URL("/stall"))
+	g.Is(p.Timeout(time.Second).WaitLoad(), context.DeadlineExceeded)
+
+	p.MustNavigate("about:blank")
+}
+
 func TestPageWaitLoadErr(t *testing.T) {
 	g := setup(t)
 
@@ -1145,3 +1420,32 @@ func TestPageResetNavigationHistory(t *testing.T) {
 	g.NotNil(finalHistory)
 	g.Eq(len(finalHistory.Entries), expectedInitialHistoryLength)
 }
+
+func TestPageHooks(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve().Route("/", ".html", `<html>ok</html>`)
+	p := g.browser.MustPage()
+
+	started := make(chan struct{}, 10)
+	ended := make(chan string, 10)
+	closed := make(chan struct{})
+	crashed := make(chan struct{})
+
+	p.OnNavigationStart(func() { started <- struct{}{} })
+	p.OnNavigationEnd(func(f *proto.PageFrame) { ended <- f.URL })
+	remove := p.OnNavigationEnd(func(*proto.PageFrame) { panic("removed") })
+	remove()
+
+	p.MustNavigate(s.URL())
+	<-started
+	g.Eq(<-ended, s.URL()+"/")
+
+	p.OnCrash(func() { close(crashed) })
+	go func() { _ = proto.PageCrash{}.Call(p) }()
+	<-crashed
+
+	p.OnClose(func() { close(closed) })
+	p.MustClose()
+	<-closed
+}
@@ -0,0 +1,166 @@
+// This file contains the helpers for the hosted fields of the payment providers, such as Stripe and Braintree.
+
+package rod
+
+import (
+	"unicode"
+
+	"github.com/xyjwsj/grod/lib/input"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+// HostedField is an input that lives in a cross-origin iframe, the payment providers use them
+// to keep the card data away from the host page.
+type HostedField struct {
+	// IFrame is the css selector of the iframe in the host page
+	IFrame string
+
+	// Input is the css selector of the input in the iframe
+	Input string
+}
+
+// CardFields are the [HostedField] of a card form, the zero fields are skipped.
+type CardFields struct {
+	Number HostedField
+	Expiry HostedField
+	CVC    HostedField
+	Postal HostedField
+}
+
+// Card data to fill into the [CardFields].
+type Card struct {
+	Number string
+
+	// Expiry such as "12/34"
+	Expiry string
+
+	CVC    string
+	Postal string
+}
+
+var (
+	// StripeCardFields of the Stripe Elements, both the split card elements and the single card element.
+	StripeCardFields = CardFields{
+		Number: HostedField{
+			IFrame: `iframe[title*="card number" i], iframe[name^="__privateStripeFrame"]`,
+			Input:  `input[name="cardnumber"], input[name="number"]`,
+		},
+		Expiry: HostedField{
+			IFrame: `iframe[title*="expiration" i], iframe[name^="__privateStripeFrame"]`,
+			Input:  `input[name="exp-date"], input[name="expiry"]`,
+		},
+		CVC: HostedField{
+			IFrame: `iframe[title*="cvc" i], iframe[name^="__privateStripeFrame"]`,
+			Input:  `input[name="cvc"]`,
+		},
+		Postal: HostedField{
+			IFrame: `iframe[name^="__privateStripeFrame"]`,
+			Input:  `input[name="postal"], input[name="postalCode"]`,
+		},
+	}
+
+	// BraintreeCardFields of the Braintree Hosted Fields.
+	BraintreeCardFields = CardFields{
+		Number: HostedField{`iframe#braintree-hosted-field-number`, `#credit-card-number`},
+		Expiry: HostedField{`iframe#braintree-hosted-field-expirationDate`, `#expiration`},
+		CVC:    HostedField{`iframe#braintree-hosted-field-cvv`, `#cvv`},
+		Postal: HostedField{`iframe#braintree-hosted-field-postalCode`, `#postal-code`},
+	}
+
+	// AdyenCardFields of the Adyen Secured Fields.
+	AdyenCardFields = CardFields{
+		Number: HostedField{`iframe[title*="card number" i]`, `input[data-fieldtype="encryptedCardNumber"]`},
+		Expiry: HostedField{`iframe[title*="expiry date" i]`, `input[data-fieldtype="encryptedExpiryDate"]`},
+		CVC:    HostedField{`iframe[title*="security code" i]`, `input[data-fieldtype="encryptedSecurityCode"]`},
+	}
+)
+
+// HostedField returns the input of the hosted field. When the selector of the iframe matches multiple iframes,
+// such as the Stripe ones share the same name prefix, the first iframe that has the input will be used.
+// It retries until the input is found.
+func (p *Page) HostedField(f HostedField) (*Element, error) {
+	return p.Race().ElementFunc(func(p *Page) (*Element, error) {
+		list, err := p.Elements(f.IFrame)
+		if err != nil {
+			return nil, err
+		}
+		for _, iframe := range list {
+			frame, err := iframe.Frame()
+			if err != nil {
+				return nil, err
+			}
+			has, el, err := frame.Has(f.Input)
+			if err != nil {
+				return nil, err
+			}
+			if has {
+				return el, nil
+			}
+		}
+		return nil, &ElementNotFoundError{}
+	}).Do()
+}
+
+// FillHostedField clears the hosted field, then types the value into it key by key, because the payment
+// providers format and validate the value on each key event, such as inserting the spaces of a card number.
+func (p *Page) FillHostedField(f HostedField, value string) error {
+	el, err := p.HostedField(f)
+	if err != nil {
+		return err
+	}
+
+	err = el.ScrollIntoView()
+	if err != nil {
+		return err
+	}
+
+	// click to give the iframe the real focus, the focus via js doesn't cross the iframe boundary
+	err = el.Click(proto.InputMouseButtonLeft, 1)
+	if err != nil {
+		return err
+	}
+
+	err = el.SelectAllText()
+	if err != nil {
+		return err
+	}
+	err = el.Type(input.Backspace)
+	if err != nil {
+		return err
+	}
+
+	frame := el.Page().Context(el.ctx)
+	for _, r := range value {
+		if r < unicode.MaxASCII && unicode.IsPrint(r) {
+			err = frame.Keyboard.Type(input.Key(r))
+		} else {
+			err = frame.InsertText(string(r))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FillCard fills the card into the hosted fields, the empty values and the zero fields are skipped.
+func (p *Page) FillCard(fields CardFields, card Card) error {
+	for _, item := range []struct {
+		field HostedField
+		value string
+	}{
+		{fields.Number, card.Number},
+		{fields.Expiry, card.Expiry},
+		{fields.CVC, card.CVC},
+		{fields.Postal, card.Postal},
+	} {
+		if item.value == "" || item.field == (HostedField{}) {
+			continue
+		}
+		err := p.FillHostedField(item.field, item.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
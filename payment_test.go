@@ -0,0 +1,35 @@
+package rod_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+)
+
+func TestFillCard(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/payment.html"))
+
+	number := p.MustHostedField(rod.StripeCardFields.Number)
+	number.MustInput("1111")
+
+	p.MustFillCard(rod.StripeCardFields, rod.Card{
+		Number: "4242424242424242",
+		Expiry: "12/34",
+		CVC:    "123",
+	})
+
+	g.Eq(number.MustProperty("value").Str(), "4242 4242 4242 4242")
+	g.Eq(number.MustEval(`() => window.keys`).Int(), 17) // the backspace and 16 digits
+	g.Eq(p.MustHostedField(rod.StripeCardFields.Expiry).MustProperty("value").Str(), "12/34")
+	g.Eq(p.MustHostedField(rod.StripeCardFields.CVC).MustProperty("value").Str(), "123")
+
+	p.MustFillHostedField(rod.StripeCardFields.CVC, "9é")
+	g.Eq(p.MustHostedField(rod.StripeCardFields.CVC).MustProperty("value").Str(), "9é")
+
+	_, err := p.Timeout(300 * time.Millisecond).HostedField(rod.BraintreeCardFields.Number)
+	g.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,397 @@
+// This file contains the helpers to measure the performance of a page.
+
+package rod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/js"
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// NavigationTiming is the timing breakdown of the last navigation of the main document.
+// The durations of the phases are zero if the phase is skipped, such as the DNS lookup of a cached host.
+type NavigationTiming struct {
+	// URL of the document
+	URL string
+
+	// DNS lookup duration
+	DNS time.Duration
+
+	// Connect is the duration to establish the connection, it includes the TLS handshake
+	Connect time.Duration
+
+	// TLS handshake duration
+	TLS time.Duration
+
+	// TTFB is the time to first byte since the navigation starts
+	TTFB time.Duration
+
+	// Download is the duration to receive the response body
+	Download time.Duration
+
+	// DOMContentLoaded is the time when the DOMContentLoaded event is finished since the navigation starts
+	DOMContentLoaded time.Duration
+
+	// Load is the time when the load event is finished since the navigation starts,
+	// it's zero if the load event hasn't finished yet
+	Load time.Duration
+
+	// TransferSize of the document in bytes, it includes the headers
+	TransferSize int
+}
+
+// NavigationTiming returns the timing breakdown of the last navigation of the page.
+// It's assembled from the PerformanceNavigationTiming entry of the document.
+func (p *Page) NavigationTiming() (*NavigationTiming, error) {
+	res, err := p.Eval(`() => {
+		const e = performance.getEntriesByType('navigation')[0]
+		return e ? e.toJSON() : null
+	}`)
+	if err != nil {
+		return nil, err
+	}
+
+	var e struct {
+		Name                     string  `json:"name"`
+		DomainLookupStart        float64 `json:"domainLookupStart"`
+		DomainLookupEnd          float64 `json:"domainLookupEnd"`
+		ConnectStart             float64 `json:"connectStart"`
+		ConnectEnd               float64 `json:"connectEnd"`
+		SecureConnectionStart    float64 `json:"secureConnectionStart"`
+		ResponseStart            float64 `json:"responseStart"`
+		ResponseEnd              float64 `json:"responseEnd"`
+		DOMContentLoadedEventEnd float64 `json:"domContentLoadedEventEnd"`
+		LoadEventEnd             float64 `json:"loadEventEnd"`
+		TransferSize             int     `json:"transferSize"`
+	}
+
+	if res.Value.Nil() {
+		return &NavigationTiming{}, nil
+	}
+
+	err = res.Value.Unmarshal(&e)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &NavigationTiming{
+		URL:              e.Name,
+		DNS:              msDuration(e.DomainLookupEnd - e.DomainLookupStart),
+		Connect:          msDuration(e.ConnectEnd - e.ConnectStart),
+		TTFB:             msDuration(e.ResponseStart),
+		Download:         msDuration(e.ResponseEnd - e.ResponseStart),
+		DOMContentLoaded: msDuration(e.DOMContentLoadedEventEnd),
+		Load:             msDuration(e.LoadEventEnd),
+		TransferSize:     e.TransferSize,
+	}
+	if e.SecureConnectionStart > 0 {
+		t.TLS = msDuration(e.ConnectEnd - e.SecureConnectionStart)
+	}
+
+	return t, nil
+}
+
+// msDuration converts the milliseconds of the performance api to duration.
+func msDuration(ms float64) time.Duration {
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// WebVitals is the Core Web Vitals of a page.
+type WebVitals struct {
+	// LCP is the largest contentful paint time since the navigation starts
+	LCP time.Duration
+
+	// CLS is the sum of the layout shift scores that are not caused by recent user inputs
+	CLS float64
+
+	// INP is the longest duration of the user interactions, it approximates the interaction to next paint
+	INP time.Duration
+
+	// FID is the first input delay
+	FID time.Duration
+
+	// TTFB is the time to first byte since the navigation starts
+	TTFB time.Duration
+}
+
+// webVitalsSettle is how long the vitals must stay unchanged to be considered settled.
+const webVitalsSettle = time.Second
+
+// WebVitals injects the PerformanceObserver of the vitals into the page, then returns the vitals once they
+// stop changing for a second or the ctx is done. The observers use buffered entries, so it's fine to call it
+// after the page is loaded. Call it again to get the latest values, the observers are only injected once.
+func (p *Page) WebVitals(ctx context.Context) (*WebVitals, error) {
+	read := func() (gson.JSON, error) {
+		res, err := p.Eval(`() => {
+			if (!window.__rodWebVitals) {
+				const v = (window.__rodWebVitals = { lcp: 0, cls: 0, inp: 0, fid: 0, ttfb: 0 })
+				const observe = (type, fn, opts) => {
+					try {
+						new PerformanceObserver((l) => l.getEntries().forEach(fn)).observe({ type, buffered: true, ...opts })
+					} catch (e) {} // the type is not supported
+				}
+				observe('largest-contentful-paint', (e) => { v.lcp = e.startTime })
+				observe('layout-shift', (e) => { if (!e.hadRecentInput) v.cls += e.value })
+				observe('first-input', (e) => { v.fid = e.processingStart - e.startTime })
+				observe('event', (e) => { if (e.interactionId) v.inp = Math.max(v.inp, e.duration) }, { durationThreshold: 16 })
+				const nav = performance.getEntriesByType('navigation')[0]
+				if (nav) v.ttfb = nav.responseStart
+			}
+			return window.__rodWebVitals
+		}`)
+		if err != nil {
+			return gson.New(nil), err
+		}
+		return res.Value, nil
+	}
+
+	last, err := read()
+	if err != nil {
+		return nil, err
+	}
+
+	settled := time.Now()
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+
+	for time.Since(settled) < webVitalsSettle {
+		select {
+		case <-ctx.Done():
+			return toWebVitals(last), nil
+		case <-p.ctx.Done():
+			return nil, p.ctx.Err()
+		case <-t.C:
+		}
+
+		current, err := read()
+		if err != nil {
+			return nil, err
+		}
+		if current.JSON("", "") != last.JSON("", "") {
+			last = current
+			settled = time.Now()
+		}
+	}
+
+	return toWebVitals(last), nil
+}
+
+func toWebVitals(v gson.JSON) *WebVitals {
+	return &WebVitals{
+		LCP:  msDuration(v.Get("lcp").Num()),
+		CLS:  v.Get("cls").Num(),
+		INP:  msDuration(v.Get("inp").Num()),
+		FID:  msDuration(v.Get("fid").Num()),
+		TTFB: msDuration(v.Get("ttfb").Num()),
+	}
+}
+
+// LongTaskSource of [LongTask].
+type LongTaskSource string
+
+const (
+	// LongTaskSourceLongTask means the task is reported by the PerformanceObserver longtask entries.
+	LongTaskSourceLongTask LongTaskSource = "longtask"
+
+	// LongTaskSourceTrace means the browser doesn't support the longtask entries, the task is a RunTask event
+	// of the main thread from the tracing of the page.
+	LongTaskSourceTrace LongTaskSource = "trace"
+)
+
+// LongTask is a stall of the main thread of the page that is longer than 50ms.
+type LongTask struct {
+	// StartTime since the navigation starts, for the [LongTaskSourceTrace] it's since the tracing starts
+	StartTime time.Duration
+
+	Duration time.Duration
+
+	// Name of the longtask entry, such as "self" or "cross-origin-ancestor",
+	// for the [LongTaskSourceTrace] it's the name of the trace event.
+	Name string
+
+	Source LongTaskSource
+}
+
+// LongTasks observes the long tasks of the page, so that we can flag the UI thread stalls.
+// The observation survives reloads. The tasks channel will be closed after stop is called or the page is closed.
+// If the browser doesn't support the longtask entries, it falls back to the tracing of the page, the browser
+// only flushes the trace when the tracing ends, so the tasks are sent when stop is called,
+// keep receiving from the channel until it's closed.
+func (p *Page) LongTasks() (tasks <-chan *LongTask, stop func() error, err error) {
+	bind := "_" + utils.RandString(8)
+
+	err = proto.RuntimeAddBinding{Name: bind}.Call(p)
+	if err != nil {
+		return
+	}
+
+	page, cancel := p.WithCancel()
+	defer func() {
+		if err != nil {
+			cancel()
+			_ = proto.RuntimeRemoveBinding{Name: bind}.Call(p)
+		}
+	}()
+
+	ch := make(chan *LongTask)
+	tasks = ch
+
+	send := func(t *LongTask) bool {
+		select {
+		case <-page.ctx.Done():
+			return false
+		case ch <- t:
+			return true
+		}
+	}
+
+	trace := &longTaskTrace{main: map[[2]int]bool{}}
+
+	// subscribe before the observer is installed so that no task will be missed
+	wait := page.EachEvent(func(e *proto.RuntimeBindingCalled) {
+		if e.Name != bind {
+			return
+		}
+
+		var list []struct {
+			StartTime float64 `json:"startTime"`
+			Duration  float64 `json:"duration"`
+			Name      string  `json:"name"`
+		}
+		if json.Unmarshal([]byte(e.Payload), &list) != nil {
+			return
+		}
+
+		for _, t := range list {
+			if !send(&LongTask{
+				StartTime: msDuration(t.StartTime),
+				Duration:  msDuration(t.Duration),
+				Name:      t.Name,
+				Source:    LongTaskSourceLongTask,
+			}) {
+				return
+			}
+		}
+	}, func(e *proto.TracingDataCollected) {
+		trace.collect(e.Value)
+	}, func(*proto.TracingTracingComplete) bool {
+		for _, t := range trace.tasks() {
+			if !send(t) {
+				break
+			}
+		}
+		return true
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(ch)
+		wait()
+	}()
+
+	res, err := page.Evaluate(Eval(js.ObserveLongTasks.Definition, bind))
+	if err != nil {
+		return
+	}
+
+	traced := !res.Value.Bool()
+	if traced {
+		err = proto.TracingStart{
+			TransferMode: proto.TracingStartTransferModeReportEvents,
+			TraceConfig:  &proto.TracingTraceConfig{IncludedCategories: []string{"toplevel"}},
+		}.Call(page)
+		if err != nil {
+			return
+		}
+	}
+
+	remove, err := page.EvalOnNewDocument(fmt.Sprintf(`(%s)("%s")`, js.ObserveLongTasks.Definition, bind))
+	if err != nil {
+		if traced {
+			_ = proto.TracingEnd{}.Call(page)
+		}
+		return
+	}
+
+	stop = func() error {
+		defer cancel()
+		defer func() { _ = proto.RuntimeRemoveBinding{Name: bind}.Call(page) }()
+
+		if traced {
+			// the trace is flushed before the TracingTracingComplete event
+			if err := (proto.TracingEnd{}).Call(page); err != nil {
+				return err
+			}
+			select {
+			case <-page.ctx.Done():
+			case <-done:
+			}
+		}
+
+		err := remove()
+		if err != nil {
+			return err
+		}
+		_, _ = page.Eval(`name => window[name] && window[name]()`, bind+"_stop")
+		return nil
+	}
+
+	return
+}
+
+// longTaskTrace collects the long RunTask events of the main thread of the renderer from the trace.
+type longTaskTrace struct {
+	// start is the timestamp of the earliest event in microseconds
+	start float64
+
+	// main is the set of the pid and tid of the main threads
+	main map[[2]int]bool
+
+	events []map[string]gson.JSON
+}
+
+func (t *longTaskTrace) collect(events []map[string]gson.JSON) {
+	for _, e := range events {
+		if ts := e["ts"].Num(); ts > 0 && (t.start == 0 || ts < t.start) {
+			t.start = ts
+		}
+
+		switch e["name"].Str() {
+		case "thread_name":
+			if e["args"].Get("name").Str() == "CrRendererMain" {
+				t.main[[2]int{e["pid"].Int(), e["tid"].Int()}] = true
+			}
+		case "RunTask":
+			if e["dur"].Num() > 50*1000 {
+				t.events = append(t.events, e)
+			}
+		}
+	}
+}
+
+// tasks returns the collected tasks of the main threads, the thread names may be reported after the tasks.
+func (t *longTaskTrace) tasks() []*LongTask {
+	list := []*LongTask{}
+	for _, e := range t.events {
+		if !t.main[[2]int{e["pid"].Int(), e["tid"].Int()}] {
+			continue
+		}
+		list = append(list, &LongTask{
+			StartTime: msDuration((e["ts"].Num() - t.start) / 1000),
+			Duration:  msDuration(e["dur"].Num() / 1000),
+			Name:      e["name"].Str(),
+			Source:    LongTaskSourceTrace,
+		})
+	}
+	return list
+}
@@ -0,0 +1,89 @@
+package rod_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestPageNavigationTiming(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>ok</body></html>`)
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+
+	timing := p.MustNavigationTiming()
+	g.Eq(timing.URL, s.URL())
+	g.Gt(timing.TTFB, 0)
+	g.Gte(timing.DOMContentLoaded, timing.TTFB)
+	g.Gte(timing.Load, timing.DOMContentLoaded)
+	g.Zero(timing.TLS)
+
+	g.Zero(g.newPage().MustNavigationTiming().TTFB)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		p.MustNavigationTiming()
+	})
+}
+
+func TestPageWebVitals(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>
+		<h1>Largest Content</h1>
+		<script>setTimeout(() => document.body.insertAdjacentHTML('afterbegin', '<div style="height: 200px">shift</div>'), 100)</script>
+	</body></html>`)
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+
+	v := p.MustWebVitals()
+	g.Gt(v.LCP, 0)
+	g.Gt(v.TTFB, 0)
+	g.Gt(v.CLS, 0)
+
+	ctx := g.Context()
+	ctx.Cancel()
+	v, err := p.WebVitals(ctx)
+	g.E(err)
+	g.Gt(v.LCP, 0)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+		p.MustWebVitals()
+	})
+}
+
+func TestPageLongTasks(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.blank())
+
+	tasks, stop := p.MustLongTasks()
+
+	p.MustEval(`() => setTimeout(() => { const t = Date.now(); while (Date.now() - t < 200); })`)
+
+	task := <-tasks
+	g.Gt(task.Duration, 100*time.Millisecond)
+	g.Gt(task.StartTime, 0)
+	g.True(task.Source == rod.LongTaskSourceLongTask || task.Source == rod.LongTaskSourceTrace)
+
+	stop()
+
+	_, ok := <-tasks
+	g.False(ok)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.RuntimeAddBinding{})
+		p.MustLongTasks()
+	})
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.PageAddScriptToEvaluateOnNewDocument{})
+		p.MustLongTasks()
+	})
+}
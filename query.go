@@ -3,8 +3,10 @@
 package rod
 
 import (
+	"context"
 	"errors"
 	"regexp"
+	"sync"
 
 	"github.com/xyjwsj/grod/lib/cdp"
 	"github.com/xyjwsj/grod/lib/js"
@@ -189,7 +191,19 @@ func (p *Page) ElementByJS(opts *EvalOptions) (*Element, error) {
 		return nil, &ExpectElementError{res}
 	}
 
-	return p.ElementFromObject(res)
+	el, err := p.ElementFromObject(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.waitUpgrade {
+		err = el.WaitUpgrade()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return el, nil
 }
 
 // Elements returns all elements that match the css selector.
@@ -239,6 +253,13 @@ func (p *Page) ElementsByJS(opts *EvalOptions) (Elements, error) {
 			return nil, err
 		}
 
+		if p.waitUpgrade {
+			err = el.WaitUpgrade()
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		elemList = append(elemList, el)
 	}
 
@@ -362,6 +383,119 @@ func (s *SearchResult) Release() {
 	_ = proto.DOMDiscardSearchResults{SearchID: s.SearchID}.Call(s.page)
 }
 
+// ElementDeep is similar to [Page.Search], but it only returns the first element in the search result.
+// The resolved element is cached by the query, so repeated calls with the same query won't walk
+// the whole composed tree again, which is useful for elements inside deeply nested shadow doms.
+// The cache will be invalidated when the page navigates or the DOM mutates, the DOM domain of the page
+// is kept enabled to receive the mutation events after the first call.
+func (p *Page) ElementDeep(query string) (*Element, error) {
+	// the mutations are missed while the DOM domain is disabled, such as by the proto.DOMDisable
+	if !p.LoadState(&proto.DOMEnable{}) {
+		p.queries.clear()
+		err := proto.DOMEnable{}.Call(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if el := p.queries.get(p.ctx, query); el != nil {
+		return el.Sleeper(p.sleeper), nil
+	}
+
+	// start listening before the search, so that a mutation during the search won't be missed
+	p.queries.listen(p)
+	gen := p.queries.generation()
+
+	sr, err := p.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	sr.Release()
+
+	p.queries.set(gen, query, sr.First)
+
+	return sr.First, nil
+}
+
+// queryCache caches the resolved elements of queries.
+type queryCache struct {
+	// the lifetime of the session that owns the cache
+	ctx context.Context
+
+	once  sync.Once
+	lock  sync.Mutex
+	gen   int
+	items map[string]*Element
+}
+
+func newQueryCache(ctx context.Context) *queryCache {
+	return &queryCache{ctx: ctx, items: map[string]*Element{}}
+}
+
+// listen to the events that may invalidate the cache.
+func (c *queryCache) listen(p *Page) {
+	c.once.Do(func() {
+		wait := p.browser.Context(c.ctx).eachEvent(p.SessionID,
+			func(*proto.PageFrameNavigated) { c.clear() },
+			func(*proto.DOMDocumentUpdated) { c.clear() },
+			func(*proto.DOMChildNodeInserted) { c.clear() },
+			func(*proto.DOMChildNodeRemoved) { c.clear() },
+			func(*proto.DOMAttributeModified) { c.clear() },
+			func(*proto.DOMAttributeRemoved) { c.clear() },
+			func(*proto.DOMShadowRootPushed) { c.clear() },
+			func(*proto.DOMShadowRootPopped) { c.clear() },
+		)
+		go wait()
+	})
+}
+
+func (c *queryCache) generation() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.gen
+}
+
+// get the cached element of the query, nil if it's not cached or it's no longer in the document.
+func (c *queryCache) get(ctx context.Context, query string) *Element {
+	c.lock.Lock()
+	el, has := c.items[query]
+	c.lock.Unlock()
+
+	if !has {
+		return nil
+	}
+
+	el = el.Context(ctx)
+
+	res, err := el.Eval(`() => this.isConnected`)
+	if err != nil || !res.Value.Bool() {
+		c.lock.Lock()
+		delete(c.items, query)
+		c.lock.Unlock()
+		return nil
+	}
+
+	return el
+}
+
+// set the element only if the cache hasn't been cleared since the generation.
+func (c *queryCache) set(gen int, query string, el *Element) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.gen == gen {
+		c.items[query] = el
+	}
+}
+
+func (c *queryCache) clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.gen++
+	c.items = map[string]*Element{}
+}
+
 type raceBranch struct {
 	condition func(*Page) (*Element, error)
 	callback  func(*Element) error
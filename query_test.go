@@ -24,6 +24,27 @@ func TestPageElements(t *testing.T) {
 	g.Eq("submit", list.Last().MustText())
 }
 
+func TestPageElementsSeq(t *testing.T) {
+	g := setup(t)
+
+	g.page.MustNavigate(g.srcFile("fixtures/input.html"))
+
+	count := 0
+	for el := range g.page.ElementsSeq("input") {
+		g.Eq("input", el.MustDescribe().LocalName)
+		count++
+	}
+	g.Eq(count, len(g.page.MustElements("input")))
+
+	for range g.page.ElementsSeq("input") {
+		break
+	}
+
+	for range g.browser.PagesSeq() {
+		break
+	}
+}
+
 func TestPagesQuery(t *testing.T) {
 	g := setup(t)
 
@@ -144,6 +165,51 @@ func TestSearch(t *testing.T) {
 	})
 }
 
+func TestElementDeep(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/shadow-dom.html")).MustWaitLoad()
+
+	el := p.MustElementDeep("p")
+	g.Eq(el.MustText(), "inside")
+
+	// cached
+	g.Eq(p.MustElementDeep("p").Object.ObjectID, el.Object.ObjectID)
+
+	// invalidated by mutation
+	el.MustEval(`() => this.remove()`)
+	p.MustEval(`() => {
+		const p = document.createElement('p')
+		p.innerText = 'new'
+		document.body.appendChild(p)
+	}`)
+	g.Eq(p.MustElementDeep("p").MustText(), "new")
+
+	// invalidated by navigation
+	p.MustNavigate(g.srcFile("fixtures/shadow-dom.html")).MustWaitLoad()
+	g.Eq(p.MustElementDeep("p").MustText(), "inside")
+
+	// the search won't disable the DOM events that the cache relies on
+	sr, err := p.Search("p")
+	g.E(err)
+	sr.Release()
+	p.MustEval(`() => {
+		const p = document.createElement('p')
+		p.innerText = 'first'
+		document.body.prepend(p)
+	}`)
+	g.Eq(p.MustElementDeep("p").MustText(), "first")
+
+	// invalidated after the DOM domain is disabled
+	g.E(proto.DOMDisable{}.Call(p))
+	p.MustEval(`() => document.body.firstElementChild.remove()`)
+	g.Eq(p.MustElementDeep("p").MustText(), "inside")
+
+	g.E(proto.DOMDisable{}.Call(p))
+	g.mc.stubErr(1, proto.DOMEnable{})
+	g.Err(p.ElementDeep("p"))
+}
+
 func TestSearchElements(t *testing.T) {
 	g := setup(t)
 
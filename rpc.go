@@ -0,0 +1,407 @@
+// This file contains the structured RPC between Go and the page, it's useful for the apps built with
+// launcher.NewAppMode .
+
+package rod
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/proto"
+	"github.com/xyjwsj/grod/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// RPCHandler handles a call of a [RPCMethod], the params are validated by the [RPCMethod.Schema].
+// The ctx will be canceled when the call times out.
+// Return a [*RPCError] to tell the caller the code of the error.
+type RPCHandler func(ctx context.Context, params gson.JSON) (interface{}, error)
+
+// RPCMethod is a Go method that the page can call.
+type RPCMethod struct {
+	// Handler of the method
+	Handler RPCHandler
+
+	// Schema (optional) is the JSON schema of the params, such as:
+	//
+	//	{"type": "object", "required": ["path"], "properties": {"path": {"type": "string"}}}
+	//
+	// The keywords "type", "properties", "required", "additionalProperties", "items", and "enum" are supported.
+	Schema string
+
+	// Timeout (optional) of the call, the default is the [RPCServer.Timeout].
+	Timeout time.Duration
+}
+
+// RPC error codes of [RPCError].
+const (
+	RPCErrMethodNotFound = "method_not_found"
+	RPCErrInvalidParams  = "invalid_params"
+	RPCErrTimeout        = "timeout"
+	RPCErrInternal       = "internal"
+)
+
+// RPCError is the error of a RPC call, it's the error of both sides.
+// In the page the error is an Error object with the code property.
+type RPCError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc %s: %s", e.Code, e.Message)
+}
+
+// Is interface.
+func (e *RPCError) Is(err error) bool {
+	target, ok := err.(*RPCError)
+	return ok && (target.Code == "" || target.Code == e.Code)
+}
+
+// RPCServer is the bidirectional RPC between Go and a page, it supersedes the ad-hoc pairs of
+// [Page.Expose] and [Page.Eval]. In the page, the rodRPC api is available in the current and new documents:
+//
+//	// call a Go method, the options are optional
+//	const file = await rodRPC.call('files.read', { path: 'a.txt' }, { timeout: 3000 })
+//
+//	// register a handler for Go to call via RPCServer.Call
+//	rodRPC.register('editor.getText', async (params) => editor.getText())
+//
+//	// list the Go methods and their JSON schemas
+//	const methods = await rodRPC.methods()
+type RPCServer struct {
+	page    *Page
+	bind    string
+	timeout time.Duration
+
+	lock    sync.Mutex
+	methods map[string]*RPCMethod
+	schemas map[string]interface{}
+
+	stop func() error
+}
+
+// rpcJS is the rodRPC api of the page.
+const rpcJS = `(bind) => {
+	if (window.rodRPC) return
+
+	const pending = new Map()
+	const handlers = new Map()
+	let id = 0
+
+	const toError = (e) => Object.assign(new Error(e.message), { code: e.code })
+
+	const send = (msg) => new Promise((resolve, reject) => {
+		msg.id = ++id
+		pending.set(msg.id, { resolve, reject })
+		window[bind](JSON.stringify(msg))
+	})
+
+	window.rodRPC = {
+		call(method, params, opts) {
+			const p = send({ method, params: params === undefined ? null : params })
+			if (!opts || !opts.timeout) return p
+			return Promise.race([p, new Promise((_, reject) => setTimeout(
+				() => reject(toError({ code: 'timeout', message: method })), opts.timeout))])
+		},
+
+		register(method, fn) {
+			handlers.set(method, fn)
+		},
+
+		methods() {
+			return send({ method: '', params: null })
+		},
+
+		_resolve(id, result, error) {
+			const p = pending.get(id)
+			if (!p) return
+			pending.delete(id)
+			error ? p.reject(toError(error)) : p.resolve(result)
+		},
+
+		async _invoke(method, params) {
+			const fn = handlers.get(method)
+			if (!fn) return { error: { code: 'method_not_found', message: method } }
+			try {
+				return { result: await fn(params) }
+			} catch (e) {
+				return { error: { code: (e && e.code) || 'internal', message: String((e && e.message) || e) } }
+			}
+		},
+	}
+}`
+
+// RPC starts the RPC server of the page. Use [RPCServer.Register] to add the Go methods, use [RPCServer.Call]
+// to call the handlers of the page. Call [RPCServer.Close] to stop it.
+func RPC(p *Page) (*RPCServer, error) {
+	r := &RPCServer{
+		page:    p,
+		bind:    "_" + utils.RandString(8),
+		timeout: time.Minute,
+		methods: map[string]*RPCMethod{},
+		schemas: map[string]interface{}{},
+	}
+
+	err := proto.RuntimeAddBinding{Name: r.bind}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.Evaluate(Eval(rpcJS, r.bind))
+	if err != nil {
+		_ = proto.RuntimeRemoveBinding{Name: r.bind}.Call(p)
+		return nil, err
+	}
+
+	remove, err := p.EvalOnNewDocument(fmt.Sprintf(`(%s)("%s")`, rpcJS, r.bind))
+	if err != nil {
+		_ = proto.RuntimeRemoveBinding{Name: r.bind}.Call(p)
+		return nil, err
+	}
+
+	p, cancel := p.WithCancel()
+	go p.EachEvent(func(e *proto.RuntimeBindingCalled) {
+		if e.Name == r.bind {
+			go r.serve(p, e.Payload)
+		}
+	})()
+
+	r.stop = func() error {
+		defer cancel()
+		defer func() { _ = proto.RuntimeRemoveBinding{Name: r.bind}.Call(p) }()
+		return remove()
+	}
+
+	return r, nil
+}
+
+// Timeout sets the default timeout of the calls, the default is 1 minute.
+func (r *RPCServer) Timeout(d time.Duration) *RPCServer {
+	r.timeout = d
+	return r
+}
+
+// Register the method with the name, the name is usually in the "service.method" format.
+// It panics if the schema is not valid JSON.
+func (r *RPCServer) Register(name string, m RPCMethod) *RPCServer {
+	var schema interface{}
+	if m.Schema != "" {
+		utils.E(json.Unmarshal([]byte(m.Schema), &schema))
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.methods[name] = &m
+	r.schemas[name] = schema
+	return r
+}
+
+// Handle is a shortcut of [RPCServer.Register] without the schema.
+func (r *RPCServer) Handle(name string, handler RPCHandler) *RPCServer {
+	return r.Register(name, RPCMethod{Handler: handler})
+}
+
+// Unregister the method.
+func (r *RPCServer) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.methods, name)
+	delete(r.schemas, name)
+}
+
+// Call the handler that the page registered via rodRPC.register, the params will be encoded as json.
+// If the ctx has no deadline the [RPCServer.Timeout] will be used.
+func (r *RPCServer) Call(ctx context.Context, method string, params interface{}) (gson.JSON, error) {
+	if _, has := ctx.Deadline(); !has {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	res, err := r.page.Context(ctx).Evaluate(
+		Eval(`(m, p) => window.rodRPC._invoke(m, p)`, method, params).ByPromise())
+	if errors.Is(err, context.DeadlineExceeded) {
+		return gson.New(nil), &RPCError{RPCErrTimeout, method}
+	}
+	if err != nil {
+		return gson.New(nil), err
+	}
+
+	if res.Value.Has("error") {
+		return gson.New(nil), &RPCError{res.Value.Get("error.code").Str(), res.Value.Get("error.message").Str()}
+	}
+	return res.Value.Get("result"), nil
+}
+
+// Close the server, the pending calls from the page won't be answered.
+func (r *RPCServer) Close() error {
+	return r.stop()
+}
+
+// serve a call from the page.
+func (r *RPCServer) serve(p *Page, payload string) {
+	var msg struct {
+		ID     int             `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if json.Unmarshal([]byte(payload), &msg) != nil {
+		return
+	}
+
+	res, err := r.handle(p.ctx, msg.Method, msg.Params)
+
+	var rpcErr *RPCError
+	if err != nil && !errors.As(err, &rpcErr) {
+		rpcErr = &RPCError{RPCErrInternal, err.Error()}
+	}
+
+	_, _ = p.Evaluate(Eval(`(id, res, err) => window.rodRPC._resolve(id, res, err)`, msg.ID, res, rpcErr))
+}
+
+func (r *RPCServer) handle(ctx context.Context, name string, raw json.RawMessage) (interface{}, error) {
+	r.lock.Lock()
+	m, has := r.methods[name]
+	schema := r.schemas[name]
+	list := map[string]interface{}{}
+	for k, v := range r.schemas {
+		list[k] = map[string]interface{}{"schema": v}
+	}
+	r.lock.Unlock()
+
+	// the empty name lists the methods
+	if name == "" {
+		return list, nil
+	}
+
+	if !has {
+		return nil, &RPCError{RPCErrMethodNotFound, name}
+	}
+
+	if schema != nil {
+		var params interface{}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, &RPCError{RPCErrInvalidParams, err.Error()}
+			}
+		}
+		if err := validateSchema(schema, params, "params"); err != nil {
+			return nil, &RPCError{RPCErrInvalidParams, err.Error()}
+		}
+	}
+
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = r.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		val interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		val, err := m.Handler(ctx, gson.New([]byte(raw)))
+		done <- result{val, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, &RPCError{RPCErrTimeout, name}
+	case res := <-done:
+		return res.val, res.err
+	}
+}
+
+// validateSchema validates the v against the subset of the JSON schema, the path is for the error message.
+func validateSchema(schema, v interface{}, path string) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s should be one of %v", path, enum)
+		}
+	}
+
+	if t, ok := s["type"].(string); ok && !schemaTypeMatch(t, v) {
+		return fmt.Errorf("%s should be %s", path, t)
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, k := range required {
+				if _, has := val[fmt.Sprint(k)]; !has {
+					return fmt.Errorf("%s.%v is required", path, k)
+				}
+			}
+		}
+
+		props, _ := s["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			sub, has := props[k]
+			if !has {
+				if s["additionalProperties"] == false {
+					return fmt.Errorf("%s.%s is not allowed", path, k)
+				}
+				continue
+			}
+			if err := validateSchema(sub, val[k], path+"."+k); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for i, item := range val {
+			if err := validateSchema(s["items"], item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func schemaTypeMatch(t string, v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && val == float64(int64(val)))
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package rod_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/ysmood/gson"
+)
+
+func TestRPC(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.blank())
+
+	r, err := rod.RPC(p)
+	g.E(err)
+	defer func() { g.E(r.Close()) }()
+
+	r.Register("files.read", rod.RPCMethod{
+		Schema: `{"type": "object", "required": ["path"], "additionalProperties": false,
+			"properties": {"path": {"type": "string"}, "lines": {"type": "array", "items": {"type": "integer"}}}}`,
+		Handler: func(_ context.Context, params gson.JSON) (interface{}, error) {
+			if params.Get("path").Str() == "missing" {
+				return nil, errors.New("not found")
+			}
+			return map[string]string{"content": "read " + params.Get("path").Str()}, nil
+		},
+	})
+	r.Register("slow", rod.RPCMethod{
+		Timeout: 100 * time.Millisecond,
+		Handler: func(ctx context.Context, _ gson.JSON) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+
+	call := func(js string) string {
+		return p.MustEval(`async () => {
+			try {
+				return JSON.stringify(await ` + js + `)
+			} catch (e) {
+				return e.code + ': ' + e.message
+			}
+		}`).Str()
+	}
+
+	g.Eq(call(`rodRPC.call('files.read', { path: 'a.txt', lines: [1, 2] })`), `{"content":"read a.txt"}`)
+	g.Eq(call(`rodRPC.call('files.read', { path: 1 })`), "invalid_params: params.path should be string")
+	g.Eq(call(`rodRPC.call('files.read', {})`), "invalid_params: params.path is required")
+	g.Eq(call(`rodRPC.call('files.read', { path: 'a', x: 1 })`), "invalid_params: params.x is not allowed")
+	g.Eq(call(`rodRPC.call('files.read', { path: 'a', lines: [1.5] })`), "invalid_params: params.lines[0] should be integer")
+	g.Eq(call(`rodRPC.call('files.read', { path: 'missing' })`), "internal: not found")
+	g.Eq(call(`rodRPC.call('nope')`), "method_not_found: nope")
+	g.Eq(call(`rodRPC.call('slow')`), "timeout: slow")
+	g.Has(call(`rodRPC.methods()`), `"files.read":{"schema":{"additionalProperties":false`)
+
+	// the api survives reloads
+	p.MustNavigate(g.blank()).MustWaitLoad()
+
+	p.MustEval(`() => {
+		rodRPC.register('editor.getText', async (params) => 'text of ' + params.id)
+		rodRPC.register('editor.fail', () => { throw Object.assign(new Error('bad'), { code: 'custom' }) })
+		rodRPC.register('editor.hang', () => new Promise(() => {}))
+	}`)
+
+	g.Eq(r.MustCall(g.Context(), "editor.getText", map[string]int{"id": 1}).Str(), "text of 1")
+
+	_, err = r.Call(g.Context(), "editor.fail", nil)
+	g.Is(err, &rod.RPCError{Code: "custom"})
+	g.Eq(err.Error(), "rpc custom: bad")
+
+	_, err = r.Call(g.Context(), "editor.none", nil)
+	g.Is(err, &rod.RPCError{Code: rod.RPCErrMethodNotFound})
+
+	_, err = r.Timeout(100*time.Millisecond).Call(context.Background(), "editor.hang", nil)
+	g.Is(err, &rod.RPCError{Code: rod.RPCErrTimeout})
+}
@@ -0,0 +1,155 @@
+// This file contains the helpers to orchestrate the scenarios that need multiple users to interact,
+// such as testing the chat or collaboration features.
+
+package rod
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ScenarioActor is a user of the [Scenario], the browser is an isolated incognito context of the user.
+type ScenarioActor func(b *Browser) error
+
+// Scenario runs multiple isolated users concurrently, and synchronizes them with the named barriers.
+//
+//	s := rod.NewScenario(browser)
+//	err := s.Run(func(b *rod.Browser) error {
+//		login(b, "alice")
+//		s.MustSync("both-logged-in")
+//		b.MustPages().First().MustElement("textarea").MustInput("hi")
+//		return nil
+//	}, func(b *rod.Browser) error {
+//		login(b, "bob")
+//		s.MustSync("both-logged-in")
+//		b.MustPages().First().MustElementR("p", "hi")
+//		return nil
+//	})
+type Scenario struct {
+	browser *Browser
+
+	lock      sync.Mutex
+	size      int
+	finished  int
+	barriers  map[string]chan struct{}
+	arrived   map[string]int
+	broken    chan struct{}
+	breakOnce *sync.Once
+}
+
+// NewScenario creates a scenario, each user of it will get an incognito context of the browser.
+func NewScenario(b *Browser) *Scenario {
+	s := &Scenario{browser: b}
+	s.reset(0)
+	return s
+}
+
+// reset the state for a run of the size of actors.
+func (s *Scenario) reset(size int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.size = size
+	s.finished = 0
+	s.barriers = map[string]chan struct{}{}
+	s.arrived = map[string]int{}
+	s.broken = make(chan struct{})
+	s.breakOnce = &sync.Once{}
+}
+
+// Run the actors concurrently and wait for them to finish, it returns the errors of the actors joined.
+// The panics of the actors are recovered as errors, so the Must functions can be used in them.
+// Once an actor fails, the pending and future [Scenario.Sync] of the others will return [ScenarioBrokenError],
+// so that they won't wait forever.
+func (s *Scenario) Run(actors ...ScenarioActor) error {
+	s.reset(len(actors))
+
+	s.lock.Lock()
+	broken, breakOnce := s.broken, s.breakOnce
+	s.lock.Unlock()
+
+	errs := make([]error, len(actors))
+	wg := sync.WaitGroup{}
+	for i, actor := range actors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := s.run(actor)
+			if err != nil {
+				errs[i] = fmt.Errorf("actor %d: %w", i, err)
+				breakOnce.Do(func() { close(broken) })
+				return
+			}
+
+			s.lock.Lock()
+			s.finished++
+			s.release()
+			s.lock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (s *Scenario) run(actor ScenarioActor) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	b, err := s.browser.Incognito()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = b.Close() }()
+
+	return actor(b)
+}
+
+// Sync blocks until all the actors of the running [Scenario.Run] reach the barrier of the name,
+// the actors that have finished without error are counted as reached.
+// Each barrier can only be passed once in a run. It returns immediately if no actor is running.
+func (s *Scenario) Sync(name string) error {
+	s.lock.Lock()
+	done, has := s.barriers[name]
+	if !has {
+		done = make(chan struct{})
+		s.barriers[name] = done
+	}
+	s.arrived[name]++
+	s.release()
+	broken := s.broken
+	s.lock.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-broken:
+		return &ScenarioBrokenError{name}
+	case <-s.browser.ctx.Done():
+		return s.browser.ctx.Err()
+	}
+}
+
+// release the barriers that all the actors have reached, it must be called with the lock held.
+func (s *Scenario) release() {
+	for name, done := range s.barriers {
+		if s.arrived[name]+s.finished < s.size {
+			continue
+		}
+
+		select {
+		case <-done:
+		default:
+			close(done)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package rod_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/xyjwsj/grod"
+)
+
+func TestScenario(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>ok</body></html>`)
+
+	sc := rod.NewScenario(g.browser)
+
+	var loggedIn int32
+	login := func(b *rod.Browser, user string) *rod.Page {
+		p := b.MustPage(s.URL()).MustWaitLoad()
+		p.MustEval(`user => { document.cookie = 'user=' + user }`, user)
+		atomic.AddInt32(&loggedIn, 1)
+		return p
+	}
+
+	err := sc.Run(func(b *rod.Browser) error {
+		p := login(b, "alice")
+		sc.MustSync("both-logged-in")
+		g.Eq(atomic.LoadInt32(&loggedIn), int32(2))
+		g.Eq(p.MustEval(`() => document.cookie`).Str(), "user=alice")
+		return nil
+	}, func(b *rod.Browser) error {
+		p := login(b, "bob")
+		sc.MustSync("both-logged-in")
+		g.Eq(atomic.LoadInt32(&loggedIn), int32(2))
+		g.Eq(p.MustEval(`() => document.cookie`).Str(), "user=bob")
+		return nil
+	})
+	g.E(err)
+
+	errFail := errors.New("fail")
+	err = sc.Run(func(_ *rod.Browser) error {
+		return errFail
+	}, func(_ *rod.Browser) error {
+		return sc.Sync("never")
+	}, func(_ *rod.Browser) error {
+		panic("boom")
+	})
+	g.Is(err, errFail)
+	g.Is(err, &rod.ScenarioBrokenError{})
+	g.Has(err.Error(), "actor 2: boom")
+	g.Eq((&rod.ScenarioBrokenError{"never"}).Error(), "another actor of the scenario failed before the barrier: never")
+
+	// the finished actors won't block the barrier
+	err = sc.Run(func(_ *rod.Browser) error {
+		return nil
+	}, func(_ *rod.Browser) error {
+		return sc.Sync("a")
+	}, func(_ *rod.Browser) error {
+		return sc.Sync("a")
+	})
+	g.E(err)
+
+	// no actor is running
+	g.E(rod.NewScenario(g.browser).Sync("a"))
+}
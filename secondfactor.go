@@ -0,0 +1,119 @@
+// This file contains the helpers for the second factor authentication.
+
+package rod
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint: gosec // RFC 6238 uses sha1 by default
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+
+	"github.com/xyjwsj/grod/lib/input"
+)
+
+// SecondFactorProvider resolves the one-time code of the second factor authentication,
+// such as a TOTP, or a code received via SMS or email. The login helpers, such as [OAuthFlow],
+// invoke it when an OTP field is detected.
+type SecondFactorProvider interface {
+	// SecondFactor returns the code to fill into the OTP field of the page.
+	SecondFactor(p *Page) (code string, err error)
+}
+
+// SecondFactorSelector is the css selector to detect the OTP field.
+var SecondFactorSelector = `input[autocomplete="one-time-code"], input[name*="otp" i], input[id*="otp" i], ` +
+	`input[name*="totp" i], input[name*="2fa" i], input[name*="mfa" i]`
+
+// TOTP is the RFC 6238 time-based one-time password generator.
+type TOTP struct {
+	// Secret is the base32 encoded shared secret, such as the one in the "otpauth://" url of the QR code.
+	// The spaces and the padding are ignored.
+	Secret string
+
+	// Digits of the code, default is 6.
+	Digits int
+
+	// Period of a code, default is 30s. It should be at least 1s.
+	Period time.Duration
+
+	// Hash algorithm, default is sha1.
+	Hash func() hash.Hash
+}
+
+var _ SecondFactorProvider = &TOTP{}
+
+// NewTOTP with the default options.
+func NewTOTP(secret string) *TOTP {
+	return &TOTP{Secret: secret, Digits: 6, Period: 30 * time.Second, Hash: sha1.New}
+}
+
+// Code at the time t.
+func (t *TOTP) Code(at time.Time) (string, error) {
+	secret := strings.ToUpper(strings.NewReplacer(" ", "", "=", "").Replace(t.Secret))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	digits, period, h := t.Digits, t.Period, t.Hash
+	if digits == 0 {
+		digits = 6
+	}
+	if period == 0 {
+		period = 30 * time.Second
+	}
+	if period < time.Second {
+		return "", fmt.Errorf("invalid totp period: %s, it should be at least 1s", period)
+	}
+	if h == nil {
+		h = sha1.New
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(at.Unix()/int64(period/time.Second)))
+
+	mac := hmac.New(h, key)
+	_, _ = mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// the dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, bin%mod), nil
+}
+
+// SecondFactor interface, it returns the code of the current time.
+func (t *TOTP) SecondFactor(_ *Page) (string, error) {
+	return t.Code(time.Now())
+}
+
+// FillSecondFactor fills the code of the provider into the OTP field that matches the [SecondFactorSelector]
+// and presses Enter to submit it. It returns false if no OTP field is found on the page.
+// The code is typed key by key, so the split inputs that move the focus automatically are supported.
+func (p *Page) FillSecondFactor(provider SecondFactorProvider) (bool, error) {
+	has, el, err := p.Has(SecondFactorSelector)
+	if err != nil || !has {
+		return false, err
+	}
+
+	code, err := provider.SecondFactor(p)
+	if err != nil {
+		return true, err
+	}
+
+	keys := []input.Key{}
+	for _, r := range code {
+		keys = append(keys, input.Key(r))
+	}
+
+	return true, el.Type(append(keys, input.Enter)...)
+}
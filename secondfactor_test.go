@@ -0,0 +1,107 @@
+package rod_test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/xyjwsj/grod"
+	"github.com/xyjwsj/grod/lib/proto"
+)
+
+func TestTOTP(t *testing.T) {
+	g := setup(t)
+
+	// the test vectors of RFC 6238
+	totp := rod.NewTOTP("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	totp.Digits = 8
+	for at, code := range map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1234567890:  "89005924",
+		20000000000: "65353130",
+	} {
+		c, err := totp.Code(time.Unix(at, 0))
+		g.E(err)
+		g.Eq(c, code)
+	}
+
+	c, err := (&rod.TOTP{Secret: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq"}).Code(time.Unix(59, 0))
+	g.E(err)
+	g.Eq(c, "287082")
+
+	c, err = (&rod.TOTP{
+		Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA====",
+		Digits: 8,
+		Hash:   sha256.New,
+	}).Code(time.Unix(59, 0))
+	g.E(err)
+	g.Eq(c, "46119246")
+
+	_, err = rod.NewTOTP("1").Code(time.Now())
+	g.Has(err.Error(), "invalid totp secret")
+
+	_, err = (&rod.TOTP{Secret: "GEZDGNBV", Period: time.Millisecond}).Code(time.Now())
+	g.Has(err.Error(), "invalid totp period")
+
+	c, err = totp.SecondFactor(nil)
+	g.E(err)
+	g.Len(c, 8)
+}
+
+type secondFactorFunc func() (string, error)
+
+func (f secondFactorFunc) SecondFactor(_ *rod.Page) (string, error) { return f() }
+
+func TestFillSecondFactor(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>
+		<form onsubmit="event.preventDefault(); window.submitted = this.code.value">
+			<input name="code" autocomplete="one-time-code">
+		</form>
+	</body></html>`)
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+	g.True(p.MustFillSecondFactor(secondFactorFunc(func() (string, error) { return "123456", nil })))
+	g.Eq(p.MustWait(`() => window.submitted`).MustEval(`() => window.submitted`).Str(), "123456")
+
+	errProvider := errors.New("no code")
+	filled, err := p.FillSecondFactor(secondFactorFunc(func() (string, error) { return "", errProvider }))
+	g.True(filled)
+	g.Eq(err, errProvider)
+
+	g.False(g.newPage(g.blank()).MustFillSecondFactor(rod.NewTOTP("GEZDGNBV")))
+}
+
+func TestOAuthFlowSecondFactor(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>
+		<button onclick="location.href = '/provider'">login</button>
+	</body></html>`)
+	s.Route("/provider", ".html", `<html><body>
+		<button id="submit" onclick="location.href = '/otp'">submit</button>
+	</body></html>`)
+	s.Route("/otp", ".html", `<html><body>
+		<form onsubmit="event.preventDefault(); location.href = '/callback?otp=' + this.otp.value">
+			<input name="otp">
+		</form>
+	</body></html>`)
+	s.Route("/callback", ".html", `ok`)
+
+	res := (&rod.OAuthFlow{
+		LoginButton: "button",
+		Credentials: func(provider *rod.Page) error {
+			return provider.MustElement("#submit").Click(proto.InputMouseButtonLeft, 1)
+		},
+		RedirectURL:  regexp.MustCompile(`/callback`),
+		SecondFactor: secondFactorFunc(func() (string, error) { return "654321", nil }),
+	}).MustRun(g.newPage(s.URL()).MustWaitLoad())
+
+	g.Eq(res.Params["otp"], "654321")
+}
@@ -0,0 +1,62 @@
+// This file contains the supervisor that relaunches the crashed browser, it's useful for long-running services.
+
+package rod
+
+import (
+	"errors"
+
+	"github.com/xyjwsj/grod/lib/launcher"
+)
+
+// ErrNoLauncher is an error that indicates the browser has no launcher, check [Browser.Launcher].
+var ErrNoLauncher = errors.New("the browser has no launcher")
+
+// BrowserRecovery is reported by [Browser.Supervise] after the browser crashed.
+type BrowserRecovery struct {
+	// Crash report of the crashed browser
+	Crash *launcher.CrashReport
+
+	// Browser connected to the relaunched browser, it's nil if the Err isn't nil
+	Browser *Browser
+
+	// Err of the relaunch or the connection, the supervising stops if the relaunch fails
+	Err error
+}
+
+// Supervise relaunches the browser with the same flags via [launcher.Launcher.Supervise] every time it crashes,
+// then connects a new browser to it with the same options as b, the handler is called after each recovery
+// with the new browser. The browser must have a launcher, such as the one set by [Browser.Launcher] or the
+// default one of [Browser.Connect], otherwise [ErrNoLauncher] is returned.
+// The b is never changed, after the crash it stays disconnected and its [Browser.DisconnectErr] has the crash
+// report, so the in-flight calls of it fail safely, use the new browser to create new pages.
+// Call stop to stop it.
+func (b *Browser) Supervise(handler func(*BrowserRecovery)) (stop func(), err error) {
+	if b.launcher == nil {
+		return nil, ErrNoLauncher
+	}
+
+	stop = b.launcher.Supervise(func(r *launcher.Recovery) {
+		res := &BrowserRecovery{Crash: r.Crash, Err: r.Err}
+		if r.Err == nil {
+			res.Browser, res.Err = b.relaunched(r.Launcher, r.URL)
+		}
+		handler(res)
+	})
+
+	return stop, nil
+}
+
+// relaunched returns a new browser that has the same options as b and connects to the relaunched browser.
+func (b *Browser) relaunched(l *launcher.Launcher, u string) (*Browser, error) {
+	// the monitor server of b is still serving, don't start another one
+	n := New().Context(b.ctx).ControlURL(u).Launcher(l).Monitor("").
+		SlowMotion(b.slowMotion).Trace(b.trace).Logger(b.logger).DefaultDevice(b.defaultDevice).
+		OCR(b.ocr).Mailbox(b.mailbox)
+	n.e = b.e
+	n.sleeper = b.sleeper
+
+	if err := n.Connect(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
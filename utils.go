@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	mr "math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -77,6 +78,63 @@ var DefaultSleeper = func() utils.Sleeper {
 	return utils.BackoffSleeper(100*time.Millisecond, time.Second, nil)
 }
 
+// SleeperPolicy configures the backoff of the sleeper that polls for retries and waits.
+// Use [Browser.SleeperPolicy] or [Page.SleeperPolicy] to apply it.
+// The growth looks like:
+//
+//	A(0) = Init, A(n) = A(n-1) * random[Factor - Jitter/2, Factor + Jitter/2), A(n) < Max
+type SleeperPolicy struct {
+	// Init interval, defaults to 100ms
+	Init time.Duration
+
+	// Max interval, defaults to 1s
+	Max time.Duration
+
+	// Factor of the interval growth, defaults to 2. If it's not greater than 1 it polls at the fixed Init interval.
+	Factor float64
+
+	// Jitter is the width of the random range around the Factor, 0 means no randomness.
+	Jitter float64
+}
+
+// DefaultSleeperPolicy is the policy of the [DefaultSleeper].
+var DefaultSleeperPolicy = SleeperPolicy{
+	Init:   100 * time.Millisecond,
+	Max:    time.Second,
+	Factor: 2,
+	Jitter: 0.2,
+}
+
+// Sleeper returns the sleeper generator of the policy.
+func (sp SleeperPolicy) Sleeper() func() utils.Sleeper {
+	if sp.Init <= 0 {
+		sp.Init = DefaultSleeperPolicy.Init
+	}
+	if sp.Max <= 0 {
+		sp.Max = DefaultSleeperPolicy.Max
+	}
+	if sp.Factor <= 0 {
+		sp.Factor = DefaultSleeperPolicy.Factor
+	}
+
+	backoff := func(interval time.Duration) time.Duration {
+		scale := sp.Factor + (mr.Float64()-0.5)*sp.Jitter //nolint: gosec
+		if scale < 0 {
+			// a jitter wider than twice the factor can't make the delay negative
+			scale = 0
+		}
+		return time.Duration(float64(interval) * scale)
+	}
+
+	return func() utils.Sleeper {
+		if sp.Factor <= 1 {
+			// a fixed interval never reaches the max, so the max is the interval
+			return utils.BackoffSleeper(sp.Init, sp.Init, nil)
+		}
+		return utils.BackoffSleeper(sp.Init, sp.Max, backoff)
+	}
+}
+
 // NewPagePool instance.
 func NewPagePool(limit int) Pool[Page] {
 	return NewPool[Page](limit)